@@ -0,0 +1,279 @@
+// Package apidoc generates the OpenAPI document of the API from the routes
+// registered on gin and the DTOs of their requests and responses, so the
+// document can't drift from the handlers.
+package apidoc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v3"
+)
+
+// MediaTypeJSON is the media type of JSON bodies.
+const MediaTypeJSON = "application/json"
+
+// Document is an OpenAPI document built while routes are registered.
+type Document struct {
+	spec *openapi3.T
+}
+
+// New creates a document with the info.
+func New(info *openapi3.Info) *Document {
+	return &Document{
+		spec: &openapi3.T{
+			OpenAPI: "3.0.3",
+			Info:    info,
+			Paths:   openapi3.NewPaths(),
+			Components: &openapi3.Components{
+				Schemas:    make(openapi3.Schemas),
+				Parameters: make(openapi3.ParametersMap),
+				Headers:    make(openapi3.Headers),
+			},
+		},
+	}
+}
+
+// Spec returns the OpenAPI document.
+func (d *Document) Spec() *openapi3.T {
+	return d.spec
+}
+
+// JSON returns the document encoded in JSON.
+func (d *Document) JSON() ([]byte, error) {
+	return json.MarshalIndent(d.spec, "", "  ")
+}
+
+// YAML returns the document encoded in YAML.
+func (d *Document) YAML() ([]byte, error) {
+	bs, err := json.Marshal(d.spec)
+	if err != nil {
+		return nil, err
+	}
+
+	// JSON is YAML, decoding it into a node keeps the order of keys.
+	var node yaml.Node
+	if err := yaml.Unmarshal(bs, &node); err != nil {
+		return nil, err
+	}
+	resetStyle(&node)
+	sortTopLevel(node.Content[0])
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// topLevelOrder is the conventional order of the top level fields, JSON
+// encoding sorts them by name.
+var topLevelOrder = []string{"openapi", "info", "servers", "tags", "paths", "components"}
+
+func sortTopLevel(mapping *yaml.Node) {
+	pairs := make([][2]*yaml.Node, 0, len(mapping.Content)/2)
+	for index := 0; index+1 < len(mapping.Content); index += 2 {
+		pairs = append(pairs, [2]*yaml.Node{mapping.Content[index], mapping.Content[index+1]})
+	}
+
+	rank := func(key string) int {
+		if index := slices.Index(topLevelOrder, key); index >= 0 {
+			return index
+		}
+		return len(topLevelOrder)
+	}
+	slices.SortStableFunc(pairs, func(a, b [2]*yaml.Node) int {
+		return rank(a[0].Value) - rank(b[0].Value)
+	})
+
+	mapping.Content = mapping.Content[:0]
+	for _, pair := range pairs {
+		mapping.Content = append(mapping.Content, pair[0], pair[1])
+	}
+}
+
+func resetStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		resetStyle(child)
+	}
+}
+
+// RouterOptions defines the documentation shared by the routes of a Router.
+type RouterOptions struct {
+	// OperationSuffix is appended to operation IDs, so the routes of every
+	// group have unique IDs.
+	OperationSuffix string
+	// Deprecated marks the operations as deprecated.
+	Deprecated bool
+}
+
+// Router registers routes on a gin router group and documents them.
+type Router struct {
+	doc     *Document
+	group   *gin.RouterGroup
+	options RouterOptions
+	schemas SchemaOptions
+}
+
+// Router returns a router registering routes on the group.
+func (d *Document) Router(group *gin.RouterGroup, options RouterOptions) *Router {
+	return &Router{
+		doc:     d,
+		group:   group,
+		options: options,
+	}
+}
+
+// Group creates a router of a sub group, see gin.RouterGroup.Group.
+func (r *Router) Group(relativePath string, handlers ...gin.HandlerFunc) *Router {
+	copied := *r
+	copied.group = r.group.Group(relativePath, handlers...)
+	return &copied
+}
+
+// Schemas returns a router generating schemas with the options.
+func (r *Router) Schemas(options SchemaOptions) *Router {
+	copied := *r
+	copied.schemas = options
+	return &copied
+}
+
+// Parameter registers the parameter as a component and returns the
+// reference to it.
+func (r *Router) Parameter(name string, parameter *openapi3.Parameter) *openapi3.ParameterRef {
+	r.doc.spec.Components.Parameters[name] = &openapi3.ParameterRef{Value: parameter}
+	return &openapi3.ParameterRef{Ref: "#/components/parameters/" + name}
+}
+
+// Header registers the header as a component and returns the reference to
+// it.
+func (r *Router) Header(name string, header *openapi3.Header) *openapi3.HeaderRef {
+	r.doc.spec.Components.Headers[name] = &openapi3.HeaderRef{Value: header}
+	return &openapi3.HeaderRef{Ref: "#/components/headers/" + name}
+}
+
+// Content defines a representation of a body.
+type Content struct {
+	MediaType string
+	// Body is a value of the DTO, its type is reflected to the schema.
+	Body    any
+	Example any
+}
+
+// JSON returns the content of a JSON body.
+func JSON(body any) []Content {
+	return []Content{{MediaType: MediaTypeJSON, Body: body}}
+}
+
+// Response defines a documented response.
+type Response struct {
+	Status      int
+	Description string
+	Headers     map[string]*openapi3.HeaderRef
+	// Content is empty for responses without body.
+	Content []Content
+}
+
+// Operation defines the documentation of a route.
+type Operation struct {
+	ID          string
+	Summary     string
+	Description string
+	Parameters  []*openapi3.ParameterRef
+	// Request is a value of the DTO of the JSON request body, nil for
+	// requests without body.
+	Request any
+	// RequestContent documents request bodies of other media types, it is
+	// used if Request is nil.
+	RequestContent []Content
+	Responses      []Response
+}
+
+// Handle registers the route and documents it. It panics if the DTOs can't
+// be described, like gin does on invalid routes.
+func (r *Router) Handle(method, relativePath string, op Operation, handlers ...gin.HandlerFunc) {
+	r.group.Handle(method, relativePath, handlers...)
+
+	operation, err := r.operation(op)
+	if err != nil {
+		panic(fmt.Sprintf("document %s %s: %v", method, relativePath, err))
+	}
+
+	r.doc.spec.AddOperation(openAPIPath(path.Join(r.group.BasePath(), relativePath)), method, operation)
+}
+
+func (r *Router) operation(op Operation) (*openapi3.Operation, error) {
+	generator := newSchemaGenerator(r.doc.spec.Components.Schemas, r.schemas)
+
+	operation := openapi3.NewOperation()
+	operation.OperationID = op.ID + r.options.OperationSuffix
+	operation.Summary = op.Summary
+	operation.Description = op.Description
+	operation.Deprecated = r.options.Deprecated
+	operation.Parameters = op.Parameters
+
+	if op.Request != nil {
+		schema, err := generator.generate(op.Request)
+		if err != nil {
+			return nil, err
+		}
+
+		operation.RequestBody = &openapi3.RequestBodyRef{
+			Value: openapi3.NewRequestBody().WithRequired(true).WithJSONSchemaRef(schema),
+		}
+	} else if len(op.RequestContent) > 0 {
+		content, err := generator.content(op.RequestContent)
+		if err != nil {
+			return nil, err
+		}
+
+		operation.RequestBody = &openapi3.RequestBodyRef{
+			Value: openapi3.NewRequestBody().WithRequired(true).WithContent(content),
+		}
+	}
+
+	operation.Responses = openapi3.NewResponsesWithCapacity(len(op.Responses))
+	for _, resp := range op.Responses {
+		response := openapi3.NewResponse().WithDescription(resp.Description)
+		response.Headers = resp.Headers
+
+		if len(resp.Content) > 0 {
+			content, err := generator.content(resp.Content)
+			if err != nil {
+				return nil, err
+			}
+			response.Content = content
+		}
+
+		operation.Responses.Set(strconv.Itoa(resp.Status), &openapi3.ResponseRef{Value: response})
+	}
+
+	return operation, nil
+}
+
+// openAPIPath converts the parameters of a gin path to the OpenAPI syntax,
+// e.g. /tasks/:id to /tasks/{id}.
+func openAPIPath(ginPath string) string {
+	segments := strings.Split(ginPath, "/")
+	for index, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[index] = "{" + segment[1:] + "}"
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,105 @@
+package apidoc_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/omegaatt36/gotasker/api/apidoc"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+)
+
+type DocumentSuite struct {
+	suite.Suite
+}
+
+type item struct {
+	ID     uint            `json:"id" description:"The ID." example:"1"`
+	Name   string          `json:"name" binding:"required" example:"Item 1"`
+	Kind   string          `json:"kind,omitempty" enum:"a,b" default:"a"`
+	Status json.RawMessage `json:"status" openapi:"status"`
+	Tags   []string        `json:"tags"`
+	Hidden string          `json:"-"`
+}
+
+func (s *DocumentSuite) TestHandle() {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+
+	doc := apidoc.New(&openapi3.Info{Title: "test", Version: "1"})
+	router := doc.Router(engine.Group("/v2"), apidoc.RouterOptions{OperationSuffix: "V2", Deprecated: true}).
+		Schemas(apidoc.SchemaOptions{
+			Suffix:      "V2",
+			Substitutes: map[string]any{"status": openapi3.NewStringSchema().WithEnum("open", "closed")},
+		})
+
+	router.Handle(http.MethodGet, "/items", apidoc.Operation{
+		ID: "listItems",
+		Responses: []apidoc.Response{
+			{Status: http.StatusOK, Description: "ok", Content: apidoc.JSON(apidoc.ArrayOf{Item: &item{}})},
+		},
+	}, func(*gin.Context) {})
+	router.Handle(http.MethodPut, "/items/:id", apidoc.Operation{
+		ID:      "updateItem",
+		Request: item{},
+		Responses: []apidoc.Response{
+			{Status: http.StatusOK, Description: "ok"},
+		},
+	}, func(*gin.Context) {})
+
+	s.Len(engine.Routes(), 2)
+
+	spec := doc.Spec()
+	list := spec.Paths.Find("/v2/items").Get
+	s.Require().NotNil(list)
+	s.Equal("listItemsV2", list.OperationID)
+	s.True(list.Deprecated)
+	s.Equal("#/components/schemas/ItemV2",
+		list.Responses.Status(http.StatusOK).Value.Content.Get(apidoc.MediaTypeJSON).Schema.Value.Items.Ref)
+
+	update := spec.Paths.Find("/v2/items/{id}").Put
+	s.Require().NotNil(update)
+	s.Equal("#/components/schemas/ItemV2", update.RequestBody.Value.Content.Get(apidoc.MediaTypeJSON).Schema.Ref)
+
+	schema := spec.Components.Schemas["ItemV2"].Value
+	s.Equal([]string{"name"}, schema.Required)
+	s.NotContains(schema.Properties, "Hidden")
+	s.Equal("The ID.", schema.Properties["id"].Value.Description)
+	s.EqualValues(1, schema.Properties["id"].Value.Example)
+	s.Equal("Item 1", schema.Properties["name"].Value.Example)
+	s.Equal([]any{"a", "b"}, schema.Properties["kind"].Value.Enum)
+	s.Equal("a", schema.Properties["kind"].Value.Default)
+	s.Equal([]any{"open", "closed"}, schema.Properties["status"].Value.Enum)
+	s.True(schema.Properties["tags"].Value.Type.Is(openapi3.TypeArray))
+}
+
+func (s *DocumentSuite) TestHandleConflictingSchemas() {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+
+	doc := apidoc.New(&openapi3.Info{Title: "test", Version: "1"})
+	router := doc.Router(engine.Group(""), apidoc.RouterOptions{})
+
+	register := func(path string, status *openapi3.Schema) {
+		router.Schemas(apidoc.SchemaOptions{Substitutes: map[string]any{"status": status}}).
+			Handle(http.MethodPost, path, apidoc.Operation{ID: path, Request: item{}}, func(*gin.Context) {})
+	}
+
+	register("/a", openapi3.NewStringSchema())
+	s.Panics(func() { register("/b", openapi3.NewIntegerSchema()) })
+}
+
+func (s *DocumentSuite) TestYAML() {
+	doc := apidoc.New(&openapi3.Info{Title: "test", Version: "1"})
+
+	bs, err := doc.YAML()
+	s.Require().NoError(err)
+	s.Equal("openapi: 3.0.3\ninfo:\n  title: test\n  version: \"1\"\npaths: {}\ncomponents: {}\n", string(bs))
+}
+
+func TestDocumentSuite(t *testing.T) {
+	suite.Run(t, new(DocumentSuite))
+}
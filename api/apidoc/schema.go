@@ -0,0 +1,331 @@
+package apidoc
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// Struct tags read from the fields of DTOs.
+const (
+	// TagDescription describes the field.
+	TagDescription = "description"
+	// TagExample is an example value of the field.
+	TagExample = "example"
+	// TagEnum is a comma separated list of the allowed values of the field.
+	TagEnum = "enum"
+	// TagDefault is the value used when the field is absent.
+	TagDefault = "default"
+	// TagSubstitute names the entry of SchemaOptions.Substitutes that
+	// replaces the schema of the field.
+	TagSubstitute = "openapi"
+)
+
+var (
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+	timeType       = reflect.TypeOf(time.Time{})
+)
+
+// SchemaOptions defines how schemas of DTOs are generated.
+type SchemaOptions struct {
+	// Suffix is appended to the names of component schemas, so the DTOs
+	// shared by API versions are published once per version.
+	Suffix string
+	// Substitutes replaces the schema of the fields tagged with
+	// `openapi:"<key>"`, for representations which depend on the API version.
+	// A value is either an *openapi3.Schema or a value whose type is
+	// reflected.
+	Substitutes map[string]any
+}
+
+// ArrayOf describes an array of the DTO, for bodies of which the Go type
+// can't be reflected, like lists converted by presenters.
+type ArrayOf struct {
+	Item any
+}
+
+// schemaGenerator generates schemas by reflection, named structs become
+// component schemas.
+type schemaGenerator struct {
+	components openapi3.Schemas
+	options    SchemaOptions
+
+	generating map[string]bool
+}
+
+func newSchemaGenerator(components openapi3.Schemas, options SchemaOptions) *schemaGenerator {
+	return &schemaGenerator{
+		components: components,
+		options:    options,
+		generating: make(map[string]bool),
+	}
+}
+
+// generate returns the schema of the type of the value, nil if the value is
+// nil.
+func (g *schemaGenerator) generate(value any) (*openapi3.SchemaRef, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	switch value := value.(type) {
+	case *openapi3.Schema:
+		copied := *value
+		return openapi3.NewSchemaRef("", &copied), nil
+	case ArrayOf:
+		items, err := g.generate(value.Item)
+		if err != nil {
+			return nil, err
+		}
+
+		schema := openapi3.NewArraySchema()
+		schema.Items = items
+		return openapi3.NewSchemaRef("", schema), nil
+	}
+
+	return g.generateType(reflect.TypeOf(value))
+}
+
+// content returns the documented content of the media types.
+func (g *schemaGenerator) content(contents []Content) (openapi3.Content, error) {
+	content := make(openapi3.Content, len(contents))
+	for _, c := range contents {
+		schema, err := g.generate(c.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		mediaType := openapi3.NewMediaType().WithSchemaRef(schema)
+		mediaType.Example = c.Example
+		content[c.MediaType] = mediaType
+	}
+
+	return content, nil
+}
+
+func (g *schemaGenerator) generateType(t reflect.Type) (*openapi3.SchemaRef, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t {
+	case rawMessageType:
+		return openapi3.NewSchemaRef("", openapi3.NewSchema()), nil
+	case timeType:
+		return openapi3.NewSchemaRef("", openapi3.NewDateTimeSchema()), nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return openapi3.NewSchemaRef("", openapi3.NewBoolSchema()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return openapi3.NewSchemaRef("", openapi3.NewIntegerSchema()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return openapi3.NewSchemaRef("", openapi3.NewIntegerSchema().WithMin(0)), nil
+	case reflect.Float32, reflect.Float64:
+		return openapi3.NewSchemaRef("", openapi3.NewFloat64Schema()), nil
+	case reflect.String:
+		return openapi3.NewSchemaRef("", openapi3.NewStringSchema()), nil
+	case reflect.Interface:
+		return openapi3.NewSchemaRef("", openapi3.NewSchema()), nil
+	case reflect.Slice, reflect.Array:
+		items, err := g.generateType(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+
+		schema := openapi3.NewArraySchema()
+		schema.Items = items
+		return openapi3.NewSchemaRef("", schema), nil
+	case reflect.Map:
+		values, err := g.generateType(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+
+		schema := openapi3.NewObjectSchema()
+		schema.AdditionalProperties = openapi3.AdditionalProperties{Schema: values}
+		return openapi3.NewSchemaRef("", schema), nil
+	case reflect.Struct:
+		if t.Name() == "" {
+			schema, err := g.generateStruct(t)
+			if err != nil {
+				return nil, err
+			}
+
+			return openapi3.NewSchemaRef("", schema), nil
+		}
+
+		return g.generateComponent(t)
+	default:
+		return nil, fmt.Errorf("unsupported type %s", t)
+	}
+}
+
+// generateComponent generates the component schema of the named struct and
+// returns the reference to it.
+func (g *schemaGenerator) generateComponent(t reflect.Type) (*openapi3.SchemaRef, error) {
+	name := g.componentName(t)
+	ref := openapi3.NewSchemaRef("#/components/schemas/"+name, nil)
+
+	// recursive types refer to the component being generated.
+	if g.generating[name] {
+		return ref, nil
+	}
+
+	g.generating[name] = true
+	defer delete(g.generating, name)
+
+	schema, err := g.generateStruct(t)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing, ok := g.components[name]; ok {
+		if !sameSchema(existing.Value, schema) {
+			return nil, fmt.Errorf("conflicting schemas of component %s", name)
+		}
+
+		return ref, nil
+	}
+
+	g.components[name] = openapi3.NewSchemaRef("", schema)
+	return ref, nil
+}
+
+func (g *schemaGenerator) componentName(t reflect.Type) string {
+	name := strings.ToUpper(t.Name()[:1]) + t.Name()[1:]
+	return strings.TrimSuffix(name, g.options.Suffix) + g.options.Suffix
+}
+
+func (g *schemaGenerator) generateStruct(t reflect.Type) (*openapi3.Schema, error) {
+	schema := openapi3.NewObjectSchema()
+
+	for index := 0; index < t.NumField(); index++ {
+		field := t.Field(index)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property, err := g.generateField(field)
+		if err != nil {
+			return nil, fmt.Errorf("field %s of %s: %w", field.Name, t, err)
+		}
+
+		schema.WithPropertyRef(name, property)
+
+		if slices.Contains(strings.Split(field.Tag.Get("binding"), ","), "required") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+
+	return schema, nil
+}
+
+func (g *schemaGenerator) generateField(field reflect.StructField) (*openapi3.SchemaRef, error) {
+	var (
+		property *openapi3.SchemaRef
+		err      error
+	)
+	if key, ok := field.Tag.Lookup(TagSubstitute); ok {
+		substitute, ok := g.options.Substitutes[key]
+		if !ok {
+			return nil, fmt.Errorf("substitute %q not found", key)
+		}
+
+		property, err = g.generate(substitute)
+	} else {
+		property, err = g.generateType(field.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// siblings of a reference are ignored.
+	if property.Ref != "" {
+		return property, nil
+	}
+
+	if err := applyTags(property.Value, field.Tag); err != nil {
+		return nil, err
+	}
+
+	return property, nil
+}
+
+// applyTags applies the documentation tags of a field to its schema.
+func applyTags(schema *openapi3.Schema, tag reflect.StructTag) error {
+	if description, ok := tag.Lookup(TagDescription); ok {
+		schema.Description = description
+	}
+
+	if example, ok := tag.Lookup(TagExample); ok {
+		value, err := parseValue(schema, example)
+		if err != nil {
+			return fmt.Errorf("invalid example: %w", err)
+		}
+		schema.Example = value
+	}
+
+	if enum, ok := tag.Lookup(TagEnum); ok {
+		schema.Enum = nil
+		for _, s := range strings.Split(enum, ",") {
+			value, err := parseValue(schema, s)
+			if err != nil {
+				return fmt.Errorf("invalid enum: %w", err)
+			}
+			schema.Enum = append(schema.Enum, value)
+		}
+	}
+
+	if defaultValue, ok := tag.Lookup(TagDefault); ok {
+		value, err := parseValue(schema, defaultValue)
+		if err != nil {
+			return fmt.Errorf("invalid default: %w", err)
+		}
+		schema.Default = value
+	}
+
+	return nil
+}
+
+// parseValue parses the tag value as a value of the schema type.
+func parseValue(schema *openapi3.Schema, s string) (any, error) {
+	switch {
+	case schema.Type.Is(openapi3.TypeString):
+		return s, nil
+	case schema.Type.Is(openapi3.TypeInteger):
+		return strconv.ParseInt(s, 10, 64)
+	case schema.Type.Is(openapi3.TypeNumber):
+		return strconv.ParseFloat(s, 64)
+	case schema.Type.Is(openapi3.TypeBoolean):
+		return strconv.ParseBool(s)
+	default:
+		var value any
+		if err := json.Unmarshal([]byte(s), &value); err != nil {
+			return nil, err
+		}
+
+		return value, nil
+	}
+}
+
+func sameSchema(a, b *openapi3.Schema) bool {
+	bsA, errA := json.Marshal(a)
+	bsB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(bsA) == string(bsB)
+}
@@ -0,0 +1,43 @@
+package apidoc
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files/v2"
+)
+
+// swaggerInitializer replaces the initializer of the Swagger UI distribution,
+// which loads the petstore example.
+//
+//go:embed swagger/swagger-initializer.js
+var swaggerInitializer []byte
+
+// Serve serves the document at /openapi.json and Swagger UI at /docs. The
+// document must be complete, it is encoded once.
+func (d *Document) Serve(router gin.IRoutes) error {
+	bs, err := d.JSON()
+	if err != nil {
+		return err
+	}
+
+	router.GET("/openapi.json", func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json; charset=utf-8", bs)
+	})
+
+	fileServer := http.StripPrefix("/docs", http.FileServer(http.FS(swaggerFiles.FS)))
+	router.GET("/docs", func(c *gin.Context) {
+		c.Redirect(http.StatusMovedPermanently, "/docs/")
+	})
+	router.GET("/docs/*filepath", func(c *gin.Context) {
+		if c.Param("filepath") == "/swagger-initializer.js" {
+			c.Data(http.StatusOK, "text/javascript; charset=utf-8", swaggerInitializer)
+			return
+		}
+
+		fileServer.ServeHTTP(c.Writer, c.Request)
+	})
+
+	return nil
+}
@@ -0,0 +1,420 @@
+package caldav
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/omegaatt36/gotasker/api/httpcache"
+	"github.com/omegaatt36/gotasker/domain"
+	"github.com/omegaatt36/gotasker/format/ical"
+	"github.com/omegaatt36/gotasker/service/caldav"
+	"github.com/omegaatt36/gotasker/service/task"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WebDAV methods.
+const (
+	MethodPropfind = "PROPFIND"
+	MethodReport   = "REPORT"
+)
+
+// Controller represents a CalDAV controller. It serves a principal, which is
+// also the calendar home, and a single calendar collection of all tasks.
+type Controller struct {
+	service *caldav.Service
+
+	homePath       string
+	collectionPath string
+}
+
+// NewController creates a new CalDAV controller.
+func NewController(service *caldav.Service) *Controller {
+	return &Controller{service: service}
+}
+
+// RegisterRoutes registers the CalDAV routes under /caldav and the
+// /.well-known/caldav redirect (RFC 6764). The routes are not documented,
+// OpenAPI can't describe WebDAV methods.
+func (x *Controller) RegisterRoutes(router *gin.RouterGroup) {
+	home := router.Group("/caldav")
+	x.homePath = strings.TrimSuffix(home.BasePath(), "/") + "/"
+	x.collectionPath = x.homePath + "tasks/"
+
+	for _, method := range []string{http.MethodGet, MethodPropfind} {
+		router.Handle(method, "/.well-known/caldav", x.RedirectHome)
+	}
+
+	for _, relativePath := range []string{"", "/"} {
+		home.OPTIONS(relativePath, x.Options)
+		home.Handle(MethodPropfind, relativePath, x.PropfindHome)
+	}
+	for _, relativePath := range []string{"/tasks", "/tasks/"} {
+		home.OPTIONS(relativePath, x.Options)
+		home.Handle(MethodPropfind, relativePath, x.PropfindCollection)
+		home.Handle(MethodReport, relativePath, x.Report)
+	}
+
+	home.OPTIONS("/tasks/:name", x.Options)
+	home.Handle(MethodPropfind, "/tasks/:name", x.PropfindObject)
+	home.GET("/tasks/:name", x.GetObject)
+	home.PUT("/tasks/:name", x.PutObject)
+	home.DELETE("/tasks/:name", x.DeleteObject)
+}
+
+// RedirectHome redirects clients discovering the CalDAV service to the
+// principal.
+func (x *Controller) RedirectHome(c *gin.Context) {
+	c.Redirect(http.StatusMovedPermanently, x.homePath)
+}
+
+// Options advertises the supported methods and the calendar-access feature.
+func (x *Controller) Options(c *gin.Context) {
+	c.Header("DAV", davCompliance)
+	c.Header("Allow", allowedMethods)
+	c.Status(http.StatusOK)
+}
+
+// PropfindHome gets the properties of the principal, and of the collection
+// unless Depth is 0.
+func (x *Controller) PropfindHome(c *gin.Context) {
+	var req propfindRequest
+	if err := decodeXML(c.Request.Body, &req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, err.Error())
+		return
+	}
+	requested := req.Prop.requested()
+
+	responses := []response{{Href: x.homePath, Propstats: propstats(x.homeProperties(), requested)}}
+	if c.GetHeader(headerDepth) != "0" {
+		properties, err := x.collectionProperties(c)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		responses = append(responses, response{Href: x.collectionPath, Propstats: propstats(properties, requested)})
+	}
+
+	writeMultistatus(c, responses)
+}
+
+// PropfindCollection gets the properties of the collection, and of all
+// objects unless Depth is 0.
+func (x *Controller) PropfindCollection(c *gin.Context) {
+	var req propfindRequest
+	if err := decodeXML(c.Request.Body, &req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, err.Error())
+		return
+	}
+	requested := req.Prop.requested()
+
+	properties, err := x.collectionProperties(c)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	responses := []response{{Href: x.collectionPath, Propstats: propstats(properties, requested)}}
+	if c.GetHeader(headerDepth) != "0" {
+		objects, err := x.service.ListObjects(c.Request.Context())
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		for index := range objects {
+			resp, err := x.objectResponse(&objects[index], requested)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, err.Error())
+				return
+			}
+			responses = append(responses, resp)
+		}
+	}
+
+	writeMultistatus(c, responses)
+}
+
+// PropfindObject gets the properties of an object.
+func (x *Controller) PropfindObject(c *gin.Context) {
+	var req propfindRequest
+	if err := decodeXML(c.Request.Body, &req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	object, err := x.service.GetObject(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		c.AbortWithStatusJSON(errorStatus(err), err.Error())
+		return
+	}
+
+	resp, err := x.objectResponse(&object, req.Prop.requested())
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeMultistatus(c, []response{resp})
+}
+
+// Report runs calendar-query and calendar-multiget reports on the
+// collection. Queries only filter by component, so every VTODO matches.
+func (x *Controller) Report(c *gin.Context) {
+	var req reportRequest
+	if err := decodeXML(c.Request.Body, &req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, err.Error())
+		return
+	}
+	requested := req.Prop.requested()
+
+	var responses []response
+	switch req.XMLName {
+	case reportCalendarQuery:
+		if !req.matchesTodos() {
+			break
+		}
+
+		objects, err := x.service.ListObjects(c.Request.Context())
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		for index := range objects {
+			resp, err := x.objectResponse(&objects[index], requested)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, err.Error())
+				return
+			}
+			responses = append(responses, resp)
+		}
+	case reportCalendarMultiget:
+		for _, href := range req.Hrefs {
+			object, err := x.service.GetObject(c.Request.Context(), x.objectName(href))
+			if errors.Is(err, domain.ErrCalendarObjectNotFound) {
+				responses = append(responses, response{Href: href, Status: statusNotFound})
+				continue
+			}
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, err.Error())
+				return
+			}
+
+			resp, err := x.objectResponse(&object, requested)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, err.Error())
+				return
+			}
+			responses = append(responses, resp)
+		}
+	default:
+		c.AbortWithStatusJSON(http.StatusForbidden, errUnsupportedReport.Error())
+		return
+	}
+
+	writeMultistatus(c, responses)
+}
+
+// GetObject gets the iCalendar document of an object.
+func (x *Controller) GetObject(c *gin.Context) {
+	object, err := x.service.GetObject(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		c.AbortWithStatusJSON(errorStatus(err), err.Error())
+		return
+	}
+
+	body, err := encodeObject(&object)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if httpcache.NotModified(c, httpcache.HashETag(body)) {
+		return
+	}
+
+	c.Data(http.StatusOK, calendarObjectContentType, body)
+}
+
+// PutObject creates or replaces an object by the VTODO of the body, the
+// If-Match and If-None-Match preconditions are compared with the ETag of the
+// current object.
+func (x *Controller) PutObject(c *gin.Context) {
+	name := c.Param("name")
+	if !x.checkPreconditions(c, name) {
+		return
+	}
+
+	todos, err := ical.Decode(c.Request.Body)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(todos) != 1 {
+		c.AbortWithStatusJSON(http.StatusForbidden, errUnsupportedComponent.Error())
+		return
+	}
+
+	_, created, err := x.service.PutObject(c.Request.Context(), name, todos[0])
+	if err != nil {
+		c.AbortWithStatusJSON(errorStatus(err), err.Error())
+		return
+	}
+
+	// properties other than of the task are dropped, so the stored object
+	// differs from the body and the ETag is left out (RFC 4791 5.3.4).
+	if created {
+		c.Status(http.StatusCreated)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// DeleteObject deletes an object and its task.
+func (x *Controller) DeleteObject(c *gin.Context) {
+	name := c.Param("name")
+	if !x.checkPreconditions(c, name) {
+		return
+	}
+
+	if err := x.service.DeleteObject(c.Request.Context(), name); err != nil {
+		c.AbortWithStatusJSON(errorStatus(err), err.Error())
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// checkPreconditions responds 412 Precondition Failed and returns false if
+// the If-Match or If-None-Match header doesn't hold for the object.
+func (x *Controller) checkPreconditions(c *gin.Context, name string) bool {
+	ifMatch, ifNoneMatch := c.GetHeader("If-Match"), c.GetHeader("If-None-Match")
+	if ifMatch == "" && ifNoneMatch == "" {
+		return true
+	}
+
+	var etag string
+	object, err := x.service.GetObject(c.Request.Context(), name)
+	switch {
+	case err == nil:
+		body, err := encodeObject(&object)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, err.Error())
+			return false
+		}
+		etag = httpcache.HashETag(body)
+	case !errors.Is(err, domain.ErrCalendarObjectNotFound):
+		c.AbortWithStatusJSON(http.StatusInternalServerError, err.Error())
+		return false
+	}
+
+	if (ifMatch != "" && (etag == "" || !httpcache.MatchIfMatch(ifMatch, etag))) ||
+		(ifNoneMatch != "" && etag != "" && httpcache.Match(ifNoneMatch, etag)) {
+		c.AbortWithStatus(http.StatusPreconditionFailed)
+		return false
+	}
+
+	return true
+}
+
+func (x *Controller) homeProperties() []property {
+	return []property{
+		{XMLName: propResourceType, InnerXML: `<collection xmlns="DAV:"/>`},
+		{XMLName: propDisplayName, Text: "gotasker"},
+		{XMLName: propCurrentUserPrincipal, InnerXML: hrefXML(x.homePath)},
+		{XMLName: propCalendarHomeSet, InnerXML: hrefXML(x.homePath)},
+	}
+}
+
+// collectionProperties returns the properties of the collection, the CTag
+// changes on every write to the tasks.
+func (x *Controller) collectionProperties(c *gin.Context) ([]property, error) {
+	version, err := x.service.Version(c.Request.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	return []property{
+		{XMLName: propResourceType, InnerXML: `<collection xmlns="DAV:"/><calendar xmlns="` + nsCalDAV + `"/>`},
+		{XMLName: propDisplayName, Text: "Tasks"},
+		{XMLName: propCurrentUserPrincipal, InnerXML: hrefXML(x.homePath)},
+		{XMLName: propSupportedComponentSet, InnerXML: `<comp xmlns="` + nsCalDAV + `" name="VTODO"/>`},
+		{XMLName: propGetCTag, Text: strconv.FormatUint(version, 10)},
+	}, nil
+}
+
+func (x *Controller) objectResponse(object *caldav.Object, requested []xml.Name) (response, error) {
+	body, err := encodeObject(object)
+	if err != nil {
+		return response{}, err
+	}
+
+	properties := []property{
+		{XMLName: propResourceType},
+		{XMLName: propGetETag, Text: httpcache.HashETag(body)},
+		{XMLName: propGetContentType, Text: calendarObjectContentType},
+		{XMLName: propCalendarData, Text: string(body)},
+	}
+
+	return response{
+		Href:      x.collectionPath + url.PathEscape(object.Name),
+		Propstats: propstats(properties, requested),
+	}, nil
+}
+
+// objectName returns the name of the object of the href, which may be an
+// absolute URL. Hrefs outside of the collection return an empty name.
+func (x *Controller) objectName(href string) string {
+	u, err := url.Parse(href)
+	if err != nil {
+		return ""
+	}
+
+	name, ok := strings.CutPrefix(u.Path, x.collectionPath)
+	if !ok || strings.Contains(name, "/") {
+		return ""
+	}
+
+	return name
+}
+
+func encodeObject(object *caldav.Object) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := ical.Encode(&buf, []ical.Todo{object.Todo()}); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func hrefXML(href string) string {
+	var buf bytes.Buffer
+	buf.WriteString(`<href xmlns="DAV:">`)
+	_ = xml.EscapeText(&buf, []byte(href))
+	buf.WriteString(`</href>`)
+
+	return buf.String()
+}
+
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrCalendarObjectNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, caldav.ErrUIDRequired),
+		errors.Is(err, task.ErrTaskNameRequired):
+		return http.StatusBadRequest
+	// the preconditions of RFC 4791 5.3.2.1 fail with 403.
+	case errors.Is(err, caldav.ErrInvalidObjectName),
+		errors.Is(err, caldav.ErrUIDConflict):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
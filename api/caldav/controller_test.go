@@ -0,0 +1,241 @@
+package caldav_test
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/omegaatt36/gotasker/api/caldav"
+	"github.com/omegaatt36/gotasker/domain"
+	"github.com/omegaatt36/gotasker/persistance"
+	"github.com/omegaatt36/gotasker/persistance/database"
+	caldavService "github.com/omegaatt36/gotasker/service/caldav"
+	"github.com/omegaatt36/gotasker/service/task"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+)
+
+type href struct {
+	Href string `xml:"DAV: href"`
+}
+
+type multistatus struct {
+	Responses []struct {
+		Href      string `xml:"DAV: href"`
+		Status    string `xml:"DAV: status"`
+		Propstats []struct {
+			Status string `xml:"DAV: status"`
+			Prop   struct {
+				ResourceType *struct {
+					Calendar *struct{} `xml:"urn:ietf:params:xml:ns:caldav calendar"`
+				} `xml:"DAV: resourcetype"`
+				CurrentUserPrincipal href   `xml:"DAV: current-user-principal"`
+				CalendarHomeSet      href   `xml:"urn:ietf:params:xml:ns:caldav calendar-home-set"`
+				CTag                 string `xml:"http://calendarserver.org/ns/ getctag"`
+				ETag                 string `xml:"DAV: getetag"`
+				CalendarData         string `xml:"urn:ietf:params:xml:ns:caldav calendar-data"`
+			} `xml:"DAV: prop"`
+		} `xml:"DAV: propstat"`
+	} `xml:"DAV: response"`
+}
+
+const todoDocument = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"PRODID:-//Client//EN\r\n" +
+	"BEGIN:VTODO\r\n" +
+	"UID:2F1B8E1C-client\r\n" +
+	"SUMMARY:buy milk\\, eggs\r\n" +
+	"STATUS:NEEDS-ACTION\r\n" +
+	"END:VTODO\r\n" +
+	"END:VCALENDAR\r\n"
+
+type CalDAVSuite struct {
+	suite.Suite
+
+	miniredis *miniredis.Miniredis
+	repo      *persistance.RedisRepo
+	engine    *gin.Engine
+}
+
+func (s *CalDAVSuite) SetupTest() {
+	s.miniredis = database.InitializeTestingRedis()
+	database.Initialize(context.Background(), s.miniredis.Addr(), "")
+
+	s.repo = persistance.NewRedisRepo(database.Redis())
+	service := caldavService.NewService(task.NewService(s.repo), persistance.NewRedisCalendarObjectRepo(database.Redis()))
+
+	gin.SetMode(gin.TestMode)
+	s.engine = gin.New()
+	caldav.NewController(service).RegisterRoutes(s.engine.Group(""))
+}
+
+func (s *CalDAVSuite) TearDownTest() {
+	s.miniredis.Close()
+}
+
+func (s *CalDAVSuite) do(method, target, body string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	recorder := httptest.NewRecorder()
+	s.engine.ServeHTTP(recorder, req)
+
+	return recorder
+}
+
+func (s *CalDAVSuite) multistatus(resp *httptest.ResponseRecorder) multistatus {
+	s.Require().Equal(http.StatusMultiStatus, resp.Code, resp.Body.String())
+
+	var ms multistatus
+	s.Require().NoError(xml.Unmarshal(resp.Body.Bytes(), &ms))
+
+	return ms
+}
+
+func (s *CalDAVSuite) TestDiscovery() {
+	resp := s.do(http.MethodGet, "/.well-known/caldav", "", nil)
+	s.Equal(http.StatusMovedPermanently, resp.Code)
+	s.Equal("/caldav/", resp.Header().Get("Location"))
+
+	resp = s.do(http.MethodOptions, "/caldav/tasks/", "", nil)
+	s.Equal(http.StatusOK, resp.Code)
+	s.Contains(resp.Header().Get("DAV"), "calendar-access")
+
+	ms := s.multistatus(s.do(caldav.MethodPropfind, "/caldav/", `<?xml version="1.0"?>
+		<d:propfind xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+			<d:prop><d:current-user-principal/><c:calendar-home-set/><d:unknown/></d:prop>
+		</d:propfind>`, http.Header{"Depth": []string{"0"}}))
+	s.Require().Len(ms.Responses, 1)
+	s.Equal("/caldav/", ms.Responses[0].Href)
+	s.Require().Len(ms.Responses[0].Propstats, 2)
+	s.Equal("HTTP/1.1 200 OK", ms.Responses[0].Propstats[0].Status)
+	s.Equal("/caldav/", ms.Responses[0].Propstats[0].Prop.CurrentUserPrincipal.Href)
+	s.Equal("/caldav/", ms.Responses[0].Propstats[0].Prop.CalendarHomeSet.Href)
+	s.Equal("HTTP/1.1 404 Not Found", ms.Responses[0].Propstats[1].Status)
+
+	ms = s.multistatus(s.do(caldav.MethodPropfind, "/caldav/", "", http.Header{"Depth": []string{"1"}}))
+	s.Require().Len(ms.Responses, 2)
+	s.Equal("/caldav/tasks/", ms.Responses[1].Href)
+	s.NotNil(ms.Responses[1].Propstats[0].Prop.ResourceType.Calendar)
+}
+
+func (s *CalDAVSuite) TestRoundTrip() {
+	_, err := s.repo.CreateTask(context.Background(), domain.CreateTaskRequest{Name: "task 1"})
+	s.Require().NoError(err)
+
+	collection := s.multistatus(s.do(caldav.MethodPropfind, "/caldav/tasks/", "", http.Header{"Depth": []string{"1"}}))
+	s.Require().Len(collection.Responses, 2)
+	ctag := collection.Responses[0].Propstats[0].Prop.CTag
+	s.NotEmpty(ctag)
+	s.Equal("/caldav/tasks/task-1.ics", collection.Responses[1].Href)
+	s.NotEmpty(collection.Responses[1].Propstats[0].Prop.ETag)
+	s.Empty(collection.Responses[1].Propstats[0].Prop.CalendarData)
+
+	// a client creates a task.
+	resp := s.do(http.MethodPut, "/caldav/tasks/2F1B8E1C.ics", todoDocument, http.Header{"If-None-Match": []string{"*"}})
+	s.Require().Equal(http.StatusCreated, resp.Code, resp.Body.String())
+
+	tasks, err := s.repo.ListTasks(context.Background())
+	s.Require().NoError(err)
+	s.Require().Len(tasks, 2)
+	s.Equal("buy milk, eggs", tasks[1].Name)
+
+	resp = s.do(http.MethodGet, "/caldav/tasks/2F1B8E1C.ics", "", nil)
+	s.Require().Equal(http.StatusOK, resp.Code)
+	s.Contains(resp.Body.String(), "UID:2F1B8E1C-client\r\n")
+	s.Contains(resp.Body.String(), "SUMMARY:buy milk\\, eggs\r\n")
+	etag := resp.Header().Get("ETag")
+	s.NotEmpty(etag)
+
+	s.T().Run("changed ctag", func(t *testing.T) {
+		collection := s.multistatus(s.do(caldav.MethodPropfind, "/caldav/tasks/", "", http.Header{"Depth": []string{"1"}}))
+		s.NotEqual(ctag, collection.Responses[0].Propstats[0].Prop.CTag)
+		s.Require().Len(collection.Responses, 3)
+		s.Equal("/caldav/tasks/2F1B8E1C.ics", collection.Responses[2].Href)
+	})
+
+	s.T().Run("create existing", func(t *testing.T) {
+		resp := s.do(http.MethodPut, "/caldav/tasks/2F1B8E1C.ics", todoDocument, http.Header{"If-None-Match": []string{"*"}})
+		s.Equal(http.StatusPreconditionFailed, resp.Code)
+	})
+
+	s.T().Run("uid conflict", func(t *testing.T) {
+		resp := s.do(http.MethodPut, "/caldav/tasks/other.ics", todoDocument, nil)
+		s.Equal(http.StatusForbidden, resp.Code)
+	})
+
+	s.T().Run("stale etag", func(t *testing.T) {
+		resp := s.do(http.MethodPut, "/caldav/tasks/2F1B8E1C.ics", todoDocument, http.Header{"If-Match": []string{`"stale"`}})
+		s.Equal(http.StatusPreconditionFailed, resp.Code)
+	})
+
+	// the client completes the task.
+	completed := strings.Replace(todoDocument, "STATUS:NEEDS-ACTION", "STATUS:COMPLETED\r\nCOMPLETED:20240502T080000Z", 1)
+	resp = s.do(http.MethodPut, "/caldav/tasks/2F1B8E1C.ics", completed, http.Header{"If-Match": []string{etag}})
+	s.Require().Equal(http.StatusNoContent, resp.Code, resp.Body.String())
+
+	updated, err := s.repo.GetTask(context.Background(), 2)
+	s.Require().NoError(err)
+	s.Equal(domain.TaskStatusCompleted, updated.Status)
+
+	// the client deletes the task.
+	resp = s.do(http.MethodDelete, "/caldav/tasks/2F1B8E1C.ics", "", nil)
+	s.Require().Equal(http.StatusNoContent, resp.Code)
+
+	_, err = s.repo.GetTask(context.Background(), 2)
+	s.ErrorIs(err, domain.ErrTaskNotFound)
+	s.Equal(http.StatusNotFound, s.do(http.MethodGet, "/caldav/tasks/2F1B8E1C.ics", "", nil).Code)
+}
+
+func (s *CalDAVSuite) TestReport() {
+	_, err := s.repo.CreateTask(context.Background(), domain.CreateTaskRequest{Name: "task 1"})
+	s.Require().NoError(err)
+
+	ms := s.multistatus(s.do(caldav.MethodReport, "/caldav/tasks/", `<?xml version="1.0"?>
+		<c:calendar-query xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+			<d:prop><d:getetag/><c:calendar-data/></d:prop>
+			<c:filter><c:comp-filter name="VCALENDAR"><c:comp-filter name="VTODO"/></c:comp-filter></c:filter>
+		</c:calendar-query>`, http.Header{"Depth": []string{"1"}}))
+	s.Require().Len(ms.Responses, 1)
+	s.Equal("/caldav/tasks/task-1.ics", ms.Responses[0].Href)
+	s.NotEmpty(ms.Responses[0].Propstats[0].Prop.ETag)
+	s.Contains(ms.Responses[0].Propstats[0].Prop.CalendarData, "UID:task-1@gotasker\r\n")
+
+	s.T().Run("events", func(t *testing.T) {
+		ms := s.multistatus(s.do(caldav.MethodReport, "/caldav/tasks/", `<?xml version="1.0"?>
+			<c:calendar-query xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+				<c:filter><c:comp-filter name="VCALENDAR"><c:comp-filter name="VEVENT"/></c:comp-filter></c:filter>
+			</c:calendar-query>`, nil))
+		s.Empty(ms.Responses)
+	})
+
+	s.T().Run("multiget", func(t *testing.T) {
+		ms := s.multistatus(s.do(caldav.MethodReport, "/caldav/tasks/", `<?xml version="1.0"?>
+			<c:calendar-multiget xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+				<d:prop><c:calendar-data/></d:prop>
+				<d:href>http://localhost/caldav/tasks/task-1.ics</d:href>
+				<d:href>/caldav/tasks/missing.ics</d:href>
+			</c:calendar-multiget>`, nil))
+		s.Require().Len(ms.Responses, 2)
+		s.Equal("/caldav/tasks/task-1.ics", ms.Responses[0].Href)
+		s.Contains(ms.Responses[0].Propstats[0].Prop.CalendarData, "SUMMARY:task 1\r\n")
+		s.Equal("/caldav/tasks/missing.ics", ms.Responses[1].Href)
+		s.Equal("HTTP/1.1 404 Not Found", ms.Responses[1].Status)
+	})
+
+	s.T().Run("unsupported", func(t *testing.T) {
+		resp := s.do(caldav.MethodReport, "/caldav/tasks/", `<d:sync-collection xmlns:d="DAV:"/>`, nil)
+		s.Equal(http.StatusForbidden, resp.Code)
+	})
+}
+
+func TestCalDAV(t *testing.T) {
+	suite.Run(t, new(CalDAVSuite))
+}
@@ -0,0 +1,222 @@
+package caldav
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+)
+
+// namespaces of the properties.
+const (
+	nsDAV            = "DAV:"
+	nsCalDAV         = "urn:ietf:params:xml:ns:caldav"
+	nsCalendarServer = "http://calendarserver.org/ns/"
+)
+
+// properties served by the resources.
+var (
+	propResourceType          = xml.Name{Space: nsDAV, Local: "resourcetype"}
+	propDisplayName           = xml.Name{Space: nsDAV, Local: "displayname"}
+	propCurrentUserPrincipal  = xml.Name{Space: nsDAV, Local: "current-user-principal"}
+	propGetETag               = xml.Name{Space: nsDAV, Local: "getetag"}
+	propGetContentType        = xml.Name{Space: nsDAV, Local: "getcontenttype"}
+	propCalendarHomeSet       = xml.Name{Space: nsCalDAV, Local: "calendar-home-set"}
+	propSupportedComponentSet = xml.Name{Space: nsCalDAV, Local: "supported-calendar-component-set"}
+	propCalendarData          = xml.Name{Space: nsCalDAV, Local: "calendar-data"}
+	propGetCTag               = xml.Name{Space: nsCalendarServer, Local: "getctag"}
+)
+
+// supported reports.
+var (
+	reportCalendarQuery    = xml.Name{Space: nsCalDAV, Local: "calendar-query"}
+	reportCalendarMultiget = xml.Name{Space: nsCalDAV, Local: "calendar-multiget"}
+)
+
+var (
+	errUnsupportedReport    = errors.New("unsupported report")
+	errUnsupportedComponent = errors.New("calendar object must contain exactly one VTODO")
+)
+
+var (
+	statusOK       = statusLine(http.StatusOK)
+	statusNotFound = statusLine(http.StatusNotFound)
+)
+
+const (
+	headerDepth = "Depth"
+
+	// davCompliance is the DAV header of OPTIONS responses.
+	davCompliance  = "1, 3, calendar-access"
+	allowedMethods = "OPTIONS, GET, PUT, DELETE, PROPFIND, REPORT"
+
+	multistatusContentType    = "application/xml; charset=utf-8"
+	calendarObjectContentType = "text/calendar; charset=utf-8; component=VTODO"
+)
+
+func statusLine(code int) string {
+	return fmt.Sprintf("HTTP/1.1 %d %s", code, http.StatusText(code))
+}
+
+// multistatus is the body of 207 Multi-Status responses (RFC 4918).
+type multistatus struct {
+	XMLName   xml.Name   `xml:"DAV: multistatus"`
+	Responses []response `xml:"DAV: response"`
+}
+
+type response struct {
+	Href      string     `xml:"DAV: href"`
+	Propstats []propstat `xml:"DAV: propstat,omitempty"`
+	Status    string     `xml:"DAV: status,omitempty"`
+}
+
+type propstat struct {
+	Prop   prop   `xml:"DAV: prop"`
+	Status string `xml:"DAV: status"`
+}
+
+type prop struct {
+	Properties []property
+}
+
+// property is a property element, either of text or of the inner XML.
+type property struct {
+	XMLName  xml.Name
+	Text     string `xml:",chardata"`
+	InnerXML string `xml:",innerxml"`
+}
+
+// propfindRequest is the body of PROPFIND requests, an empty body requests
+// all properties.
+type propfindRequest struct {
+	XMLName  xml.Name   `xml:"DAV: propfind"`
+	AllProp  *struct{}  `xml:"DAV: allprop"`
+	PropName *struct{}  `xml:"DAV: propname"`
+	Prop     *propNames `xml:"DAV: prop"`
+}
+
+type propNames struct {
+	Names []nameElement `xml:",any"`
+}
+
+type nameElement struct {
+	XMLName xml.Name
+}
+
+// requested returns the names of the requested properties, nil for all of
+// them.
+func (p *propNames) requested() []xml.Name {
+	if p == nil {
+		return nil
+	}
+
+	names := make([]xml.Name, len(p.Names))
+	for index, name := range p.Names {
+		names[index] = name.XMLName
+	}
+
+	return names
+}
+
+// reportRequest is the body of calendar-query and calendar-multiget REPORT
+// requests (RFC 4791).
+type reportRequest struct {
+	XMLName xml.Name
+	Prop    *propNames `xml:"DAV: prop"`
+	Hrefs   []string   `xml:"DAV: href"`
+	Filter  *struct {
+		CompFilter compFilter `xml:"urn:ietf:params:xml:ns:caldav comp-filter"`
+	} `xml:"urn:ietf:params:xml:ns:caldav filter"`
+}
+
+type compFilter struct {
+	Name         string       `xml:"name,attr"`
+	IsNotDefined *struct{}    `xml:"urn:ietf:params:xml:ns:caldav is-not-defined"`
+	CompFilters  []compFilter `xml:"urn:ietf:params:xml:ns:caldav comp-filter"`
+}
+
+// matchesTodos returns whether VTODOs match the filter of the query, other
+// filters than of components aren't supported and match all VTODOs.
+func (r *reportRequest) matchesTodos() bool {
+	if r.Filter == nil {
+		return true
+	}
+
+	calendar := r.Filter.CompFilter
+	if calendar.Name != "VCALENDAR" || calendar.IsNotDefined != nil {
+		return false
+	}
+	if len(calendar.CompFilters) == 0 {
+		return true
+	}
+
+	for _, component := range calendar.CompFilters {
+		if component.Name == "VTODO" && component.IsNotDefined == nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// decodeXML decodes the request body, an empty body leaves v untouched.
+func decodeXML(body io.Reader, v any) error {
+	err := xml.NewDecoder(body).Decode(v)
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+
+	return err
+}
+
+// propstats splits the properties of a resource into found and not found
+// ones. All properties but calendar-data are returned if requested is nil.
+func propstats(properties []property, requested []xml.Name) []propstat {
+	if requested == nil {
+		found := make([]property, 0, len(properties))
+		for _, p := range properties {
+			if p.XMLName != propCalendarData {
+				found = append(found, p)
+			}
+		}
+
+		return []propstat{{Prop: prop{Properties: found}, Status: statusOK}}
+	}
+
+	var found, missing []property
+	for _, name := range requested {
+		index := slices.IndexFunc(properties, func(p property) bool {
+			return p.XMLName == name
+		})
+		if index < 0 {
+			missing = append(missing, property{XMLName: name})
+			continue
+		}
+		found = append(found, properties[index])
+	}
+
+	var result []propstat
+	if len(found) > 0 {
+		result = append(result, propstat{Prop: prop{Properties: found}, Status: statusOK})
+	}
+	if len(missing) > 0 {
+		result = append(result, propstat{Prop: prop{Properties: missing}, Status: statusNotFound})
+	}
+
+	return result
+}
+
+// writeMultistatus responds 207 Multi-Status.
+func writeMultistatus(c *gin.Context, responses []response) {
+	bs, err := xml.Marshal(multistatus{Responses: responses})
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.Data(http.StatusMultiStatus, multistatusContentType, append([]byte(xml.Header), bs...))
+}
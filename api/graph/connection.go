@@ -0,0 +1,137 @@
+package graph
+
+import (
+	"cmp"
+	"encoding/base64"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/omegaatt36/gotasker/api/graph/model"
+	"github.com/omegaatt36/gotasker/domain"
+)
+
+// DefaultPageSize is the number of tasks of a connection if first is null,
+// MaxPageSize is the maximum of first.
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+var (
+	// ErrInvalidCursor is returned for cursors not issued by a connection.
+	ErrInvalidCursor = errors.New("invalid cursor")
+	// ErrInvalidPageSize is returned if first is out of range.
+	ErrInvalidPageSize = errors.New("first must be between 0 and 100")
+)
+
+const cursorPrefix = "task:"
+
+// encodeCursor returns the opaque cursor of the task, the cursor is stable
+// across writes since tasks are ordered by ID.
+func encodeCursor(id uint) string {
+	return base64.StdEncoding.EncodeToString([]byte(cursorPrefix + strconv.FormatUint(uint64(id), 10)))
+}
+
+func decodeCursor(cursor string) (uint, error) {
+	bs, err := base64.StdEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+
+	id, ok := strings.CutPrefix(string(bs), cursorPrefix)
+	if !ok {
+		return 0, ErrInvalidCursor
+	}
+
+	parsed, err := strconv.ParseUint(id, 10, 0)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+
+	return uint(parsed), nil
+}
+
+// newTaskConnection returns the page of at most first tasks after the task
+// of the cursor.
+func newTaskConnection(tasks []domain.Task, first *int, after *string) (*model.TaskConnection, error) {
+	size := DefaultPageSize
+	if first != nil {
+		size = *first
+	}
+	if size < 0 || size > MaxPageSize {
+		return nil, ErrInvalidPageSize
+	}
+
+	var afterID uint
+	if after != nil {
+		var err error
+		if afterID, err = decodeCursor(*after); err != nil {
+			return nil, err
+		}
+	}
+
+	slices.SortFunc(tasks, func(left, right domain.Task) int {
+		return cmp.Compare(left.ID, right.ID)
+	})
+	start, _ := slices.BinarySearchFunc(tasks, afterID+1, func(task domain.Task, id uint) int {
+		return cmp.Compare(task.ID, id)
+	})
+	end := min(start+size, len(tasks))
+
+	connection := &model.TaskConnection{
+		Edges: make([]model.TaskEdge, 0, end-start),
+		PageInfo: &model.PageInfo{
+			HasNextPage:     end < len(tasks),
+			HasPreviousPage: start > 0,
+		},
+		TotalCount: len(tasks),
+	}
+	for index := start; index < end; index++ {
+		connection.Edges = append(connection.Edges, model.TaskEdge{
+			Cursor: encodeCursor(tasks[index].ID),
+			Node:   &tasks[index],
+		})
+	}
+
+	if len(connection.Edges) > 0 {
+		connection.PageInfo.StartCursor = &connection.Edges[0].Cursor
+		connection.PageInfo.EndCursor = &connection.Edges[len(connection.Edges)-1].Cursor
+	}
+
+	return connection, nil
+}
+
+func toDomainFilter(filter *model.TaskFilter) domain.TaskFilter {
+	if filter == nil {
+		return domain.TaskFilter{}
+	}
+
+	domainFilter := domain.TaskFilter{IDs: filter.Ids}
+	if filter.Status != nil {
+		status := toDomainStatus(*filter.Status)
+		domainFilter.Status = &status
+	}
+	if filter.NameContains != nil {
+		domainFilter.NameContains = *filter.NameContains
+	}
+
+	return domainFilter
+}
+
+func toStatus(status domain.TaskStatus) model.TaskStatus {
+	if status == domain.TaskStatusCompleted {
+		return model.TaskStatusCompleted
+	}
+
+	return model.TaskStatusIncomplete
+}
+
+func toDomainStatus(status model.TaskStatus) domain.TaskStatus {
+	if status == model.TaskStatusCompleted {
+		return domain.TaskStatusCompleted
+	}
+
+	return domain.TaskStatusIncomplete
+}
@@ -0,0 +1,79 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const codeDepthLimitExceeded = "DEPTH_LIMIT_EXCEEDED"
+
+func init() {
+	errcode.RegisterErrorType(codeDepthLimitExceeded, errcode.KindProtocol)
+}
+
+// depthLimit rejects operations nested deeper than the limit before they
+// are executed.
+type depthLimit struct {
+	limit int
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationContextMutator
+} = depthLimit{}
+
+// ExtensionName returns the name of the extension.
+func (depthLimit) ExtensionName() string {
+	return "DepthLimit"
+}
+
+// Validate validates the limit.
+func (d depthLimit) Validate(graphql.ExecutableSchema) error {
+	if d.limit < 1 {
+		return errors.New("depth limit must be positive")
+	}
+
+	return nil
+}
+
+// MutateOperationContext rejects the operation if it's too deep.
+func (d depthLimit) MutateOperationContext(_ context.Context, rc *graphql.OperationContext) *gqlerror.Error {
+	if depth := selectionDepth(rc.Operation.SelectionSet); depth > d.limit {
+		err := gqlerror.Errorf("operation has depth %d, which exceeds the limit of %d", depth, d.limit)
+		errcode.Set(err, codeDepthLimitExceeded)
+		return err
+	}
+
+	return nil
+}
+
+// selectionDepth returns the depth of the deepest field of the selections,
+// fragments don't add to the depth. Introspection fields are skipped, the
+// schema of clients is deeply nested but cheap.
+func selectionDepth(selections ast.SelectionSet) int {
+	var depth int
+	for _, selection := range selections {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(selection.Name, "__") {
+				continue
+			}
+			depth = max(depth, 1+selectionDepth(selection.SelectionSet))
+		case *ast.InlineFragment:
+			depth = max(depth, selectionDepth(selection.SelectionSet))
+		case *ast.FragmentSpread:
+			// cycles of fragments are rejected by the validation before.
+			if selection.Definition != nil {
+				depth = max(depth, selectionDepth(selection.Definition.SelectionSet))
+			}
+		}
+	}
+
+	return depth
+}
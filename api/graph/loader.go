@@ -0,0 +1,71 @@
+package graph
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/omegaatt36/gotasker/domain"
+	"github.com/omegaatt36/gotasker/service/task"
+
+	"github.com/graph-gophers/dataloader/v7"
+)
+
+// loaderWait is how long a loader collects keys before reading them in a
+// batch.
+const loaderWait = time.Millisecond
+
+type loadersKey struct{}
+
+// loaders batch and cache the reads of a request.
+type loaders struct {
+	tasks *dataloader.Loader[uint, domain.Task]
+}
+
+// withLoaders creates the loaders of every request, so the cache never
+// serves the reads of another request.
+func withLoaders(service *task.Service, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		l := &loaders{
+			tasks: dataloader.NewBatchedLoader(batchGetTasks(service), dataloader.WithWait[uint, domain.Task](loaderWait)),
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), loadersKey{}, l)))
+	})
+}
+
+func loadersFor(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+// batchGetTasks gets the tasks of the keys in a single read, missing tasks
+// are loaded as domain.ErrTaskNotFound.
+func batchGetTasks(service *task.Service) dataloader.BatchFunc[uint, domain.Task] {
+	return func(ctx context.Context, ids []uint) []*dataloader.Result[domain.Task] {
+		results := make([]*dataloader.Result[domain.Task], len(ids))
+
+		tasks, err := service.GetTasks(ctx, ids)
+		if err != nil {
+			for index := range results {
+				results[index] = &dataloader.Result[domain.Task]{Error: err}
+			}
+			return results
+		}
+
+		byID := make(map[uint]domain.Task, len(tasks))
+		for _, task := range tasks {
+			byID[task.ID] = task
+		}
+
+		for index, id := range ids {
+			task, ok := byID[id]
+			if !ok {
+				results[index] = &dataloader.Result[domain.Task]{Error: domain.ErrTaskNotFound}
+				continue
+			}
+			results[index] = &dataloader.Result[domain.Task]{Data: task}
+		}
+
+		return results
+	}
+}
@@ -0,0 +1,95 @@
+// Code generated by github.com/99designs/gqlgen, DO NOT EDIT.
+
+package model
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/omegaatt36/gotasker/domain"
+)
+
+type CreateTaskInput struct {
+	Name string `json:"name"`
+}
+
+type Mutation struct {
+}
+
+type PageInfo struct {
+	HasNextPage     bool    `json:"hasNextPage"`
+	HasPreviousPage bool    `json:"hasPreviousPage"`
+	StartCursor     *string `json:"startCursor,omitempty"`
+	EndCursor       *string `json:"endCursor,omitempty"`
+}
+
+type Query struct {
+}
+
+// TaskConnection is a page of tasks ordered by ID.
+type TaskConnection struct {
+	Edges      []TaskEdge `json:"edges"`
+	PageInfo   *PageInfo  `json:"pageInfo"`
+	TotalCount int        `json:"totalCount"`
+}
+
+type TaskEdge struct {
+	Cursor string       `json:"cursor"`
+	Node   *domain.Task `json:"node"`
+}
+
+// TaskFilter selects tasks, criteria are combined with AND.
+type TaskFilter struct {
+	Ids          []uint      `json:"ids,omitempty"`
+	Status       *TaskStatus `json:"status,omitempty"`
+	NameContains *string     `json:"nameContains,omitempty"`
+}
+
+// UpdateTaskInput updates the fields which are set.
+type UpdateTaskInput struct {
+	ID     uint        `json:"id"`
+	Name   *string     `json:"name,omitempty"`
+	Status *TaskStatus `json:"status,omitempty"`
+}
+
+type TaskStatus string
+
+const (
+	TaskStatusIncomplete TaskStatus = "INCOMPLETE"
+	TaskStatusCompleted  TaskStatus = "COMPLETED"
+)
+
+var AllTaskStatus = []TaskStatus{
+	TaskStatusIncomplete,
+	TaskStatusCompleted,
+}
+
+func (e TaskStatus) IsValid() bool {
+	switch e {
+	case TaskStatusIncomplete, TaskStatusCompleted:
+		return true
+	}
+	return false
+}
+
+func (e TaskStatus) String() string {
+	return string(e)
+}
+
+func (e *TaskStatus) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = TaskStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid TaskStatus", str)
+	}
+	return nil
+}
+
+func (e TaskStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
@@ -0,0 +1,112 @@
+package graph
+
+//go:generate gqlgen generate --config gqlgen.yml
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/omegaatt36/gotasker/api/graph/model"
+	"github.com/omegaatt36/gotasker/domain"
+	"github.com/omegaatt36/gotasker/logging"
+	"github.com/omegaatt36/gotasker/service/task"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"go.uber.org/zap"
+)
+
+// default limits of operations.
+const (
+	DefaultMaxDepth      = 10
+	DefaultMaxComplexity = 1000
+)
+
+// codes of the errors caused by the arguments of the client.
+const (
+	codeNotFound     = "NOT_FOUND"
+	codeBadUserInput = "BAD_USER_INPUT"
+)
+
+// Resolver resolves the GraphQL schema with the task service.
+type Resolver struct {
+	service *task.Service
+}
+
+// Limits defines the limits of operations, zero values keep the defaults.
+type Limits struct {
+	// MaxDepth is the maximum nesting of the fields of an operation,
+	// introspection fields are not counted.
+	MaxDepth int
+	// MaxComplexity is the maximum complexity of an operation, every field
+	// costs 1, and the fields of a task connection cost as many times as
+	// the tasks requested.
+	MaxComplexity int
+}
+
+// NewHandler creates the handler serving GraphQL over GET and POST.
+func NewHandler(service *task.Service, limits Limits) http.Handler {
+	if limits.MaxDepth < 1 {
+		limits.MaxDepth = DefaultMaxDepth
+	}
+	if limits.MaxComplexity < 1 {
+		limits.MaxComplexity = DefaultMaxComplexity
+	}
+
+	cfg := Config{Resolvers: &Resolver{service: service}}
+	cfg.Complexity.Query.Tasks = func(childComplexity int, _ *model.TaskFilter, first *int, _ *string) int {
+		if first == nil || *first < 1 {
+			return childComplexity
+		}
+
+		return *first * childComplexity
+	}
+
+	srv := handler.New(NewExecutableSchema(cfg))
+	srv.AddTransport(transport.GET{})
+	srv.AddTransport(transport.POST{})
+	srv.SetQueryCache(lru.New(1000))
+	srv.Use(extension.Introspection{})
+	srv.Use(extension.FixedComplexityLimit(limits.MaxComplexity))
+	srv.Use(depthLimit{limit: limits.MaxDepth})
+	srv.SetErrorPresenter(presentError)
+	srv.SetRecoverFunc(recoverPanic)
+
+	return withLoaders(service, srv)
+}
+
+// presentError adds the code of the service errors to the extensions of the
+// error, like the REST API maps them to status codes.
+func presentError(ctx context.Context, err error) *gqlerror.Error {
+	gqlErr := graphql.DefaultErrorPresenter(ctx, err)
+
+	switch {
+	case errors.Is(err, domain.ErrTaskNotFound):
+		errcode.Set(gqlErr, codeNotFound)
+	case errors.Is(err, task.ErrTaskNameRequired),
+		errors.Is(err, task.ErrInvalidStatus),
+		errors.Is(err, domain.ErrInvalidTaskID),
+		errors.Is(err, ErrInvalidCursor),
+		errors.Is(err, ErrInvalidPageSize):
+		errcode.Set(gqlErr, codeBadUserInput)
+	}
+
+	return gqlErr
+}
+
+func recoverPanic(ctx context.Context, r any) error {
+	logging.ErrorWithFieldCtx(ctx,
+		"[Recovery from panic]",
+		zap.Any("error", r),
+		zap.String("stack", string(debug.Stack())),
+	)
+
+	return gqlerror.Errorf("recover from panic")
+}
@@ -0,0 +1,258 @@
+package graph_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/omegaatt36/gotasker/api/graph"
+	"github.com/omegaatt36/gotasker/domain"
+	"github.com/omegaatt36/gotasker/domain/stub"
+	"github.com/omegaatt36/gotasker/logging"
+	"github.com/omegaatt36/gotasker/service/task"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// countingRepo counts the batched reads of the repository.
+type countingRepo struct {
+	domain.TaskRepository
+	getTasks atomic.Int32
+}
+
+func (r *countingRepo) GetTasks(ctx context.Context, ids []uint) ([]domain.Task, error) {
+	r.getTasks.Add(1)
+	return r.TaskRepository.GetTasks(ctx, ids)
+}
+
+type response struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string `json:"message"`
+		Extensions struct {
+			Code string `json:"code"`
+		} `json:"extensions"`
+	} `json:"errors"`
+}
+
+type GraphSuite struct {
+	suite.Suite
+
+	repo    *countingRepo
+	handler http.Handler
+}
+
+func (s *GraphSuite) SetupSuite() {
+	logging.Init(false, "error")
+}
+
+func (s *GraphSuite) SetupTest() {
+	s.repo = &countingRepo{TaskRepository: stub.NewInMemoryTaskRepository()}
+	s.handler = graph.NewHandler(task.NewService(s.repo), graph.Limits{MaxDepth: 4, MaxComplexity: 200})
+}
+
+func (s *GraphSuite) do(query string, variables map[string]any, data any) response {
+	body, err := json.Marshal(map[string]any{"query": query, "variables": variables})
+	s.Require().NoError(err)
+
+	req := httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	s.handler.ServeHTTP(rec, req)
+
+	var resp response
+	s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &resp))
+	if data != nil && len(resp.Data) > 0 {
+		s.Require().NoError(json.Unmarshal(resp.Data, data))
+	}
+
+	return resp
+}
+
+func (s *GraphSuite) createTasks(names ...string) {
+	for _, name := range names {
+		resp := s.do(`mutation($name: String!) { createTask(input: {name: $name}) { id } }`,
+			map[string]any{"name": name}, nil)
+		s.Require().Empty(resp.Errors)
+	}
+}
+
+func (s *GraphSuite) TestMutations() {
+	var created struct {
+		CreateTask struct {
+			ID     string `json:"id"`
+			Name   string `json:"name"`
+			Status string `json:"status"`
+		} `json:"createTask"`
+	}
+	resp := s.do(`mutation { createTask(input: {name: "task 1"}) { id name status } }`, nil, &created)
+	s.Require().Empty(resp.Errors)
+	s.Equal("1", created.CreateTask.ID)
+	s.Equal("task 1", created.CreateTask.Name)
+	s.Equal("INCOMPLETE", created.CreateTask.Status)
+
+	var updated struct {
+		UpdateTask struct {
+			Name   string `json:"name"`
+			Status string `json:"status"`
+		} `json:"updateTask"`
+	}
+	resp = s.do(`mutation { updateTask(input: {id: 1, status: COMPLETED}) { name status } }`, nil, &updated)
+	s.Require().Empty(resp.Errors)
+	s.Equal("task 1", updated.UpdateTask.Name)
+	s.Equal("COMPLETED", updated.UpdateTask.Status)
+
+	var deleted struct {
+		DeleteTask string `json:"deleteTask"`
+	}
+	resp = s.do(`mutation { deleteTask(id: 1) }`, nil, &deleted)
+	s.Require().Empty(resp.Errors)
+	s.Equal("1", deleted.DeleteTask)
+
+	var got struct {
+		Task *struct{} `json:"task"`
+	}
+	resp = s.do(`{ task(id: 1) { id } }`, nil, &got)
+	s.Empty(resp.Errors)
+	s.Nil(got.Task)
+}
+
+func (s *GraphSuite) TestTasks() {
+	s.createTasks("task 1", "task 2", "task 3", "other")
+	s.Require().Empty(s.do(`mutation { updateTask(input: {id: 2, status: COMPLETED}) { id } }`, nil, nil).Errors)
+
+	type page struct {
+		Tasks struct {
+			Edges []struct {
+				Cursor string `json:"cursor"`
+				Node   struct {
+					ID string `json:"id"`
+				} `json:"node"`
+			} `json:"edges"`
+			PageInfo struct {
+				HasNextPage     bool    `json:"hasNextPage"`
+				HasPreviousPage bool    `json:"hasPreviousPage"`
+				EndCursor       *string `json:"endCursor"`
+			} `json:"pageInfo"`
+			TotalCount int `json:"totalCount"`
+		} `json:"tasks"`
+	}
+	const query = `query($filter: TaskFilter, $after: String) {
+		tasks(filter: $filter, first: 2, after: $after) {
+			edges { cursor node { id } }
+			pageInfo { hasNextPage hasPreviousPage endCursor }
+			totalCount
+		}
+	}`
+
+	var first page
+	s.Require().Empty(s.do(query, nil, &first).Errors)
+	s.Equal(4, first.Tasks.TotalCount)
+	s.Require().Len(first.Tasks.Edges, 2)
+	s.Equal("1", first.Tasks.Edges[0].Node.ID)
+	s.Equal("2", first.Tasks.Edges[1].Node.ID)
+	s.True(first.Tasks.PageInfo.HasNextPage)
+	s.False(first.Tasks.PageInfo.HasPreviousPage)
+	s.Require().NotNil(first.Tasks.PageInfo.EndCursor)
+
+	var second page
+	s.Require().Empty(s.do(query, map[string]any{"after": *first.Tasks.PageInfo.EndCursor}, &second).Errors)
+	s.Require().Len(second.Tasks.Edges, 2)
+	s.Equal("3", second.Tasks.Edges[0].Node.ID)
+	s.Equal("4", second.Tasks.Edges[1].Node.ID)
+	s.False(second.Tasks.PageInfo.HasNextPage)
+	s.True(second.Tasks.PageInfo.HasPreviousPage)
+
+	s.T().Run("filter", func(t *testing.T) {
+		var filtered page
+		s.Require().Empty(s.do(query, map[string]any{
+			"filter": map[string]any{"status": "INCOMPLETE", "nameContains": "task"},
+		}, &filtered).Errors)
+		s.Equal(2, filtered.Tasks.TotalCount)
+		s.Require().Len(filtered.Tasks.Edges, 2)
+		s.Equal("1", filtered.Tasks.Edges[0].Node.ID)
+		s.Equal("3", filtered.Tasks.Edges[1].Node.ID)
+	})
+}
+
+func (s *GraphSuite) TestErrors() {
+	for name, testCase := range map[string]struct {
+		query string
+		code  string
+	}{
+		"without name": {
+			query: `mutation { createTask(input: {name: ""}) { id } }`,
+			code:  "BAD_USER_INPUT",
+		},
+		"update not found": {
+			query: `mutation { updateTask(input: {id: 9, name: "task"}) { id } }`,
+			code:  "NOT_FOUND",
+		},
+		"delete not found": {
+			query: `mutation { deleteTask(id: 9) }`,
+			code:  "NOT_FOUND",
+		},
+		"invalid cursor": {
+			query: `{ tasks(after: "cursor") { totalCount } }`,
+			code:  "BAD_USER_INPUT",
+		},
+		"invalid page size": {
+			query: `{ tasks(first: 101) { totalCount } }`,
+			code:  "BAD_USER_INPUT",
+		},
+		"too complex": {
+			query: `{ tasks(first: 100) { edges { node { id name } } } }`,
+			code:  "COMPLEXITY_LIMIT_EXCEEDED",
+		},
+	} {
+		s.T().Run(name, func(t *testing.T) {
+			resp := s.do(testCase.query, nil, nil)
+			s.Require().Len(resp.Errors, 1)
+			s.Equal(testCase.code, resp.Errors[0].Extensions.Code)
+		})
+	}
+}
+
+func (s *GraphSuite) TestDepthLimit() {
+	s.handler = graph.NewHandler(task.NewService(s.repo), graph.Limits{MaxDepth: 3})
+
+	resp := s.do(`{ tasks { pageInfo { endCursor } edges { node { name } } } }`, nil, nil)
+	s.Require().Len(resp.Errors, 1)
+	s.Equal("DEPTH_LIMIT_EXCEEDED", resp.Errors[0].Extensions.Code)
+
+	resp = s.do(`query { ...page } fragment page on Query { tasks { edges { node { id } } } }`, nil, nil)
+	s.Require().Len(resp.Errors, 1)
+	s.Equal("DEPTH_LIMIT_EXCEEDED", resp.Errors[0].Extensions.Code)
+
+	// introspection fields are not counted.
+	resp = s.do(`{ __schema { types { name fields { name type { name ofType { name ofType { name } } } } } } }`, nil, nil)
+	s.Empty(resp.Errors)
+}
+
+func (s *GraphSuite) TestBatchedReads() {
+	s.createTasks("task 1", "task 2", "task 3")
+
+	var got map[string]*struct {
+		Name string `json:"name"`
+	}
+	resp := s.do(`{
+		a: task(id: 1) { name }
+		b: task(id: 2) { name }
+		c: task(id: 3) { name }
+		d: task(id: 9) { name }
+	}`, nil, &got)
+	s.Require().Empty(resp.Errors)
+	s.Equal("task 1", got["a"].Name)
+	s.Equal("task 2", got["b"].Name)
+	s.Equal("task 3", got["c"].Name)
+	s.Nil(got["d"])
+	s.Equal(int32(1), s.repo.getTasks.Load())
+}
+
+func TestGraph(t *testing.T) {
+	suite.Run(t, new(GraphSuite))
+}
@@ -0,0 +1,103 @@
+package graph
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.49
+
+import (
+	"context"
+	"errors"
+
+	"github.com/omegaatt36/gotasker/api/graph/model"
+	"github.com/omegaatt36/gotasker/domain"
+	"github.com/omegaatt36/gotasker/service/task"
+)
+
+// CreateTask is the resolver for the createTask field.
+func (r *mutationResolver) CreateTask(ctx context.Context, input model.CreateTaskInput) (*domain.Task, error) {
+	created, err := r.service.CreateTask(ctx, task.CreateTaskRequest{Name: input.Name})
+	if err != nil {
+		return nil, err
+	}
+
+	loadersFor(ctx).tasks.Prime(ctx, created.ID, created)
+
+	return &created, nil
+}
+
+// UpdateTask is the resolver for the updateTask field.
+func (r *mutationResolver) UpdateTask(ctx context.Context, input model.UpdateTaskInput) (*domain.Task, error) {
+	req := task.UpdateTaskRequest{Name: input.Name}
+	if input.Status != nil {
+		status := toDomainStatus(*input.Status)
+		req.Status = &status
+	}
+
+	if err := r.service.UpdateTask(ctx, input.ID, req); err != nil {
+		return nil, err
+	}
+
+	// the task may have been loaded by an earlier field.
+	tasks := loadersFor(ctx).tasks
+	tasks.Clear(ctx, input.ID)
+
+	updated, err := tasks.Load(ctx, input.ID)()
+	if err != nil {
+		return nil, err
+	}
+
+	return &updated, nil
+}
+
+// DeleteTask is the resolver for the deleteTask field.
+func (r *mutationResolver) DeleteTask(ctx context.Context, id uint) (uint, error) {
+	if err := r.service.DeleteTask(ctx, id); err != nil {
+		return 0, err
+	}
+
+	loadersFor(ctx).tasks.Clear(ctx, id)
+
+	return id, nil
+}
+
+// Task is the resolver for the task field.
+func (r *queryResolver) Task(ctx context.Context, id uint) (*domain.Task, error) {
+	found, err := loadersFor(ctx).tasks.Load(ctx, id)()
+	if err != nil {
+		if errors.Is(err, domain.ErrTaskNotFound) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &found, nil
+}
+
+// Tasks is the resolver for the tasks field.
+func (r *queryResolver) Tasks(ctx context.Context, filter *model.TaskFilter, first *int, after *string) (*model.TaskConnection, error) {
+	tasks, err := r.service.FindTasks(ctx, toDomainFilter(filter))
+	if err != nil {
+		return nil, err
+	}
+
+	return newTaskConnection(tasks, first, after)
+}
+
+// Status is the resolver for the status field.
+func (r *taskResolver) Status(ctx context.Context, obj *domain.Task) (model.TaskStatus, error) {
+	return toStatus(obj.Status), nil
+}
+
+// Mutation returns MutationResolver implementation.
+func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
+
+// Query returns QueryResolver implementation.
+func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
+
+// Task returns TaskResolver implementation.
+func (r *Resolver) Task() TaskResolver { return &taskResolver{r} }
+
+type mutationResolver struct{ *Resolver }
+type queryResolver struct{ *Resolver }
+type taskResolver struct{ *Resolver }
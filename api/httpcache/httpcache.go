@@ -0,0 +1,75 @@
+// Package httpcache implements conditional requests with entity tags.
+package httpcache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CacheControl makes clients revalidate cached responses on every use, so
+// they never act on stale tasks but can still skip the download.
+const CacheControl = "no-cache"
+
+// ETag returns a strong entity tag quoting the opaque value.
+func ETag(value string) string {
+	return `"` + value + `"`
+}
+
+// HashETag returns a strong entity tag derived from the representation.
+func HashETag(representation []byte) string {
+	sum := sha256.Sum256(representation)
+
+	return ETag(hex.EncodeToString(sum[:16]))
+}
+
+// Match reports whether the If-None-Match header matches the entity tag.
+// Entity tags are compared weakly, as required for If-None-Match.
+func Match(ifNoneMatch string, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MatchIfMatch reports whether the If-Match header matches the entity tag of
+// an existing representation. Entity tags are compared strongly, as required
+// for If-Match.
+func MatchIfMatch(ifMatch string, etag string) bool {
+	for _, candidate := range strings.Split(ifMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || (candidate == etag && !strings.HasPrefix(etag, "W/")) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NotModified sets the validator headers and, when the request's
+// If-None-Match matches the entity tag, responds 304 Not Modified. It returns
+// whether the response was written.
+func NotModified(c *gin.Context, etag string) bool {
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", CacheControl)
+
+	if !Match(c.GetHeader("If-None-Match"), etag) {
+		return false
+	}
+
+	c.AbortWithStatus(http.StatusNotModified)
+
+	return true
+}
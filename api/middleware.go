@@ -1,6 +1,7 @@
 package api
 
 import (
+	"fmt"
 	"net/http"
 	"net/http/httputil"
 	"runtime/debug"
@@ -13,6 +14,16 @@ import (
 	"go.uber.org/zap"
 )
 
+// limitBody limits the bodies of requests to size bytes, reading beyond it
+// fails with *http.MaxBytesError.
+func limitBody(size int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, size)
+
+		c.Next()
+	}
+}
+
 func injectLogging(skipPaths []string) gin.HandlerFunc {
 	mSkipPaths := make(map[string]struct{})
 	for _, path := range skipPaths {
@@ -89,7 +100,8 @@ func corsMiddleware() gin.HandlerFunc {
 			http.MethodDelete,
 			http.MethodOptions,
 		},
-		AllowHeaders:     []string{"Origin", "Content-Length", "Content-Type", "Authorization"},
+		AllowHeaders:     []string{"Origin", "Content-Length", "Content-Type", "Authorization", "If-None-Match", "Last-Event-ID"},
+		ExposeHeaders:    []string{"ETag", "Deprecation", "Sunset", "Link"},
 		AllowCredentials: false,
 		MaxAge:           12 * time.Hour,
 	}
@@ -97,3 +109,18 @@ func corsMiddleware() gin.HandlerFunc {
 
 	return cors.New(config)
 }
+
+// deprecated marks the responses of legacy routes with the Deprecation and
+// Sunset (RFC 8594) headers, linking to the successor route.
+func deprecated(sunset time.Time, successor string) gin.HandlerFunc {
+	sunsetHeader := sunset.UTC().Format(http.TimeFormat)
+	linkHeader := fmt.Sprintf(`<%s>; rel="successor-version"`, successor)
+
+	return func(c *gin.Context) {
+		c.Header("Deprecation", "true")
+		c.Header("Sunset", sunsetHeader)
+		c.Header("Link", linkHeader)
+
+		c.Next()
+	}
+}
@@ -0,0 +1,267 @@
+package notification
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/omegaatt36/gotasker/domain"
+	"github.com/omegaatt36/gotasker/service/notification"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Controller represents a notification controller.
+type Controller struct {
+	service *notification.Service
+}
+
+// NewController creates a new notification controller.
+func NewController(service *notification.Service) *Controller {
+	return &Controller{service: service}
+}
+
+// recipientDetail defines DTO for domain.Recipient.
+type recipientDetail struct {
+	ID        uint      `json:"id" description:"The recipient ID." example:"1"`
+	Email     string    `json:"email" description:"The address the notifications are sent to." example:"alice@example.com"`
+	Name      string    `json:"name" description:"The display name of the recipient." example:"Alice"`
+	Events    []string  `json:"events" openapi:"events"`
+	Active    bool      `json:"active" description:"Inactive recipients are not notified." example:"true"`
+	CreatedAt time.Time `json:"created_at" description:"The creation time in RFC 3339." example:"2024-05-01T08:00:00Z"`
+	UpdatedAt time.Time `json:"updated_at" description:"The last update time in RFC 3339." example:"2024-05-01T08:00:00Z"`
+}
+
+func toRecipientDetail(recipient *domain.Recipient) recipientDetail {
+	events := recipient.Events
+	if events == nil {
+		events = []string{}
+	}
+
+	return recipientDetail{
+		ID:        recipient.ID,
+		Email:     recipient.Email,
+		Name:      recipient.Name,
+		Events:    events,
+		Active:    recipient.Active,
+		CreatedAt: recipient.CreatedAt,
+		UpdatedAt: recipient.UpdatedAt,
+	}
+}
+
+// notificationDetail defines DTO for domain.Notification.
+type notificationDetail struct {
+	ID          uint                        `json:"id" description:"The notification ID." example:"1"`
+	RecipientID uint                        `json:"recipient_id" description:"The recipient ID." example:"1"`
+	EventID     string                      `json:"event_id" description:"The event ID." example:"lq3x9k2-1"`
+	Event       string                      `json:"event" enum:"created,renamed,completed" example:"created"`
+	TaskID      uint                        `json:"task_id" description:"The task ID." example:"1"`
+	Subject     string                      `json:"subject" description:"The subject of the email." example:"Task created: Buy milk"`
+	State       string                      `json:"state" enum:"pending,succeeded,failed" example:"succeeded"`
+	Attempts    []notificationAttemptDetail `json:"attempts"`
+	// NextAttemptAt is only shown while the notification is pending.
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty" description:"The time of the next attempt of a pending notification." example:"2024-05-01T08:00:30Z"`
+	CreatedAt     time.Time  `json:"created_at" example:"2024-05-01T08:00:00Z"`
+	UpdatedAt     time.Time  `json:"updated_at" example:"2024-05-01T08:00:01Z"`
+}
+
+// notificationAttemptDetail defines DTO for domain.DeliveryAttempt of
+// notifications.
+type notificationAttemptDetail struct {
+	At         time.Time `json:"at" example:"2024-05-01T08:00:00Z"`
+	ReplyCode  int       `json:"reply_code" description:"The SMTP reply code, 0 if no reply was received." example:"250"`
+	Error      string    `json:"error,omitempty" example:"smtp error: 451 try again later"`
+	DurationMS int64     `json:"duration_ms" example:"120"`
+}
+
+func toNotificationDetail(n *domain.Notification) notificationDetail {
+	attempts := make([]notificationAttemptDetail, len(n.Attempts))
+	for index, attempt := range n.Attempts {
+		attempts[index] = notificationAttemptDetail{
+			At:         attempt.At,
+			ReplyCode:  attempt.StatusCode,
+			Error:      attempt.Error,
+			DurationMS: attempt.Duration.Milliseconds(),
+		}
+	}
+
+	detail := notificationDetail{
+		ID:          n.ID,
+		RecipientID: n.RecipientID,
+		EventID:     n.EventID,
+		Event:       n.Event,
+		TaskID:      n.TaskID,
+		Subject:     n.Subject,
+		State:       n.State.String(),
+		Attempts:    attempts,
+		CreatedAt:   n.CreatedAt,
+		UpdatedAt:   n.UpdatedAt,
+	}
+	if n.State == domain.DeliveryStatePending {
+		detail.NextAttemptAt = &n.NextAttemptAt
+	}
+
+	return detail
+}
+
+// ListRecipients lists all recipients.
+func (x *Controller) ListRecipients(c *gin.Context) {
+	recipients, err := x.service.ListRecipients(c.Request.Context())
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	details := make([]recipientDetail, len(recipients))
+	for index := range recipients {
+		details[index] = toRecipientDetail(&recipients[index])
+	}
+
+	c.JSON(http.StatusOK, details)
+}
+
+// GetRecipient gets a recipient.
+func (x *Controller) GetRecipient(c *gin.Context) {
+	recipientID, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	recipient, err := x.service.GetRecipient(c.Request.Context(), recipientID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toRecipientDetail(&recipient))
+}
+
+// createRecipientRequest defines the request for creating a recipient.
+type createRecipientRequest struct {
+	Email  string   `json:"email" binding:"required" description:"The address the notifications are sent to." example:"alice@example.com"`
+	Name   string   `json:"name" description:"The display name of the recipient." example:"Alice"`
+	Events []string `json:"events" openapi:"events"`
+}
+
+// CreateRecipient creates a new recipient.
+func (x *Controller) CreateRecipient(c *gin.Context) {
+	var req createRecipientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	recipient, err := x.service.CreateRecipient(c.Request.Context(), notification.CreateRecipientRequest{
+		Email:  req.Email,
+		Name:   req.Name,
+		Events: req.Events,
+	})
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, toRecipientDetail(&recipient))
+}
+
+// updateRecipientRequest defines the request for updating a recipient.
+type updateRecipientRequest struct {
+	Email  *string   `json:"email" description:"The address the notifications are sent to." example:"alice@example.com"`
+	Name   *string   `json:"name" description:"The display name of the recipient." example:"Alice"`
+	Events *[]string `json:"events" openapi:"events"`
+	Active *bool     `json:"active" description:"Pauses the notifications if false." example:"false"`
+}
+
+// UpdateRecipient updates a recipient.
+func (x *Controller) UpdateRecipient(c *gin.Context) {
+	recipientID, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req updateRecipientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	recipient, err := x.service.UpdateRecipient(c.Request.Context(), recipientID, notification.UpdateRecipientRequest{
+		Email:  req.Email,
+		Name:   req.Name,
+		Events: req.Events,
+		Active: req.Active,
+	})
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toRecipientDetail(&recipient))
+}
+
+// DeleteRecipient deletes a recipient and its notification log.
+func (x *Controller) DeleteRecipient(c *gin.Context) {
+	recipientID, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := x.service.DeleteRecipient(c.Request.Context(), recipientID); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// ListNotifications lists the notification log of a recipient, the latest
+// first.
+func (x *Controller) ListNotifications(c *gin.Context) {
+	recipientID, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	notifications, err := x.service.ListNotifications(c.Request.Context(), recipientID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	details := make([]notificationDetail, len(notifications))
+	for index := range notifications {
+		details[index] = toNotificationDetail(&notifications[index])
+	}
+
+	c.JSON(http.StatusOK, details)
+}
+
+// parseID parses a positive ID from the path parameter, the request is
+// aborted otherwise.
+func parseID(c *gin.Context, param string) (uint, bool) {
+	id, err := strconv.Atoi(c.Param(param))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, err.Error())
+		return 0, false
+	}
+
+	if id < 1 {
+		c.AbortWithStatusJSON(http.StatusBadRequest, domain.ErrInvalidRecipientID.Error())
+		return 0, false
+	}
+
+	return uint(id), true
+}
+
+// abortWithError responds with the status of the service error.
+func abortWithError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrRecipientNotFound):
+		c.AbortWithStatusJSON(http.StatusNotFound, err.Error())
+	case errors.Is(err, notification.ErrInvalidEmail), errors.Is(err, notification.ErrInvalidEvent):
+		c.AbortWithStatusJSON(http.StatusBadRequest, err.Error())
+	default:
+		c.AbortWithStatusJSON(http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,132 @@
+package notification_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/omegaatt36/gotasker/api/notification"
+	"github.com/omegaatt36/gotasker/domain/stub"
+	"github.com/omegaatt36/gotasker/logging"
+	"github.com/omegaatt36/gotasker/persistance"
+	"github.com/omegaatt36/gotasker/persistance/database"
+	notificationService "github.com/omegaatt36/gotasker/service/notification"
+	taskService "github.com/omegaatt36/gotasker/service/task"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+)
+
+type NotificationControllerSuite struct {
+	suite.Suite
+}
+
+func (s *NotificationControllerSuite) SetupSuite() {
+	gin.SetMode(gin.TestMode)
+	logging.Init(false, "error")
+}
+
+// senderFunc sends messages by calling itself.
+type senderFunc func(ctx context.Context, msg notificationService.Message) error
+
+func (f senderFunc) Send(ctx context.Context, msg notificationService.Message) error {
+	return f(ctx, msg)
+}
+
+func (s *NotificationControllerSuite) TestRecipients() {
+	miniredis := database.InitializeTestingRedis()
+	defer miniredis.Close()
+
+	database.Initialize(context.Background(), miniredis.Addr(), "")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var sent []notificationService.Message
+	sender := senderFunc(func(_ context.Context, msg notificationService.Message) error {
+		sent = append(sent, msg)
+		return nil
+	})
+
+	tasks := taskService.NewService(stub.NewInMemoryTaskRepository())
+	service := notificationService.NewService(persistance.NewRedisNotificationRepo(database.Redis()), tasks, sender)
+	controller := notification.NewController(service)
+
+	engine := gin.New()
+	engine.GET("/notifications/recipients", controller.ListRecipients)
+	engine.POST("/notifications/recipients", controller.CreateRecipient)
+	engine.GET("/notifications/recipients/:id", controller.GetRecipient)
+	engine.PUT("/notifications/recipients/:id", controller.UpdateRecipient)
+	engine.DELETE("/notifications/recipients/:id", controller.DeleteRecipient)
+	engine.GET("/notifications/recipients/:id/notifications", controller.ListNotifications)
+
+	request := func(method, url, body string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		engine.ServeHTTP(recorder, httptest.NewRequest(method, url, strings.NewReader(body)))
+		return recorder
+	}
+
+	s.T().Run("invalid", func(t *testing.T) {
+		recorder := request(http.MethodPost, "/notifications/recipients", `{"email":"Alice <alice@example.com>"}`)
+		s.Equal(http.StatusBadRequest, recorder.Code)
+		s.Equal(`"recipient email must be a plain address like alice@example.com"`, recorder.Body.String())
+
+		recorder = request(http.MethodPost, "/notifications/recipients", `{"email":"alice@example.com","events":["deleted"]}`)
+		s.Equal(http.StatusBadRequest, recorder.Code)
+
+		s.Equal(http.StatusBadRequest, request(http.MethodPost, "/notifications/recipients", `{}`).Code)
+		s.Equal(http.StatusBadRequest, request(http.MethodGet, "/notifications/recipients/0", "").Code)
+		s.Equal(http.StatusNotFound, request(http.MethodGet, "/notifications/recipients/1", "").Code)
+	})
+
+	recorder := request(http.MethodPost, "/notifications/recipients",
+		`{"email":"alice@example.com","name":"Alice","events":["renamed"]}`)
+	s.Require().Equal(http.StatusCreated, recorder.Code)
+
+	var created map[string]any
+	s.Require().NoError(json.Unmarshal(recorder.Body.Bytes(), &created))
+	s.Equal("alice@example.com", created["email"])
+	s.Equal([]any{"renamed"}, created["events"])
+	s.Equal(true, created["active"])
+
+	recorder = request(http.MethodPut, "/notifications/recipients/1", `{"events":["created"]}`)
+	s.Equal(http.StatusOK, recorder.Code)
+	s.Contains(recorder.Body.String(), `"events":["created"]`)
+
+	stopped := service.Start(ctx)
+	_, err := tasks.CreateTask(ctx, taskService.CreateTaskRequest{Name: "task 1"})
+	s.Require().NoError(err)
+	cancel()
+	// stopping waits for the queued events to be recorded.
+	<-stopped
+
+	_, err = service.SendDue(context.Background())
+	s.Require().NoError(err)
+	s.Require().Len(sent, 1)
+	s.Equal("alice@example.com", sent[0].To.Address)
+	s.Equal("Task created: task 1", sent[0].Subject)
+
+	recorder = request(http.MethodGet, "/notifications/recipients/1/notifications", "")
+	s.Require().Equal(http.StatusOK, recorder.Code)
+
+	var notifications []map[string]any
+	s.Require().NoError(json.Unmarshal(recorder.Body.Bytes(), &notifications))
+	s.Require().Len(notifications, 1)
+	s.Equal("succeeded", notifications[0]["state"])
+	s.Equal("created", notifications[0]["event"])
+	s.Equal(float64(250), notifications[0]["attempts"].([]any)[0].(map[string]any)["reply_code"])
+
+	s.Equal(http.StatusOK, request(http.MethodDelete, "/notifications/recipients/1", "").Code)
+	s.Equal(http.StatusNotFound, request(http.MethodGet, "/notifications/recipients/1/notifications", "").Code)
+
+	recorder = request(http.MethodGet, "/notifications/recipients", "")
+	s.Equal(http.StatusOK, recorder.Code)
+	s.Equal("[]", recorder.Body.String())
+}
+
+func TestNotificationController(t *testing.T) {
+	suite.Run(t, new(NotificationControllerSuite))
+}
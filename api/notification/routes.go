@@ -0,0 +1,109 @@
+package notification
+
+import (
+	"net/http"
+
+	"github.com/omegaatt36/gotasker/api/apidoc"
+	"github.com/omegaatt36/gotasker/domain"
+	"github.com/omegaatt36/gotasker/service/notification"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// RegisterRoutes registers the notification routes on the router and
+// documents them.
+func (x *Controller) RegisterRoutes(router *apidoc.Router) {
+	eventNames := make([]any, len(notification.Events))
+	for index, event := range notification.Events {
+		eventNames[index] = string(event)
+	}
+	events := openapi3.NewArraySchema().
+		WithItems(openapi3.NewStringSchema().WithEnum(eventNames...))
+	events.Description = "The task events the recipient is notified of, empty notifies of all events."
+
+	router = router.Group("/notifications/recipients").Schemas(apidoc.SchemaOptions{
+		Substitutes: map[string]any{"events": events},
+	})
+
+	recipientID := router.Parameter("RecipientID", openapi3.NewPathParameter("id").
+		WithDescription("The recipient ID. must be a positive integer.").
+		WithSchema(openapi3.NewIntegerSchema()))
+
+	badRequest := errorResponse(http.StatusBadRequest, "Invalid parameters.", notification.ErrInvalidEmail)
+	notFound := errorResponse(http.StatusNotFound, "Recipient not found.", domain.ErrRecipientNotFound)
+
+	router.Handle(http.MethodGet, "", apidoc.Operation{
+		ID:      "listRecipients",
+		Summary: "List all notification recipients.",
+		Responses: []apidoc.Response{
+			{Status: http.StatusOK, Description: "The recipients.", Content: apidoc.JSON([]recipientDetail{})},
+		},
+	}, x.ListRecipients)
+	router.Handle(http.MethodPost, "", apidoc.Operation{
+		ID:      "createRecipient",
+		Summary: "Create a notification recipient.",
+		Description: "The recipient is emailed when tasks are created, renamed or completed, as chosen by the events.\n" +
+			"Emails are sent only if the server is configured with a SMTP server, failed ones are retried with exponential backoff.",
+		Request: createRecipientRequest{},
+		Responses: []apidoc.Response{
+			{Status: http.StatusCreated, Description: "The recipient.", Content: apidoc.JSON(recipientDetail{})},
+			badRequest,
+		},
+	}, x.CreateRecipient)
+	router.Handle(http.MethodGet, "/:id", apidoc.Operation{
+		ID:         "getRecipient",
+		Summary:    "Get a notification recipient.",
+		Parameters: []*openapi3.ParameterRef{recipientID},
+		Responses: []apidoc.Response{
+			{Status: http.StatusOK, Description: "The recipient.", Content: apidoc.JSON(recipientDetail{})},
+			errorResponse(http.StatusBadRequest, "Invalid parameters.", domain.ErrInvalidRecipientID),
+			notFound,
+		},
+	}, x.GetRecipient)
+	router.Handle(http.MethodPut, "/:id", apidoc.Operation{
+		ID:         "updateRecipient",
+		Summary:    "Update a notification recipient.",
+		Parameters: []*openapi3.ParameterRef{recipientID},
+		Request:    updateRecipientRequest{},
+		Responses: []apidoc.Response{
+			{Status: http.StatusOK, Description: "The updated recipient.", Content: apidoc.JSON(recipientDetail{})},
+			badRequest,
+			notFound,
+		},
+	}, x.UpdateRecipient)
+	router.Handle(http.MethodDelete, "/:id", apidoc.Operation{
+		ID:         "deleteRecipient",
+		Summary:    "Delete a notification recipient and its notification log.",
+		Parameters: []*openapi3.ParameterRef{recipientID},
+		Responses: []apidoc.Response{
+			{Status: http.StatusOK, Description: "The recipient is deleted."},
+			errorResponse(http.StatusBadRequest, "Invalid parameters.", domain.ErrInvalidRecipientID),
+			notFound,
+		},
+	}, x.DeleteRecipient)
+	router.Handle(http.MethodGet, "/:id/notifications", apidoc.Operation{
+		ID:          "listRecipientNotifications",
+		Summary:     "List the notification log of a recipient.",
+		Description: "The latest notifications are listed first, old ones are removed from the log.",
+		Parameters:  []*openapi3.ParameterRef{recipientID},
+		Responses: []apidoc.Response{
+			{Status: http.StatusOK, Description: "The notifications.", Content: apidoc.JSON([]notificationDetail{})},
+			errorResponse(http.StatusBadRequest, "Invalid parameters.", domain.ErrInvalidRecipientID),
+			notFound,
+		},
+	}, x.ListNotifications)
+}
+
+// errorResponse documents a response of the error message, which is a JSON
+// string.
+func errorResponse(status int, description string, example error) apidoc.Response {
+	return apidoc.Response{
+		Status:      status,
+		Description: description,
+		Content: []apidoc.Content{{
+			MediaType: apidoc.MediaTypeJSON,
+			Body:      "",
+			Example:   example.Error(),
+		}},
+	}
+}
@@ -0,0 +1,215 @@
+package render
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+func init() {
+	Register(Format{
+		Name:        "json",
+		MediaType:   "application/json",
+		ContentType: "application/json; charset=utf-8",
+		Structured:  true,
+		Encoder:     EncoderFunc(encodeJSON),
+	})
+	Register(Format{
+		Name:        "csv",
+		MediaType:   "text/csv",
+		ContentType: "text/csv; charset=utf-8",
+		Encoder:     EncoderFunc(encodeCSV),
+	})
+	Register(Format{
+		Name:       "yaml",
+		MediaType:  "application/yaml",
+		Structured: true,
+		Encoder:    EncoderFunc(encodeYAML),
+	})
+	Register(Format{
+		Name:      "ndjson",
+		MediaType: "application/x-ndjson",
+		Encoder:   EncoderFunc(encodeNDJSON),
+	})
+}
+
+func encodeJSON(w io.Writer, items any) error {
+	return json.NewEncoder(w).Encode(items)
+}
+
+// encodeNDJSON writes one JSON document per line.
+func encodeNDJSON(w io.Writer, items any) error {
+	value, err := sliceValue(items)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(w)
+	for index := range value.Len() {
+		if err := encoder.Encode(value.Index(index).Interface()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// sliceValue returns the value of the slice of items.
+func sliceValue(items any) (reflect.Value, error) {
+	value := reflect.ValueOf(items)
+	if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+		return reflect.Value{}, fmt.Errorf("unsupported list type %T", items)
+	}
+
+	return value, nil
+}
+
+// encodeYAML writes items as a YAML sequence. Items go through JSON first, so
+// the keys and their order follow the json tags of the DTOs.
+func encodeYAML(w io.Writer, items any) error {
+	bs, err := json.Marshal(items)
+	if err != nil {
+		return err
+	}
+
+	// JSON is valid YAML, decoding it into a node keeps the key order.
+	var node yaml.Node
+	if err := yaml.Unmarshal(bs, &node); err != nil {
+		return err
+	}
+	resetStyle(&node)
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
+
+// resetStyle turns the flow style inherited from JSON into block style.
+func resetStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		resetStyle(child)
+	}
+}
+
+// encodeCSV writes the items with a header row. Columns are the json tagged
+// fields of the element type of the slice, so empty lists still have a
+// header, nested values are written as JSON. Items of interface slices, e.g.
+// []any, are described by the type of the first item.
+func encodeCSV(w io.Writer, items any) error {
+	value, err := sliceValue(items)
+	if err != nil {
+		return err
+	}
+
+	itemType := value.Type().Elem()
+	if itemType.Kind() == reflect.Interface && value.Len() > 0 {
+		itemType = reflect.TypeOf(value.Index(0).Interface())
+	}
+	for itemType != nil && itemType.Kind() == reflect.Pointer {
+		itemType = itemType.Elem()
+	}
+
+	writer := csv.NewWriter(w)
+	if itemType == nil || itemType.Kind() == reflect.Interface {
+		// the items of an empty interface slice have no columns.
+		writer.Flush()
+		return writer.Error()
+	}
+	if itemType.Kind() != reflect.Struct {
+		return fmt.Errorf("csv: unsupported item type %v", itemType)
+	}
+
+	columns := csvColumns(itemType)
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = column.name
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for index := range value.Len() {
+		item := value.Index(index)
+		for item.Kind() == reflect.Pointer || item.Kind() == reflect.Interface {
+			item = item.Elem()
+		}
+		if !item.IsValid() || item.Type() != itemType {
+			return fmt.Errorf("csv: unsupported item type %T", value.Index(index).Interface())
+		}
+
+		record := make([]string, len(columns))
+		for i, column := range columns {
+			cell, err := csvCell(item.FieldByIndex(column.index))
+			if err != nil {
+				return err
+			}
+			record[i] = cell
+		}
+
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+type csvColumn struct {
+	name  string
+	index []int
+}
+
+func csvColumns(t reflect.Type) []csvColumn {
+	var columns []csvColumn
+	for _, field := range reflect.VisibleFields(t) {
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = field.Name
+		}
+
+		columns = append(columns, csvColumn{name: name, index: field.Index})
+	}
+
+	return columns
+}
+
+func csvCell(value reflect.Value) (string, error) {
+	for value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return "", nil
+		}
+		value = value.Elem()
+	}
+
+	switch value.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+		bs, err := json.Marshal(value.Interface())
+		if err != nil {
+			return "", err
+		}
+
+		return string(bytes.Trim(bs, `"`)), nil
+	default:
+		return fmt.Sprint(value.Interface()), nil
+	}
+}
@@ -0,0 +1,216 @@
+// Package render writes response bodies in the format negotiated with the
+// client. Formats are looked up from a registry, so a new format is added by
+// registering an Encoder instead of touching every handler.
+package render
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FormatQuery is the query parameter which overrides the Accept header.
+const FormatQuery = "format"
+
+// Encoder encodes a list of DTOs into a response body.
+type Encoder interface {
+	// Encode writes the items to w, items is a slice of the DTOs, e.g.
+	// []taskDetail, whose element type describes empty lists too.
+	Encode(w io.Writer, items any) error
+}
+
+// EncoderFunc is an adapter to allow the use of ordinary functions as Encoder.
+type EncoderFunc func(w io.Writer, items any) error
+
+// Encode calls f(w, items).
+func (f EncoderFunc) Encode(w io.Writer, items any) error {
+	return f(w, items)
+}
+
+// Format defines a registered response format.
+type Format struct {
+	// Name is the value of the format query parameter, e.g. csv.
+	Name string
+	// MediaType is the media type matched against the Accept header, e.g.
+	// text/csv.
+	MediaType string
+	// ContentType is the Content-Type header of the response, which defaults
+	// to MediaType.
+	ContentType string
+	// Structured reports whether the list is encoded as a single document,
+	// which is described by the schema of the list, rather than as lines of
+	// text.
+	Structured bool
+	Encoder    Encoder
+}
+
+var registry struct {
+	sync.RWMutex
+
+	formats []Format
+}
+
+// Register registers a format. The first registered format is the default
+// one, used when the client accepts anything. Registering a name twice
+// replaces the previous format.
+func Register(format Format) {
+	if format.ContentType == "" {
+		format.ContentType = format.MediaType
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+
+	index := slices.IndexFunc(registry.formats, func(f Format) bool {
+		return f.Name == format.Name
+	})
+	if index >= 0 {
+		registry.formats[index] = format
+		return
+	}
+
+	registry.formats = append(registry.formats, format)
+}
+
+// Formats returns the names of registered formats.
+func Formats() []string {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	names := make([]string, len(registry.formats))
+	for index, format := range registry.formats {
+		names[index] = format.Name
+	}
+
+	return names
+}
+
+// All returns the registered formats in the order of registration.
+func All() []Format {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	return slices.Clone(registry.formats)
+}
+
+// Negotiate selects the format from the format query parameter, or else from
+// the Accept header. It returns false if no registered format is acceptable.
+func Negotiate(c *gin.Context) (Format, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	if len(registry.formats) == 0 {
+		return Format{}, false
+	}
+
+	if name := c.Query(FormatQuery); name != "" {
+		index := slices.IndexFunc(registry.formats, func(f Format) bool {
+			return f.Name == name
+		})
+		if index < 0 {
+			return Format{}, false
+		}
+
+		return registry.formats[index], true
+	}
+
+	accepts := parseAccept(c.GetHeader("Accept"))
+	if len(accepts) == 0 {
+		return registry.formats[0], true
+	}
+
+	for _, accept := range accepts {
+		for _, format := range registry.formats {
+			if accept.match(format.MediaType) {
+				return format, true
+			}
+		}
+	}
+
+	return Format{}, false
+}
+
+// List writes the slice of items in the negotiated format, or responds 406
+// Not Acceptable if the client accepts none of the registered formats.
+func List(c *gin.Context, status int, items any) {
+	format, ok := Negotiate(c)
+	if !ok {
+		NotAcceptable(c)
+		return
+	}
+
+	Write(c, format, status, items)
+}
+
+// NotAcceptable responds 406 Not Acceptable with the registered formats.
+func NotAcceptable(c *gin.Context) {
+	c.Header("Vary", "Accept")
+	c.AbortWithStatusJSON(http.StatusNotAcceptable,
+		fmt.Sprintf("acceptable formats are %s", strings.Join(Formats(), ", ")))
+}
+
+// Write writes the slice of items in the given format.
+func Write(c *gin.Context, format Format, status int, items any) {
+	c.Header("Vary", "Accept")
+	c.Header("Content-Type", format.ContentType)
+	c.Status(status)
+
+	if err := format.Encoder.Encode(c.Writer, items); err != nil {
+		// the status is sent already, so the error can only be recorded.
+		_ = c.Error(fmt.Errorf("failed to encode %s: %w", format.Name, err))
+	}
+}
+
+// acceptRange is a media range of the Accept header.
+type acceptRange struct {
+	mediaType string
+	quality   float64
+}
+
+func (a acceptRange) match(mediaType string) bool {
+	switch {
+	case a.mediaType == "*/*", a.mediaType == mediaType:
+		return true
+	case strings.HasSuffix(a.mediaType, "/*"):
+		return strings.HasPrefix(mediaType, strings.TrimSuffix(a.mediaType, "*"))
+	default:
+		return false
+	}
+}
+
+// parseAccept parses the Accept header into media ranges ordered by quality,
+// ranges with zero quality are dropped.
+func parseAccept(header string) []acceptRange {
+	var accepts []acceptRange
+	for _, part := range strings.Split(header, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+
+		if quality > 0 {
+			accepts = append(accepts, acceptRange{mediaType: mediaType, quality: quality})
+		}
+	}
+
+	sort.SliceStable(accepts, func(i, j int) bool {
+		return accepts[i].quality > accepts[j].quality
+	})
+
+	return accepts
+}
@@ -0,0 +1,112 @@
+package render_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/omegaatt36/gotasker/api/render"
+	"github.com/omegaatt36/gotasker/util"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+)
+
+type item struct {
+	ID      uint    `json:"id"`
+	Name    string  `json:"name"`
+	Note    *string `json:"note,omitempty"`
+	Ignored string  `json:"-"`
+}
+
+type RenderSuite struct {
+	suite.Suite
+}
+
+func (s *RenderSuite) list(url string, header http.Header) *util.HTTPTestResponse {
+	return s.listItems(url, header, []*item{
+		{ID: 1, Name: "task 1", Note: util.Pointer("a, b")},
+		{ID: 2, Name: "task 2", Ignored: "ignored"},
+	})
+}
+
+func (s *RenderSuite) listItems(url string, header http.Header, items any) *util.HTTPTestResponse {
+	resp, err := util.HTTPTest(util.HTTPTestRequest{
+		ServedURL:            "/items",
+		RequestURLWithParams: url,
+		Method:               http.MethodGet,
+		Header:               header,
+		HandleFuncs: []gin.HandlerFunc{
+			func(c *gin.Context) {
+				render.List(c, http.StatusOK, items)
+			},
+		},
+	})
+	s.NoError(err)
+
+	return resp
+}
+
+func (s *RenderSuite) TestJSON() {
+	for _, header := range []http.Header{
+		nil,
+		{"Accept": []string{"*/*"}},
+		{"Accept": []string{"application/json"}},
+		{"Accept": []string{"text/html, application/*;q=0.9"}},
+	} {
+		resp := s.list("/items", header)
+		s.Equal(http.StatusOK, resp.StatusCode)
+		s.JSONEq(`[{"id":1,"name":"task 1","note":"a, b"},{"id":2,"name":"task 2"}]`, string(resp.Body))
+	}
+}
+
+func (s *RenderSuite) TestCSV() {
+	expected := "id,name,note\n1,task 1,\"a, b\"\n2,task 2,\n"
+
+	resp := s.list("/items", http.Header{"Accept": []string{"application/json;q=0.5, text/csv"}})
+	s.Equal(http.StatusOK, resp.StatusCode)
+	s.Equal(expected, string(resp.Body))
+
+	resp = s.list("/items?format=csv", http.Header{"Accept": []string{"application/json"}})
+	s.Equal(http.StatusOK, resp.StatusCode)
+	s.Equal(expected, string(resp.Body))
+
+	// the header of empty lists is taken from the element type.
+	resp = s.listItems("/items?format=csv", nil, []item{})
+	s.Equal(http.StatusOK, resp.StatusCode)
+	s.Equal("id,name,note\n", string(resp.Body))
+
+	resp = s.listItems("/items?format=csv", nil, []any{item{ID: 1, Name: "task 1"}})
+	s.Equal(http.StatusOK, resp.StatusCode)
+	s.Equal("id,name,note\n1,task 1,\n", string(resp.Body))
+}
+
+func (s *RenderSuite) TestYAML() {
+	resp := s.list("/items?format=yaml", nil)
+	s.Equal(http.StatusOK, resp.StatusCode)
+	s.Equal(`- id: 1
+  name: task 1
+  note: a, b
+- id: 2
+  name: task 2
+`, string(resp.Body))
+}
+
+func (s *RenderSuite) TestNDJSON() {
+	resp := s.list("/items", http.Header{"Accept": []string{"application/x-ndjson"}})
+	s.Equal(http.StatusOK, resp.StatusCode)
+	s.Equal(`{"id":1,"name":"task 1","note":"a, b"}
+{"id":2,"name":"task 2"}
+`, string(resp.Body))
+}
+
+func (s *RenderSuite) TestNotAcceptable() {
+	resp := s.list("/items?format=xml", nil)
+	s.Equal(http.StatusNotAcceptable, resp.StatusCode)
+
+	resp = s.list("/items", http.Header{"Accept": []string{"text/html, application/json;q=0"}})
+	s.Equal(http.StatusNotAcceptable, resp.StatusCode)
+}
+
+func TestRender(t *testing.T) {
+	suite.Run(t, new(RenderSuite))
+}
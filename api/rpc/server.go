@@ -0,0 +1,88 @@
+package rpc
+
+import (
+	"context"
+	"net"
+	"runtime/debug"
+
+	"github.com/omegaatt36/gotasker/logging"
+	taskv1 "github.com/omegaatt36/gotasker/proto/gotasker/task/v1"
+	"github.com/omegaatt36/gotasker/service/task"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+// Server serves the gRPC API.
+type Server struct {
+	server *grpc.Server
+	// stopping ends the open Watch streams, which would keep the server from
+	// stopping gracefully.
+	stopping chan struct{}
+}
+
+// NewServer creates a gRPC server serving the task service and the
+// reflection service.
+func NewServer(service *task.Service) *Server {
+	s := &Server{
+		server: grpc.NewServer(
+			grpc.ChainUnaryInterceptor(unaryRecovery),
+			grpc.ChainStreamInterceptor(streamRecovery),
+		),
+		stopping: make(chan struct{}),
+	}
+
+	taskv1.RegisterTaskServiceServer(s.server, &TaskServer{service: service, stopping: s.stopping})
+	reflection.Register(s.server)
+
+	return s
+}
+
+// Serve serves the listener until ctx is done, it returns after the
+// in-flight calls are finished.
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+
+		<-ctx.Done()
+		close(s.stopping)
+		s.server.GracefulStop()
+	}()
+
+	if err := s.server.Serve(listener); err != nil {
+		return err
+	}
+	<-stopped
+
+	return nil
+}
+
+func unaryRecovery(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (_ any, err error) {
+	defer recoverPanic(ctx, info.FullMethod, &err)
+
+	return handler(ctx, req)
+}
+
+func streamRecovery(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	defer recoverPanic(stream.Context(), info.FullMethod, &err)
+
+	return handler(srv, stream)
+}
+
+// recoverPanic logs the panic of a call and ends it with INTERNAL.
+func recoverPanic(ctx context.Context, method string, err *error) {
+	if r := recover(); r != nil {
+		logging.ErrorWithFieldCtx(ctx,
+			"[Recovery from panic]",
+			zap.Any("error", r),
+			zap.String("method", method),
+			zap.String("stack", string(debug.Stack())),
+		)
+
+		*err = status.Error(codes.Internal, "recover from panic")
+	}
+}
@@ -0,0 +1,220 @@
+package rpc_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/omegaatt36/gotasker/api/rpc"
+	"github.com/omegaatt36/gotasker/domain/stub"
+	"github.com/omegaatt36/gotasker/logging"
+	taskv1 "github.com/omegaatt36/gotasker/proto/gotasker/task/v1"
+	"github.com/omegaatt36/gotasker/service/task"
+
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	reflectionv1 "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+)
+
+type ServerSuite struct {
+	suite.Suite
+
+	cancel  context.CancelFunc
+	stopped chan struct{}
+	conn    *grpc.ClientConn
+	client  taskv1.TaskServiceClient
+}
+
+func (s *ServerSuite) SetupSuite() {
+	logging.Init(false, "error")
+}
+
+func (s *ServerSuite) SetupTest() {
+	listener := bufconn.Listen(1 << 20)
+	server := rpc.NewServer(task.NewService(stub.NewInMemoryTaskRepository()))
+
+	var ctx context.Context
+	ctx, s.cancel = context.WithCancel(context.Background())
+	s.stopped = make(chan struct{})
+	go func() {
+		defer close(s.stopped)
+		s.NoError(server.Serve(ctx, listener))
+	}()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	s.Require().NoError(err)
+	s.conn = conn
+	s.client = taskv1.NewTaskServiceClient(conn)
+}
+
+func (s *ServerSuite) TearDownTest() {
+	s.conn.Close()
+	s.cancel()
+	<-s.stopped
+}
+
+func (s *ServerSuite) TestTasks() {
+	ctx := context.Background()
+
+	created, err := s.client.CreateTask(ctx, &taskv1.CreateTaskRequest{Name: "task 1"})
+	s.Require().NoError(err)
+	s.Equal(uint64(1), created.GetTask().GetId())
+	s.Equal(taskv1.TaskStatus_TASK_STATUS_INCOMPLETE, created.GetTask().GetStatus())
+	s.NotNil(created.GetTask().GetCreatedAt())
+
+	got, err := s.client.GetTask(ctx, &taskv1.GetTaskRequest{Id: 1})
+	s.Require().NoError(err)
+	s.True(proto.Equal(created.GetTask(), got.GetTask()))
+
+	updated, err := s.client.UpdateTask(ctx, &taskv1.UpdateTaskRequest{
+		Id:     1,
+		Status: taskv1.TaskStatus_TASK_STATUS_COMPLETED.Enum(),
+	})
+	s.Require().NoError(err)
+	s.Equal("task 1", updated.GetTask().GetName())
+	s.Equal(taskv1.TaskStatus_TASK_STATUS_COMPLETED, updated.GetTask().GetStatus())
+
+	listed, err := s.client.ListTasks(ctx, &taskv1.ListTasksRequest{})
+	s.Require().NoError(err)
+	s.Require().Len(listed.GetTasks(), 1)
+	s.True(proto.Equal(updated.GetTask(), listed.GetTasks()[0]))
+
+	_, err = s.client.DeleteTask(ctx, &taskv1.DeleteTaskRequest{Id: 1})
+	s.Require().NoError(err)
+
+	listed, err = s.client.ListTasks(ctx, &taskv1.ListTasksRequest{})
+	s.Require().NoError(err)
+	s.Empty(listed.GetTasks())
+}
+
+func (s *ServerSuite) TestErrors() {
+	ctx := context.Background()
+
+	for name, testCase := range map[string]struct {
+		call func() error
+		code codes.Code
+	}{
+		"without name": {
+			call: func() error {
+				_, err := s.client.CreateTask(ctx, &taskv1.CreateTaskRequest{})
+				return err
+			},
+			code: codes.InvalidArgument,
+		},
+		"invalid id": {
+			call: func() error {
+				_, err := s.client.GetTask(ctx, &taskv1.GetTaskRequest{})
+				return err
+			},
+			code: codes.InvalidArgument,
+		},
+		"not found": {
+			call: func() error {
+				_, err := s.client.GetTask(ctx, &taskv1.GetTaskRequest{Id: 9})
+				return err
+			},
+			code: codes.NotFound,
+		},
+		"update not found": {
+			call: func() error {
+				_, err := s.client.UpdateTask(ctx, &taskv1.UpdateTaskRequest{Id: 9, Name: proto.String("task")})
+				return err
+			},
+			code: codes.NotFound,
+		},
+		"unspecified status": {
+			call: func() error {
+				_, err := s.client.UpdateTask(ctx, &taskv1.UpdateTaskRequest{
+					Id:     1,
+					Status: taskv1.TaskStatus_TASK_STATUS_UNSPECIFIED.Enum(),
+				})
+				return err
+			},
+			code: codes.InvalidArgument,
+		},
+		"delete not found": {
+			call: func() error {
+				_, err := s.client.DeleteTask(ctx, &taskv1.DeleteTaskRequest{Id: 9})
+				return err
+			},
+			code: codes.NotFound,
+		},
+	} {
+		s.T().Run(name, func(t *testing.T) {
+			s.Equal(testCase.code, status.Code(testCase.call()))
+		})
+	}
+}
+
+func (s *ServerSuite) TestWatch() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := s.client.Watch(ctx, &taskv1.WatchRequest{})
+	s.Require().NoError(err)
+	// the stream is subscribed once the headers are received.
+	_, err = stream.Header()
+	s.Require().NoError(err)
+
+	_, err = s.client.CreateTask(ctx, &taskv1.CreateTaskRequest{Name: "task 1"})
+	s.Require().NoError(err)
+	_, err = s.client.DeleteTask(ctx, &taskv1.DeleteTaskRequest{Id: 1})
+	s.Require().NoError(err)
+
+	event, err := stream.Recv()
+	s.Require().NoError(err)
+	s.Equal(taskv1.EventType_EVENT_TYPE_CREATED, event.GetType())
+	s.Equal("task 1", event.GetTask().GetName())
+
+	deleted, err := stream.Recv()
+	s.Require().NoError(err)
+	s.Equal(taskv1.EventType_EVENT_TYPE_DELETED, deleted.GetType())
+
+	s.T().Run("resume", func(t *testing.T) {
+		resumed, err := s.client.Watch(ctx, &taskv1.WatchRequest{LastEventId: event.GetId()})
+		s.Require().NoError(err)
+
+		replayed, err := resumed.Recv()
+		s.Require().NoError(err)
+		s.Equal(deleted.GetId(), replayed.GetId())
+	})
+	s.T().Run("reset", func(t *testing.T) {
+		resumed, err := s.client.Watch(ctx, &taskv1.WatchRequest{LastEventId: "unknown-1"})
+		s.Require().NoError(err)
+
+		reset, err := resumed.Recv()
+		s.Require().NoError(err)
+		s.Equal(taskv1.EventType_EVENT_TYPE_RESET, reset.GetType())
+	})
+}
+
+func (s *ServerSuite) TestReflection() {
+	stream, err := reflectionv1.NewServerReflectionClient(s.conn).ServerReflectionInfo(context.Background())
+	s.Require().NoError(err)
+	s.Require().NoError(stream.Send(&reflectionv1.ServerReflectionRequest{
+		MessageRequest: &reflectionv1.ServerReflectionRequest_ListServices{},
+	}))
+
+	resp, err := stream.Recv()
+	s.Require().NoError(err)
+
+	var services []string
+	for _, service := range resp.GetListServicesResponse().GetService() {
+		services = append(services, service.GetName())
+	}
+	s.Contains(services, "gotasker.task.v1.TaskService")
+}
+
+func TestServer(t *testing.T) {
+	suite.Run(t, new(ServerSuite))
+}
@@ -0,0 +1,224 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/omegaatt36/gotasker/domain"
+	taskv1 "github.com/omegaatt36/gotasker/proto/gotasker/task/v1"
+	"github.com/omegaatt36/gotasker/service/task"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// TaskServer implements the gRPC task service on top of the task service,
+// so the rules are the same as the REST API.
+type TaskServer struct {
+	taskv1.UnimplementedTaskServiceServer
+
+	service  *task.Service
+	stopping <-chan struct{}
+}
+
+// GetTask gets a task.
+func (x *TaskServer) GetTask(ctx context.Context, req *taskv1.GetTaskRequest) (*taskv1.GetTaskResponse, error) {
+	id, err := parseID(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	domainTask, err := x.service.GetTask(ctx, id)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &taskv1.GetTaskResponse{Task: toTask(&domainTask)}, nil
+}
+
+// ListTasks lists all tasks.
+func (x *TaskServer) ListTasks(ctx context.Context, _ *taskv1.ListTasksRequest) (*taskv1.ListTasksResponse, error) {
+	tasks, err := x.service.ListTasks(ctx)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	resp := &taskv1.ListTasksResponse{Tasks: make([]*taskv1.Task, len(tasks))}
+	for index := range tasks {
+		resp.Tasks[index] = toTask(&tasks[index])
+	}
+
+	return resp, nil
+}
+
+// CreateTask creates a new task.
+func (x *TaskServer) CreateTask(ctx context.Context, req *taskv1.CreateTaskRequest) (*taskv1.CreateTaskResponse, error) {
+	domainTask, err := x.service.CreateTask(ctx, task.CreateTaskRequest{Name: req.GetName()})
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &taskv1.CreateTaskResponse{Task: toTask(&domainTask)}, nil
+}
+
+// UpdateTask updates a task and responds with the updated task.
+func (x *TaskServer) UpdateTask(ctx context.Context, req *taskv1.UpdateTaskRequest) (*taskv1.UpdateTaskResponse, error) {
+	id, err := parseID(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	var update task.UpdateTaskRequest
+	update.Name = req.Name
+	if req.Status != nil {
+		taskStatus, err := toDomainStatus(req.GetStatus())
+		if err != nil {
+			return nil, toStatusError(err)
+		}
+		update.Status = &taskStatus
+	}
+
+	if err := x.service.UpdateTask(ctx, id, update); err != nil {
+		return nil, toStatusError(err)
+	}
+
+	domainTask, err := x.service.GetTask(ctx, id)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &taskv1.UpdateTaskResponse{Task: toTask(&domainTask)}, nil
+}
+
+// DeleteTask deletes a task.
+func (x *TaskServer) DeleteTask(ctx context.Context, req *taskv1.DeleteTaskRequest) (*taskv1.DeleteTaskResponse, error) {
+	id, err := parseID(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := x.service.DeleteTask(ctx, id); err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &taskv1.DeleteTaskResponse{}, nil
+}
+
+// Watch streams task events like the SSE endpoint, see
+// task.Service.SubscribeEvents.
+func (x *TaskServer) Watch(req *taskv1.WatchRequest, stream grpc.ServerStreamingServer[taskv1.WatchResponse]) error {
+	subscription := x.service.SubscribeEvents(req.GetLastEventId())
+	defer subscription.Close()
+
+	// the headers tell the client that the stream is subscribed.
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+
+	if subscription.Missed {
+		if err := stream.Send(&taskv1.WatchResponse{Type: taskv1.EventType_EVENT_TYPE_RESET}); err != nil {
+			return err
+		}
+	}
+	for _, event := range subscription.Replay {
+		if err := stream.Send(toWatchResponse(event)); err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return status.FromContextError(stream.Context().Err()).Err()
+		case <-x.stopping:
+			return status.Error(codes.Unavailable, "server is stopping")
+		case event, ok := <-subscription.Events():
+			// the client lagged behind, it resumes with the last event id.
+			if !ok {
+				return status.Error(codes.ResourceExhausted, "watch lagged behind")
+			}
+
+			if err := stream.Send(toWatchResponse(event)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func parseID(id uint64) (uint, error) {
+	if id < 1 {
+		return 0, status.Error(codes.InvalidArgument, domain.ErrInvalidTaskID.Error())
+	}
+
+	return uint(id), nil
+}
+
+// toStatusError maps the errors of the task service to gRPC status codes,
+// like the REST API maps them to HTTP status codes.
+func toStatusError(err error) error {
+	switch {
+	case errors.Is(err, domain.ErrTaskNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, task.ErrTaskNameRequired),
+		errors.Is(err, task.ErrInvalidStatus),
+		errors.Is(err, domain.ErrInvalidTaskStatus):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+func toTask(domainTask *domain.Task) *taskv1.Task {
+	return &taskv1.Task{
+		Id:        uint64(domainTask.ID),
+		Name:      domainTask.Name,
+		Status:    toStatus(domainTask.Status),
+		CreatedAt: timestamppb.New(domainTask.CreatedAt),
+		UpdatedAt: timestamppb.New(domainTask.UpdatedAt),
+	}
+}
+
+func toStatus(taskStatus domain.TaskStatus) taskv1.TaskStatus {
+	switch taskStatus {
+	case domain.TaskStatusIncomplete:
+		return taskv1.TaskStatus_TASK_STATUS_INCOMPLETE
+	case domain.TaskStatusCompleted:
+		return taskv1.TaskStatus_TASK_STATUS_COMPLETED
+	default:
+		return taskv1.TaskStatus_TASK_STATUS_UNSPECIFIED
+	}
+}
+
+func toDomainStatus(taskStatus taskv1.TaskStatus) (domain.TaskStatus, error) {
+	switch taskStatus {
+	case taskv1.TaskStatus_TASK_STATUS_INCOMPLETE:
+		return domain.TaskStatusIncomplete, nil
+	case taskv1.TaskStatus_TASK_STATUS_COMPLETED:
+		return domain.TaskStatusCompleted, nil
+	default:
+		return 0, domain.ErrInvalidTaskStatus
+	}
+}
+
+func toWatchResponse(event task.Event) *taskv1.WatchResponse {
+	var eventType taskv1.EventType
+	switch event.Type {
+	case task.EventTypeCreated:
+		eventType = taskv1.EventType_EVENT_TYPE_CREATED
+	case task.EventTypeUpdated:
+		eventType = taskv1.EventType_EVENT_TYPE_UPDATED
+	case task.EventTypeDeleted:
+		eventType = taskv1.EventType_EVENT_TYPE_DELETED
+	}
+
+	return &taskv1.WatchResponse{
+		Id:   event.ID,
+		Type: eventType,
+		Task: toTask(&event.Task),
+	}
+}
@@ -1,17 +1,37 @@
 package api
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
+	"io/fs"
+	"net"
 	"net/http"
+	"time"
 
+	"github.com/omegaatt36/gotasker/api/apidoc"
+	"github.com/omegaatt36/gotasker/api/caldav"
+	"github.com/omegaatt36/gotasker/api/graph"
+	"github.com/omegaatt36/gotasker/api/notification"
+	"github.com/omegaatt36/gotasker/api/rpc"
+	"github.com/omegaatt36/gotasker/api/smtp"
 	"github.com/omegaatt36/gotasker/api/task"
+	"github.com/omegaatt36/gotasker/api/taskwarrior"
+	"github.com/omegaatt36/gotasker/api/validation"
+	"github.com/omegaatt36/gotasker/api/webhook"
+	"github.com/omegaatt36/gotasker/doc/openapi"
 	"github.com/omegaatt36/gotasker/logging"
 	"github.com/omegaatt36/gotasker/persistance"
 	"github.com/omegaatt36/gotasker/persistance/database"
+	caldavService "github.com/omegaatt36/gotasker/service/caldav"
+	"github.com/omegaatt36/gotasker/service/inbox"
+	notificationService "github.com/omegaatt36/gotasker/service/notification"
 	taskService "github.com/omegaatt36/gotasker/service/task"
+	taskwarriorService "github.com/omegaatt36/gotasker/service/taskwarrior"
+	webhookService "github.com/omegaatt36/gotasker/service/webhook"
 
+	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/gin-gonic/gin"
 )
 
@@ -19,37 +39,206 @@ import (
 type Server struct {
 	router *gin.Engine
 
-	taskController *task.Controller
+	taskController         *task.Controller
+	taskControllerV2       *task.Controller
+	webhookController      *webhook.Controller
+	caldavController       *caldav.Controller
+	taskwarriorController  *taskwarrior.Controller
+	notificationController *notification.Controller
+
+	webhookService *webhookService.Service
+	caldavService  *caldavService.Service
+	// notificationService is nil if notifications aren't sent.
+	notificationService *notificationService.Service
+
+	graphQLHandler http.Handler
+
+	grpcServer *rpc.Server
+	grpcPort   string
+
+	smtpServer *smtp.Server
+	smtpHost   string
+	smtpPort   string
+
+	validator *validation.Validator
+	doc       *apidoc.Document
+}
+
+// legacyAPISunset is the date after which the unversioned and v1 routes may
+// be removed.
+var legacyAPISunset = time.Date(2027, time.June, 30, 0, 0, 0, 0, time.UTC)
+
+// Config defines the configuration of the server.
+type Config struct {
+	// BatchMaxSize is the maximum number of operations of a batch request.
+	BatchMaxSize int
+	// ResponseValidation defines how responses are validated against the
+	// OpenAPI document, requests are always validated.
+	ResponseValidation validation.ResponseMode
+	// EventReplaySize is the number of task events kept for event stream
+	// clients resuming with Last-Event-ID.
+	EventReplaySize int
+	// EventHeartbeatInterval is the interval of heartbeats on idle event
+	// streams.
+	EventHeartbeatInterval time.Duration
+	// WebSocket defines the limits of WebSocket connections, zero values keep
+	// the defaults.
+	WebSocket task.WebSocketConfig
+	// ChangeLogMaxLen is the number of task changes kept in the change log
+	// stream.
+	ChangeLogMaxLen int
+	// GRPCPort is the port of the gRPC API, which isn't served if empty.
+	GRPCPort string
+	// GraphQL defines the limits of GraphQL operations, zero values keep the
+	// defaults.
+	GraphQL graph.Limits
+	// SMTPHost is the host the SMTP listener binds, it defaults to
+	// smtp.DefaultHost as senders aren't authenticated.
+	SMTPHost string
+	// SMTPPort is the port of the SMTP listener creating tasks from emails,
+	// which isn't served if empty.
+	SMTPPort string
+	// SMTPAllowlist is the senders allowed to create tasks by email, entries
+	// like "@example.com" allow every address of the domain.
+	SMTPAllowlist []string
+	// SMTPRateLimit is the number of emails each sender can send in
+	// SMTPRateInterval, the senders of an allowed domain share the limit.
+	SMTPRateLimit    int
+	SMTPRateInterval time.Duration
+	// Notification is the SMTP server the notifications of task events are
+	// sent by, they aren't sent if Host is empty.
+	Notification notificationService.SMTPConfig
+	// NotificationTemplates overrides the built-in templates of the
+	// notifications, it may be nil.
+	NotificationTemplates fs.FS
 }
 
 // NewServer creates a new server
-func NewServer() *Server {
+func NewServer(cfg Config) *Server {
 	apiEngine := gin.New()
 	apiEngine.RedirectTrailingSlash = true
 
-	repo := persistance.NewRedisRepo(database.Redis())
-	taskController := task.NewController(taskService.NewService(repo))
+	repo := persistance.NewRedisRepo(database.Redis(),
+		persistance.WithChangeLogMaxLen(int64(cfg.ChangeLogMaxLen)),
+	)
+	validator, err := validation.NewValidator(openapi.Spec, cmp.Or(cfg.ResponseValidation, validation.ResponseModeOff))
+	if err != nil {
+		logging.Panicf("load openapi spec failed: %v", err)
+	}
+
+	service := taskService.NewService(repo,
+		taskService.WithMaxBatchSize(cfg.BatchMaxSize),
+		taskService.WithEventReplaySize(cfg.EventReplaySize),
+	)
 
-	return &Server{
+	webhooks := webhookService.NewService(persistance.NewRedisWebhookRepo(database.Redis()), service)
+
+	calendar := caldavService.NewService(service, persistance.NewRedisCalendarObjectRepo(database.Redis()))
+
+	notifications := newNotificationService(service, cfg)
+
+	controllerOpts := []task.Option{
+		task.WithHeartbeatInterval(cfg.EventHeartbeatInterval),
+		task.WithWebSocketConfig(cfg.WebSocket),
+	}
+
+	s := &Server{
 		router: apiEngine,
 
-		taskController: taskController,
+		taskController:    task.NewController(service, controllerOpts...),
+		taskControllerV2:  task.NewControllerV2(service, controllerOpts...),
+		webhookController: webhook.NewController(webhooks),
+		caldavController:  caldav.NewController(calendar),
+		taskwarriorController: taskwarrior.NewController(taskwarriorService.NewService(service,
+			persistance.NewRedisTaskwarriorRepo(database.Redis()))),
+		notificationController: notification.NewController(notifications),
+
+		webhookService: webhooks,
+		caldavService:  calendar,
+
+		graphQLHandler: graph.NewHandler(service, cfg.GraphQL),
+
+		grpcServer: rpc.NewServer(service),
+		grpcPort:   cfg.GRPCPort,
+
+		smtpServer: smtp.NewServer(inbox.NewService(service,
+			inbox.WithAllowlist(cfg.SMTPAllowlist...),
+			inbox.WithRateLimit(cfg.SMTPRateLimit, cfg.SMTPRateInterval),
+		)),
+		smtpHost: cmp.Or(cfg.SMTPHost, smtp.DefaultHost),
+		smtpPort: cfg.SMTPPort,
+
+		validator: validator,
+		doc:       newDocument(),
 	}
-}
 
-// Start starts the server
-func (s *Server) Start(ctx context.Context, appPort string) <-chan struct{} {
 	s.router.Use(corsMiddleware())
 	s.registerRoutes()
 
+	if err := s.doc.Serve(s.router); err != nil {
+		logging.Panicf("serve openapi document failed: %v", err)
+	}
+
+	if cfg.Notification.Host != "" {
+		s.notificationService = notifications
+	}
+
+	return s
+}
+
+// newNotificationService creates the notification service, recipients can
+// be managed without a SMTP server though nothing is sent.
+func newNotificationService(tasks *taskService.Service, cfg Config) *notificationService.Service {
+	templates, err := notificationService.ParseTemplates(cfg.NotificationTemplates)
+	if err != nil {
+		logging.Panicf("load notification templates failed: %v", err)
+	}
+
+	var sender notificationService.Sender
+	if cfg.Notification.Host != "" {
+		if sender, err = notificationService.NewSMTPSender(cfg.Notification); err != nil {
+			logging.Panicf("create notification sender failed: %v", err)
+		}
+	}
+
+	return notificationService.NewService(persistance.NewRedisNotificationRepo(database.Redis()), tasks, sender,
+		notificationService.WithTemplates(templates),
+	)
+}
+
+// Document returns the OpenAPI document generated from the registered routes.
+func (s *Server) Document() *apidoc.Document {
+	return s.doc
+}
+
+// Handler returns the http handler serving the routes of the server.
+func (s *Server) Handler() http.Handler {
+	return s.router
+}
+
+// Start starts the server
+func (s *Server) Start(ctx context.Context, appPort string) <-chan struct{} {
 	srv := &http.Server{
 		Addr:    fmt.Sprintf(":%s", appPort),
 		Handler: s.router,
+		// event streams only end when their request is canceled.
+		BaseContext: func(net.Listener) context.Context { return ctx },
 	}
 
+	webhooksStopped := s.webhookService.Start(ctx)
+	caldavStopped := s.caldavService.Start(ctx)
+	notificationsStopped := s.startNotifications(ctx)
+	grpcStopped := s.startGRPC(ctx)
+	smtpStopped := s.startSMTP(ctx)
+
 	closeChain := make(chan struct{})
 	go func() {
 		defer func() {
+			<-webhooksStopped
+			<-caldavStopped
+			<-notificationsStopped
+			<-grpcStopped
+			<-smtpStopped
 			logging.Info("api stopped")
 			closeChain <- struct{}{}
 			close(closeChain)
@@ -73,14 +262,103 @@ func (s *Server) Start(ctx context.Context, appPort string) <-chan struct{} {
 	return closeChain
 }
 
+// startNotifications sends the notifications of task events until ctx is
+// done.
+func (s *Server) startNotifications(ctx context.Context) <-chan struct{} {
+	if s.notificationService == nil {
+		stopped := make(chan struct{})
+		close(stopped)
+		return stopped
+	}
+
+	return s.notificationService.Start(ctx)
+}
+
+// startGRPC serves the gRPC API until ctx is done.
+func (s *Server) startGRPC(ctx context.Context) <-chan struct{} {
+	stopped := make(chan struct{})
+	if s.grpcPort == "" {
+		close(stopped)
+		return stopped
+	}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%s", s.grpcPort))
+	if err != nil {
+		logging.Fatalf("grpc listen: %s\n", err)
+	}
+
+	go func() {
+		defer close(stopped)
+
+		if err := s.grpcServer.Serve(ctx, listener); err != nil {
+			logging.Fatalf("grpc serve: %s\n", err)
+		}
+	}()
+
+	return stopped
+}
+
+// startSMTP serves the SMTP listener until ctx is done.
+func (s *Server) startSMTP(ctx context.Context) <-chan struct{} {
+	stopped := make(chan struct{})
+	if s.smtpPort == "" {
+		close(stopped)
+		return stopped
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(s.smtpHost, s.smtpPort))
+	if err != nil {
+		logging.Fatalf("smtp listen: %s\n", err)
+	}
+
+	go func() {
+		defer close(stopped)
+
+		if err := s.smtpServer.Serve(ctx, listener); err != nil {
+			logging.Fatalf("smtp serve: %s\n", err)
+		}
+	}()
+
+	return stopped
+}
+
 func (s *Server) registerRoutes() {
 	groupedRouter := s.router.Group("")
 
-	groupedRouter.Use(injectLogging([]string{}), recovery())
+	// bodies are limited before the validator reads them as a whole, the
+	// largest ones are the imported dumps.
+	groupedRouter.Use(injectLogging([]string{}), recovery(), limitBody(task.MaxImportBodySize),
+		s.validator.Middleware())
+
+	// the unversioned routes are kept as alias of v1.
+	legacy := deprecated(legacyAPISunset, "/v2/tasks")
+	s.taskController.RegisterRoutes(s.doc.Router(groupedRouter.Group("", legacy),
+		apidoc.RouterOptions{Deprecated: true}))
+	s.taskController.RegisterRoutes(s.doc.Router(groupedRouter.Group("/v1", legacy),
+		apidoc.RouterOptions{OperationSuffix: "V1", Deprecated: true}))
+	s.taskControllerV2.RegisterRoutes(s.doc.Router(groupedRouter.Group("/v2"),
+		apidoc.RouterOptions{OperationSuffix: "V2"}))
+	s.webhookController.RegisterRoutes(s.doc.Router(groupedRouter.Group(""), apidoc.RouterOptions{}))
+	s.taskwarriorController.RegisterRoutes(s.doc.Router(groupedRouter.Group(""), apidoc.RouterOptions{}))
+	s.notificationController.RegisterRoutes(s.doc.Router(groupedRouter.Group(""), apidoc.RouterOptions{}))
+
+	s.caldavController.RegisterRoutes(groupedRouter)
+
+	// GraphQL has its own schema, so it isn't in the OpenAPI document.
+	groupedRouter.GET("/graphql", gin.WrapH(s.graphQLHandler))
+	groupedRouter.POST("/graphql", gin.WrapH(s.graphQLHandler))
+}
 
-	groupFilmLog := groupedRouter.Group("/tasks")
-	groupFilmLog.GET("", s.taskController.ListTasks)
-	groupFilmLog.POST("", s.taskController.CreateTask)
-	groupFilmLog.PUT("/:id", s.taskController.UpdateTask)
-	groupFilmLog.DELETE("/:id", s.taskController.DeleteTask)
+func newDocument() *apidoc.Document {
+	return apidoc.New(&openapi3.Info{
+		Title: "GoTasker API Documentation",
+		Description: "- The efficient communication between engineers.\n" +
+			"- `/v2` presents task status by name and timestamps in RFC 3339.\n" +
+			"- The unversioned routes are aliases of `/v1`, both are deprecated and respond with `Deprecation` and `Sunset` headers.",
+		Version: "0.0.1",
+		License: &openapi3.License{
+			Name: "Unlicense",
+			URL:  "https://github.com/omegaatt36/gotasker",
+		},
+	})
 }
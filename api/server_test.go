@@ -0,0 +1,145 @@
+package api_test
+
+import (
+	"context"
+	"flag"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/omegaatt36/gotasker/api"
+	"github.com/omegaatt36/gotasker/api/task"
+	"github.com/omegaatt36/gotasker/api/validation"
+	"github.com/omegaatt36/gotasker/doc/openapi"
+	"github.com/omegaatt36/gotasker/logging"
+	"github.com/omegaatt36/gotasker/persistance/database"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+)
+
+var pathParameter = regexp.MustCompile(`:(\w+)`)
+
+var update = flag.Bool("update", false, "update doc/openapi/api.yaml from the generated document")
+
+type ServerSuite struct {
+	suite.Suite
+
+	miniredis *miniredis.Miniredis
+	server    *api.Server
+}
+
+func (s *ServerSuite) SetupSuite() {
+	gin.SetMode(gin.TestMode)
+	logging.Init(false, "error")
+
+	s.miniredis = database.InitializeTestingRedis()
+	database.Initialize(context.Background(), s.miniredis.Addr(), "")
+
+	s.server = api.NewServer(api.Config{ResponseValidation: validation.ResponseModeOff})
+}
+
+func (s *ServerSuite) TearDownSuite() {
+	s.miniredis.Close()
+}
+
+func (s *ServerSuite) TestOpenAPIDocument() {
+	generated, err := s.server.Document().YAML()
+	s.Require().NoError(err)
+
+	if *update {
+		s.Require().NoError(os.WriteFile("../doc/openapi/api.yaml", generated, 0o644))
+		return
+	}
+
+	s.Equal(string(generated), string(openapi.Spec),
+		"doc/openapi/api.yaml is outdated, run go generate ./doc/openapi")
+}
+
+func (s *ServerSuite) TestOpenAPIDocumentCoversRoutes() {
+	bs, err := s.server.Document().JSON()
+	s.Require().NoError(err)
+
+	loader := openapi3.NewLoader()
+	doc, err := loader.LoadFromData(bs)
+	s.Require().NoError(err)
+	s.Require().NoError(doc.Validate(loader.Context))
+
+	engine, ok := s.server.Handler().(*gin.Engine)
+	s.Require().True(ok)
+
+	for _, route := range engine.Routes() {
+		// GraphQL has its own schema, and OpenAPI can't describe WebDAV.
+		if route.Path == "/openapi.json" || route.Path == "/docs" || route.Path == "/docs/*filepath" ||
+			route.Path == "/graphql" || route.Path == "/.well-known/caldav" || strings.HasPrefix(route.Path, "/caldav") {
+			continue
+		}
+
+		pathItem := doc.Paths.Find(pathParameter.ReplaceAllString(route.Path, "{$1}"))
+		if s.NotNil(pathItem, route.Path) {
+			s.NotNil(pathItem.GetOperation(route.Method), "%s %s", route.Method, route.Path)
+		}
+	}
+}
+
+func (s *ServerSuite) TestServeDocs() {
+	for _, tc := range []struct {
+		url         string
+		contentType string
+	}{
+		{url: "/openapi.json", contentType: "application/json; charset=utf-8"},
+		{url: "/docs/", contentType: "text/html; charset=utf-8"},
+		{url: "/docs/swagger-initializer.js", contentType: "text/javascript; charset=utf-8"},
+	} {
+		recorder := httptest.NewRecorder()
+		s.server.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, tc.url, nil))
+
+		s.Equal(http.StatusOK, recorder.Code, tc.url)
+		s.Equal(tc.contentType, recorder.Header().Get("Content-Type"), tc.url)
+	}
+
+	recorder := httptest.NewRecorder()
+	s.server.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	s.Equal(http.StatusMovedPermanently, recorder.Code)
+}
+
+// countingReader reads size bytes of 'a', counting the bytes read.
+type countingReader struct {
+	size, read int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n := min(len(p), r.size-r.read)
+	if n == 0 {
+		return 0, io.EOF
+	}
+
+	for index := range n {
+		p[index] = 'a'
+	}
+	r.read += n
+
+	return n, nil
+}
+
+func (s *ServerSuite) TestImportBodyLimit() {
+	// the body is limited before the request validation reads it.
+	body := &countingReader{size: 2 * task.MaxImportBodySize}
+	req := httptest.NewRequest(http.MethodPost, "/v2/tasks/import?format=markdown", body)
+	req.Header.Set("Content-Type", "text/markdown")
+
+	recorder := httptest.NewRecorder()
+	s.server.Handler().ServeHTTP(recorder, req)
+	s.Equal(http.StatusRequestEntityTooLarge, recorder.Code)
+	s.LessOrEqual(body.read, task.MaxImportBodySize+1<<20)
+}
+
+func TestServerSuite(t *testing.T) {
+	suite.Run(t, new(ServerSuite))
+}
@@ -0,0 +1,186 @@
+package smtp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"time"
+
+	"github.com/omegaatt36/gotasker/format/email"
+	"github.com/omegaatt36/gotasker/logging"
+	"github.com/omegaatt36/gotasker/service/inbox"
+	"github.com/omegaatt36/gotasker/service/task"
+
+	gosmtp "github.com/emersion/go-smtp"
+)
+
+// DefaultHost is the default host the listener binds, only local MTAs can
+// connect to it as senders aren't authenticated.
+const DefaultHost = "127.0.0.1"
+
+// limits of connections.
+const (
+	MaxMessageBytes = 1 << 20
+	MaxRecipients   = 50
+	Timeout         = time.Minute
+	// shutdownTimeout is the time given to open sessions to finish.
+	shutdownTimeout = 10 * time.Second
+)
+
+var (
+	errSenderNotAllowed = &gosmtp.SMTPError{
+		Code:         550,
+		EnhancedCode: gosmtp.EnhancedCode{5, 7, 1},
+		Message:      "Sender is not allowed",
+	}
+	errRateLimited = &gosmtp.SMTPError{
+		Code:         451,
+		EnhancedCode: gosmtp.EnhancedCode{4, 7, 1},
+		Message:      "Too many messages, try again later",
+	}
+	errInvalidMessage = &gosmtp.SMTPError{
+		Code:         554,
+		EnhancedCode: gosmtp.EnhancedCode{5, 6, 0},
+		Message:      "Message can't be turned into a task",
+	}
+	errInternal = &gosmtp.SMTPError{
+		Code:         451,
+		EnhancedCode: gosmtp.EnhancedCode{4, 3, 0},
+		Message:      "Task can't be created, try again later",
+	}
+)
+
+// Server receives emails by SMTP and creates a task of each of them by the
+// inbox service. Every recipient is accepted, so the address tasks are sent
+// to only matters to the mail routing. Clients are neither authenticated nor
+// encrypted, and senders are only checked by their MAIL FROM, which any
+// client can forge. The server must be reachable only by trusted MTAs, e.g.
+// by binding DefaultHost behind a local MTA which verifies the senders.
+type Server struct {
+	server  *gosmtp.Server
+	service *inbox.Service
+}
+
+// NewServer creates a SMTP server of the inbox service.
+func NewServer(service *inbox.Service) *Server {
+	s := &Server{service: service}
+
+	s.server = gosmtp.NewServer(nil)
+	s.server.Domain = "gotasker"
+	if hostname, err := os.Hostname(); err == nil {
+		s.server.Domain = hostname
+	}
+	s.server.MaxMessageBytes = MaxMessageBytes
+	s.server.MaxRecipients = MaxRecipients
+	s.server.ReadTimeout = Timeout
+	s.server.WriteTimeout = Timeout
+	s.server.ErrorLog = errorLog{}
+
+	return s
+}
+
+// Serve serves the listener until ctx is done, it returns after the open
+// sessions are finished or the shutdown times out.
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	// messages being received still create their tasks on shutdown.
+	sessionCtx := context.WithoutCancel(ctx)
+	s.server.Backend = gosmtp.BackendFunc(func(c *gosmtp.Conn) (gosmtp.Session, error) {
+		return &session{ctx: sessionCtx, service: s.service, remote: c.Conn().RemoteAddr().String()}, nil
+	})
+
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(sessionCtx, shutdownTimeout)
+		defer cancel()
+
+		if err := s.server.Shutdown(shutdownCtx); err != nil {
+			logging.Warnf("smtp shutdown: %v", err)
+			_ = s.server.Close()
+		}
+	}()
+
+	if err := s.server.Serve(listener); err != nil {
+		return err
+	}
+	<-stopped
+
+	return nil
+}
+
+// session is a SMTP session, which can send several messages.
+type session struct {
+	ctx     context.Context
+	service *inbox.Service
+	remote  string
+
+	sender string
+}
+
+// Mail checks the envelope sender against the allowlist, it's only as
+// trustworthy as the MTA relaying the message.
+func (s *session) Mail(from string, _ *gosmtp.MailOptions) error {
+	if err := s.service.CheckSender(from); err != nil {
+		logging.InfofCtx(s.ctx, "smtp: rejected sender %q from %s", from, s.remote)
+		return errSenderNotAllowed
+	}
+
+	s.sender = from
+
+	return nil
+}
+
+func (s *session) Rcpt(string, *gosmtp.RcptOptions) error {
+	return nil
+}
+
+func (s *session) Data(r io.Reader) error {
+	// the message must be read to its end before responding.
+	defer func() { _, _ = io.Copy(io.Discard, r) }()
+
+	t, err := s.service.Receive(s.ctx, s.sender, r)
+
+	var smtpErr *gosmtp.SMTPError
+	switch {
+	case err == nil:
+		logging.InfofCtx(s.ctx, "smtp: created task %d from %s", t.ID, s.sender)
+		return nil
+	case errors.As(err, &smtpErr):
+		// e.g. the message exceeds the maximum size.
+		return smtpErr
+	case errors.Is(err, inbox.ErrSenderNotAllowed):
+		return errSenderNotAllowed
+	case errors.Is(err, inbox.ErrRateLimited):
+		return errRateLimited
+	case errors.Is(err, email.ErrMalformed), errors.Is(err, email.ErrUnknownCharset),
+		errors.Is(err, task.ErrTaskNameRequired):
+		return errInvalidMessage
+	default:
+		logging.ErrorfCtx(s.ctx, "smtp: create task from %s: %v", s.sender, err)
+		return errInternal
+	}
+}
+
+func (s *session) Reset() {
+	s.sender = ""
+}
+
+func (s *session) Logout() error {
+	return nil
+}
+
+// errorLog logs the errors of the SMTP server, e.g. failed connections.
+type errorLog struct{}
+
+func (errorLog) Printf(format string, v ...any) {
+	logging.Errorf("smtp: "+format, v...)
+}
+
+func (errorLog) Println(v ...any) {
+	logging.Error("smtp: " + fmt.Sprint(v...))
+}
@@ -0,0 +1,113 @@
+package smtp_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	netsmtp "net/smtp"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/omegaatt36/gotasker/api/smtp"
+	"github.com/omegaatt36/gotasker/domain/stub"
+	"github.com/omegaatt36/gotasker/logging"
+	"github.com/omegaatt36/gotasker/service/inbox"
+	"github.com/omegaatt36/gotasker/service/task"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type ServerSuite struct {
+	suite.Suite
+
+	repo    *stub.InMemoryTaskRepository
+	addr    string
+	cancel  context.CancelFunc
+	stopped chan struct{}
+}
+
+func (s *ServerSuite) SetupSuite() {
+	logging.Init(false, "error")
+}
+
+func (s *ServerSuite) SetupTest() {
+	s.repo = stub.NewInMemoryTaskRepository()
+	server := smtp.NewServer(inbox.NewService(task.NewService(s.repo),
+		inbox.WithAllowlist("alice@example.com"),
+		inbox.WithRateLimit(2, time.Hour),
+	))
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	s.Require().NoError(err)
+	s.addr = listener.Addr().String()
+
+	var ctx context.Context
+	ctx, s.cancel = context.WithCancel(context.Background())
+	s.stopped = make(chan struct{})
+	go func() {
+		defer close(s.stopped)
+		s.NoError(server.Serve(ctx, listener))
+	}()
+}
+
+func (s *ServerSuite) TearDownTest() {
+	s.cancel()
+	<-s.stopped
+}
+
+func (s *ServerSuite) send(from, message string) error {
+	return netsmtp.SendMail(s.addr, nil, from, []string{"tasks@example.com"},
+		[]byte(strings.ReplaceAll(message, "\n", "\r\n")))
+}
+
+// code returns the reply code of the error of the client.
+func (s *ServerSuite) code(err error) int {
+	var protoErr *textproto.Error
+	s.Require().True(errors.As(err, &protoErr), err)
+
+	return protoErr.Code
+}
+
+func (s *ServerSuite) TestReceive() {
+	s.Require().NoError(s.send("alice@example.com", `From: Bob <bob@example.org>
+Subject: Fwd: Fix the printer
+Content-Type: multipart/alternative; boundary="b"
+
+--b
+Content-Type: text/plain; charset=utf-8
+
+The printer on the 2nd floor is jammed.
+--b
+Content-Type: text/html
+
+<p>The printer on the 2nd floor is jammed.</p>
+--b--
+`))
+
+	tasks, err := s.repo.ListTasks(context.Background())
+	s.Require().NoError(err)
+	s.Require().Len(tasks, 1)
+	s.Equal("Fix the printer", tasks[0].Name)
+	s.Equal("The printer on the 2nd floor is jammed.", tasks[0].Notes)
+}
+
+func (s *ServerSuite) TestReject() {
+	s.Equal(550, s.code(s.send("mallory@example.com", "Subject: task\n\nbody\n")))
+	s.Equal(554, s.code(s.send("alice@example.com", "From: alice@example.com\n\nno subject\n")))
+
+	s.NoError(s.send("alice@example.com", "Subject: task 1\n\n"))
+	s.NoError(s.send("alice@example.com", "Subject: task 2\n\n"))
+	s.Equal(451, s.code(s.send("alice@example.com", "Subject: task 3\n\n")))
+
+	s.Equal(552, s.code(s.send("alice@example.com", "Subject: large\n\n"+strings.Repeat("large\n", smtp.MaxMessageBytes/6))))
+
+	tasks, err := s.repo.ListTasks(context.Background())
+	s.Require().NoError(err)
+	s.Len(tasks, 2)
+}
+
+func TestServerSuite(t *testing.T) {
+	suite.Run(t, new(ServerSuite))
+}
@@ -0,0 +1,166 @@
+package task
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/omegaatt36/gotasker/domain"
+	"github.com/omegaatt36/gotasker/service/task"
+
+	"github.com/gin-gonic/gin"
+)
+
+// batch modes
+const (
+	batchModeAtomic     = "atomic"
+	batchModeBestEffort = "best_effort"
+)
+
+// batchOperationRequest defines a single operation of a batch.
+type batchOperationRequest struct {
+	Op     string          `json:"op" binding:"required" enum:"create,update,delete" example:"update"`
+	ID     uint            `json:"id" description:"The target task ID of update and delete operations." example:"1"`
+	Name   *string         `json:"name" description:"The task name of create and update operations." example:"Task 1"`
+	Status json.RawMessage `json:"status" openapi:"status"`
+}
+
+// batchTasksRequest defines the request for batching task operations.
+type batchTasksRequest struct {
+	Mode       string                  `json:"mode" enum:"atomic,best_effort" default:"atomic"`
+	Operations []batchOperationRequest `json:"operations" binding:"required,dive"`
+}
+
+// batchOperationResult defines the result of a single operation of a batch.
+type batchOperationResult struct {
+	Index  int    `json:"index" example:"0"`
+	Op     string `json:"op" enum:"create,update,delete" example:"update"`
+	Status int    `json:"status" description:"HTTP-like status code of the operation. 424 means the operation was rolled back because another one failed." example:"200"`
+	Task   any    `json:"task,omitempty" openapi:"task"`
+	Error  string `json:"error,omitempty" example:"task not found"`
+}
+
+// batchTasksResponse defines the response of batching task operations.
+type batchTasksResponse struct {
+	Results []batchOperationResult `json:"results"`
+}
+
+// BatchTasks applies a batch of create/update/delete operations.
+func (x *Controller) BatchTasks(c *gin.Context) {
+	var req batchTasksRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var atomic bool
+	switch req.Mode {
+	case "", batchModeAtomic:
+		atomic = true
+	case batchModeBestEffort:
+	default:
+		c.AbortWithStatusJSON(http.StatusBadRequest, "invalid batch mode")
+		return
+	}
+
+	ops := make([]task.BatchOperation, len(req.Operations))
+	for index, op := range req.Operations {
+		var err error
+		if ops[index], err = x.toBatchOperation(&op); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	results, err := x.service.BatchTasks(c.Request.Context(), task.BatchTasksRequest{
+		Atomic:     atomic,
+		Operations: ops,
+	})
+	if err != nil {
+		if errors.Is(err, task.ErrBatchSizeExceeded) {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, err.Error())
+			return
+		}
+
+		c.AbortWithStatusJSON(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resp := batchTasksResponse{
+		Results: make([]batchOperationResult, len(results)),
+	}
+	for index, result := range results {
+		resp.Results[index] = batchOperationResult{
+			Index:  index,
+			Op:     ops[index].Type.String(),
+			Status: batchOperationStatus(ops[index].Type, result.Err),
+		}
+
+		if result.Err != nil {
+			resp.Results[index].Error = result.Err.Error()
+			continue
+		}
+
+		resp.Results[index].Task = x.presenter.taskDetail(&result.Task)
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func (x *Controller) toBatchOperation(op *batchOperationRequest) (task.BatchOperation, error) {
+	opType, err := domain.ParseBatchOperationType(op.Op)
+	if err != nil {
+		return task.BatchOperation{}, err
+	}
+
+	// validity of the status is checked per operation by the service.
+	var status *domain.TaskStatus
+	if !isAbsent(op.Status) {
+		domainTaskStatus, err := x.presenter.parseStatus(op.Status)
+		if err != nil {
+			return task.BatchOperation{}, err
+		}
+		status = &domainTaskStatus
+	}
+
+	return task.BatchOperation{
+		Type: opType,
+		ID:   op.ID,
+		Create: task.CreateTaskRequest{
+			Name: stringValue(op.Name),
+		},
+		Update: task.UpdateTaskRequest{
+			Name:   op.Name,
+			Status: status,
+		},
+	}, nil
+}
+
+// batchOperationStatus maps the result of an operation to a HTTP status code.
+func batchOperationStatus(opType domain.BatchOperationType, err error) int {
+	switch {
+	case err == nil && opType == domain.BatchOperationTypeCreate:
+		return http.StatusCreated
+	case err == nil:
+		return http.StatusOK
+	case errors.Is(err, domain.ErrTaskNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrBatchRolledBack):
+		return http.StatusFailedDependency
+	case errors.Is(err, domain.ErrInvalidTaskID),
+		errors.Is(err, domain.ErrInvalidBatchOperationType),
+		errors.Is(err, task.ErrTaskNameRequired),
+		errors.Is(err, task.ErrInvalidStatus):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+
+	return *s
+}
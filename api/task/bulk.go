@@ -0,0 +1,131 @@
+package task
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/omegaatt36/gotasker/domain"
+	"github.com/omegaatt36/gotasker/service/task"
+
+	"github.com/gin-gonic/gin"
+)
+
+// taskFilter defines DTO for domain.TaskFilter.
+type taskFilter struct {
+	IDs          []uint          `json:"ids" example:"[1, 2, 3]"`
+	Status       json.RawMessage `json:"status" openapi:"status"`
+	NameContains string          `json:"name_contains" example:"release"`
+}
+
+func (filter *taskFilter) toDomain(p presenter) (domain.TaskFilter, error) {
+	status, err := parseStatus(p, filter.Status)
+	if err != nil {
+		return domain.TaskFilter{}, domain.ErrInvalidTaskStatus
+	}
+
+	return domain.TaskFilter{
+		IDs:          filter.IDs,
+		Status:       status,
+		NameContains: filter.NameContains,
+	}, nil
+}
+
+// bulkUpdateTasksRequest defines the request for updating tasks by filter.
+type bulkUpdateTasksRequest struct {
+	Filter taskFilter        `json:"filter"`
+	Patch  updateTaskRequest `json:"patch"`
+	DryRun bool              `json:"dry_run" default:"false"`
+}
+
+// bulkDeleteTasksRequest defines the request for deleting tasks by filter.
+type bulkDeleteTasksRequest struct {
+	Filter taskFilter `json:"filter"`
+	DryRun bool       `json:"dry_run" default:"false"`
+}
+
+// bulkResponse defines the response of bulk operations.
+type bulkResponse struct {
+	IDs    []uint `json:"ids" example:"[1, 2, 3]"`
+	Count  int    `json:"count" example:"3"`
+	DryRun bool   `json:"dry_run" example:"false"`
+}
+
+// BulkUpdateTasks updates all tasks matching the filter.
+func (x *Controller) BulkUpdateTasks(c *gin.Context) {
+	var req bulkUpdateTasksRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	filter, err := req.Filter.toDomain(x.presenter)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	status, err := parseStatus(x.presenter, req.Patch.Status)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, domain.ErrInvalidTaskStatus.Error())
+		return
+	}
+
+	result, err := x.service.BulkUpdateTasks(c.Request.Context(), task.BulkUpdateTasksRequest{
+		Filter: filter,
+		Patch: task.UpdateTaskRequest{
+			Name:   req.Patch.Name,
+			Status: status,
+		},
+		DryRun: req.DryRun,
+	})
+	if err != nil {
+		x.abortWithBulkError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, bulkResponse{
+		IDs:    result.IDs,
+		Count:  len(result.IDs),
+		DryRun: req.DryRun,
+	})
+}
+
+// BulkDeleteTasks deletes all tasks matching the filter.
+func (x *Controller) BulkDeleteTasks(c *gin.Context) {
+	var req bulkDeleteTasksRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	filter, err := req.Filter.toDomain(x.presenter)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, err := x.service.BulkDeleteTasks(c.Request.Context(), task.BulkDeleteTasksRequest{
+		Filter: filter,
+		DryRun: req.DryRun,
+	})
+	if err != nil {
+		x.abortWithBulkError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, bulkResponse{
+		IDs:    result.IDs,
+		Count:  len(result.IDs),
+		DryRun: req.DryRun,
+	})
+}
+
+func (x *Controller) abortWithBulkError(c *gin.Context, err error) {
+	if errors.Is(err, task.ErrEmptyFilter) || errors.Is(err, task.ErrInvalidStatus) {
+		c.AbortWithStatusJSON(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	c.AbortWithStatusJSON(http.StatusInternalServerError, err.Error())
+}
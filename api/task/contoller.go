@@ -1,10 +1,15 @@
 package task
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
+	"github.com/omegaatt36/gotasker/api/httpcache"
+	"github.com/omegaatt36/gotasker/api/render"
 	"github.com/omegaatt36/gotasker/domain"
 	"github.com/omegaatt36/gotasker/service/task"
 
@@ -13,47 +18,124 @@ import (
 
 // Controller represents a task controller.
 type Controller struct {
-	service *task.Service
+	service   *task.Service
+	presenter presenter
+
+	heartbeatInterval time.Duration
+	websocket         WebSocketConfig
 }
 
-// NewController creates a new task controller.
-func NewController(service *task.Service) *Controller {
-	return &Controller{service: service}
+// Option configures a task controller.
+type Option func(*Controller)
+
+// WithHeartbeatInterval sets the interval of heartbeats on idle event
+// streams.
+func WithHeartbeatInterval(interval time.Duration) Option {
+	return func(x *Controller) {
+		if interval > 0 {
+			x.heartbeatInterval = interval
+		}
+	}
 }
 
-// taskDetail defines DTO for domain.Task.
-type taskDetail struct {
-	ID     uint   `json:"id"`
-	Name   string `json:"name"`
-	Status int    `json:"status"`
+func newController(service *task.Service, p presenter, opts []Option) *Controller {
+	x := &Controller{
+		service:           service,
+		presenter:         p,
+		heartbeatInterval: DefaultHeartbeatInterval,
+		websocket:         DefaultWebSocketConfig,
+	}
+
+	for _, opt := range opts {
+		opt(x)
+	}
+
+	return x
 }
 
-func (task *taskDetail) fromDomain(domainTask *domain.Task) {
-	task.ID = domainTask.ID
-	task.Name = domainTask.Name
-	task.Status = int(domainTask.Status)
+// NewController creates a new task controller serving the v1 API.
+func NewController(service *task.Service, opts ...Option) *Controller {
+	return newController(service, v1Presenter{}, opts)
 }
 
-// ListTasks lists all tasks.
+// NewControllerV2 creates a new task controller serving the v2 API.
+func NewControllerV2(service *task.Service, opts ...Option) *Controller {
+	return newController(service, v2Presenter{}, opts)
+}
+
+// ListTasks lists all tasks in the format negotiated by the Accept header or
+// the format query parameter. The entity tag is derived from the version of
+// the task collection, so unchanged lists are answered with 304 Not Modified.
 func (x *Controller) ListTasks(c *gin.Context) {
+	format, ok := render.Negotiate(c)
+	if !ok {
+		render.NotAcceptable(c)
+		return
+	}
+
+	// the version is read before the tasks, a concurrent write then changes
+	// the version after the tag is taken and can't be hidden behind it.
+	version, err := x.service.Version(c.Request.Context())
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.Header("Vary", "Accept")
+	if httpcache.NotModified(c, httpcache.ETag(fmt.Sprintf("tasks-%d-%s", version, format.Name))) {
+		return
+	}
+
 	tasks, err := x.service.ListTasks(c.Request.Context())
 	if err != nil {
 		c.AbortWithStatusJSON(http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	taskDetails := make([]*taskDetail, len(tasks))
-	for index := range tasks {
-		taskDetails[index] = &taskDetail{}
-		taskDetails[index].fromDomain(&tasks[index])
+	render.Write(c, format, http.StatusOK, x.presenter.taskDetails(tasks))
+}
+
+// GetTask gets a task. The entity tag is a hash of the representation.
+func (x *Controller) GetTask(c *gin.Context) {
+	taskID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, err.Error())
+		return
 	}
 
-	c.JSON(http.StatusOK, taskDetails)
+	if taskID < 1 {
+		c.AbortWithStatusJSON(http.StatusBadRequest, domain.ErrInvalidTaskID.Error())
+		return
+	}
+
+	domainTask, err := x.service.GetTask(c.Request.Context(), uint(taskID))
+	if err != nil {
+		if errors.Is(err, domain.ErrTaskNotFound) {
+			c.AbortWithStatusJSON(http.StatusNotFound, err.Error())
+			return
+		}
+
+		c.AbortWithStatusJSON(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	bs, err := json.Marshal(x.presenter.taskDetail(&domainTask))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if httpcache.NotModified(c, httpcache.HashETag(bs)) {
+		return
+	}
+
+	c.Data(http.StatusOK, "application/json; charset=utf-8", bs)
 }
 
 // createTaskRequest defines the request for creating a task.
 type createTaskRequest struct {
-	Name string `json:"name" binding:"required"`
+	Name  string `json:"name" binding:"required" description:"The task name." example:"Task 1"`
+	Notes string `json:"notes" description:"Free text about the task, shown by the v2 API only." example:"The printer on the 2nd floor is jammed."`
 }
 
 // CreateTask creates a new task.
@@ -64,20 +146,23 @@ func (x *Controller) CreateTask(c *gin.Context) {
 		return
 	}
 
-	if err := x.service.CreateTask(c.Request.Context(), task.CreateTaskRequest{
-		Name: req.Name,
-	}); err != nil {
+	domainTask, err := x.service.CreateTask(c.Request.Context(), task.CreateTaskRequest{
+		Name:  req.Name,
+		Notes: req.Notes,
+	})
+	if err != nil {
 		c.AbortWithStatusJSON(http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	c.Status(http.StatusCreated)
+	c.JSON(http.StatusCreated, x.presenter.taskDetail(&domainTask))
 }
 
 // UpdateTaskRequest defines the request for updating a task.
 type updateTaskRequest struct {
-	Name   *string `json:"name"`
-	Status *int    `json:"status"`
+	Name *string `json:"name" description:"The task name." example:"Task 1 - updated"`
+	// Status is decoded by the presenter of the API version.
+	Status json.RawMessage `json:"status" openapi:"status"`
 }
 
 // UpdateTask updates a task.
@@ -99,15 +184,10 @@ func (x *Controller) UpdateTask(c *gin.Context) {
 		return
 	}
 
-	var status *domain.TaskStatus
-	if req.Status != nil {
-		domainTaskStatus := domain.TaskStatus(*req.Status)
-		if !domainTaskStatus.IsValid() {
-			c.AbortWithStatusJSON(http.StatusBadRequest, domain.ErrInvalidTaskStatus.Error())
-			return
-		}
-
-		status = &domainTaskStatus
+	status, err := parseStatus(x.presenter, req.Status)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, domain.ErrInvalidTaskStatus.Error())
+		return
 	}
 
 	if err := x.service.UpdateTask(c.Request.Context(), uint(taskID), task.UpdateTaskRequest{
@@ -1,13 +1,21 @@
 package task_test
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"testing/iotest"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
 	"github.com/omegaatt36/gotasker/api/task"
 	"github.com/omegaatt36/gotasker/domain"
 	"github.com/omegaatt36/gotasker/persistance"
@@ -63,9 +71,10 @@ func (s *TaskControllerSuite) TestListTasks() {
 
 	// insert 10 tasks
 	for index := range 10 {
-		s.NoError(repo.CreateTask(context.Background(), domain.CreateTaskRequest{
+		_, err := repo.CreateTask(context.Background(), domain.CreateTaskRequest{
 			Name: fmt.Sprintf("task %d", index+1),
-		}))
+		})
+		s.NoError(err)
 	}
 
 	{
@@ -85,6 +94,221 @@ func (s *TaskControllerSuite) TestListTasks() {
 	}
 }
 
+func (s *TaskControllerSuite) TestListTasksFormats() {
+	miniredis := database.InitializeTestingRedis()
+	defer miniredis.Close()
+
+	database.Initialize(context.Background(), miniredis.Addr(), "")
+
+	repo := persistance.NewRedisRepo(database.Redis())
+	service := taskService.NewService(repo)
+	controller := task.NewController(service)
+
+	for index := range 2 {
+		_, err := repo.CreateTask(context.Background(), domain.CreateTaskRequest{
+			Name: fmt.Sprintf("task %d", index+1),
+		})
+		s.NoError(err)
+	}
+
+	s.T().Run("csv", func(t *testing.T) {
+		resp, err := util.HTTPTest(util.HTTPTestRequest{
+			ServedURL:            "/tasks",
+			RequestURLWithParams: "/tasks",
+			Method:               http.MethodGet,
+			Header:               http.Header{"Accept": []string{"text/csv"}},
+			HandleFuncs: []gin.HandlerFunc{
+				controller.ListTasks,
+			},
+		})
+		s.NoError(err)
+		s.Equal(http.StatusOK, resp.StatusCode)
+		s.Equal("id,name,status\n1,task 1,0\n2,task 2,0\n", string(resp.Body))
+	})
+
+	s.T().Run("ndjson", func(t *testing.T) {
+		resp, err := util.HTTPTest(util.HTTPTestRequest{
+			ServedURL:            "/tasks",
+			RequestURLWithParams: "/tasks?format=ndjson",
+			Method:               http.MethodGet,
+			HandleFuncs: []gin.HandlerFunc{
+				controller.ListTasks,
+			},
+		})
+		s.NoError(err)
+		s.Equal(http.StatusOK, resp.StatusCode)
+		s.Equal("{\"id\":1,\"name\":\"task 1\",\"status\":0}\n{\"id\":2,\"name\":\"task 2\",\"status\":0}\n", string(resp.Body))
+	})
+
+	s.T().Run("not acceptable", func(t *testing.T) {
+		resp, err := util.HTTPTest(util.HTTPTestRequest{
+			ServedURL:            "/tasks",
+			RequestURLWithParams: "/tasks",
+			Method:               http.MethodGet,
+			Header:               http.Header{"Accept": []string{"application/xml"}},
+			HandleFuncs: []gin.HandlerFunc{
+				controller.ListTasks,
+			},
+		})
+		s.NoError(err)
+		s.Equal(http.StatusNotAcceptable, resp.StatusCode)
+	})
+
+	s.T().Run("empty csv", func(t *testing.T) {
+		for _, id := range []uint{1, 2} {
+			s.Require().NoError(repo.DeleteTask(context.Background(), id))
+		}
+
+		resp, err := util.HTTPTest(util.HTTPTestRequest{
+			ServedURL:            "/tasks",
+			RequestURLWithParams: "/tasks?format=csv",
+			Method:               http.MethodGet,
+			HandleFuncs: []gin.HandlerFunc{
+				task.NewControllerV2(service).ListTasks,
+			},
+		})
+		s.NoError(err)
+		s.Equal(http.StatusOK, resp.StatusCode)
+		s.Equal("id,name,status,created_at,updated_at,notes\n", string(resp.Body))
+	})
+}
+
+func (s *TaskControllerSuite) TestListTasksConditional() {
+	miniredis := database.InitializeTestingRedis()
+	defer miniredis.Close()
+
+	database.Initialize(context.Background(), miniredis.Addr(), "")
+
+	repo := persistance.NewRedisRepo(database.Redis())
+	service := taskService.NewService(repo)
+	controller := task.NewController(service)
+
+	list := func(header http.Header) *util.HTTPTestResponse {
+		resp, err := util.HTTPTest(util.HTTPTestRequest{
+			ServedURL:            "/tasks",
+			RequestURLWithParams: "/tasks",
+			Method:               http.MethodGet,
+			Header:               header,
+			HandleFuncs: []gin.HandlerFunc{
+				controller.ListTasks,
+			},
+		})
+		s.NoError(err)
+
+		return resp
+	}
+
+	_, err := repo.CreateTask(context.Background(), domain.CreateTaskRequest{
+		Name: "task 1",
+	})
+	s.NoError(err)
+
+	resp := list(nil)
+	s.Equal(http.StatusOK, resp.StatusCode)
+	s.Equal("no-cache", resp.Header.Get("Cache-Control"))
+	etag := resp.Header.Get("ETag")
+	s.NotEmpty(etag)
+
+	s.T().Run("not modified", func(t *testing.T) {
+		resp := list(http.Header{"If-None-Match": []string{etag}})
+		s.Equal(http.StatusNotModified, resp.StatusCode)
+		s.Equal(etag, resp.Header.Get("ETag"))
+		s.Empty(resp.Body)
+	})
+
+	s.T().Run("other format", func(t *testing.T) {
+		resp := list(http.Header{
+			"If-None-Match": []string{etag},
+			"Accept":        []string{"text/csv"},
+		})
+		s.Equal(http.StatusOK, resp.StatusCode)
+		s.NotEqual(etag, resp.Header.Get("ETag"))
+	})
+
+	s.T().Run("modified", func(t *testing.T) {
+		s.NoError(repo.UpdateTask(context.Background(), 1, domain.UpdateTaskRequest{
+			Status: util.Pointer(domain.TaskStatusCompleted),
+		}))
+
+		resp := list(http.Header{"If-None-Match": []string{etag}})
+		s.Equal(http.StatusOK, resp.StatusCode)
+		s.NotEqual(etag, resp.Header.Get("ETag"))
+	})
+}
+
+func (s *TaskControllerSuite) TestGetTask() {
+	miniredis := database.InitializeTestingRedis()
+	defer miniredis.Close()
+
+	database.Initialize(context.Background(), miniredis.Addr(), "")
+
+	repo := persistance.NewRedisRepo(database.Redis())
+	service := taskService.NewService(repo)
+	controller := task.NewController(service)
+
+	get := func(url string, header http.Header) *util.HTTPTestResponse {
+		resp, err := util.HTTPTest(util.HTTPTestRequest{
+			ServedURL:            "/tasks/:id",
+			RequestURLWithParams: url,
+			Method:               http.MethodGet,
+			Header:               header,
+			HandleFuncs: []gin.HandlerFunc{
+				controller.GetTask,
+			},
+		})
+		s.NoError(err)
+
+		return resp
+	}
+
+	s.T().Run("invalid id - not numeric", func(t *testing.T) {
+		s.Equal(http.StatusBadRequest, get("/tasks/a", nil).StatusCode)
+	})
+
+	s.T().Run("invalid id - not found", func(t *testing.T) {
+		s.Equal(http.StatusNotFound, get("/tasks/1", nil).StatusCode)
+	})
+
+	_, err := repo.CreateTask(context.Background(), domain.CreateTaskRequest{
+		Name: "task 1",
+	})
+	s.NoError(err)
+	_, err = repo.CreateTask(context.Background(), domain.CreateTaskRequest{
+		Name: "task 2",
+	})
+	s.NoError(err)
+
+	resp := get("/tasks/1", nil)
+	s.Equal(http.StatusOK, resp.StatusCode)
+	s.JSONEq(`{"id":1,"name":"task 1","status":0}`, string(resp.Body))
+	etag := resp.Header.Get("ETag")
+	s.NotEmpty(etag)
+
+	s.T().Run("not modified", func(t *testing.T) {
+		resp := get("/tasks/1", http.Header{"If-None-Match": []string{etag}})
+		s.Equal(http.StatusNotModified, resp.StatusCode)
+	})
+
+	s.T().Run("other task modified", func(t *testing.T) {
+		s.NoError(repo.UpdateTask(context.Background(), 2, domain.UpdateTaskRequest{
+			Name: util.Pointer("task 2 - updated"),
+		}))
+
+		resp := get("/tasks/1", http.Header{"If-None-Match": []string{etag}})
+		s.Equal(http.StatusNotModified, resp.StatusCode)
+	})
+
+	s.T().Run("modified", func(t *testing.T) {
+		s.NoError(repo.UpdateTask(context.Background(), 1, domain.UpdateTaskRequest{
+			Name: util.Pointer("task 1 - updated"),
+		}))
+
+		resp := get("/tasks/1", http.Header{"If-None-Match": []string{etag}})
+		s.Equal(http.StatusOK, resp.StatusCode)
+		s.NotEqual(etag, resp.Header.Get("ETag"))
+	})
+}
+
 func (s *TaskControllerSuite) TestCreateTask() {
 	miniredis := database.InitializeTestingRedis()
 	defer miniredis.Close()
@@ -180,9 +404,10 @@ func (s *TaskControllerSuite) TestUpdateTask() {
 	})
 
 	for index := range 10 {
-		s.NoError(repo.CreateTask(context.Background(), domain.CreateTaskRequest{
+		_, err := repo.CreateTask(context.Background(), domain.CreateTaskRequest{
 			Name: fmt.Sprintf("task %d", index+1),
-		}))
+		})
+		s.NoError(err)
 	}
 
 	s.T().Run("invalid id - not numeric", func(t *testing.T) {
@@ -275,9 +500,10 @@ func (s *TaskControllerSuite) TestDeleteTask() {
 	})
 
 	for index := range 10 {
-		s.NoError(repo.CreateTask(context.Background(), domain.CreateTaskRequest{
+		_, err := repo.CreateTask(context.Background(), domain.CreateTaskRequest{
 			Name: fmt.Sprintf("task %d", index+1),
-		}))
+		})
+		s.NoError(err)
 	}
 
 	s.T().Run("invalid id - not numeric", func(t *testing.T) {
@@ -328,6 +554,977 @@ func (s *TaskControllerSuite) TestDeleteTask() {
 	})
 }
 
+func (s *TaskControllerSuite) TestBatchTasks() {
+	miniredis := database.InitializeTestingRedis()
+	defer miniredis.Close()
+
+	database.Initialize(context.Background(), miniredis.Addr(), "")
+
+	repo := persistance.NewRedisRepo(database.Redis())
+	service := taskService.NewService(repo, taskService.WithMaxBatchSize(3))
+	controller := task.NewController(service)
+
+	type batchTasksResponse struct {
+		Results []struct {
+			Index  int    `json:"index"`
+			Op     string `json:"op"`
+			Status int    `json:"status"`
+			Task   *struct {
+				ID     uint   `json:"id"`
+				Name   string `json:"name"`
+				Status int    `json:"status"`
+			} `json:"task"`
+			Error string `json:"error"`
+		} `json:"results"`
+	}
+
+	batch := func(payload map[string]any) (*util.HTTPTestResponse, error) {
+		return util.HTTPTest(util.HTTPTestRequest{
+			ServedURL:            "/tasks/batch",
+			RequestURLWithParams: "/tasks/batch",
+			Method:               http.MethodPost,
+			HandleFuncs: []gin.HandlerFunc{
+				controller.BatchTasks,
+			},
+			Payload: payload,
+		})
+	}
+
+	_, err := repo.CreateTask(context.Background(), domain.CreateTaskRequest{
+		Name: "task 1",
+	})
+	s.NoError(err)
+
+	s.T().Run("invalid operation", func(t *testing.T) {
+		resp, err := batch(map[string]any{
+			"operations": []map[string]any{
+				{"op": "upsert", "name": "task 2"},
+			},
+		})
+		s.NoError(err)
+		s.Equal(http.StatusBadRequest, resp.StatusCode)
+	})
+
+	s.T().Run("batch size exceeded", func(t *testing.T) {
+		resp, err := batch(map[string]any{
+			"operations": []map[string]any{
+				{"op": "delete", "id": 1},
+				{"op": "delete", "id": 2},
+				{"op": "delete", "id": 3},
+				{"op": "delete", "id": 4},
+			},
+		})
+		s.NoError(err)
+		s.Equal(http.StatusRequestEntityTooLarge, resp.StatusCode)
+	})
+
+	s.T().Run("atomic - rolled back", func(t *testing.T) {
+		resp, err := batch(map[string]any{
+			"mode": "atomic",
+			"operations": []map[string]any{
+				{"op": "create", "name": "task 2"},
+				{"op": "delete", "id": 99},
+			},
+		})
+		s.NoError(err)
+		s.Equal(http.StatusOK, resp.StatusCode)
+
+		var body batchTasksResponse
+		s.NoError(json.Unmarshal(resp.Body, &body))
+		s.Len(body.Results, 2)
+		s.Equal(http.StatusFailedDependency, body.Results[0].Status)
+		s.Equal(http.StatusNotFound, body.Results[1].Status)
+
+		tasksInRepo, err := repo.ListTasks(context.Background())
+		s.NoError(err)
+		s.Len(tasksInRepo, 1)
+	})
+
+	s.T().Run("atomic - success", func(t *testing.T) {
+		resp, err := batch(map[string]any{
+			"operations": []map[string]any{
+				{"op": "create", "name": "task 2"},
+				{"op": "create", "name": "task 3"},
+				{"op": "update", "id": 1, "status": domain.TaskStatusCompleted},
+			},
+		})
+		s.NoError(err)
+		s.Equal(http.StatusOK, resp.StatusCode)
+
+		var body batchTasksResponse
+		s.NoError(json.Unmarshal(resp.Body, &body))
+		s.Len(body.Results, 3)
+		s.Equal(http.StatusCreated, body.Results[0].Status)
+		s.Equal(uint(2), body.Results[0].Task.ID)
+		s.Equal(http.StatusCreated, body.Results[1].Status)
+		s.Equal(uint(3), body.Results[1].Task.ID)
+		s.Equal(http.StatusOK, body.Results[2].Status)
+		s.Equal(int(domain.TaskStatusCompleted), body.Results[2].Task.Status)
+
+		tasksInRepo, err := repo.ListTasks(context.Background())
+		s.NoError(err)
+		s.Len(tasksInRepo, 3)
+		s.Equal(domain.TaskStatusCompleted, tasksInRepo[0].Status)
+		s.Equal("task 2", tasksInRepo[1].Name)
+		s.Equal("task 3", tasksInRepo[2].Name)
+	})
+
+	s.T().Run("best effort", func(t *testing.T) {
+		resp, err := batch(map[string]any{
+			"mode": "best_effort",
+			"operations": []map[string]any{
+				{"op": "delete", "id": 1},
+				{"op": "delete", "id": 99},
+				{"op": "update", "id": 2, "status": 99999},
+			},
+		})
+		s.NoError(err)
+		s.Equal(http.StatusOK, resp.StatusCode)
+
+		var body batchTasksResponse
+		s.NoError(json.Unmarshal(resp.Body, &body))
+		s.Len(body.Results, 3)
+		s.Equal(http.StatusOK, body.Results[0].Status)
+		s.Equal(http.StatusNotFound, body.Results[1].Status)
+		s.Equal(http.StatusBadRequest, body.Results[2].Status)
+
+		tasksInRepo, err := repo.ListTasks(context.Background())
+		s.NoError(err)
+		s.Len(tasksInRepo, 2)
+		s.Equal("task 2", tasksInRepo[0].Name)
+	})
+}
+
+func (s *TaskControllerSuite) TestBulkTasks() {
+	miniredis := database.InitializeTestingRedis()
+	defer miniredis.Close()
+
+	database.Initialize(context.Background(), miniredis.Addr(), "")
+
+	repo := persistance.NewRedisRepo(database.Redis())
+	service := taskService.NewService(repo, taskService.WithMaxBatchSize(3))
+	controller := task.NewController(service)
+
+	type bulkResponse struct {
+		IDs    []uint `json:"ids"`
+		Count  int    `json:"count"`
+		DryRun bool   `json:"dry_run"`
+	}
+
+	for index := range 10 {
+		_, err := repo.CreateTask(context.Background(), domain.CreateTaskRequest{
+			Name: fmt.Sprintf("task %d", index+1),
+		})
+		s.NoError(err)
+	}
+
+	s.T().Run("empty filter", func(t *testing.T) {
+		resp, err := util.HTTPTest(util.HTTPTestRequest{
+			ServedURL:            "/tasks/bulk-delete",
+			RequestURLWithParams: "/tasks/bulk-delete",
+			Method:               http.MethodPost,
+			HandleFuncs: []gin.HandlerFunc{
+				controller.BulkDeleteTasks,
+			},
+			Payload: map[string]any{},
+		})
+		s.NoError(err)
+		s.Equal(http.StatusBadRequest, resp.StatusCode)
+	})
+
+	s.T().Run("update - dry run", func(t *testing.T) {
+		resp, err := util.HTTPTest(util.HTTPTestRequest{
+			ServedURL:            "/tasks/bulk-update",
+			RequestURLWithParams: "/tasks/bulk-update",
+			Method:               http.MethodPost,
+			HandleFuncs: []gin.HandlerFunc{
+				controller.BulkUpdateTasks,
+			},
+			Payload: map[string]any{
+				"filter":  map[string]any{"ids": []uint{1, 2, 3, 4, 5}},
+				"patch":   map[string]any{"status": domain.TaskStatusCompleted},
+				"dry_run": true,
+			},
+		})
+		s.NoError(err)
+		s.Equal(http.StatusOK, resp.StatusCode)
+
+		var body bulkResponse
+		s.NoError(json.Unmarshal(resp.Body, &body))
+		s.Equal([]uint{1, 2, 3, 4, 5}, body.IDs)
+		s.Equal(5, body.Count)
+		s.True(body.DryRun)
+
+		tasksInRepo, err := repo.ListTasks(context.Background())
+		s.NoError(err)
+		for _, t := range tasksInRepo {
+			s.Equal(domain.TaskStatusIncomplete, t.Status)
+		}
+	})
+
+	s.T().Run("update", func(t *testing.T) {
+		resp, err := util.HTTPTest(util.HTTPTestRequest{
+			ServedURL:            "/tasks/bulk-update",
+			RequestURLWithParams: "/tasks/bulk-update",
+			Method:               http.MethodPost,
+			HandleFuncs: []gin.HandlerFunc{
+				controller.BulkUpdateTasks,
+			},
+			Payload: map[string]any{
+				"filter": map[string]any{"ids": []uint{1, 2, 3, 4, 5}},
+				"patch":  map[string]any{"status": domain.TaskStatusCompleted},
+			},
+		})
+		s.NoError(err)
+		s.Equal(http.StatusOK, resp.StatusCode)
+
+		var body bulkResponse
+		s.NoError(json.Unmarshal(resp.Body, &body))
+		s.Equal(5, body.Count)
+
+		tasksInRepo, err := repo.ListTasks(context.Background())
+		s.NoError(err)
+		for _, t := range tasksInRepo {
+			if t.ID <= 5 {
+				s.Equal(domain.TaskStatusCompleted, t.Status)
+			} else {
+				s.Equal(domain.TaskStatusIncomplete, t.Status)
+			}
+		}
+	})
+
+	s.T().Run("delete", func(t *testing.T) {
+		resp, err := util.HTTPTest(util.HTTPTestRequest{
+			ServedURL:            "/tasks/bulk-delete",
+			RequestURLWithParams: "/tasks/bulk-delete",
+			Method:               http.MethodPost,
+			HandleFuncs: []gin.HandlerFunc{
+				controller.BulkDeleteTasks,
+			},
+			Payload: map[string]any{
+				"filter": map[string]any{"status": domain.TaskStatusCompleted},
+			},
+		})
+		s.NoError(err)
+		s.Equal(http.StatusOK, resp.StatusCode)
+
+		var body bulkResponse
+		s.NoError(json.Unmarshal(resp.Body, &body))
+		s.Equal([]uint{1, 2, 3, 4, 5}, body.IDs)
+
+		tasksInRepo, err := repo.ListTasks(context.Background())
+		s.NoError(err)
+		s.Len(tasksInRepo, 5)
+		s.Equal("task 6", tasksInRepo[0].Name)
+	})
+}
+
+func (s *TaskControllerSuite) TestV2() {
+	miniredis := database.InitializeTestingRedis()
+	defer miniredis.Close()
+
+	database.Initialize(context.Background(), miniredis.Addr(), "")
+
+	repo := persistance.NewRedisRepo(database.Redis())
+	service := taskService.NewService(repo)
+	controller := task.NewControllerV2(service)
+
+	type taskDetail struct {
+		ID        uint   `json:"id"`
+		Name      string `json:"name"`
+		Status    string `json:"status"`
+		CreatedAt string `json:"created_at"`
+		UpdatedAt string `json:"updated_at"`
+	}
+
+	_, err := repo.CreateTask(context.Background(), domain.CreateTaskRequest{
+		Name: "task 1",
+	})
+	s.NoError(err)
+
+	s.T().Run("list tasks", func(t *testing.T) {
+		resp, err := util.HTTPTest(util.HTTPTestRequest{
+			ServedURL:            "/v2/tasks",
+			RequestURLWithParams: "/v2/tasks",
+			Method:               http.MethodGet,
+			HandleFuncs: []gin.HandlerFunc{
+				controller.ListTasks,
+			},
+		})
+		s.NoError(err)
+		s.Equal(http.StatusOK, resp.StatusCode)
+
+		var tasks []taskDetail
+		s.NoError(json.Unmarshal(resp.Body, &tasks))
+		s.Len(tasks, 1)
+		s.Equal("task 1", tasks[0].Name)
+		s.Equal("incomplete", tasks[0].Status)
+
+		_, err = time.Parse(time.RFC3339, tasks[0].CreatedAt)
+		s.NoError(err)
+		_, err = time.Parse(time.RFC3339, tasks[0].UpdatedAt)
+		s.NoError(err)
+	})
+
+	s.T().Run("update task - integer status", func(t *testing.T) {
+		resp, err := util.HTTPTest(util.HTTPTestRequest{
+			ServedURL:            "/v2/tasks/:id",
+			RequestURLWithParams: "/v2/tasks/1",
+			Method:               http.MethodPut,
+			HandleFuncs: []gin.HandlerFunc{
+				controller.UpdateTask,
+			},
+			Payload: map[string]any{
+				"status": domain.TaskStatusCompleted,
+			},
+		})
+		s.NoError(err)
+		s.Equal(http.StatusBadRequest, resp.StatusCode)
+	})
+
+	s.T().Run("update task", func(t *testing.T) {
+		resp, err := util.HTTPTest(util.HTTPTestRequest{
+			ServedURL:            "/v2/tasks/:id",
+			RequestURLWithParams: "/v2/tasks/1",
+			Method:               http.MethodPut,
+			HandleFuncs: []gin.HandlerFunc{
+				controller.UpdateTask,
+			},
+			Payload: map[string]any{
+				"status": "completed",
+			},
+		})
+		s.NoError(err)
+		s.Equal(http.StatusOK, resp.StatusCode)
+
+		tasksInRepo, err := repo.ListTasks(context.Background())
+		s.NoError(err)
+		s.Equal(domain.TaskStatusCompleted, tasksInRepo[0].Status)
+	})
+
+	s.T().Run("batch tasks", func(t *testing.T) {
+		resp, err := util.HTTPTest(util.HTTPTestRequest{
+			ServedURL:            "/v2/tasks/batch",
+			RequestURLWithParams: "/v2/tasks/batch",
+			Method:               http.MethodPost,
+			HandleFuncs: []gin.HandlerFunc{
+				controller.BatchTasks,
+			},
+			Payload: map[string]any{
+				"operations": []map[string]any{
+					{"op": "update", "id": 1, "status": "incomplete"},
+				},
+			},
+		})
+		s.NoError(err)
+		s.Equal(http.StatusOK, resp.StatusCode)
+
+		var body struct {
+			Results []struct {
+				Task taskDetail `json:"task"`
+			} `json:"results"`
+		}
+		s.NoError(json.Unmarshal(resp.Body, &body))
+		s.Len(body.Results, 1)
+		s.Equal("incomplete", body.Results[0].Task.Status)
+	})
+}
+
+func (s *TaskControllerSuite) TestStreamEvents() {
+	miniredis := database.InitializeTestingRedis()
+	defer miniredis.Close()
+
+	database.Initialize(context.Background(), miniredis.Addr(), "")
+
+	repo := persistance.NewRedisRepo(database.Redis())
+	service := taskService.NewService(repo)
+	controller := task.NewControllerV2(service, task.WithHeartbeatInterval(50*time.Millisecond))
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/tasks/events", controller.StreamEvents)
+	server := httptest.NewServer(engine)
+	defer server.Close()
+
+	// connect returns a reader of the lines of the event stream.
+	connect := func(lastEventID string) (*bufio.Reader, context.CancelFunc) {
+		ctx, cancel := context.WithCancel(context.Background())
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/tasks/events", nil)
+		s.Require().NoError(err)
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		s.Require().NoError(err)
+		s.Equal(http.StatusOK, resp.StatusCode)
+		s.Equal("text/event-stream", resp.Header.Get("Content-Type"))
+
+		return bufio.NewReader(resp.Body), func() {
+			cancel()
+			resp.Body.Close()
+		}
+	}
+
+	type event struct {
+		id, name, data string
+	}
+	// next reads the next event, skipping heartbeats.
+	next := func(reader *bufio.Reader) event {
+		var e event
+		for {
+			line, err := reader.ReadString('\n')
+			s.Require().NoError(err)
+
+			line = strings.TrimSuffix(line, "\n")
+			switch {
+			case line == "" && e.name != "":
+				return e
+			case strings.HasPrefix(line, "id:"):
+				e.id = line[len("id:"):]
+			case strings.HasPrefix(line, "event:"):
+				e.name = line[len("event:"):]
+			case strings.HasPrefix(line, "data:"):
+				e.data = line[len("data:"):]
+			}
+		}
+	}
+
+	reader, disconnect := connect("")
+
+	created, err := service.CreateTask(context.Background(), taskService.CreateTaskRequest{Name: "task 1"})
+	s.Require().NoError(err)
+	s.Require().NoError(service.UpdateTask(context.Background(), created.ID, taskService.UpdateTaskRequest{
+		Status: util.Pointer(domain.TaskStatusCompleted),
+	}))
+
+	first := next(reader)
+	s.Equal("created", first.name)
+	s.NotEmpty(first.id)
+	s.JSONEq(`{"id":1,"name":"task 1","status":"incomplete"}`, stripTimestamps(s, first.data))
+
+	second := next(reader)
+	s.Equal("updated", second.name)
+	s.JSONEq(`{"id":1,"name":"task 1","status":"completed"}`, stripTimestamps(s, second.data))
+
+	s.T().Run("heartbeat", func(t *testing.T) {
+		line, err := reader.ReadString('\n')
+		s.Require().NoError(err)
+		s.Equal(":heartbeat\n", line)
+	})
+	disconnect()
+
+	s.Require().NoError(service.DeleteTask(context.Background(), created.ID))
+
+	s.T().Run("resume", func(t *testing.T) {
+		reader, disconnect := connect(first.id)
+		defer disconnect()
+
+		e := next(reader)
+		s.Equal(second, e)
+		e = next(reader)
+		s.Equal("deleted", e.name)
+	})
+
+	s.T().Run("reset", func(t *testing.T) {
+		reader, disconnect := connect("unknown-1")
+		defer disconnect()
+
+		s.Equal("reset", next(reader).name)
+	})
+}
+
+func (s *TaskControllerSuite) TestServeWebSocket() {
+	miniredis := database.InitializeTestingRedis()
+	defer miniredis.Close()
+
+	database.Initialize(context.Background(), miniredis.Addr(), "")
+
+	repo := persistance.NewRedisRepo(database.Redis())
+	service := taskService.NewService(repo)
+	controller := task.NewControllerV2(service, task.WithWebSocketConfig(task.WebSocketConfig{
+		PingInterval: 50 * time.Millisecond,
+	}))
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/ws", controller.ServeWebSocket)
+	server := httptest.NewServer(engine)
+	defer server.Close()
+
+	conn, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", nil)
+	s.Require().NoError(err)
+	defer conn.Close()
+	s.Equal(http.StatusSwitchingProtocols, resp.StatusCode)
+
+	pinged := make(chan struct{}, 1)
+	conn.SetPingHandler(func(data string) error {
+		select {
+		case pinged <- struct{}{}:
+		default:
+		}
+		return conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+	})
+
+	// read reads the next message, the ping handler runs while reading.
+	read := func() map[string]any {
+		var message map[string]any
+		s.Require().NoError(conn.SetReadDeadline(time.Now().Add(time.Second)))
+		s.Require().NoError(conn.ReadJSON(&message))
+		if task, ok := message["task"].(map[string]any); ok {
+			delete(task, "created_at")
+			delete(task, "updated_at")
+		}
+		return message
+	}
+	send := func(message string) {
+		s.Require().NoError(conn.WriteMessage(websocket.TextMessage, []byte(message)))
+	}
+
+	send(`{"request_id":"1","action":"subscribe","filter":{"status":"completed"}}`)
+	s.Equal(map[string]any{"request_id": "1", "type": "result", "status": float64(http.StatusOK)}, read())
+
+	send(`{"request_id":"2","action":"create","name":"task 1"}`)
+	s.Equal(map[string]any{
+		"request_id": "2",
+		"type":       "result",
+		"status":     float64(http.StatusCreated),
+		"task":       map[string]any{"id": float64(1), "name": "task 1", "status": "incomplete"},
+	}, read())
+
+	// the incomplete task created above is filtered out.
+	send(`{"request_id":"3","action":"update","id":1,"status":"completed"}`)
+	s.Equal(map[string]any{
+		"request_id": "3",
+		"type":       "result",
+		"status":     float64(http.StatusOK),
+		"task":       map[string]any{"id": float64(1), "name": "task 1", "status": "completed"},
+	}, read())
+	s.Equal(map[string]any{
+		"type":  "event",
+		"event": "updated",
+		"task":  map[string]any{"id": float64(1), "name": "task 1", "status": "completed"},
+	}, read())
+
+	send(`{"request_id":"4","action":"delete","id":2}`)
+	s.Equal(map[string]any{
+		"request_id": "4",
+		"type":       "error",
+		"status":     float64(http.StatusNotFound),
+		"error":      domain.ErrTaskNotFound.Error(),
+	}, read())
+
+	send(`{"request_id":"5","action":"archive"}`)
+	s.Equal("error", read()["type"])
+
+	s.T().Run("ping", func(t *testing.T) {
+		go func() {
+			// keep reading for the ping handler until the connection is closed.
+			for {
+				if _, _, err := conn.NextReader(); err != nil {
+					return
+				}
+			}
+		}()
+
+		select {
+		case <-pinged:
+		case <-time.After(time.Second):
+			s.Fail("no ping received")
+		}
+	})
+}
+
+func (s *TaskControllerSuite) TestTasksICS() {
+	miniredis := database.InitializeTestingRedis()
+	defer miniredis.Close()
+
+	database.Initialize(context.Background(), miniredis.Addr(), "")
+
+	repo := persistance.NewRedisRepo(database.Redis())
+	service := taskService.NewService(repo)
+	controller := task.NewControllerV2(service)
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/tasks.ics", controller.ExportTasksICS)
+	engine.POST("/tasks/import/ics", controller.ImportTasksICS)
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		engine.ServeHTTP(recorder, req)
+		return recorder
+	}
+	importICS := func(document string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/tasks/import/ics", strings.NewReader(document))
+		req.Header.Set("Content-Type", "text/calendar")
+		return serve(req)
+	}
+
+	resp := importICS("BEGIN:VCALENDAR\r\n" +
+		"VERSION:2.0\r\n" +
+		"BEGIN:VTODO\r\n" +
+		"UID:abc\r\n" +
+		"SUMMARY:buy milk\\, eggs\\; and a very long name which is folded across\r\n" +
+		"  lines\r\n" +
+		"END:VTODO\r\n" +
+		"BEGIN:VTODO\r\n" +
+		"SUMMARY:task 2\r\n" +
+		"STATUS:COMPLETED\r\n" +
+		"END:VTODO\r\n" +
+		"END:VCALENDAR\r\n")
+	s.Require().Equal(http.StatusCreated, resp.Code, resp.Body.String())
+
+	var created []struct {
+		ID   uint   `json:"id"`
+		Name string `json:"name"`
+	}
+	s.Require().NoError(json.Unmarshal(resp.Body.Bytes(), &created))
+	s.Require().Len(created, 2)
+	s.Equal("buy milk, eggs; and a very long name which is folded across lines", created[0].Name)
+	s.Equal("task 2", created[1].Name)
+
+	s.NoError(repo.UpdateTask(context.Background(), 2, domain.UpdateTaskRequest{
+		Status: util.Pointer(domain.TaskStatusCompleted),
+	}))
+
+	resp = serve(httptest.NewRequest(http.MethodGet, "/tasks.ics", nil))
+	s.Require().Equal(http.StatusOK, resp.Code)
+	s.Equal("text/calendar; charset=utf-8", resp.Header().Get("Content-Type"))
+	body := resp.Body.String()
+	s.True(strings.HasPrefix(body, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"), body)
+	s.Contains(body, "UID:task-1@gotasker\r\nDTSTAMP:")
+	s.Contains(body, "SUMMARY:buy milk\\, eggs\\; and a very long name which is folded across")
+	s.Contains(body, "STATUS:NEEDS-ACTION\r\n")
+	s.Contains(body, "UID:task-2@gotasker\r\n")
+	s.Contains(body, "STATUS:COMPLETED\r\nCOMPLETED:")
+	for _, line := range strings.Split(body, "\r\n") {
+		s.LessOrEqual(len(line), 75, line)
+	}
+
+	s.T().Run("not modified", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/tasks.ics", nil)
+		req.Header.Set("If-None-Match", resp.Header().Get("ETag"))
+		s.Equal(http.StatusNotModified, serve(req).Code)
+	})
+
+	s.T().Run("malformed", func(t *testing.T) {
+		resp := importICS("BEGIN:VTODO\r\nEND:VTODO\r\n")
+		s.Equal(http.StatusBadRequest, resp.Code)
+	})
+
+	s.T().Run("without summary", func(t *testing.T) {
+		resp := importICS("BEGIN:VCALENDAR\r\n" +
+			"BEGIN:VTODO\r\nSUMMARY:task 3\r\nEND:VTODO\r\n" +
+			"BEGIN:VTODO\r\nUID:empty\r\nEND:VTODO\r\n" +
+			"END:VCALENDAR\r\n")
+		s.Equal(http.StatusBadRequest, resp.Code)
+		s.Contains(resp.Body.String(), "VTODO 2: task name is required")
+
+		tasks, err := repo.ListTasks(context.Background())
+		s.NoError(err)
+		s.Len(tasks, 2)
+	})
+}
+
+func (s *TaskControllerSuite) TestExportImportTasks() {
+	miniredis := database.InitializeTestingRedis()
+	defer miniredis.Close()
+
+	database.Initialize(context.Background(), miniredis.Addr(), "")
+
+	repo := persistance.NewRedisRepo(database.Redis())
+	service := taskService.NewService(repo, taskService.WithMaxBatchSize(2))
+	controller := task.NewControllerV2(service)
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/tasks/export", controller.ExportTasks)
+	engine.POST("/tasks/import", controller.ImportTasks)
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		engine.ServeHTTP(recorder, req)
+		return recorder
+	}
+	importTasks := func(query, contentType, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/tasks/import?"+query, strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		return serve(req)
+	}
+
+	type report struct {
+		DryRun      bool `json:"dry_run"`
+		Total       int  `json:"total"`
+		Created     int  `json:"created"`
+		Overwritten int  `json:"overwritten"`
+		Skipped     int  `json:"skipped"`
+		Failed      int  `json:"failed"`
+		Rows        []struct {
+			Line   int    `json:"line"`
+			Action string `json:"action"`
+			Task   *struct {
+				ID     uint   `json:"id"`
+				Name   string `json:"name"`
+				Status string `json:"status"`
+			} `json:"task"`
+			Error string `json:"error"`
+		} `json:"rows"`
+	}
+	decodeReport := func(resp *httptest.ResponseRecorder) report {
+		var r report
+		s.Require().NoError(json.Unmarshal(resp.Body.Bytes(), &r), resp.Body.String())
+		return r
+	}
+
+	for index := range 3 {
+		_, err := repo.CreateTask(context.Background(), domain.CreateTaskRequest{Name: fmt.Sprintf("task %d", index+1)})
+		s.Require().NoError(err)
+	}
+	s.NoError(repo.UpdateTask(context.Background(), 2, domain.UpdateTaskRequest{
+		Status: util.Pointer(domain.TaskStatusCompleted),
+	}))
+
+	s.T().Run("export", func(t *testing.T) {
+		resp := serve(httptest.NewRequest(http.MethodGet, "/tasks/export", nil))
+		s.Require().Equal(http.StatusOK, resp.Code)
+		s.Equal("application/x-ndjson; charset=utf-8", resp.Header().Get("Content-Type"))
+
+		lines := strings.Split(strings.TrimSpace(resp.Body.String()), "\n")
+		s.Require().Len(lines, 3)
+		for _, line := range lines {
+			var record map[string]any
+			s.Require().NoError(json.Unmarshal([]byte(line), &record))
+			s.Contains(record, "created_at")
+			if record["id"] == float64(2) {
+				s.Equal("completed", record["status"])
+			}
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/tasks/export", nil)
+		req.Header.Set("Accept", "text/csv")
+		resp = serve(req)
+		s.Require().Equal(http.StatusOK, resp.Code)
+		s.Equal("text/csv; charset=utf-8", resp.Header().Get("Content-Type"))
+		lines = strings.Split(strings.TrimSpace(resp.Body.String()), "\n")
+		s.Require().Len(lines, 4)
+		s.Equal("id,name,status,created_at,updated_at,notes", lines[0])
+
+		resp = serve(httptest.NewRequest(http.MethodGet, "/tasks/export?format=xml", nil))
+		s.Equal(http.StatusBadRequest, resp.Code)
+	})
+
+	s.T().Run("dry run", func(t *testing.T) {
+		resp := importTasks("dry_run=true&preserve_ids=true", "text/csv",
+			"name,id,notes\n"+
+				"task 4,,buy milk\n"+
+				"task 2,2,\n"+
+				"\"unterminated,3\n")
+		s.Require().Equal(http.StatusOK, resp.Code, resp.Body.String())
+
+		r := decodeReport(resp)
+		s.True(r.DryRun)
+		s.Equal(3, r.Total)
+		s.Equal(2, r.Failed)
+		s.Require().Len(r.Rows, 3)
+		s.Equal(2, r.Rows[0].Line)
+		s.Equal("rolled back", r.Rows[0].Error)
+		s.Equal("task already exists", r.Rows[1].Error)
+		s.Equal(4, r.Rows[2].Line)
+		s.Contains(r.Rows[2].Error, "malformed record")
+
+		resp = importTasks("dry_run=true&preserve_ids=true&conflict=skip", "application/x-ndjson",
+			"{\"name\": \"task 4\"}\n\n{\"id\": 2, \"name\": \"task 2\"}\n")
+		s.Require().Equal(http.StatusOK, resp.Code, resp.Body.String())
+
+		r = decodeReport(resp)
+		s.Equal(1, r.Created)
+		s.Equal(1, r.Skipped)
+		s.Equal(3, r.Rows[1].Line)
+		s.Equal("skipped", r.Rows[1].Action)
+		s.Equal("completed", r.Rows[1].Task.Status, "the existing task is kept")
+
+		tasks, err := repo.ListTasks(context.Background())
+		s.NoError(err)
+		s.Len(tasks, 3)
+	})
+
+	s.T().Run("invalid", func(t *testing.T) {
+		resp := importTasks("format=ndjson", "text/plain",
+			"{\"name\": \"task 4\"}\n{\"name\": \"\"}\n{\"name\": \"task 6\", \"status\": \"unknown\"}\n")
+		s.Require().Equal(http.StatusUnprocessableEntity, resp.Code, resp.Body.String())
+
+		r := decodeReport(resp)
+		s.False(r.DryRun)
+		s.Equal(2, r.Failed)
+		s.Equal("rolled back", r.Rows[0].Error)
+		s.Equal(taskService.ErrTaskNameRequired.Error(), r.Rows[1].Error)
+		s.Equal(domain.ErrInvalidTaskStatus.Error(), r.Rows[2].Error)
+
+		resp = importTasks("conflict=ignore", "text/csv", "name\ntask 4\n")
+		s.Equal(http.StatusBadRequest, resp.Code)
+		resp = importTasks("", "text/csv", "title\ntask 4\n")
+		s.Equal(http.StatusBadRequest, resp.Code)
+
+		tasks, err := repo.ListTasks(context.Background())
+		s.NoError(err)
+		s.Len(tasks, 3)
+	})
+
+	s.T().Run("new ids", func(t *testing.T) {
+		resp := importTasks("", "text/csv", "id,name,status\n2,task 4,completed\n")
+		s.Require().Equal(http.StatusOK, resp.Code, resp.Body.String())
+
+		r := decodeReport(resp)
+		s.Equal(1, r.Created)
+		s.Equal(uint(4), r.Rows[0].Task.ID)
+		s.Equal("completed", r.Rows[0].Task.Status)
+	})
+
+	s.T().Run("preserve ids", func(t *testing.T) {
+		resp := importTasks("preserve_ids=true&conflict=overwrite", "application/x-ndjson",
+			"{\"id\": 10, \"name\": \"task 10\", \"created_at\": \"2024-05-01T08:30:00Z\"}\n"+
+				"{\"name\": \"task 11\"}\n"+
+				"{\"id\": 1, \"name\": \"task 1 renamed\", \"status\": \"completed\"}\n")
+		s.Require().Equal(http.StatusOK, resp.Code, resp.Body.String())
+
+		r := decodeReport(resp)
+		s.Equal(2, r.Created)
+		s.Equal(1, r.Overwritten)
+		s.Equal(uint(11), r.Rows[1].Task.ID, "new IDs follow the largest preserved one")
+		s.Equal("overwritten", r.Rows[2].Action)
+
+		imported, err := repo.GetTask(context.Background(), 10)
+		s.Require().NoError(err)
+		s.Equal(time.Date(2024, time.May, 1, 8, 30, 0, 0, time.UTC), imported.CreatedAt.UTC())
+
+		overwritten, err := repo.GetTask(context.Background(), 1)
+		s.Require().NoError(err)
+		s.Equal("task 1 renamed", overwritten.Name)
+		s.Equal(domain.TaskStatusCompleted, overwritten.Status)
+
+		created, err := repo.CreateTask(context.Background(), domain.CreateTaskRequest{Name: "task 12"})
+		s.Require().NoError(err)
+		s.Equal(uint(12), created.ID, "the auto increment ID is moved past the imported ones")
+	})
+
+	s.T().Run("todo.txt", func(t *testing.T) {
+		resp := importTasks("", "text/plain; charset=utf-8",
+			"(A) 2024-05-01 call mom +family @phone due:2024-05-03\n"+
+				"\n"+
+				"x 2024-05-02 2024-05-01 buy milk\n")
+		s.Require().Equal(http.StatusOK, resp.Code, resp.Body.String())
+
+		r := decodeReport(resp)
+		s.Equal(2, r.Created)
+		s.Equal(3, r.Rows[1].Line)
+
+		imported, err := repo.GetTask(context.Background(), r.Rows[0].Task.ID)
+		s.Require().NoError(err)
+		s.Equal("(A) call mom +family @phone due:2024-05-03", imported.Name, "unknown tokens are kept in the name")
+		s.Equal(domain.TaskStatusIncomplete, imported.Status)
+		s.Equal(time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC), imported.CreatedAt.UTC())
+
+		completed, err := repo.GetTask(context.Background(), r.Rows[1].Task.ID)
+		s.Require().NoError(err)
+		s.Equal(domain.TaskStatusCompleted, completed.Status)
+		s.Equal(time.Date(2024, time.May, 2, 0, 0, 0, 0, time.UTC), completed.UpdatedAt.UTC())
+
+		resp = serve(httptest.NewRequest(http.MethodGet, "/tasks/export?format=todotxt", nil))
+		s.Require().Equal(http.StatusOK, resp.Code)
+		s.Equal("text/plain; charset=utf-8", resp.Header().Get("Content-Type"))
+		s.Equal(`attachment; filename="tasks.txt"`, resp.Header().Get("Content-Disposition"))
+		s.Contains(resp.Body.String(), "(A) 2024-05-01 call mom +family @phone due:2024-05-03\n")
+		s.Contains(resp.Body.String(), "x 2024-05-02 2024-05-01 buy milk\n")
+	})
+
+	s.T().Run("notes", func(t *testing.T) {
+		created, err := repo.CreateTask(context.Background(), domain.CreateTaskRequest{Name: "task 13", Notes: "buy oat milk"})
+		s.Require().NoError(err)
+
+		resp := serve(httptest.NewRequest(http.MethodGet, "/tasks/export?format=csv", nil))
+		s.Require().Equal(http.StatusOK, resp.Code)
+		s.Contains(resp.Body.String(), ",buy oat milk\n")
+
+		// a round trip through CSV keeps the notes.
+		resp = importTasks("preserve_ids=true&conflict=overwrite", "text/csv", resp.Body.String())
+		s.Require().Equal(http.StatusOK, resp.Code, resp.Body.String())
+
+		imported, err := repo.GetTask(context.Background(), created.ID)
+		s.Require().NoError(err)
+		s.Equal("buy oat milk", imported.Notes)
+
+		// Markdown has no notes, overwriting keeps them.
+		resp = importTasks("preserve_ids=true&conflict=overwrite", "text/markdown",
+			fmt.Sprintf("- [x] task 13 <!-- gotasker:%d -->\n", created.ID))
+		s.Require().Equal(http.StatusOK, resp.Code, resp.Body.String())
+		s.Equal(1, decodeReport(resp).Overwritten)
+
+		imported, err = repo.GetTask(context.Background(), created.ID)
+		s.Require().NoError(err)
+		s.Equal(domain.TaskStatusCompleted, imported.Status)
+		s.Equal("buy oat milk", imported.Notes)
+	})
+}
+
+func (s *TaskControllerSuite) TestImportTasksSize() {
+	miniredis := database.InitializeTestingRedis()
+	defer miniredis.Close()
+
+	database.Initialize(context.Background(), miniredis.Addr(), "")
+
+	repo := persistance.NewRedisRepo(database.Redis())
+	controller := task.NewControllerV2(taskService.NewService(repo, taskService.WithMaxImportSize(2)))
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.POST("/tasks/import", controller.ImportTasks)
+
+	importTasks := func(query string, body io.Reader) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/tasks/import?"+query, body))
+		return recorder
+	}
+
+	s.T().Run("records", func(t *testing.T) {
+		// the dump isn't read beyond the record exceeding the size.
+		body := io.MultiReader(
+			strings.NewReader("{\"name\":\"task 1\"}\n{\"name\":\"task 2\"}\n{\"name\":\"task 3\"}\n"),
+			iotest.ErrReader(errors.New("read too far")),
+		)
+
+		recorder := importTasks("", body)
+		s.Equal(http.StatusRequestEntityTooLarge, recorder.Code)
+		s.Contains(recorder.Body.String(), taskService.ErrImportSizeExceeded.Error())
+
+		tasks, err := repo.ListTasks(context.Background())
+		s.Require().NoError(err)
+		s.Empty(tasks)
+	})
+
+	s.T().Run("bytes", func(t *testing.T) {
+		// Markdown dumps are read as a whole.
+		recorder := importTasks("format=markdown", strings.NewReader("- [ ] "+strings.Repeat("a", 33<<20)+"\n"))
+		s.Equal(http.StatusRequestEntityTooLarge, recorder.Code)
+	})
+
+	recorder := importTasks("", strings.NewReader("{\"name\":\"task 1\"}\n{\"name\":\"task 2\"}\n"))
+	s.Equal(http.StatusOK, recorder.Code)
+}
+
+// stripTimestamps removes the timestamps of a v2 task.
+func stripTimestamps(s *TaskControllerSuite, data string) string {
+	var task map[string]any
+	s.Require().NoError(json.Unmarshal([]byte(data), &task))
+	delete(task, "created_at")
+	delete(task, "updated_at")
+
+	bs, err := json.Marshal(task)
+	s.Require().NoError(err)
+
+	return string(bs)
+}
+
 func TestTaskController(t *testing.T) {
 	suite.Run(t, new(TaskControllerSuite))
 }
@@ -0,0 +1,278 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/omegaatt36/gotasker/domain"
+	"github.com/omegaatt36/gotasker/format/dump"
+	"github.com/omegaatt36/gotasker/logging"
+	"github.com/omegaatt36/gotasker/service/task"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MaxImportBodySize is the maximum size of an imported dump, todo.txt and
+// Markdown dumps are read as a whole before their records are counted. The
+// bodies of requests must be limited to it before they are validated, which
+// reads them as a whole too.
+const MaxImportBodySize = 32 << 20
+
+// negotiateDump selects the dump format from the format query parameter, or
+// else from the media types of the header, NDJSON by default.
+func negotiateDump(c *gin.Context, header string) (dump.Format, error) {
+	if name := c.Query("format"); name != "" {
+		return dump.ParseFormat(name)
+	}
+
+	for _, value := range strings.Split(c.GetHeader(header), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(value))
+		if err != nil {
+			continue
+		}
+
+		if format, ok := dump.FormatOf(mediaType); ok {
+			return format, nil
+		}
+	}
+
+	return dump.FormatNDJSON, nil
+}
+
+func toRecord(t *domain.Task) dump.Record {
+	return dump.Record{
+		ID:        t.ID,
+		Name:      t.Name,
+		Status:    t.Status.String(),
+		CreatedAt: t.CreatedAt,
+		UpdatedAt: t.UpdatedAt,
+		Notes:     t.Notes,
+	}
+}
+
+// toImportedTask maps the record to a task, the status defaults to
+// incomplete.
+func toImportedTask(record *dump.Record) (domain.Task, error) {
+	t := domain.Task{
+		ID:        record.ID,
+		Name:      record.Name,
+		CreatedAt: record.CreatedAt,
+		UpdatedAt: record.UpdatedAt,
+		Notes:     record.Notes,
+	}
+
+	if record.Status != "" {
+		status, err := domain.ParseTaskStatus(record.Status)
+		if err != nil {
+			return domain.Task{}, domain.ErrInvalidTaskStatus
+		}
+		t.Status = status
+	}
+
+	return t, nil
+}
+
+// ExportTasks streams all tasks as NDJSON, CSV, todo.txt or Markdown, chunk
+// by chunk, so large sets are never held in memory at once.
+func (x *Controller) ExportTasks(c *gin.Context) {
+	format, err := negotiateDump(c, "Accept")
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	c.Header("Content-Type", format.MediaType()+"; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="tasks%s"`, format.Extension()))
+	c.Status(http.StatusOK)
+
+	encoder := dump.NewEncoder(c.Writer, format)
+	err = x.service.ExportTasks(c.Request.Context(), func(tasks []domain.Task) error {
+		records := make([]dump.Record, len(tasks))
+		for index := range tasks {
+			records[index] = toRecord(&tasks[index])
+		}
+
+		if err := encoder.Encode(records...); err != nil {
+			return err
+		}
+		if err := encoder.Flush(); err != nil {
+			return err
+		}
+
+		c.Writer.Flush()
+		return nil
+	})
+	if err == nil {
+		err = encoder.Flush()
+	}
+	if err == nil {
+		return
+	}
+
+	// the status is sent with the first chunk, a failure after it can only
+	// cut the stream short.
+	if !c.Writer.Written() {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	logging.ErrorfCtx(c.Request.Context(), "failed to export tasks: %v", err)
+	c.Abort()
+}
+
+// importTasksQuery defines the query parameters of importing tasks.
+type importTasksQuery struct {
+	DryRun      bool   `form:"dry_run"`
+	PreserveIDs bool   `form:"preserve_ids"`
+	Conflict    string `form:"conflict"`
+}
+
+// importRowResult defines the result of importing a single record.
+type importRowResult struct {
+	Line   int    `json:"line" description:"The line of the dump the record starts at." example:"2"`
+	Action string `json:"action,omitempty" enum:"created,overwritten,skipped" example:"created"`
+	Task   any    `json:"task,omitempty" openapi:"task" description:"The imported task, or the existing one if it's skipped. Absent for records which can't be decoded."`
+	Error  string `json:"error,omitempty" example:"task already exists"`
+}
+
+// importTasksResponse defines the report of importing tasks.
+type importTasksResponse struct {
+	DryRun      bool              `json:"dry_run"`
+	Total       int               `json:"total" example:"3"`
+	Created     int               `json:"created" example:"2"`
+	Overwritten int               `json:"overwritten" example:"0"`
+	Skipped     int               `json:"skipped" example:"1"`
+	Failed      int               `json:"failed" description:"The number of invalid records, other records are rolled back if any." example:"0"`
+	Rows        []importRowResult `json:"rows"`
+}
+
+// ImportTasks imports the tasks of an NDJSON, CSV, todo.txt or Markdown dump
+// atomically. Every record is validated, the report tells the line and error
+// of each invalid one.
+func (x *Controller) ImportTasks(c *gin.Context) {
+	var query importTasksQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	conflict := domain.ImportConflictFail
+	if query.Conflict != "" {
+		var err error
+		if conflict, err = domain.ParseImportConflict(query.Conflict); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	format, err := negotiateDump(c, "Content-Type")
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// a dump exceeding the import size is rejected after reading one record
+	// more than the size, the body limit bounds the dumps read as a whole.
+	maxSize := x.service.MaxImportSize()
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, MaxImportBodySize)
+	rows, err := dump.Decode(body, format, maxSize+1)
+	if err != nil {
+		if maxBytesErr := new(http.MaxBytesError); errors.As(err, &maxBytesErr) {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, err.Error())
+			return
+		}
+
+		c.AbortWithStatusJSON(http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(rows) > maxSize {
+		c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("%v: more than %d records", task.ErrImportSizeExceeded, maxSize))
+		return
+	}
+
+	// records which can't be mapped to tasks fail the import, the others are
+	// still validated by a dry run.
+	rowErrs := make([]error, len(rows))
+	tasks := make([]domain.Task, 0, len(rows))
+	for index := range rows {
+		if rowErrs[index] = rows[index].Err; rowErrs[index] != nil {
+			continue
+		}
+
+		t, err := toImportedTask(&rows[index].Record)
+		if err != nil {
+			rowErrs[index] = err
+			continue
+		}
+		tasks = append(tasks, t)
+	}
+
+	invalid := len(tasks) < len(rows)
+	results, err := x.service.ImportTasks(c.Request.Context(), task.ImportTasksRequest{
+		Tasks:       tasks,
+		PreserveIDs: query.PreserveIDs,
+		Conflict:    conflict,
+		DryRun:      query.DryRun || invalid,
+	})
+	if err != nil {
+		if errors.Is(err, task.ErrImportSizeExceeded) {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, err.Error())
+			return
+		}
+
+		c.AbortWithStatusJSON(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resp := importTasksResponse{
+		DryRun: query.DryRun,
+		Total:  len(rows),
+		Rows:   make([]importRowResult, len(rows)),
+	}
+	for index := range rows {
+		row := &resp.Rows[index]
+		row.Line = rows[index].Line
+
+		if rowErrs[index] != nil {
+			row.Error = rowErrs[index].Error()
+			resp.Failed++
+			continue
+		}
+
+		result := results[0]
+		results = results[1:]
+		row.Task = x.presenter.taskDetail(&result.Task)
+		switch {
+		case result.Err != nil:
+			row.Error = result.Err.Error()
+			if !errors.Is(result.Err, domain.ErrBatchRolledBack) {
+				resp.Failed++
+			}
+			continue
+		case invalid:
+			row.Error = domain.ErrBatchRolledBack.Error()
+			continue
+		}
+
+		row.Action = result.Action.String()
+		switch result.Action {
+		case domain.ImportActionCreated:
+			resp.Created++
+		case domain.ImportActionOverwritten:
+			resp.Overwritten++
+		case domain.ImportActionSkipped:
+			resp.Skipped++
+		}
+	}
+
+	if resp.Failed > 0 && !query.DryRun {
+		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, resp)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
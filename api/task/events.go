@@ -0,0 +1,72 @@
+package task
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/omegaatt36/gotasker/service/task"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultHeartbeatInterval is the default interval of heartbeats on idle
+// event streams, which keeps proxies from closing them.
+const DefaultHeartbeatInterval = 15 * time.Second
+
+// eventReset tells the client that events were missed and the tasks must be
+// reloaded.
+const eventReset = "reset"
+
+// StreamEvents streams task events as Server-Sent Events. A client
+// reconnecting with Last-Event-ID receives the events it missed, or a reset
+// event if they are no longer buffered.
+func (x *Controller) StreamEvents(c *gin.Context) {
+	subscription := x.service.SubscribeEvents(c.GetHeader("Last-Event-ID"))
+	defer subscription.Close()
+
+	c.Header("Content-Type", sse.ContentType)
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	// disable response buffering of nginx.
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	if subscription.Missed {
+		c.Render(-1, sse.Event{Event: eventReset, Data: "{}"})
+	}
+	for _, event := range subscription.Replay {
+		x.renderEvent(c, event)
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(x.heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-subscription.Events():
+			// the client lagged behind, it reconnects and resumes.
+			if !ok {
+				return
+			}
+
+			x.renderEvent(c, event)
+		case <-heartbeat.C:
+			_, _ = c.Writer.WriteString(":heartbeat\n\n")
+		}
+
+		c.Writer.Flush()
+	}
+}
+
+func (x *Controller) renderEvent(c *gin.Context, event task.Event) {
+	c.Render(-1, sse.Event{
+		Id:    event.ID,
+		Event: string(event.Type),
+		Data:  x.presenter.taskDetail(&event.Task),
+	})
+}
@@ -0,0 +1,112 @@
+package task
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/omegaatt36/gotasker/api/httpcache"
+	"github.com/omegaatt36/gotasker/domain"
+	"github.com/omegaatt36/gotasker/format/ical"
+	"github.com/omegaatt36/gotasker/service/task"
+
+	"github.com/gin-gonic/gin"
+)
+
+// icsContentType is the Content-Type of the iCalendar feed.
+const icsContentType = ical.MediaType + "; charset=utf-8"
+
+// toTodo maps the task to a VTODO, completed tasks are regarded as completed
+// at their last update.
+func toTodo(t *domain.Task) ical.Todo {
+	todo := ical.Todo{
+		UID:          t.CalendarUID(),
+		Summary:      t.Name,
+		Status:       ical.StatusNeedsAction,
+		Created:      t.CreatedAt,
+		LastModified: t.UpdatedAt,
+	}
+	if t.Status == domain.TaskStatusCompleted {
+		todo.Status = ical.StatusCompleted
+		todo.Completed = t.UpdatedAt
+	}
+
+	return todo
+}
+
+// ExportTasksICS serves all tasks as an iCalendar feed with a VTODO per task.
+func (x *Controller) ExportTasksICS(c *gin.Context) {
+	version, err := x.service.Version(c.Request.Context())
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if httpcache.NotModified(c, httpcache.ETag(fmt.Sprintf("tasks-%d-ics", version))) {
+		return
+	}
+
+	tasks, err := x.service.ListTasks(c.Request.Context())
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	todos := make([]ical.Todo, len(tasks))
+	for index := range tasks {
+		todos[index] = toTodo(&tasks[index])
+	}
+
+	var buf bytes.Buffer
+	if err := ical.Encode(&buf, todos); err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.Data(http.StatusOK, icsContentType, buf.Bytes())
+}
+
+// ImportTasksICS creates a task of every VTODO of the iCalendar body, named
+// by its SUMMARY. The tasks are created atomically.
+func (x *Controller) ImportTasksICS(c *gin.Context) {
+	todos, err := ical.Decode(c.Request.Body)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ops := make([]task.BatchOperation, len(todos))
+	for index, todo := range todos {
+		ops[index] = task.BatchOperation{
+			Type:   domain.BatchOperationTypeCreate,
+			Create: task.CreateTaskRequest{Name: todo.Summary},
+		}
+	}
+
+	results, err := x.service.BatchTasks(c.Request.Context(), task.BatchTasksRequest{
+		Atomic:     true,
+		Operations: ops,
+	})
+	if err != nil {
+		if errors.Is(err, task.ErrBatchSizeExceeded) {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, err.Error())
+			return
+		}
+
+		c.AbortWithStatusJSON(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	taskDetails := make([]any, len(results))
+	for index, result := range results {
+		if result.Err != nil && !errors.Is(result.Err, domain.ErrBatchRolledBack) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, fmt.Sprintf("VTODO %d: %v", index+1, result.Err))
+			return
+		}
+
+		taskDetails[index] = x.presenter.taskDetail(&result.Task)
+	}
+
+	c.JSON(http.StatusCreated, taskDetails)
+}
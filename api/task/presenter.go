@@ -0,0 +1,48 @@
+package task
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/omegaatt36/gotasker/domain"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// presenter maps between domain models and the DTOs of an API version.
+type presenter interface {
+	// taskDetail converts the task to its DTO.
+	taskDetail(task *domain.Task) any
+	// taskDetails converts the tasks to a slice of their DTOs.
+	taskDetails(tasks []domain.Task) any
+	// parseStatus decodes a task status from the raw JSON value.
+	parseStatus(raw json.RawMessage) (domain.TaskStatus, error)
+	// statusSchema returns the OpenAPI schema of the task status.
+	statusSchema() *openapi3.Schema
+	// schemaSuffix is appended to the names of the OpenAPI schemas of the DTOs.
+	schemaSuffix() string
+}
+
+// parseStatus decodes an optional task status, nil is returned if the status
+// is absent or null.
+func parseStatus(p presenter, raw json.RawMessage) (*domain.TaskStatus, error) {
+	if isAbsent(raw) {
+		return nil, nil
+	}
+
+	status, err := p.parseStatus(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	if !status.IsValid() {
+		return nil, domain.ErrInvalidTaskStatus
+	}
+
+	return &status, nil
+}
+
+// isAbsent returns whether the optional JSON value is absent or null.
+func isAbsent(raw json.RawMessage) bool {
+	return len(raw) == 0 || bytes.Equal(raw, []byte("null"))
+}
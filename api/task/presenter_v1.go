@@ -0,0 +1,67 @@
+package task
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/omegaatt36/gotasker/domain"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// taskDetail defines DTO for domain.Task.
+type taskDetail struct {
+	ID     uint   `json:"id" description:"The task ID." example:"1"`
+	Name   string `json:"name" description:"The task name." example:"Task 1"`
+	Status int    `json:"status" openapi:"status"`
+}
+
+func (task *taskDetail) fromDomain(domainTask *domain.Task) {
+	task.ID = domainTask.ID
+	task.Name = domainTask.Name
+	task.Status = int(domainTask.Status)
+}
+
+// v1Presenter presents task status as integer.
+type v1Presenter struct{}
+
+func (v1Presenter) taskDetail(domainTask *domain.Task) any {
+	task := &taskDetail{}
+	task.fromDomain(domainTask)
+
+	return task
+}
+
+func (v1Presenter) taskDetails(domainTasks []domain.Task) any {
+	tasks := make([]taskDetail, len(domainTasks))
+	for index := range domainTasks {
+		tasks[index].fromDomain(&domainTasks[index])
+	}
+
+	return tasks
+}
+
+func (v1Presenter) parseStatus(raw json.RawMessage) (domain.TaskStatus, error) {
+	var status int
+	if err := json.Unmarshal(raw, &status); err != nil {
+		return 0, fmt.Errorf("%w: %w", domain.ErrInvalidTaskStatus, err)
+	}
+
+	return domain.TaskStatus(status), nil
+}
+
+func (v1Presenter) statusSchema() *openapi3.Schema {
+	statuses := domain.TaskStatusValues()
+	values := make([]any, len(statuses))
+	for index, status := range statuses {
+		values[index] = int(status)
+	}
+
+	schema := openapi3.NewIntegerSchema().WithEnum(values...)
+	schema.Description = "The task status. 0 represents an incomplete task, while 1 represents a completed task."
+	return schema
+}
+
+func (v1Presenter) schemaSuffix() string {
+	return ""
+}
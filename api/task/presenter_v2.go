@@ -0,0 +1,84 @@
+package task
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/omegaatt36/gotasker/domain"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// taskDetailV2 defines DTO for domain.Task of the v2 API.
+type taskDetailV2 struct {
+	ID        uint   `json:"id" description:"The task ID." example:"1"`
+	Name      string `json:"name" description:"The task name." example:"Task 1"`
+	Status    string `json:"status" openapi:"status"`
+	CreatedAt string `json:"created_at,omitempty" description:"The creation time in RFC 3339, absent on tasks created before timestamps were recorded." example:"2024-04-01T08:00:00Z"`
+	UpdatedAt string `json:"updated_at,omitempty" description:"The last modification time in RFC 3339, absent on tasks created before timestamps were recorded." example:"2024-04-01T08:00:00Z"`
+	Notes     string `json:"notes,omitempty" description:"Free text about the task, e.g. the body of the email it was created from." example:"The printer on the 2nd floor is jammed."`
+}
+
+func (task *taskDetailV2) fromDomain(domainTask *domain.Task) {
+	task.ID = domainTask.ID
+	task.Name = domainTask.Name
+	task.Status = domainTask.Status.String()
+	task.CreatedAt = formatTime(domainTask.CreatedAt)
+	task.UpdatedAt = formatTime(domainTask.UpdatedAt)
+	task.Notes = domainTask.Notes
+}
+
+// formatTime formats the time in RFC 3339, tasks created before timestamps
+// were recorded have none.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.UTC().Format(time.RFC3339)
+}
+
+// v2Presenter presents task status by name and timestamps in RFC 3339.
+type v2Presenter struct{}
+
+func (v2Presenter) taskDetail(domainTask *domain.Task) any {
+	task := &taskDetailV2{}
+	task.fromDomain(domainTask)
+
+	return task
+}
+
+func (v2Presenter) taskDetails(domainTasks []domain.Task) any {
+	tasks := make([]taskDetailV2, len(domainTasks))
+	for index := range domainTasks {
+		tasks[index].fromDomain(&domainTasks[index])
+	}
+
+	return tasks
+}
+
+func (v2Presenter) parseStatus(raw json.RawMessage) (domain.TaskStatus, error) {
+	var name string
+	if err := json.Unmarshal(raw, &name); err != nil {
+		return 0, fmt.Errorf("%w: %w", domain.ErrInvalidTaskStatus, err)
+	}
+
+	return domain.ParseTaskStatus(name)
+}
+
+func (v2Presenter) statusSchema() *openapi3.Schema {
+	statuses := domain.TaskStatusValues()
+	values := make([]any, len(statuses))
+	for index, status := range statuses {
+		values[index] = status.String()
+	}
+
+	schema := openapi3.NewStringSchema().WithEnum(values...)
+	schema.Description = "The task status."
+	return schema
+}
+
+func (v2Presenter) schemaSuffix() string {
+	return "V2"
+}
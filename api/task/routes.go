@@ -0,0 +1,293 @@
+package task
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/omegaatt36/gotasker/api/apidoc"
+	"github.com/omegaatt36/gotasker/api/httpcache"
+	"github.com/omegaatt36/gotasker/api/render"
+	"github.com/omegaatt36/gotasker/domain"
+	"github.com/omegaatt36/gotasker/format/dump"
+	"github.com/omegaatt36/gotasker/format/ical"
+	"github.com/omegaatt36/gotasker/service/task"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// RegisterRoutes registers the task routes on the router and documents them.
+func (x *Controller) RegisterRoutes(router *apidoc.Router) {
+	router.Handle(http.MethodGet, "/ws", apidoc.Operation{
+		ID:      "connectWebSocket",
+		Summary: "Subscribe to task changes and send commands over WebSocket.",
+		Description: "Messages are JSON objects. Clients send `{\"request_id\", \"action\", ...}` where the action is one of\n" +
+			"- `subscribe` with an optional `filter` of `ids` and `status`, an empty filter matches all tasks,\n" +
+			"- `unsubscribe`,\n" +
+			"- `create`, `update` and `delete` with the fields of batch operations.\n\n" +
+			"Each request is answered with `{\"request_id\", \"type\": \"result\"|\"error\", \"status\", \"task\", \"error\"}`, " +
+			"events of subscribed tasks are sent as `{\"type\": \"event\", \"event\", \"task\"}`.\n" +
+			"The server pings idle connections, connections lagging behind the events are closed with code 1013.",
+		Responses: []apidoc.Response{
+			{Status: http.StatusSwitchingProtocols, Description: "Switching to the WebSocket protocol."},
+			{Status: http.StatusBadRequest, Description: "Not a WebSocket handshake."},
+		},
+	}, x.ServeWebSocket)
+
+	ifNoneMatch := router.Parameter("IfNoneMatch", openapi3.NewHeaderParameter("If-None-Match").
+		WithDescription("Entity tags of cached representations.").
+		WithSchema(openapi3.NewStringSchema()))
+	router.Handle(http.MethodGet, "/tasks.ics", apidoc.Operation{
+		ID:      "exportTasksICS",
+		Summary: "Export tasks as an iCalendar feed.",
+		Description: "Export all tasks as an RFC 5545 VCALENDAR with a VTODO per task, to be subscribed by calendar apps.\n" +
+			"The UID of a VTODO is `task-{id}@gotasker`, STATUS is `NEEDS-ACTION` or `COMPLETED`.",
+		Parameters: []*openapi3.ParameterRef{ifNoneMatch},
+		Responses: []apidoc.Response{
+			{Status: http.StatusOK, Description: "The iCalendar feed.", Content: []apidoc.Content{{
+				MediaType: ical.MediaType,
+				Body:      "",
+				Example:   "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:" + ical.ProductID + "\r\nBEGIN:VTODO\r\nUID:task-1@gotasker\r\n...",
+			}}},
+			{Status: http.StatusNotModified, Description: "The feed has not been modified since the entity tag of If-None-Match."},
+		},
+	}, x.ExportTasksICS)
+
+	router = router.Group("/tasks").Schemas(apidoc.SchemaOptions{
+		Suffix: x.presenter.schemaSuffix(),
+		Substitutes: map[string]any{
+			"status": x.presenter.statusSchema(),
+			"task":   x.presenter.taskDetail(&domain.Task{}),
+		},
+	})
+
+	taskID := router.Parameter("TaskID", openapi3.NewPathParameter("id").
+		WithDescription("The task ID. must be a positive integer.").
+		WithSchema(openapi3.NewIntegerSchema()))
+	cacheHeaders := map[string]*openapi3.HeaderRef{
+		"ETag": router.Header("ETag", &openapi3.Header{Parameter: openapi3.Parameter{
+			Description: "Strong entity tag of the representation.",
+			Schema:      openapi3.NewSchemaRef("", openapi3.NewStringSchema()),
+		}}),
+		"Cache-Control": router.Header("CacheControl", &openapi3.Header{Parameter: openapi3.Parameter{
+			Description: "Cached responses must be revalidated with If-None-Match.",
+			Schema:      openapi3.NewSchemaRef("", openapi3.NewStringSchema().WithDefault(httpcache.CacheControl)),
+		}}),
+	}
+
+	formats := render.All()
+	formatNames := make([]any, len(formats))
+	listContent := make([]apidoc.Content, len(formats))
+	for index, format := range formats {
+		formatNames[index] = format.Name
+		listContent[index] = apidoc.Content{MediaType: format.MediaType, Body: ""}
+		if format.Structured {
+			listContent[index].Body = apidoc.ArrayOf{Item: x.presenter.taskDetail(&domain.Task{})}
+		}
+	}
+	formatParameter := router.Parameter("Format", openapi3.NewQueryParameter(render.FormatQuery).
+		WithDescription("The response format, overrides the Accept header.").
+		WithSchema(openapi3.NewStringSchema().WithEnum(formatNames...)))
+
+	detail := x.presenter.taskDetail(&domain.Task{})
+	badRequest := errorResponse(http.StatusBadRequest, "Invalid parameters.", domain.ErrInvalidTaskID)
+	notFound := errorResponse(http.StatusNotFound, "Task not found.", domain.ErrTaskNotFound)
+
+	router.Handle(http.MethodGet, "", apidoc.Operation{
+		ID:      "listTasks",
+		Summary: "List all tasks.",
+		Description: "List all tasks.\n" +
+			"The format is negotiated by the `Accept` header, the `format` query parameter takes precedence over it.",
+		Parameters: []*openapi3.ParameterRef{formatParameter, ifNoneMatch},
+		Responses: []apidoc.Response{
+			{Status: http.StatusOK, Description: "The list of tasks.", Headers: cacheHeaders, Content: listContent},
+			{Status: http.StatusNotModified, Description: "The list has not been modified since the entity tag of If-None-Match."},
+			{Status: http.StatusNotAcceptable, Description: "None of the formats is acceptable.", Content: []apidoc.Content{{
+				MediaType: apidoc.MediaTypeJSON,
+				Body:      "",
+				Example:   fmt.Sprintf("acceptable formats are %s", strings.Join(render.Formats(), ", ")),
+			}}},
+		},
+	}, x.ListTasks)
+	router.Handle(http.MethodPost, "", apidoc.Operation{
+		ID:      "createTask",
+		Summary: "Create a new task.",
+		Request: createTaskRequest{},
+		Responses: []apidoc.Response{
+			{Status: http.StatusCreated, Description: "The created task.", Content: apidoc.JSON(detail)},
+			errorResponse(http.StatusBadRequest, "Invalid parameters.", task.ErrTaskNameRequired),
+		},
+	}, x.CreateTask)
+	router.Handle(http.MethodPost, "/batch", apidoc.Operation{
+		ID:      "batchTasks",
+		Summary: "Apply a batch of operations.",
+		Description: "Apply a batch of create/update/delete operations.\n" +
+			"In atomic mode all operations are applied or none of them, in best_effort mode every operation is applied on its own.",
+		Request: batchTasksRequest{},
+		Responses: []apidoc.Response{
+			{Status: http.StatusOK, Description: "The result of each operation in request order.", Content: apidoc.JSON(batchTasksResponse{})},
+			errorResponse(http.StatusBadRequest, "Invalid parameters.", domain.ErrInvalidBatchOperationType),
+			errorResponse(http.StatusRequestEntityTooLarge, "Too many operations.", task.ErrBatchSizeExceeded),
+		},
+	}, x.BatchTasks)
+	router.Handle(http.MethodPost, "/bulk-update", apidoc.Operation{
+		ID:      "bulkUpdateTasks",
+		Summary: "Update tasks by filter.",
+		Description: "Update all tasks matching the filter with the patch.\n" +
+			"Tasks are scanned and updated in chunks, so Redis is not blocked on large sets.",
+		Request: bulkUpdateTasksRequest{},
+		Responses: []apidoc.Response{
+			{Status: http.StatusOK, Description: "The updated tasks, or the matching ones in dry run.", Content: apidoc.JSON(bulkResponse{})},
+			errorResponse(http.StatusBadRequest, "Invalid parameters.", task.ErrEmptyFilter),
+		},
+	}, x.BulkUpdateTasks)
+	router.Handle(http.MethodPost, "/bulk-delete", apidoc.Operation{
+		ID:      "bulkDeleteTasks",
+		Summary: "Delete tasks by filter.",
+		Description: "Delete all tasks matching the filter.\n" +
+			"Tasks are scanned and deleted in chunks, so Redis is not blocked on large sets.",
+		Request: bulkDeleteTasksRequest{},
+		Responses: []apidoc.Response{
+			{Status: http.StatusOK, Description: "The deleted tasks, or the matching ones in dry run.", Content: apidoc.JSON(bulkResponse{})},
+			errorResponse(http.StatusBadRequest, "Invalid parameters.", task.ErrEmptyFilter),
+		},
+	}, x.BulkDeleteTasks)
+	router.Handle(http.MethodPost, "/import/ics", apidoc.Operation{
+		ID:      "importTasksICS",
+		Summary: "Import tasks from iCalendar.",
+		Description: "Create a task of every VTODO of the iCalendar document, named by its SUMMARY.\n" +
+			"The tasks are created atomically, other components and properties are skipped.",
+		RequestContent: []apidoc.Content{{
+			MediaType: ical.MediaType,
+			Body:      "",
+			Example:   "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nBEGIN:VTODO\r\nSUMMARY:Task 1\r\nEND:VTODO\r\nEND:VCALENDAR\r\n",
+		}},
+		Responses: []apidoc.Response{
+			{Status: http.StatusCreated, Description: "The created tasks.", Content: apidoc.JSON(apidoc.ArrayOf{Item: detail})},
+			errorResponse(http.StatusBadRequest, "Invalid iCalendar document.", ical.ErrMalformed),
+			errorResponse(http.StatusRequestEntityTooLarge, "Too many VTODOs.", task.ErrBatchSizeExceeded),
+		},
+	}, x.ImportTasksICS)
+	dumpFormats := make([]any, 0, len(dump.Formats()))
+	dumpContent := make([]apidoc.Content, 0, len(dump.Formats()))
+	for _, format := range dump.Formats() {
+		dumpFormats = append(dumpFormats, string(format))
+		dumpContent = append(dumpContent, apidoc.Content{MediaType: format.MediaType(), Body: ""})
+	}
+	dumpContent[0].Example = "{\"id\":1,\"name\":\"Task 1\",\"status\":\"incomplete\",\"created_at\":\"2024-05-01T08:30:00Z\",\"updated_at\":\"2024-05-01T08:30:00Z\",\"notes\":\"Buy oat milk\"}\n"
+	dumpContent[1].Example = strings.Join(dump.Columns, ",") + "\n1,Task 1,incomplete,2024-05-01T08:30:00Z,2024-05-01T08:30:00Z,Buy oat milk\n"
+	dumpContent[2].Example = "(A) 2024-05-01 Task 1 +project @context due:2024-05-03\nx 2024-05-02 2024-05-01 Task 2\n"
+	dumpContent[3].Example = "- [ ] Task 1 <!-- gotasker:1 -->\n- [x] Task 2 <!-- gotasker:2 -->\n"
+	dumpFormat := router.Parameter("DumpFormat", openapi3.NewQueryParameter("format").
+		WithDescription("The format of the dump.").
+		WithSchema(openapi3.NewStringSchema().WithEnum(dumpFormats...)))
+	dumpFormatDescription := "Every line of NDJSON, or row of CSV after the header, is a task of `" + strings.Join(dump.Columns, "`, `") + "`.\n" +
+		"Statuses are names, times are RFC 3339. Only NDJSON and CSV keep the notes of tasks.\n" +
+		"Every line of todo.txt is a task, completed by a leading `x`. The priority, projects, contexts and `key:value` tags are kept in the name, " +
+		"the creation date is the date of `created_at` and the completion date is the date of `updated_at`. todo.txt has no IDs.\n" +
+		"Every item of a Markdown task list is a task, IDs are kept in markers like `<!-- gotasker:1 -->`. Nested items are flattened, " +
+		"the rest of the document is ignored and Markdown has no times."
+	router.Handle(http.MethodGet, "/export", apidoc.Operation{
+		ID:      "exportTasks",
+		Summary: "Export tasks as NDJSON, CSV, todo.txt or Markdown.",
+		Description: "Stream all tasks in no particular order. " + dumpFormatDescription + "\n" +
+			"The format is negotiated by the `Accept` header, the `format` query parameter takes precedence over it, NDJSON is the default.",
+		Parameters: []*openapi3.ParameterRef{dumpFormat},
+		Responses: []apidoc.Response{
+			{Status: http.StatusOK, Description: "The dump of tasks.", Content: dumpContent},
+			errorResponse(http.StatusBadRequest, "Unknown format.", dump.ErrUnknownFormat),
+		},
+	}, x.ExportTasks)
+	router.Handle(http.MethodPost, "/import", apidoc.Operation{
+		ID:      "importTasks",
+		Summary: "Import tasks from NDJSON, CSV, todo.txt or Markdown.",
+		Description: "Import the tasks of a dump atomically. " + dumpFormatDescription + " Only `name` is required.\n" +
+			"The format is taken from the `Content-Type` header, the `format` query parameter takes precedence over it.\n" +
+			"Every record is validated, any invalid one fails the import and the others are reported as rolled back.\n" +
+			"Overwritten tasks keep their notes if the records have none.",
+		Parameters: []*openapi3.ParameterRef{
+			dumpFormat,
+			router.Parameter("DryRun", openapi3.NewQueryParameter("dry_run").
+				WithDescription("Only validate the records and report what the import would do.").
+				WithSchema(openapi3.NewBoolSchema())),
+			router.Parameter("PreserveIDs", openapi3.NewQueryParameter("preserve_ids").
+				WithDescription("Keep the IDs of the records, new IDs follow the largest one. Otherwise every task is created with a new ID.").
+				WithSchema(openapi3.NewBoolSchema())),
+			router.Parameter("ImportConflict", openapi3.NewQueryParameter("conflict").
+				WithDescription("What to do with preserved IDs of existing tasks: fail the import, skip the record or overwrite the task.").
+				WithSchema(openapi3.NewStringSchema().WithEnum("fail", "skip", "overwrite").WithDefault("fail"))),
+		},
+		RequestContent: dumpContent,
+		Responses: []apidoc.Response{
+			{Status: http.StatusOK, Description: "The report of the import.", Content: apidoc.JSON(importTasksResponse{})},
+			errorResponse(http.StatusBadRequest, "Invalid parameters or dump.", dump.ErrMalformed),
+			errorResponse(http.StatusRequestEntityTooLarge, "Too many records, or the dump exceeds 32 MiB.", task.ErrImportSizeExceeded),
+			{Status: http.StatusUnprocessableEntity, Description: "The report of the failed import, no task is written.", Content: apidoc.JSON(importTasksResponse{})},
+		},
+	}, x.ImportTasks)
+	router.Handle(http.MethodGet, "/events", apidoc.Operation{
+		ID:      "streamTaskEvents",
+		Summary: "Stream task changes.",
+		Description: "Stream created/updated/deleted events of tasks as Server-Sent Events, the data is the task.\n" +
+			"Clients reconnecting with `Last-Event-ID` receive the events they missed, or a `reset` event if the events are no longer buffered and the tasks must be reloaded.\n" +
+			"Comments are sent as heartbeats on idle streams.",
+		Parameters: []*openapi3.ParameterRef{
+			router.Parameter("LastEventID", openapi3.NewHeaderParameter("Last-Event-ID").
+				WithDescription("The ID of the last received event.").
+				WithSchema(openapi3.NewStringSchema())),
+		},
+		Responses: []apidoc.Response{
+			{Status: http.StatusOK, Description: "The event stream.", Content: []apidoc.Content{{
+				MediaType: "text/event-stream",
+				Body:      "",
+				Example:   "id: lq3x9k2-1\nevent: created\ndata: {\"id\":1,\"name\":\"Task 1\",\"status\":0}\n\n",
+			}}},
+		},
+	}, x.StreamEvents)
+	router.Handle(http.MethodGet, "/:id", apidoc.Operation{
+		ID:         "getTask",
+		Summary:    "Get a task.",
+		Parameters: []*openapi3.ParameterRef{taskID, ifNoneMatch},
+		Responses: []apidoc.Response{
+			{Status: http.StatusOK, Description: "The task.", Headers: cacheHeaders, Content: apidoc.JSON(detail)},
+			{Status: http.StatusNotModified, Description: "The task has not been modified since the entity tag of If-None-Match."},
+			badRequest,
+			notFound,
+		},
+	}, x.GetTask)
+	router.Handle(http.MethodPut, "/:id", apidoc.Operation{
+		ID:         "updateTask",
+		Summary:    "Update a task.",
+		Parameters: []*openapi3.ParameterRef{taskID},
+		Request:    updateTaskRequest{},
+		Responses: []apidoc.Response{
+			{Status: http.StatusOK, Description: "The task is updated."},
+			badRequest,
+			notFound,
+		},
+	}, x.UpdateTask)
+	router.Handle(http.MethodDelete, "/:id", apidoc.Operation{
+		ID:         "deleteTask",
+		Summary:    "Delete a task.",
+		Parameters: []*openapi3.ParameterRef{taskID},
+		Responses: []apidoc.Response{
+			{Status: http.StatusOK, Description: "The task is deleted."},
+			badRequest,
+			notFound,
+		},
+	}, x.DeleteTask)
+}
+
+// errorResponse documents a response of the error message, which is a JSON
+// string.
+func errorResponse(status int, description string, example error) apidoc.Response {
+	return apidoc.Response{
+		Status:      status,
+		Description: description,
+		Content: []apidoc.Content{{
+			MediaType: apidoc.MediaTypeJSON,
+			Body:      "",
+			Example:   example.Error(),
+		}},
+	}
+}
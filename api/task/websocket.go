@@ -0,0 +1,340 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/omegaatt36/gotasker/domain"
+	"github.com/omegaatt36/gotasker/logging"
+	"github.com/omegaatt36/gotasker/service/task"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// WebSocketConfig defines the keepalive and backpressure limits of WebSocket
+// connections.
+type WebSocketConfig struct {
+	// PingInterval is the interval of pings, connections not answering with
+	// a pong within two intervals are closed.
+	PingInterval time.Duration
+	// SendQueueSize is the number of outgoing messages queued per
+	// connection. Commands aren't read while the queue is full, connections
+	// overflowing it with events are closed.
+	SendQueueSize int
+	// MaxMessageSize is the maximum size of incoming messages in bytes.
+	MaxMessageSize int64
+}
+
+// DefaultWebSocketConfig is the default configuration of WebSocket
+// connections.
+var DefaultWebSocketConfig = WebSocketConfig{
+	PingInterval:   30 * time.Second,
+	SendQueueSize:  64,
+	MaxMessageSize: 64 << 10,
+}
+
+// WithWebSocketConfig sets the limits of WebSocket connections, zero values
+// keep the defaults.
+func WithWebSocketConfig(cfg WebSocketConfig) Option {
+	return func(x *Controller) {
+		if cfg.PingInterval > 0 {
+			x.websocket.PingInterval = cfg.PingInterval
+		}
+		if cfg.SendQueueSize > 0 {
+			x.websocket.SendQueueSize = cfg.SendQueueSize
+		}
+		if cfg.MaxMessageSize > 0 {
+			x.websocket.MaxMessageSize = cfg.MaxMessageSize
+		}
+	}
+}
+
+// writeWait is the time allowed to write a message.
+const writeWait = 10 * time.Second
+
+// actions of WebSocket requests besides the batch operation types.
+const (
+	wsActionSubscribe   = "subscribe"
+	wsActionUnsubscribe = "unsubscribe"
+)
+
+// types of WebSocket messages sent to clients.
+const (
+	wsTypeResult = "result"
+	wsTypeError  = "error"
+	wsTypeEvent  = "event"
+)
+
+var errSlowConsumer = errors.New("slow consumer")
+
+// wsRequest defines a message sent by WebSocket clients. Actions create,
+// update and delete take the fields of batch operations, subscribe takes a
+// filter.
+type wsRequest struct {
+	// RequestID is echoed in the response.
+	RequestID string          `json:"request_id"`
+	Action    string          `json:"action"`
+	ID        uint            `json:"id"`
+	Name      *string         `json:"name"`
+	Status    json.RawMessage `json:"status"`
+	Filter    *taskFilter     `json:"filter"`
+}
+
+// wsResponse defines a message sent to WebSocket clients, either the
+// response of a request or a task event.
+type wsResponse struct {
+	RequestID string `json:"request_id,omitempty"`
+	Type      string `json:"type"`
+	Status    int    `json:"status,omitempty"`
+	Event     string `json:"event,omitempty"`
+	Task      any    `json:"task,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+var upgrader = websocket.Upgrader{
+	// the API is served to every origin, see corsMiddleware.
+	CheckOrigin: func(*http.Request) bool { return true },
+}
+
+// ServeWebSocket upgrades the connection to WebSocket. Clients subscribe to
+// task events with a filter and send create/update/delete commands.
+func (x *Controller) ServeWebSocket(c *gin.Context) {
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// the upgrader has responded with the error.
+		return
+	}
+
+	ws := &wsConn{
+		controller: x,
+		conn:       conn,
+		send:       make(chan wsResponse, x.websocket.SendQueueSize),
+		done:       make(chan struct{}),
+	}
+	ws.serve(c.Request.Context())
+}
+
+// wsConn is a WebSocket connection. Messages are written by a single writer
+// goroutine from the send queue.
+type wsConn struct {
+	controller *Controller
+	conn       *websocket.Conn
+
+	send      chan wsResponse
+	done      chan struct{}
+	closeOnce sync.Once
+	closeErr  error
+
+	mu         sync.Mutex
+	subscribed bool
+	filter     domain.TaskFilter
+}
+
+func (ws *wsConn) serve(ctx context.Context) {
+	cfg := ws.controller.websocket
+	subscription := ws.controller.service.SubscribeEvents("")
+	defer subscription.Close()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		ws.writeLoop(ctx, cfg.PingInterval)
+	}()
+	go func() {
+		defer wg.Done()
+		ws.eventLoop(subscription)
+	}()
+
+	ws.conn.SetReadLimit(cfg.MaxMessageSize)
+	_ = ws.conn.SetReadDeadline(time.Now().Add(2 * cfg.PingInterval))
+	ws.conn.SetPongHandler(func(string) error {
+		return ws.conn.SetReadDeadline(time.Now().Add(2 * cfg.PingInterval))
+	})
+
+	ws.readLoop(ctx)
+	ws.close(nil)
+	wg.Wait()
+	ws.conn.Close()
+}
+
+// readLoop handles requests one by one until the connection is closed.
+func (ws *wsConn) readLoop(ctx context.Context) {
+	for {
+		var req wsRequest
+		if err := ws.conn.ReadJSON(&req); err != nil {
+			var syntaxErr *json.SyntaxError
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) {
+				if !ws.enqueue(wsResponse{Type: wsTypeError, Status: http.StatusBadRequest, Error: err.Error()}) {
+					return
+				}
+				continue
+			}
+
+			if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				logging.DebugfCtx(ctx, "websocket read failed: %v", err)
+			}
+			return
+		}
+
+		resp := ws.handle(ctx, &req)
+		resp.RequestID = req.RequestID
+		if !ws.enqueue(resp) {
+			return
+		}
+	}
+}
+
+func (ws *wsConn) handle(ctx context.Context, req *wsRequest) wsResponse {
+	presenter := ws.controller.presenter
+
+	switch req.Action {
+	case wsActionSubscribe:
+		var filter domain.TaskFilter
+		if req.Filter != nil {
+			var err error
+			if filter, err = req.Filter.toDomain(presenter); err != nil {
+				return wsResponse{Type: wsTypeError, Status: http.StatusBadRequest, Error: err.Error()}
+			}
+		}
+
+		ws.mu.Lock()
+		ws.subscribed, ws.filter = true, filter
+		ws.mu.Unlock()
+
+		return wsResponse{Type: wsTypeResult, Status: http.StatusOK}
+
+	case wsActionUnsubscribe:
+		ws.mu.Lock()
+		ws.subscribed = false
+		ws.mu.Unlock()
+
+		return wsResponse{Type: wsTypeResult, Status: http.StatusOK}
+	}
+
+	op, err := ws.controller.toBatchOperation(&batchOperationRequest{
+		Op:     req.Action,
+		ID:     req.ID,
+		Name:   req.Name,
+		Status: req.Status,
+	})
+	if err != nil {
+		return wsResponse{Type: wsTypeError, Status: http.StatusBadRequest, Error: err.Error()}
+	}
+
+	results, err := ws.controller.service.BatchTasks(ctx, task.BatchTasksRequest{
+		Atomic:     true,
+		Operations: []task.BatchOperation{op},
+	})
+	if err != nil {
+		return wsResponse{Type: wsTypeError, Status: http.StatusInternalServerError, Error: err.Error()}
+	}
+
+	result := results[0]
+	status := batchOperationStatus(op.Type, result.Err)
+	if result.Err != nil {
+		return wsResponse{Type: wsTypeError, Status: status, Error: result.Err.Error()}
+	}
+
+	return wsResponse{Type: wsTypeResult, Status: status, Task: presenter.taskDetail(&result.Task)}
+}
+
+// eventLoop forwards the events matching the filter. The connection is
+// closed if its send queue overflows, instead of blocking other subscribers.
+func (ws *wsConn) eventLoop(subscription *task.Subscription) {
+	for {
+		select {
+		case <-ws.done:
+			return
+		case event, ok := <-subscription.Events():
+			if !ok {
+				ws.close(errSlowConsumer)
+				return
+			}
+
+			ws.mu.Lock()
+			match := ws.subscribed && ws.filter.Match(&event.Task)
+			ws.mu.Unlock()
+			if !match {
+				continue
+			}
+
+			select {
+			case ws.send <- wsResponse{
+				Type:  wsTypeEvent,
+				Event: string(event.Type),
+				Task:  ws.controller.presenter.taskDetail(&event.Task),
+			}:
+			default:
+				ws.close(errSlowConsumer)
+				return
+			}
+		}
+	}
+}
+
+// writeLoop writes queued messages and pings until the connection is
+// closed or the server shuts down.
+func (ws *wsConn) writeLoop(ctx context.Context, pingInterval time.Duration) {
+	ping := time.NewTicker(pingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			ws.close(nil)
+		case <-ws.done:
+			ws.writeClose()
+			return
+		case resp := <-ws.send:
+			_ = ws.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := ws.conn.WriteJSON(resp); err != nil {
+				ws.close(nil)
+				return
+			}
+		case <-ping.C:
+			if err := ws.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait)); err != nil {
+				ws.close(nil)
+				return
+			}
+		}
+	}
+}
+
+// enqueue queues a response, blocking while the queue is full so clients
+// sending faster than they read are slowed down. It returns false if the
+// connection is closed.
+func (ws *wsConn) enqueue(resp wsResponse) bool {
+	select {
+	case ws.send <- resp:
+		return true
+	case <-ws.done:
+		return false
+	}
+}
+
+// close closes the connection with the reason, the first call wins.
+func (ws *wsConn) close(err error) {
+	ws.closeOnce.Do(func() {
+		ws.closeErr = err
+		close(ws.done)
+	})
+}
+
+// writeClose sends the close message and unblocks the reader.
+func (ws *wsConn) writeClose() {
+	message := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+	if errors.Is(ws.closeErr, errSlowConsumer) {
+		message = websocket.FormatCloseMessage(websocket.CloseTryAgainLater, ws.closeErr.Error())
+	}
+
+	_ = ws.conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(writeWait))
+	// the reader waits for the close message of the client at most this long.
+	_ = ws.conn.SetReadDeadline(time.Now().Add(writeWait))
+}
@@ -0,0 +1,192 @@
+package taskwarrior
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/omegaatt36/gotasker/domain"
+	format "github.com/omegaatt36/gotasker/format/taskwarrior"
+	"github.com/omegaatt36/gotasker/logging"
+	"github.com/omegaatt36/gotasker/service/task"
+	"github.com/omegaatt36/gotasker/service/taskwarrior"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Controller represents a Taskwarrior controller.
+type Controller struct {
+	service *taskwarrior.Service
+}
+
+// NewController creates a new Taskwarrior controller.
+func NewController(service *taskwarrior.Service) *Controller {
+	return &Controller{service: service}
+}
+
+// taskwarriorTask documents the JSON of format.Task, which encodes itself.
+type taskwarriorTask struct {
+	UUID        string                  `json:"uuid" example:"5d2a4a57-2e1a-4c1b-9c1f-0e1a2b3c4d5e"`
+	Description string                  `json:"description" description:"The task name." example:"call mom"`
+	Status      string                  `json:"status" description:"Pending, waiting and recurring tasks are incomplete, deleted ones delete their tasks on import." enum:"pending,completed,deleted,waiting,recurring" example:"pending"`
+	Entry       string                  `json:"entry,omitempty" description:"The creation time in UTC." example:"20240501T083000Z"`
+	Modified    string                  `json:"modified,omitempty" description:"The last update time in UTC." example:"20240501T083000Z"`
+	End         string                  `json:"end,omitempty" description:"The completion time in UTC." example:"20240501T083000Z"`
+	Tags        []string                `json:"tags,omitempty" example:"[\"home\"]"`
+	Annotations []taskwarriorAnnotation `json:"annotations,omitempty"`
+}
+
+// taskwarriorAnnotation documents the JSON of format.Annotation.
+type taskwarriorAnnotation struct {
+	Entry       string `json:"entry" example:"20240501T083000Z"`
+	Description string `json:"description" example:"after lunch"`
+}
+
+// ExportTasks streams all tasks as a Taskwarrior export, chunk by chunk.
+func (x *Controller) ExportTasks(c *gin.Context) {
+	c.Header("Content-Type", format.MediaType+"; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="tasks.json"`)
+	c.Status(http.StatusOK)
+
+	encoder := format.NewEncoder(c.Writer)
+	err := x.service.ExportTasks(c.Request.Context(), func(tasks []format.Task) error {
+		if err := encoder.Encode(tasks...); err != nil {
+			return err
+		}
+
+		c.Writer.Flush()
+		return nil
+	})
+	if err == nil {
+		err = encoder.Close()
+	}
+	if err == nil {
+		return
+	}
+
+	// the status is sent with the first chunk, a failure after it can only
+	// cut the stream short.
+	if !c.Writer.Written() {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	logging.ErrorfCtx(c.Request.Context(), "failed to export taskwarrior tasks: %v", err)
+	c.Abort()
+}
+
+// importTasksQuery defines the query parameters of importing tasks.
+type importTasksQuery struct {
+	DryRun bool `form:"dry_run"`
+}
+
+// importTaskwarriorRow defines the result of importing a single Taskwarrior task.
+type importTaskwarriorRow struct {
+	Index  int    `json:"index" description:"The position of the task in the export, counted from 1." example:"1"`
+	UUID   string `json:"uuid,omitempty" description:"The UUID in lower case, absent for tasks which can't be decoded." example:"5d2a4a57-2e1a-4c1b-9c1f-0e1a2b3c4d5e"`
+	Action string `json:"action,omitempty" enum:"created,overwritten,skipped,deleted" example:"created"`
+	TaskID uint   `json:"task_id,omitempty" description:"The imported or deleted task, absent for created tasks of dry runs." example:"1"`
+	Error  string `json:"error,omitempty" example:"duplicate taskwarrior uuid"`
+}
+
+// importTaskwarriorResponse defines the report of importing Taskwarrior tasks.
+type importTaskwarriorResponse struct {
+	DryRun      bool                   `json:"dry_run"`
+	Total       int                    `json:"total" example:"3"`
+	Created     int                    `json:"created" example:"1"`
+	Overwritten int                    `json:"overwritten" example:"1"`
+	Deleted     int                    `json:"deleted" example:"1"`
+	Skipped     int                    `json:"skipped" description:"The number of deleted tasks without tasks." example:"0"`
+	Failed      int                    `json:"failed" description:"The number of invalid tasks, other tasks are rolled back if any." example:"0"`
+	Rows        []importTaskwarriorRow `json:"rows"`
+}
+
+// ImportTasks imports the tasks of a Taskwarrior export. Every task is
+// validated, the report tells the index and error of each invalid one.
+func (x *Controller) ImportTasks(c *gin.Context) {
+	var query importTasksQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rows, err := format.Decode(c.Request.Body)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// tasks which can't be decoded fail the import, the others are still
+	// validated by a dry run.
+	tasks := make([]format.Task, 0, len(rows))
+	for index := range rows {
+		if rows[index].Err == nil {
+			tasks = append(tasks, rows[index].Task)
+		}
+	}
+
+	invalid := len(tasks) < len(rows)
+	results, err := x.service.ImportTasks(c.Request.Context(), taskwarrior.ImportTasksRequest{
+		Tasks:  tasks,
+		DryRun: query.DryRun || invalid,
+	})
+	if err != nil {
+		if errors.Is(err, task.ErrImportSizeExceeded) {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, err.Error())
+			return
+		}
+
+		c.AbortWithStatusJSON(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resp := importTaskwarriorResponse{
+		DryRun: query.DryRun,
+		Total:  len(rows),
+		Rows:   make([]importTaskwarriorRow, len(rows)),
+	}
+	for index := range rows {
+		row := &resp.Rows[index]
+		row.Index = rows[index].Index
+
+		if rows[index].Err != nil {
+			row.Error = rows[index].Err.Error()
+			resp.Failed++
+			continue
+		}
+
+		result := results[0]
+		results = results[1:]
+		row.UUID = result.UUID
+		row.TaskID = result.Task.ID
+		switch {
+		case result.Err != nil:
+			row.Error = result.Err.Error()
+			if !errors.Is(result.Err, domain.ErrBatchRolledBack) {
+				resp.Failed++
+			}
+			continue
+		case invalid:
+			row.Error = domain.ErrBatchRolledBack.Error()
+			continue
+		}
+
+		row.Action = result.Action.String()
+		switch result.Action {
+		case domain.ImportActionCreated:
+			resp.Created++
+		case domain.ImportActionOverwritten:
+			resp.Overwritten++
+		case domain.ImportActionDeleted:
+			resp.Deleted++
+		case domain.ImportActionSkipped:
+			resp.Skipped++
+		}
+	}
+
+	if resp.Failed > 0 && !query.DryRun {
+		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, resp)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
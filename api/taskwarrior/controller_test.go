@@ -0,0 +1,178 @@
+package taskwarrior_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/omegaatt36/gotasker/api/taskwarrior"
+	"github.com/omegaatt36/gotasker/domain"
+	"github.com/omegaatt36/gotasker/persistance"
+	"github.com/omegaatt36/gotasker/persistance/database"
+	"github.com/omegaatt36/gotasker/service/task"
+	taskwarriorService "github.com/omegaatt36/gotasker/service/taskwarrior"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+)
+
+type report struct {
+	DryRun      bool `json:"dry_run"`
+	Total       int  `json:"total"`
+	Created     int  `json:"created"`
+	Overwritten int  `json:"overwritten"`
+	Deleted     int  `json:"deleted"`
+	Skipped     int  `json:"skipped"`
+	Failed      int  `json:"failed"`
+	Rows        []struct {
+		Index  int    `json:"index"`
+		UUID   string `json:"uuid"`
+		Action string `json:"action"`
+		TaskID uint   `json:"task_id"`
+		Error  string `json:"error"`
+	} `json:"rows"`
+}
+
+type TaskwarriorSuite struct {
+	suite.Suite
+
+	miniredis *miniredis.Miniredis
+	repo      *persistance.RedisRepo
+	engine    *gin.Engine
+}
+
+func (s *TaskwarriorSuite) SetupTest() {
+	s.miniredis = database.InitializeTestingRedis()
+	database.Initialize(context.Background(), s.miniredis.Addr(), "")
+
+	s.repo = persistance.NewRedisRepo(database.Redis())
+	controller := taskwarrior.NewController(taskwarriorService.NewService(task.NewService(s.repo),
+		persistance.NewRedisTaskwarriorRepo(database.Redis())))
+
+	gin.SetMode(gin.TestMode)
+	s.engine = gin.New()
+	s.engine.GET("/taskwarrior/export", controller.ExportTasks)
+	s.engine.POST("/taskwarrior/import", controller.ImportTasks)
+}
+
+func (s *TaskwarriorSuite) TearDownTest() {
+	s.miniredis.Close()
+}
+
+func (s *TaskwarriorSuite) do(method, target, body string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	s.engine.ServeHTTP(recorder, httptest.NewRequest(method, target, strings.NewReader(body)))
+
+	return recorder
+}
+
+func (s *TaskwarriorSuite) importTasks(target, body string, status int) report {
+	resp := s.do(http.MethodPost, target, body)
+	s.Require().Equal(status, resp.Code, resp.Body.String())
+
+	var r report
+	s.Require().NoError(json.Unmarshal(resp.Body.Bytes(), &r))
+
+	return r
+}
+
+func (s *TaskwarriorSuite) TestExportImport() {
+	ctx := context.Background()
+
+	_, err := s.repo.CreateTask(ctx, domain.CreateTaskRequest{Name: "task 1"})
+	s.Require().NoError(err)
+
+	resp := s.do(http.MethodGet, "/taskwarrior/export", "")
+	s.Require().Equal(http.StatusOK, resp.Code)
+	s.Equal("application/json; charset=utf-8", resp.Header().Get("Content-Type"))
+
+	var exported []map[string]any
+	s.Require().NoError(json.Unmarshal(resp.Body.Bytes(), &exported))
+	s.Require().Len(exported, 1)
+	s.Equal("676f7461-736b-8000-8000-000000000001", exported[0]["uuid"])
+	s.Equal("task 1", exported[0]["description"])
+	s.Equal("pending", exported[0]["status"])
+
+	body := `[
+{"uuid":"676f7461-736b-8000-8000-000000000001","description":"task 1","status":"completed","end":"20240502T090000Z","project":"home"},
+{"uuid":"5d2a4a57-2e1a-4c1b-9c1f-0e1a2b3c4d5e","description":"task 2","status":"pending","entry":"20240501T083000Z","tags":["phone"]},
+{"uuid":"7b1c2d3e-4f50-4a6b-8c7d-9e0f1a2b3c4d","description":"task 3","status":"deleted"}
+]`
+
+	r := s.importTasks("/taskwarrior/import?dry_run=true", body, http.StatusOK)
+	s.True(r.DryRun)
+	s.Equal(3, r.Total)
+	s.Equal(1, r.Created)
+	s.Equal(1, r.Overwritten)
+	s.Equal(1, r.Skipped)
+	s.Equal([]string{"overwritten", "created", "skipped"}, []string{r.Rows[0].Action, r.Rows[1].Action, r.Rows[2].Action})
+
+	tasks, err := s.repo.ListTasks(ctx)
+	s.Require().NoError(err)
+	s.Len(tasks, 1)
+
+	r = s.importTasks("/taskwarrior/import", body, http.StatusOK)
+	s.False(r.DryRun)
+	s.Equal(uint(1), r.Rows[0].TaskID)
+	s.Equal(uint(2), r.Rows[1].TaskID)
+
+	// importing again updates the tasks rather than duplicating them.
+	r = s.importTasks("/taskwarrior/import", body, http.StatusOK)
+	s.Equal(0, r.Created)
+	s.Equal(2, r.Overwritten)
+
+	tasks, err = s.repo.ListTasks(ctx)
+	s.Require().NoError(err)
+	s.Require().Len(tasks, 2)
+	s.Equal(domain.TaskStatusCompleted, tasks[0].Status)
+
+	resp = s.do(http.MethodGet, "/taskwarrior/export", "")
+	s.Require().Equal(http.StatusOK, resp.Code)
+	s.Require().NoError(json.Unmarshal(resp.Body.Bytes(), &exported))
+	s.Require().Len(exported, 2)
+	for _, t := range exported {
+		switch t["description"] {
+		case "task 1":
+			s.Equal("home", t["project"])
+			s.Equal("20240502T090000Z", t["end"])
+		case "task 2":
+			s.Equal("5d2a4a57-2e1a-4c1b-9c1f-0e1a2b3c4d5e", t["uuid"])
+			s.Equal([]any{"phone"}, t["tags"])
+		}
+	}
+
+	r = s.importTasks("/taskwarrior/import", `[{"uuid":"5d2a4a57-2e1a-4c1b-9c1f-0e1a2b3c4d5e","status":"deleted"}]`, http.StatusOK)
+	s.Equal(1, r.Deleted)
+	s.Equal(uint(2), r.Rows[0].TaskID)
+
+	tasks, err = s.repo.ListTasks(ctx)
+	s.Require().NoError(err)
+	s.Len(tasks, 1)
+}
+
+func (s *TaskwarriorSuite) TestImportInvalid() {
+	r := s.importTasks("/taskwarrior/import", `[
+{"uuid":"5d2a4a57-2e1a-4c1b-9c1f-0e1a2b3c4d5e","description":"task 1","status":"pending"},
+{"uuid":"not a uuid","description":"task 2","status":"pending"},
+{"uuid":"7b1c2d3e-4f50-4a6b-8c7d-9e0f1a2b3c4d","description":"","status":"pending"}
+]`, http.StatusUnprocessableEntity)
+	s.Equal(2, r.Failed)
+	s.Equal(domain.ErrBatchRolledBack.Error(), r.Rows[0].Error)
+	s.Contains(r.Rows[1].Error, "not a UUID")
+	s.Equal(task.ErrTaskNameRequired.Error(), r.Rows[2].Error)
+
+	tasks, err := s.repo.ListTasks(context.Background())
+	s.Require().NoError(err)
+	s.Empty(tasks)
+
+	resp := s.do(http.MethodPost, "/taskwarrior/import", `[{"uuid":`)
+	s.Equal(http.StatusBadRequest, resp.Code)
+}
+
+func TestTaskwarriorSuite(t *testing.T) {
+	suite.Run(t, new(TaskwarriorSuite))
+}
@@ -0,0 +1,63 @@
+package taskwarrior
+
+import (
+	"net/http"
+
+	"github.com/omegaatt36/gotasker/api/apidoc"
+	format "github.com/omegaatt36/gotasker/format/taskwarrior"
+	"github.com/omegaatt36/gotasker/service/task"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// RegisterRoutes registers the Taskwarrior routes on the router and
+// documents them.
+func (x *Controller) RegisterRoutes(router *apidoc.Router) {
+	router = router.Group("/taskwarrior")
+
+	formatDescription := "Tasks are mapped by their UUIDs, tasks never imported have UUIDs of their IDs like `676f7461-736b-8000-8000-000000000001`. " +
+		"Tags, annotations and the other attributes, e.g. `project` and `due`, are kept for exports."
+
+	router.Handle(http.MethodGet, "/export", apidoc.Operation{
+		ID:      "exportTaskwarriorTasks",
+		Summary: "Export tasks as Taskwarrior JSON.",
+		Description: "Stream all tasks in no particular order like `task export`, the export can be imported by `task import`. " +
+			formatDescription + "\n" +
+			"Completed tasks are regarded as completed at their last update.",
+		Responses: []apidoc.Response{
+			{Status: http.StatusOK, Description: "The Taskwarrior export.", Content: apidoc.JSON([]taskwarriorTask{})},
+		},
+	}, x.ExportTasks)
+	router.Handle(http.MethodPost, "/import", apidoc.Operation{
+		ID:      "importTaskwarriorTasks",
+		Summary: "Import tasks from Taskwarrior JSON.",
+		Description: "Import the tasks of `task export`. " + formatDescription + "\n" +
+			"Tasks of known UUIDs overwrite their tasks, so importing an export again updates the tasks rather than duplicating them. " +
+			"Tasks of other UUIDs are created, and deleted ones delete their tasks.\n" +
+			"Every task is validated, any invalid one fails the import and the others are reported as rolled back.",
+		Parameters: []*openapi3.ParameterRef{
+			router.Parameter("TaskwarriorDryRun", openapi3.NewQueryParameter("dry_run").
+				WithDescription("Only validate the tasks and report what the import would do.").
+				WithSchema(openapi3.NewBoolSchema())),
+		},
+		Request: []taskwarriorTask{},
+		Responses: []apidoc.Response{
+			{Status: http.StatusOK, Description: "The report of the import.", Content: apidoc.JSON(importTaskwarriorResponse{})},
+			errorResponse(http.StatusBadRequest, "Invalid parameters or export.", format.ErrMalformed),
+			errorResponse(http.StatusRequestEntityTooLarge, "Too many tasks.", task.ErrImportSizeExceeded),
+			{Status: http.StatusUnprocessableEntity, Description: "The report of the failed import, no task is written.", Content: apidoc.JSON(importTaskwarriorResponse{})},
+		},
+	}, x.ImportTasks)
+}
+
+func errorResponse(status int, description string, example error) apidoc.Response {
+	return apidoc.Response{
+		Status:      status,
+		Description: description,
+		Content: []apidoc.Content{{
+			MediaType: apidoc.MediaTypeJSON,
+			Body:      "",
+			Example:   example.Error(),
+		}},
+	}
+}
@@ -0,0 +1,271 @@
+// Package validation validates requests, and optionally responses, against
+// the OpenAPI document of the API.
+package validation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/omegaatt36/gotasker/logging"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// ResponseMode defines how responses are validated.
+type ResponseMode string
+
+// response modes
+const (
+	// ResponseModeOff skips validating responses.
+	ResponseModeOff ResponseMode = "off"
+	// ResponseModeLog logs mismatched responses and sends them as is.
+	ResponseModeLog ResponseMode = "log"
+	// ResponseModeFail replaces mismatched responses with 500.
+	ResponseModeFail ResponseMode = "fail"
+)
+
+// ParseResponseMode parses a response mode.
+func ParseResponseMode(s string) (ResponseMode, error) {
+	switch mode := ResponseMode(s); mode {
+	case ResponseModeOff, ResponseModeLog, ResponseModeFail:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid response mode: %s", s)
+	}
+}
+
+func init() {
+	// keep error messages short, without dumping the schema and the value.
+	openapi3.SchemaErrorDetailsDisabled = true
+
+	// the spec describes these bodies as plain strings.
+	openapi3filter.RegisterBodyDecoder("text/csv", decodeString)
+	openapi3filter.RegisterBodyDecoder("application/x-ndjson", decodeString)
+	openapi3filter.RegisterBodyDecoder("application/yaml", decodeYAML)
+	openapi3filter.RegisterBodyDecoder("text/calendar", decodeString)
+	openapi3filter.RegisterBodyDecoder("text/markdown", decodeString)
+}
+
+func decodeString(body io.Reader, _ http.Header, _ *openapi3.SchemaRef, _ openapi3filter.EncodingFn) (any, error) {
+	bs, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(bs), nil
+}
+
+// decodeYAML decodes the body like JSON, so numbers are compared with the
+// schema as float64 as well.
+func decodeYAML(body io.Reader, _ http.Header, _ *openapi3.SchemaRef, _ openapi3filter.EncodingFn) (any, error) {
+	var value any
+	if err := yaml.NewDecoder(body).Decode(&value); err != nil {
+		return nil, err
+	}
+
+	bs, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var normalized any
+	if err := json.Unmarshal(bs, &normalized); err != nil {
+		return nil, err
+	}
+
+	return normalized, nil
+}
+
+// Validator validates requests against an OpenAPI document.
+type Validator struct {
+	router       routers.Router
+	responseMode ResponseMode
+}
+
+// NewValidator loads the OpenAPI document in YAML or JSON.
+func NewValidator(spec []byte, responseMode ResponseMode) (*Validator, error) {
+	doc, err := openapi3.NewLoader().LoadFromData(spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load spec: %w", err)
+	}
+
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("invalid spec: %w", err)
+	}
+
+	// match routes regardless of the host the server is deployed on.
+	doc.Servers = nil
+
+	router, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create router: %w", err)
+	}
+
+	return &Validator{
+		router:       router,
+		responseMode: responseMode,
+	}, nil
+}
+
+// Middleware validates path parameters, query and body of requests before
+// they reach the handlers, and responds 400 Bad Request on mismatch, or 413
+// Request Entity Too Large if the body exceeds the limit of its reader, see
+// http.MaxBytesReader. Routes absent from the document are passed through.
+func (v *Validator) Middleware() gin.HandlerFunc {
+	options := &openapi3filter.Options{
+		AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+	}
+
+	return func(c *gin.Context) {
+		route, pathParams, err := v.router.FindRoute(c.Request)
+		if err != nil {
+			logging.DebugfCtx(c.Request.Context(), "skip validating undocumented route %s %s", c.Request.Method, c.Request.URL.Path)
+			c.Next()
+			return
+		}
+
+		input := &openapi3filter.RequestValidationInput{
+			Request:    c.Request,
+			PathParams: pathParams,
+			Route:      route,
+			Options:    options,
+		}
+
+		if err := openapi3filter.ValidateRequest(c.Request.Context(), input); err != nil {
+			if maxBytesErr := new(http.MaxBytesError); errors.As(err, &maxBytesErr) {
+				c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, maxBytesErr.Error())
+				return
+			}
+
+			c.AbortWithStatusJSON(http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if v.responseMode == ResponseModeOff || isStream(route.Operation) {
+			c.Next()
+			return
+		}
+
+		writer := &bufferedWriter{ResponseWriter: c.Writer, status: http.StatusOK}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+
+		v.validateResponse(c, input, writer)
+	}
+}
+
+// isStream reports whether the operation responds with an event stream or
+// switches protocols, which never ends and can't be buffered for validation.
+func isStream(operation *openapi3.Operation) bool {
+	if operation.Responses.Status(http.StatusSwitchingProtocols) != nil {
+		return true
+	}
+
+	for _, response := range operation.Responses.Map() {
+		if response.Value != nil && response.Value.Content.Get("text/event-stream") != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (v *Validator) validateResponse(c *gin.Context, input *openapi3filter.RequestValidationInput, writer *bufferedWriter) {
+	err := openapi3filter.ValidateResponse(c.Request.Context(), &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: input,
+		Status:                 writer.status,
+		Header:                 writer.Header(),
+		Body:                   io.NopCloser(bytes.NewReader(writer.body.Bytes())),
+	})
+	if err == nil {
+		writer.flush()
+		return
+	}
+
+	logging.WarnWithFieldCtx(c.Request.Context(), "response does not match the OpenAPI spec",
+		zap.String("method", c.Request.Method),
+		zap.String("path", c.Request.URL.Path),
+		zap.Int("status", writer.status),
+		zap.Error(err),
+	)
+
+	if v.responseMode != ResponseModeFail {
+		writer.flush()
+		return
+	}
+
+	c.Writer.Header().Del("Content-Length")
+	c.JSON(http.StatusInternalServerError, fmt.Sprintf("response does not match the OpenAPI spec: %s", err))
+}
+
+// bufferedWriter holds the response back until it's validated.
+type bufferedWriter struct {
+	gin.ResponseWriter
+
+	status  int
+	written bool
+	body    bytes.Buffer
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	if code > 0 && !w.written {
+		w.status = code
+	}
+}
+
+func (w *bufferedWriter) WriteHeaderNow() {
+	w.written = true
+}
+
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	w.written = true
+	return w.body.Write(data)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	w.written = true
+	return w.body.WriteString(s)
+}
+
+// Flush holds the response back too, streamed responses are sent once
+// they're complete.
+func (w *bufferedWriter) Flush() {}
+
+func (w *bufferedWriter) Status() int {
+	return w.status
+}
+
+func (w *bufferedWriter) Size() int {
+	if !w.written {
+		return -1
+	}
+
+	return w.body.Len()
+}
+
+func (w *bufferedWriter) Written() bool {
+	return w.written
+}
+
+// flush sends the buffered response.
+func (w *bufferedWriter) flush() {
+	w.ResponseWriter.WriteHeader(w.status)
+	if w.body.Len() == 0 {
+		w.ResponseWriter.WriteHeaderNow()
+		return
+	}
+
+	_, _ = w.ResponseWriter.Write(w.body.Bytes())
+}
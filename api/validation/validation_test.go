@@ -0,0 +1,174 @@
+package validation_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/omegaatt36/gotasker/api/validation"
+	"github.com/omegaatt36/gotasker/doc/openapi"
+	"github.com/omegaatt36/gotasker/logging"
+	"github.com/omegaatt36/gotasker/util"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+)
+
+type ValidationSuite struct {
+	suite.Suite
+}
+
+func (s *ValidationSuite) SetupSuite() {
+	logging.Init(false, "error")
+}
+
+func (s *ValidationSuite) newValidator(mode validation.ResponseMode) *validation.Validator {
+	validator, err := validation.NewValidator(openapi.Spec, mode)
+	s.Require().NoError(err)
+
+	return validator
+}
+
+func (s *ValidationSuite) TestRequest() {
+	validator := s.newValidator(validation.ResponseModeOff)
+
+	var called bool
+	handler := func(c *gin.Context) {
+		called = true
+		c.Status(http.StatusOK)
+	}
+
+	for _, tc := range []struct {
+		name    string
+		req     util.HTTPTestRequest
+		status  int
+		reached bool
+	}{
+		{
+			name: "invalid body",
+			req: util.HTTPTestRequest{
+				Method:               http.MethodPost,
+				ServedURL:            "/tasks",
+				RequestURLWithParams: "/tasks",
+				Payload:              map[string]any{"name": 1},
+			},
+			status: http.StatusBadRequest,
+		},
+		{
+			name: "missing required field",
+			req: util.HTTPTestRequest{
+				Method:               http.MethodPost,
+				ServedURL:            "/tasks",
+				RequestURLWithParams: "/tasks",
+				Payload:              map[string]any{},
+			},
+			status: http.StatusBadRequest,
+		},
+		{
+			name: "invalid path parameter",
+			req: util.HTTPTestRequest{
+				Method:               http.MethodPut,
+				ServedURL:            "/tasks/:id",
+				RequestURLWithParams: "/tasks/a",
+				Payload:              map[string]any{"name": "task 1"},
+			},
+			status: http.StatusBadRequest,
+		},
+		{
+			name: "invalid enum",
+			req: util.HTTPTestRequest{
+				Method:               http.MethodPut,
+				ServedURL:            "/tasks/:id",
+				RequestURLWithParams: "/tasks/1",
+				Payload:              map[string]any{"status": 2},
+			},
+			status: http.StatusBadRequest,
+		},
+		{
+			name: "invalid query",
+			req: util.HTTPTestRequest{
+				Method:               http.MethodGet,
+				ServedURL:            "/tasks",
+				RequestURLWithParams: "/tasks?format=xml",
+			},
+			status: http.StatusBadRequest,
+		},
+		{
+			name: "valid",
+			req: util.HTTPTestRequest{
+				Method:               http.MethodPut,
+				ServedURL:            "/tasks/:id",
+				RequestURLWithParams: "/tasks/1",
+				Payload:              map[string]any{"status": 1},
+			},
+			status:  http.StatusOK,
+			reached: true,
+		},
+		{
+			name: "undocumented route",
+			req: util.HTTPTestRequest{
+				Method:               http.MethodGet,
+				ServedURL:            "/undocumented",
+				RequestURLWithParams: "/undocumented",
+			},
+			status:  http.StatusOK,
+			reached: true,
+		},
+	} {
+		s.T().Run(tc.name, func(t *testing.T) {
+			called = false
+			tc.req.HandleFuncs = []gin.HandlerFunc{validator.Middleware(), handler}
+
+			resp, err := util.HTTPTest(tc.req)
+			s.NoError(err)
+			s.Equal(tc.status, resp.StatusCode)
+			s.Equal(tc.reached, called)
+		})
+	}
+}
+
+func (s *ValidationSuite) TestResponse() {
+	mismatched := func(c *gin.Context) {
+		c.JSON(http.StatusOK, map[string]any{"id": "1", "name": "task 1", "status": 0})
+	}
+	matched := func(c *gin.Context) {
+		c.JSON(http.StatusOK, map[string]any{"id": 1, "name": "task 1", "status": 0})
+	}
+
+	get := func(validator *validation.Validator, handler gin.HandlerFunc) *util.HTTPTestResponse {
+		resp, err := util.HTTPTest(util.HTTPTestRequest{
+			Method:               http.MethodGet,
+			ServedURL:            "/tasks/:id",
+			RequestURLWithParams: "/tasks/1",
+			HandleFuncs:          []gin.HandlerFunc{validator.Middleware(), handler},
+		})
+		s.NoError(err)
+
+		return resp
+	}
+
+	s.T().Run("fail - matched", func(t *testing.T) {
+		resp := get(s.newValidator(validation.ResponseModeFail), matched)
+		s.Equal(http.StatusOK, resp.StatusCode)
+		s.JSONEq(`{"id":1,"name":"task 1","status":0}`, string(resp.Body))
+	})
+
+	s.T().Run("fail - mismatched", func(t *testing.T) {
+		resp := get(s.newValidator(validation.ResponseModeFail), mismatched)
+		s.Equal(http.StatusInternalServerError, resp.StatusCode)
+	})
+
+	s.T().Run("log - mismatched", func(t *testing.T) {
+		resp := get(s.newValidator(validation.ResponseModeLog), mismatched)
+		s.Equal(http.StatusOK, resp.StatusCode)
+		s.JSONEq(`{"id":"1","name":"task 1","status":0}`, string(resp.Body))
+	})
+
+	s.T().Run("off - mismatched", func(t *testing.T) {
+		resp := get(s.newValidator(validation.ResponseModeOff), mismatched)
+		s.Equal(http.StatusOK, resp.StatusCode)
+	})
+}
+
+func TestValidation(t *testing.T) {
+	suite.Run(t, new(ValidationSuite))
+}
@@ -0,0 +1,292 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/omegaatt36/gotasker/domain"
+	"github.com/omegaatt36/gotasker/service/webhook"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Controller represents a webhook controller.
+type Controller struct {
+	service *webhook.Service
+}
+
+// NewController creates a new webhook controller.
+func NewController(service *webhook.Service) *Controller {
+	return &Controller{service: service}
+}
+
+// webhookDetail defines DTO for domain.Webhook.
+type webhookDetail struct {
+	ID     uint     `json:"id" description:"The webhook ID." example:"1"`
+	URL    string   `json:"url" description:"The URL receiving the deliveries." example:"https://example.com/hooks/tasks"`
+	Events []string `json:"events" openapi:"events"`
+	// Secret is only shown on creation and rotation.
+	Secret    string    `json:"secret,omitempty" description:"The key of the HMAC-SHA256 signatures, only returned on creation and rotation." example:"5f2b8c1e9a7d4e3f"`
+	Active    bool      `json:"active" description:"Inactive webhooks are not delivered to." example:"true"`
+	CreatedAt time.Time `json:"created_at" description:"The creation time in RFC 3339." example:"2024-05-01T08:00:00Z"`
+	UpdatedAt time.Time `json:"updated_at" description:"The last update time in RFC 3339." example:"2024-05-01T08:00:00Z"`
+}
+
+func toWebhookDetail(hook *domain.Webhook) webhookDetail {
+	events := hook.Events
+	if events == nil {
+		events = []string{}
+	}
+
+	return webhookDetail{
+		ID:        hook.ID,
+		URL:       hook.URL,
+		Events:    events,
+		Active:    hook.Active,
+		CreatedAt: hook.CreatedAt,
+		UpdatedAt: hook.UpdatedAt,
+	}
+}
+
+// deliveryDetail defines DTO for domain.Delivery.
+type deliveryDetail struct {
+	ID        uint            `json:"id" description:"The delivery ID." example:"1"`
+	WebhookID uint            `json:"webhook_id" description:"The webhook ID." example:"1"`
+	EventID   string          `json:"event_id" description:"The event ID, redeliveries share it." example:"lq3x9k2-1"`
+	Event     string          `json:"event" enum:"created,updated,deleted" example:"created"`
+	State     string          `json:"state" enum:"pending,succeeded,failed" example:"succeeded"`
+	Payload   json.RawMessage `json:"payload" description:"The delivered body."`
+	Attempts  []attemptDetail `json:"attempts"`
+	// NextAttemptAt is only shown while the delivery is pending.
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty" description:"The time of the next attempt of a pending delivery." example:"2024-05-01T08:00:10Z"`
+	CreatedAt     time.Time  `json:"created_at" example:"2024-05-01T08:00:00Z"`
+	UpdatedAt     time.Time  `json:"updated_at" example:"2024-05-01T08:00:01Z"`
+}
+
+// attemptDetail defines DTO for domain.DeliveryAttempt.
+type attemptDetail struct {
+	At         time.Time `json:"at" example:"2024-05-01T08:00:00Z"`
+	StatusCode int       `json:"status_code" description:"The response status, 0 if no response was received." example:"200"`
+	Error      string    `json:"error,omitempty" example:"500 Internal Server Error"`
+	DurationMS int64     `json:"duration_ms" example:"42"`
+}
+
+func toDeliveryDetail(delivery *domain.Delivery) deliveryDetail {
+	attempts := make([]attemptDetail, len(delivery.Attempts))
+	for index, attempt := range delivery.Attempts {
+		attempts[index] = attemptDetail{
+			At:         attempt.At,
+			StatusCode: attempt.StatusCode,
+			Error:      attempt.Error,
+			DurationMS: attempt.Duration.Milliseconds(),
+		}
+	}
+
+	detail := deliveryDetail{
+		ID:        delivery.ID,
+		WebhookID: delivery.WebhookID,
+		EventID:   delivery.EventID,
+		Event:     delivery.Event,
+		State:     delivery.State.String(),
+		Payload:   delivery.Payload,
+		Attempts:  attempts,
+		CreatedAt: delivery.CreatedAt,
+		UpdatedAt: delivery.UpdatedAt,
+	}
+	if delivery.State == domain.DeliveryStatePending {
+		detail.NextAttemptAt = &delivery.NextAttemptAt
+	}
+
+	return detail
+}
+
+// ListWebhooks lists all webhooks.
+func (x *Controller) ListWebhooks(c *gin.Context) {
+	hooks, err := x.service.ListWebhooks(c.Request.Context())
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	details := make([]webhookDetail, len(hooks))
+	for index := range hooks {
+		details[index] = toWebhookDetail(&hooks[index])
+	}
+
+	c.JSON(http.StatusOK, details)
+}
+
+// GetWebhook gets a webhook.
+func (x *Controller) GetWebhook(c *gin.Context) {
+	webhookID, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	hook, err := x.service.GetWebhook(c.Request.Context(), webhookID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toWebhookDetail(&hook))
+}
+
+// createWebhookRequest defines the request for creating a webhook.
+type createWebhookRequest struct {
+	URL    string   `json:"url" binding:"required" description:"The URL receiving the deliveries." example:"https://example.com/hooks/tasks"`
+	Events []string `json:"events" openapi:"events"`
+	Secret string   `json:"secret" description:"The key of the signatures, a random one is generated if empty." example:"my-secret"`
+}
+
+// CreateWebhook creates a new webhook, the secret is only returned here.
+func (x *Controller) CreateWebhook(c *gin.Context) {
+	var req createWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	hook, err := x.service.CreateWebhook(c.Request.Context(), webhook.CreateWebhookRequest{
+		URL:    req.URL,
+		Events: req.Events,
+		Secret: req.Secret,
+	})
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	detail := toWebhookDetail(&hook)
+	detail.Secret = hook.Secret
+	c.JSON(http.StatusCreated, detail)
+}
+
+// updateWebhookRequest defines the request for updating a webhook.
+type updateWebhookRequest struct {
+	URL    *string   `json:"url" description:"The URL receiving the deliveries." example:"https://example.com/hooks/tasks"`
+	Events *[]string `json:"events" openapi:"events"`
+	Secret *string   `json:"secret" description:"The key of the signatures, an empty one rotates it to a random one, which is returned." example:"my-new-secret"`
+	Active *bool     `json:"active" description:"Pauses the deliveries if false." example:"false"`
+}
+
+// UpdateWebhook updates a webhook, a rotated secret is returned as it can't
+// be read later.
+func (x *Controller) UpdateWebhook(c *gin.Context) {
+	webhookID, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req updateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	hook, err := x.service.UpdateWebhook(c.Request.Context(), webhookID, webhook.UpdateWebhookRequest{
+		URL:    req.URL,
+		Events: req.Events,
+		Secret: req.Secret,
+		Active: req.Active,
+	})
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	detail := toWebhookDetail(&hook)
+	if req.Secret != nil && *req.Secret == "" {
+		detail.Secret = hook.Secret
+	}
+	c.JSON(http.StatusOK, detail)
+}
+
+// DeleteWebhook deletes a webhook and its delivery log.
+func (x *Controller) DeleteWebhook(c *gin.Context) {
+	webhookID, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := x.service.DeleteWebhook(c.Request.Context(), webhookID); err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// ListDeliveries lists the delivery log of a webhook, the latest first.
+func (x *Controller) ListDeliveries(c *gin.Context) {
+	webhookID, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	deliveries, err := x.service.ListDeliveries(c.Request.Context(), webhookID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	details := make([]deliveryDetail, len(deliveries))
+	for index := range deliveries {
+		details[index] = toDeliveryDetail(&deliveries[index])
+	}
+
+	c.JSON(http.StatusOK, details)
+}
+
+// Redeliver queues a new delivery of the payload of a logged delivery.
+func (x *Controller) Redeliver(c *gin.Context) {
+	webhookID, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	deliveryID, ok := parseID(c, "delivery_id")
+	if !ok {
+		return
+	}
+
+	delivery, err := x.service.Redeliver(c.Request.Context(), webhookID, deliveryID)
+	if err != nil {
+		abortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, toDeliveryDetail(&delivery))
+}
+
+// parseID parses a positive ID from the path parameter, the request is
+// aborted otherwise.
+func parseID(c *gin.Context, param string) (uint, bool) {
+	id, err := strconv.Atoi(c.Param(param))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, err.Error())
+		return 0, false
+	}
+
+	if id < 1 {
+		c.AbortWithStatusJSON(http.StatusBadRequest, domain.ErrInvalidWebhookID.Error())
+		return 0, false
+	}
+
+	return uint(id), true
+}
+
+// abortWithError responds with the status of the service error.
+func abortWithError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrWebhookNotFound), errors.Is(err, domain.ErrDeliveryNotFound):
+		c.AbortWithStatusJSON(http.StatusNotFound, err.Error())
+	case errors.Is(err, webhook.ErrInvalidURL), errors.Is(err, webhook.ErrInvalidEvent):
+		c.AbortWithStatusJSON(http.StatusBadRequest, err.Error())
+	default:
+		c.AbortWithStatusJSON(http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,149 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/omegaatt36/gotasker/api/webhook"
+	"github.com/omegaatt36/gotasker/domain/stub"
+	"github.com/omegaatt36/gotasker/logging"
+	"github.com/omegaatt36/gotasker/persistance"
+	"github.com/omegaatt36/gotasker/persistance/database"
+	taskService "github.com/omegaatt36/gotasker/service/task"
+	webhookService "github.com/omegaatt36/gotasker/service/webhook"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+)
+
+type WebhookControllerSuite struct {
+	suite.Suite
+}
+
+func (s *WebhookControllerSuite) SetupSuite() {
+	gin.SetMode(gin.TestMode)
+	logging.Init(false, "error")
+}
+
+func (s *WebhookControllerSuite) TestWebhooks() {
+	miniredis := database.InitializeTestingRedis()
+	defer miniredis.Close()
+
+	database.Initialize(context.Background(), miniredis.Addr(), "")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tasks := taskService.NewService(stub.NewInMemoryTaskRepository())
+	service := webhookService.NewService(persistance.NewRedisWebhookRepo(database.Redis()), tasks)
+	controller := webhook.NewController(service)
+
+	engine := gin.New()
+	engine.GET("/webhooks", controller.ListWebhooks)
+	engine.POST("/webhooks", controller.CreateWebhook)
+	engine.GET("/webhooks/:id", controller.GetWebhook)
+	engine.PUT("/webhooks/:id", controller.UpdateWebhook)
+	engine.DELETE("/webhooks/:id", controller.DeleteWebhook)
+	engine.GET("/webhooks/:id/deliveries", controller.ListDeliveries)
+	engine.POST("/webhooks/:id/deliveries/:delivery_id/redeliver", controller.Redeliver)
+
+	var received []*http.Request
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r)
+	}))
+	defer receiver.Close()
+
+	request := func(method, url, body string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		engine.ServeHTTP(recorder, httptest.NewRequest(method, url, strings.NewReader(body)))
+		return recorder
+	}
+
+	s.T().Run("invalid", func(t *testing.T) {
+		recorder := request(http.MethodPost, "/webhooks", `{"url":"example.com"}`)
+		s.Equal(http.StatusBadRequest, recorder.Code)
+		s.Equal(`"webhook url must be an absolute http or https url"`, recorder.Body.String())
+
+		recorder = request(http.MethodPost, "/webhooks", `{"url":"http://example.com","events":["archived"]}`)
+		s.Equal(http.StatusBadRequest, recorder.Code)
+
+		s.Equal(http.StatusBadRequest, request(http.MethodGet, "/webhooks/0", "").Code)
+		s.Equal(http.StatusNotFound, request(http.MethodGet, "/webhooks/1", "").Code)
+	})
+
+	recorder := request(http.MethodPost, "/webhooks",
+		fmt.Sprintf(`{"url":%q,"events":["created"],"secret":"secret"}`, receiver.URL))
+	s.Require().Equal(http.StatusCreated, recorder.Code)
+
+	var created map[string]any
+	s.Require().NoError(json.Unmarshal(recorder.Body.Bytes(), &created))
+	s.Equal("secret", created["secret"])
+	s.Equal([]any{"created"}, created["events"])
+
+	recorder = request(http.MethodGet, "/webhooks/1", "")
+	s.Equal(http.StatusOK, recorder.Code)
+	s.NotContains(recorder.Body.String(), "secret")
+
+	recorder = request(http.MethodPut, "/webhooks/1", `{"events":[]}`)
+	s.Equal(http.StatusOK, recorder.Code)
+	s.Contains(recorder.Body.String(), `"events":[]`)
+
+	s.T().Run("rotate secret", func(t *testing.T) {
+		recorder := request(http.MethodPut, "/webhooks/1", `{"secret":""}`)
+		s.Require().Equal(http.StatusOK, recorder.Code)
+
+		var rotated map[string]any
+		s.Require().NoError(json.Unmarshal(recorder.Body.Bytes(), &rotated))
+		s.NotEmpty(rotated["secret"])
+		s.NotEqual("secret", rotated["secret"])
+
+		// a secret of the caller isn't returned.
+		recorder = request(http.MethodPut, "/webhooks/1", `{"secret":"secret"}`)
+		s.Require().Equal(http.StatusOK, recorder.Code)
+		s.NotContains(recorder.Body.String(), "secret")
+	})
+
+	stopped := service.Start(ctx)
+	_, err := tasks.CreateTask(ctx, taskService.CreateTaskRequest{Name: "task 1"})
+	s.Require().NoError(err)
+	cancel()
+	// stopping waits for the queued events to be recorded.
+	<-stopped
+
+	_, err = service.DeliverDue(context.Background())
+	s.Require().NoError(err)
+	s.Require().Len(received, 1)
+	s.Equal("created", received[0].Header.Get(webhookService.HeaderEvent))
+
+	recorder = request(http.MethodGet, "/webhooks/1/deliveries", "")
+	s.Require().Equal(http.StatusOK, recorder.Code)
+
+	var deliveries []map[string]any
+	s.Require().NoError(json.Unmarshal(recorder.Body.Bytes(), &deliveries))
+	s.Require().Len(deliveries, 1)
+	s.Equal("succeeded", deliveries[0]["state"])
+	s.Equal(float64(http.StatusOK), deliveries[0]["attempts"].([]any)[0].(map[string]any)["status_code"])
+	s.Equal("created", deliveries[0]["payload"].(map[string]any)["event"])
+
+	recorder = request(http.MethodPost, "/webhooks/1/deliveries/1/redeliver", "")
+	s.Equal(http.StatusAccepted, recorder.Code)
+	s.Contains(recorder.Body.String(), `"state":"pending"`)
+
+	s.Equal(http.StatusNotFound, request(http.MethodPost, "/webhooks/1/deliveries/9/redeliver", "").Code)
+
+	s.Equal(http.StatusOK, request(http.MethodDelete, "/webhooks/1", "").Code)
+	s.Equal(http.StatusNotFound, request(http.MethodGet, "/webhooks/1/deliveries", "").Code)
+
+	recorder = request(http.MethodGet, "/webhooks", "")
+	s.Equal(http.StatusOK, recorder.Code)
+	s.Equal("[]", recorder.Body.String())
+}
+
+func TestWebhookController(t *testing.T) {
+	suite.Run(t, new(WebhookControllerSuite))
+}
@@ -0,0 +1,123 @@
+package webhook
+
+import (
+	"net/http"
+
+	"github.com/omegaatt36/gotasker/api/apidoc"
+	"github.com/omegaatt36/gotasker/domain"
+	"github.com/omegaatt36/gotasker/service/webhook"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// RegisterRoutes registers the webhook routes on the router and documents
+// them.
+func (x *Controller) RegisterRoutes(router *apidoc.Router) {
+	eventNames := make([]any, len(webhook.EventTypes))
+	for index, eventType := range webhook.EventTypes {
+		eventNames[index] = string(eventType)
+	}
+	events := openapi3.NewArraySchema().
+		WithItems(openapi3.NewStringSchema().WithEnum(eventNames...))
+	events.Description = "The subscribed task events, empty subscribes to all events."
+
+	router = router.Group("/webhooks").Schemas(apidoc.SchemaOptions{
+		Substitutes: map[string]any{"events": events},
+	})
+
+	webhookID := router.Parameter("WebhookID", openapi3.NewPathParameter("id").
+		WithDescription("The webhook ID. must be a positive integer.").
+		WithSchema(openapi3.NewIntegerSchema()))
+	deliveryID := router.Parameter("DeliveryID", openapi3.NewPathParameter("delivery_id").
+		WithDescription("The delivery ID. must be a positive integer.").
+		WithSchema(openapi3.NewIntegerSchema()))
+
+	badRequest := errorResponse(http.StatusBadRequest, "Invalid parameters.", webhook.ErrInvalidURL)
+	notFound := errorResponse(http.StatusNotFound, "Webhook not found.", domain.ErrWebhookNotFound)
+
+	router.Handle(http.MethodGet, "", apidoc.Operation{
+		ID:      "listWebhooks",
+		Summary: "List all webhooks.",
+		Responses: []apidoc.Response{
+			{Status: http.StatusOK, Description: "The webhooks.", Content: apidoc.JSON([]webhookDetail{})},
+		},
+	}, x.ListWebhooks)
+	router.Handle(http.MethodPost, "", apidoc.Operation{
+		ID:      "createWebhook",
+		Summary: "Create a webhook.",
+		Description: "Task events are delivered as JSON `POST` requests signed with HMAC-SHA256 of the body keyed by the secret, " +
+			"the `X-Gotasker-Signature` header is `sha256=<hex digest>`.\n" +
+			"Failed deliveries are retried with exponential backoff.",
+		Request: createWebhookRequest{},
+		Responses: []apidoc.Response{
+			{Status: http.StatusCreated, Description: "The webhook with its secret.", Content: apidoc.JSON(webhookDetail{})},
+			badRequest,
+		},
+	}, x.CreateWebhook)
+	router.Handle(http.MethodGet, "/:id", apidoc.Operation{
+		ID:         "getWebhook",
+		Summary:    "Get a webhook.",
+		Parameters: []*openapi3.ParameterRef{webhookID},
+		Responses: []apidoc.Response{
+			{Status: http.StatusOK, Description: "The webhook.", Content: apidoc.JSON(webhookDetail{})},
+			errorResponse(http.StatusBadRequest, "Invalid parameters.", domain.ErrInvalidWebhookID),
+			notFound,
+		},
+	}, x.GetWebhook)
+	router.Handle(http.MethodPut, "/:id", apidoc.Operation{
+		ID:         "updateWebhook",
+		Summary:    "Update a webhook.",
+		Parameters: []*openapi3.ParameterRef{webhookID},
+		Request:    updateWebhookRequest{},
+		Responses: []apidoc.Response{
+			{Status: http.StatusOK, Description: "The updated webhook.", Content: apidoc.JSON(webhookDetail{})},
+			badRequest,
+			notFound,
+		},
+	}, x.UpdateWebhook)
+	router.Handle(http.MethodDelete, "/:id", apidoc.Operation{
+		ID:         "deleteWebhook",
+		Summary:    "Delete a webhook and its delivery log.",
+		Parameters: []*openapi3.ParameterRef{webhookID},
+		Responses: []apidoc.Response{
+			{Status: http.StatusOK, Description: "The webhook is deleted."},
+			errorResponse(http.StatusBadRequest, "Invalid parameters.", domain.ErrInvalidWebhookID),
+			notFound,
+		},
+	}, x.DeleteWebhook)
+	router.Handle(http.MethodGet, "/:id/deliveries", apidoc.Operation{
+		ID:          "listWebhookDeliveries",
+		Summary:     "List the delivery log of a webhook.",
+		Description: "The latest deliveries are listed first, old ones are removed from the log.",
+		Parameters:  []*openapi3.ParameterRef{webhookID},
+		Responses: []apidoc.Response{
+			{Status: http.StatusOK, Description: "The deliveries.", Content: apidoc.JSON([]deliveryDetail{})},
+			errorResponse(http.StatusBadRequest, "Invalid parameters.", domain.ErrInvalidWebhookID),
+			notFound,
+		},
+	}, x.ListDeliveries)
+	router.Handle(http.MethodPost, "/:id/deliveries/:delivery_id/redeliver", apidoc.Operation{
+		ID:         "redeliverWebhookDelivery",
+		Summary:    "Replay a delivery.",
+		Parameters: []*openapi3.ParameterRef{webhookID, deliveryID},
+		Responses: []apidoc.Response{
+			{Status: http.StatusAccepted, Description: "The new delivery is queued.", Content: apidoc.JSON(deliveryDetail{})},
+			errorResponse(http.StatusBadRequest, "Invalid parameters.", domain.ErrInvalidWebhookID),
+			errorResponse(http.StatusNotFound, "Webhook or delivery not found.", domain.ErrDeliveryNotFound),
+		},
+	}, x.Redeliver)
+}
+
+// errorResponse documents a response of the error message, which is a JSON
+// string.
+func errorResponse(status int, description string, example error) apidoc.Response {
+	return apidoc.Response{
+		Status:      status,
+		Description: description,
+		Content: []apidoc.Content{{
+			MediaType: apidoc.MediaTypeJSON,
+			Body:      "",
+			Example:   example.Error(),
+		}},
+	}
+}
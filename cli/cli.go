@@ -0,0 +1,117 @@
+// Package cli is the command-line interface of gotasker, which starts the
+// server or talks to a running one over HTTP.
+package cli
+
+import (
+	"cmp"
+	"fmt"
+
+	"github.com/omegaatt36/gotasker/client"
+	"github.com/omegaatt36/gotasker/util"
+
+	"github.com/spf13/cobra"
+)
+
+// DefaultServer is the server used if no server is configured.
+const DefaultServer = "http://localhost:8070"
+
+// options are the global flags of the commands talking to a server.
+type options struct {
+	configPath string
+	profile    string
+	server     string
+	token      string
+	output     outputFormat
+}
+
+// NewCommand creates the root command of the CLI.
+func NewCommand() *cobra.Command {
+	o := &options{output: outputTable}
+
+	cmd := &cobra.Command{
+		Use:   "gotasker",
+		Short: "GoTasker is a task management service",
+		Long: "GoTasker is a task management service.\n\n" +
+			"Run `gotasker serve` to start the server, the other commands talk to a running server over HTTP.\n" +
+			"The server and its credentials are read from the flags, the GOTASKER_SERVER, GOTASKER_TOKEN and\n" +
+			"GOTASKER_PROFILE env vars, or the profile of the config file, in that order.",
+		SilenceUsage: true,
+	}
+
+	cmd.PersistentFlags().StringVar(&o.configPath, "config", util.GetENV("GOTASKER_CONFIG", defaultConfigPath()),
+		"path of the config file\ndefault to the value of the GOTASKER_CONFIG env var, if it is set")
+
+	cmd.AddCommand(
+		newServeCommand(),
+		newTaskCommand(o),
+		newMarkdownCommand(o),
+		newTaskwarriorCommand(o),
+		newTUICommand(o),
+		newConfigCommand(o),
+	)
+
+	return cmd
+}
+
+// addClientFlags adds the flags selecting the server to the command and its
+// subcommands.
+func (o *options) addClientFlags(cmd *cobra.Command) {
+	flags := cmd.PersistentFlags()
+	flags.StringVar(&o.profile, "profile", "", "profile of the config file, default to the current profile")
+	flags.StringVar(&o.server, "server", "", "base URL of the server, overrides the profile")
+	flags.StringVar(&o.token, "token", "", "bearer token of the server, overrides the profile")
+
+	_ = cmd.RegisterFlagCompletionFunc("profile", o.completeProfiles)
+}
+
+// addOutputFlag adds the output format flag to the command and its
+// subcommands.
+func (o *options) addOutputFlag(cmd *cobra.Command) {
+	cmd.PersistentFlags().VarP(&o.output, "output", "o", fmt.Sprintf("output format, one of %v", outputFormats))
+
+	_ = cmd.RegisterFlagCompletionFunc("output", cobra.FixedCompletions(outputFormats, cobra.ShellCompDirectiveNoFileComp))
+}
+
+// resolveProfile returns the profile selected by the flags, env vars and the
+// config file.
+func (o *options) resolveProfile() (Profile, error) {
+	cfg, err := LoadConfig(o.configPath)
+	if err != nil {
+		return Profile{}, err
+	}
+
+	name := cmp.Or(o.profile, util.GetENV("GOTASKER_PROFILE", ""))
+	profile, ok := cfg.Profiles[cmp.Or(name, cfg.CurrentProfile, DefaultProfile)]
+	if name != "" && !ok {
+		return Profile{}, fmt.Errorf("%w: %s", ErrProfileNotFound, name)
+	}
+
+	return Profile{
+		Server: cmp.Or(o.server, util.GetENV("GOTASKER_SERVER", ""), profile.Server, DefaultServer),
+		Token:  cmp.Or(o.token, util.GetENV("GOTASKER_TOKEN", ""), profile.Token),
+	}, nil
+}
+
+// client creates a client of the resolved profile.
+func (o *options) client() (*client.Client, error) {
+	profile, err := o.resolveProfile()
+	if err != nil {
+		return nil, err
+	}
+
+	var opts []client.Option
+	if profile.Token != "" {
+		opts = append(opts, client.WithBearerToken(profile.Token))
+	}
+
+	return client.New(profile.Server, opts...)
+}
+
+func (o *options) completeProfiles(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	cfg, err := LoadConfig(o.configPath)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	return cfg.profileNames(), cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,464 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/omegaatt36/gotasker/api"
+	"github.com/omegaatt36/gotasker/api/validation"
+	"github.com/omegaatt36/gotasker/cli"
+	"github.com/omegaatt36/gotasker/domain"
+	"github.com/omegaatt36/gotasker/logging"
+	"github.com/omegaatt36/gotasker/persistance/database"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+)
+
+type CLISuite struct {
+	suite.Suite
+
+	miniredis     *miniredis.Miniredis
+	server        *httptest.Server
+	configPath    string
+	authorization string
+	// failBatch fails the batch request of the number, counting from 1.
+	failBatch int
+	batches   int
+}
+
+func (s *CLISuite) SetupSuite() {
+	gin.SetMode(gin.TestMode)
+	logging.Init(false, "error")
+}
+
+func (s *CLISuite) SetupTest() {
+	s.miniredis = database.InitializeTestingRedis()
+	database.Initialize(context.Background(), s.miniredis.Addr(), "")
+
+	handler := api.NewServer(api.Config{ResponseValidation: validation.ResponseModeFail}).Handler()
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		s.authorization = req.Header.Get("Authorization")
+		if req.URL.Path == "/v2/tasks/batch" {
+			s.batches++
+			if s.batches == s.failBatch {
+				http.Error(w, "injected failure", http.StatusInternalServerError)
+				return
+			}
+		}
+		handler.ServeHTTP(w, req)
+	}))
+
+	s.failBatch, s.batches = 0, 0
+	s.configPath = filepath.Join(s.T().TempDir(), "gotasker", "config.yaml")
+	s.T().Setenv("GOTASKER_SERVER", "")
+	s.T().Setenv("GOTASKER_TOKEN", "")
+	s.T().Setenv("GOTASKER_PROFILE", "")
+}
+
+func (s *CLISuite) TearDownTest() {
+	s.server.Close()
+	s.miniredis.Close()
+}
+
+// run runs the command with the config file of the test.
+func (s *CLISuite) run(args ...string) (string, error) {
+	var out bytes.Buffer
+
+	cmd := cli.NewCommand()
+	cmd.SetArgs(append([]string{"--config", s.configPath}, args...))
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+
+	err := cmd.ExecuteContext(context.Background())
+
+	return out.String(), err
+}
+
+func (s *CLISuite) TestTasks() {
+	out, err := s.run("task", "add", "--server", s.server.URL, "buy", "milk")
+	s.Require().NoError(err, out)
+	s.Contains(out, "ID")
+	s.Contains(out, "buy milk")
+
+	out, err = s.run("task", "add", "--server", s.server.URL, "-o", "json", "walk the dog")
+	s.Require().NoError(err, out)
+
+	var created map[string]any
+	s.Require().NoError(json.Unmarshal([]byte(out), &created))
+	s.Equal(float64(2), created["id"])
+	s.Equal("incomplete", created["status"])
+
+	out, err = s.run("task", "done", "--server", s.server.URL, "1")
+	s.Require().NoError(err, out)
+	s.Contains(out, "completed")
+
+	out, err = s.run("task", "edit", "--server", s.server.URL, "2", "--name", "walk the cat")
+	s.Require().NoError(err, out)
+	s.Contains(out, "walk the cat")
+
+	s.T().Run("list", func(t *testing.T) {
+		out, err := s.run("task", "list", "--server", s.server.URL)
+		s.Require().NoError(err, out)
+
+		lines := strings.Split(strings.TrimSpace(out), "\n")
+		s.Require().Len(lines, 3)
+		s.Regexp(`^ID\s+STATUS\s+NAME\s+UPDATED$`, lines[0])
+		s.Regexp(`^1\s+completed\s+buy milk\s+\S+$`, lines[1])
+		s.Regexp(`^2\s+incomplete\s+walk the cat\s+\S+$`, lines[2])
+
+		out, err = s.run("task", "list", "--server", s.server.URL, "--status", "incomplete", "-o", "json")
+		s.Require().NoError(err, out)
+
+		var tasks []map[string]any
+		s.Require().NoError(json.Unmarshal([]byte(out), &tasks))
+		s.Require().Len(tasks, 1)
+		s.Equal("walk the cat", tasks[0]["name"])
+
+		out, err = s.run("task", "list", "--server", s.server.URL, "--search", "nothing", "-o", "json")
+		s.Require().NoError(err, out)
+		s.JSONEq("[]", out)
+	})
+
+	out, err = s.run("task", "rm", "--server", s.server.URL, "1", "2")
+	s.Require().NoError(err, out)
+	s.Empty(out)
+
+	_, err = s.run("task", "rm", "--server", s.server.URL, "1")
+	s.ErrorIs(err, domain.ErrTaskNotFound)
+
+	_, err = s.run("task", "done", "--server", s.server.URL, "x")
+	s.ErrorIs(err, domain.ErrInvalidTaskID)
+
+	_, err = s.run("task", "edit", "--server", s.server.URL, "1")
+	s.ErrorContains(err, "nothing to edit")
+
+	_, err = s.run("task", "list", "--server", s.server.URL, "-o", "yaml")
+	s.Error(err)
+}
+
+func (s *CLISuite) TestExportImport() {
+	for _, name := range []string{"buy milk", "walk the dog"} {
+		_, err := s.run("task", "add", "--server", s.server.URL, name)
+		s.Require().NoError(err)
+	}
+
+	dir := s.T().TempDir()
+	out, err := s.run("task", "export", "--server", s.server.URL, filepath.Join(dir, "tasks.csv"))
+	s.Require().NoError(err, out)
+
+	bs, err := os.ReadFile(filepath.Join(dir, "tasks.csv"))
+	s.Require().NoError(err)
+	lines := strings.Split(strings.TrimSpace(string(bs)), "\n")
+	s.Require().Len(lines, 3)
+	s.Equal("id,name,status,created_at,updated_at,notes", lines[0])
+
+	out, err = s.run("task", "export", "--server", s.server.URL, "--format", "ndjson")
+	s.Require().NoError(err, out)
+	s.Len(strings.Split(strings.TrimSpace(out), "\n"), 2)
+
+	out, err = s.run("task", "import", "--server", s.server.URL, "--dry-run", filepath.Join(dir, "tasks.csv"))
+	s.Require().NoError(err, out)
+	s.Contains(out, "2 records: 2 created, 0 overwritten, 0 skipped, 0 failed (dry run)")
+
+	out, err = s.run("task", "import", "--server", s.server.URL, "--preserve-ids", filepath.Join(dir, "tasks.csv"))
+	s.Error(err)
+	s.Regexp(`(?m)^2\s+1\s+buy milk\s+task already exists$`, out)
+	s.Contains(out, "2 records: 0 created, 0 overwritten, 0 skipped, 2 failed")
+
+	out, err = s.run("task", "import", "--server", s.server.URL, "--preserve-ids", "--conflict", "skip", "-o", "json",
+		filepath.Join(dir, "tasks.csv"))
+	s.Require().NoError(err, out)
+
+	var report map[string]any
+	s.Require().NoError(json.Unmarshal([]byte(out), &report))
+	s.Equal(float64(2), report["skipped"])
+
+	s.Require().NoError(os.WriteFile(filepath.Join(dir, "more.ndjson"), []byte(`{"name": "feed the cat", "status": "completed"}`+"\n"), 0o600))
+	out, err = s.run("task", "import", "--server", s.server.URL, filepath.Join(dir, "more.ndjson"))
+	s.Require().NoError(err, out)
+	s.Regexp(`(?m)^1\s+created\s+3\s+feed the cat\s*$`, out)
+
+	_, err = s.run("task", "import", "--server", s.server.URL, "--conflict", "ignore", filepath.Join(dir, "more.ndjson"))
+	s.ErrorIs(err, domain.ErrInvalidImportConflict)
+}
+
+func (s *CLISuite) TestMarkdownSync() {
+	for _, name := range []string{"buy milk", "walk the dog"} {
+		_, err := s.run("task", "add", "--server", s.server.URL, name)
+		s.Require().NoError(err)
+	}
+
+	path := filepath.Join(s.T().TempDir(), "README.md")
+	document := "# TODO\n" +
+		"\n" +
+		"- [ ] buy milk <!-- gotasker:1 -->\n" +
+		"  - [x] oat milk\n" +
+		"- [ ] walk the dog <!-- gotasker:2 -->\n" +
+		"- [ ] call mom <!-- gotasker:9 -->\n" +
+		"\n" +
+		"```\n- [ ] in a code block\n```\n"
+	s.Require().NoError(os.WriteFile(path, []byte(document), 0o600))
+
+	out, err := s.run("md", "sync", "--server", s.server.URL, "--dry-run", path)
+	s.Require().NoError(err, out)
+	s.Regexp(`(?m)^4\s+created\s+oat milk\s*$`, out)
+	s.Contains(out, "4 items: 2 created, 0 pushed, 0 pulled, 0 merged, 0 removed, 0 conflicts (dry run)")
+	bs, err := os.ReadFile(path)
+	s.Require().NoError(err)
+	s.Equal(document, string(bs), "a dry run writes nothing")
+
+	out, err = s.run("md", "sync", "--server", s.server.URL, path)
+	s.Require().NoError(err, out)
+	s.Regexp(`(?m)^4\s+created\s+3\s+oat milk\s*$`, out)
+	s.Regexp(`(?m)^6\s+created\s+4\s+call mom\s*$`, out)
+	bs, err = os.ReadFile(path)
+	s.Require().NoError(err)
+	s.Regexp("^# TODO\n\n"+
+		`- \[ \] buy milk <!-- gotasker:1 o[0-9a-f]{8} -->\n`+
+		`  - \[x\] oat milk <!-- gotasker:3 x[0-9a-f]{8} -->\n`+
+		`- \[ \] walk the dog <!-- gotasker:2 o[0-9a-f]{8} -->\n`+
+		`- \[ \] call mom <!-- gotasker:4 o[0-9a-f]{8} -->\n`+
+		"\n```\n- \\[ \\] in a code block\n```\n$", string(bs))
+
+	out, err = s.run("task", "list", "--server", s.server.URL, "-o", "json")
+	s.Require().NoError(err, out)
+	var tasks []map[string]any
+	s.Require().NoError(json.Unmarshal([]byte(out), &tasks))
+	s.Require().Len(tasks, 4)
+	s.Equal("completed", tasks[2]["status"])
+
+	for _, args := range [][]string{
+		{"task", "edit", "1", "--name", "buy oat milk"},
+		{"task", "done", "2"},
+		{"task", "edit", "3", "--name", "oat milk, 2 bottles"},
+		{"task", "rm", "4"},
+	} {
+		out, err := s.run(append(args, "--server", s.server.URL)...)
+		s.Require().NoError(err, out)
+	}
+
+	edited := strings.NewReplacer(
+		"- [ ] buy milk", "- [x] buy milk",
+		"walk the dog", "walk the cat",
+		"oat milk <!--", "oat milk, 1 bottle <!--",
+	).Replace(string(bs))
+	s.Require().NoError(os.WriteFile(path, []byte(edited), 0o600))
+
+	out, err = s.run("md", "sync", "--server", s.server.URL, path)
+	s.Require().NoError(err, out)
+	s.Regexp(`(?m)^3\s+merged\s+1\s+buy oat milk\s*$`, out)
+	s.Regexp(`(?m)^4\s+pulled \(conflict\)\s+3\s+oat milk, 2 bottles\s*$`, out)
+	s.Regexp(`(?m)^5\s+merged\s+2\s+walk the cat\s*$`, out)
+	s.Regexp(`(?m)^6\s+removed\s+4\s+call mom\s*$`, out)
+	s.Contains(out, "4 items: 0 created, 0 pushed, 1 pulled, 2 merged, 1 removed, 1 conflicts")
+
+	bs, err = os.ReadFile(path)
+	s.Require().NoError(err)
+	s.Contains(string(bs), "- [x] buy oat milk <!-- gotasker:1 x")
+	s.Contains(string(bs), "  - [x] oat milk, 2 bottles <!-- gotasker:3 x")
+	s.Contains(string(bs), "- [x] walk the cat <!-- gotasker:2 x")
+	s.NotContains(string(bs), "call mom")
+
+	out, err = s.run("task", "list", "--server", s.server.URL, "-o", "json")
+	s.Require().NoError(err, out)
+	s.Require().NoError(json.Unmarshal([]byte(out), &tasks))
+	s.Require().Len(tasks, 3)
+	s.Equal("completed", tasks[0]["status"])
+	s.Equal("walk the cat", tasks[1]["name"])
+
+	out, err = s.run("md", "sync", "--server", s.server.URL, path)
+	s.Require().NoError(err, out)
+	s.Contains(out, "3 items: 0 created, 0 pushed, 0 pulled, 0 merged, 0 removed, 0 conflicts")
+
+	s.Require().NoError(os.WriteFile(path, []byte("- [ ] a <!-- gotasker:1 -->\n- [ ] b <!-- gotasker:1 -->\n"), 0o600))
+	_, err = s.run("md", "sync", "--server", s.server.URL, path)
+	s.ErrorContains(err, "duplicate task marker: task 1 on lines 1 and 2")
+}
+
+func (s *CLISuite) TestMarkdownSyncBatches() {
+	var document strings.Builder
+	for index := range 150 {
+		fmt.Fprintf(&document, "- [ ] task %d\n", index+1)
+	}
+	path := filepath.Join(s.T().TempDir(), "TODO.md")
+	s.Require().NoError(os.WriteFile(path, []byte(document.String()), 0o600))
+
+	out, err := s.run("md", "sync", "--server", s.server.URL, path)
+	s.Require().NoError(err, out)
+	s.Contains(out, "150 items: 150 created")
+
+	bs, err := os.ReadFile(path)
+	s.Require().NoError(err)
+	s.Require().NoError(os.WriteFile(path, bytes.ReplaceAll(bs, []byte("- [ ]"), []byte("- [x]")), 0o600))
+
+	// the updates exceed the batch size of the server, the second batch
+	// fails and only the items of the first one are synced.
+	s.failBatch = 2
+	out, err = s.run("md", "sync", "--server", s.server.URL, path)
+	s.Require().ErrorContains(err, "injected failure", out)
+	s.Equal(2, s.batches)
+
+	bs, err = os.ReadFile(path)
+	s.Require().NoError(err)
+	s.Equal(100, strings.Count(string(bs), " x"), "the markers of the applied items are updated")
+
+	out, err = s.run("task", "list", "--server", s.server.URL, "--status", "completed", "-o", "json")
+	s.Require().NoError(err, out)
+	var tasks []map[string]any
+	s.Require().NoError(json.Unmarshal([]byte(out), &tasks))
+	s.Len(tasks, 100)
+
+	out, err = s.run("md", "sync", "--server", s.server.URL, path)
+	s.Require().NoError(err, out)
+	s.Contains(out, "150 items: 0 created, 50 pushed, 0 pulled, 0 merged, 0 removed, 0 conflicts")
+
+	out, err = s.run("md", "sync", "--server", s.server.URL, path)
+	s.Require().NoError(err, out)
+	s.Contains(out, "150 items: 0 created, 0 pushed")
+}
+
+func (s *CLISuite) TestProfiles() {
+	// an existing config file readable by others is tightened.
+	s.Require().NoError(os.MkdirAll(filepath.Dir(s.configPath), 0o700))
+	s.Require().NoError(os.WriteFile(s.configPath, nil, 0o644))
+	s.Require().NoError(os.Chmod(s.configPath, 0o644))
+
+	out, err := s.run("config", "set", "local", "--server", s.server.URL+"/", "--token", "secret")
+	s.Require().NoError(err, out)
+	out, err = s.run("config", "set", "prod", "--server", "http://127.0.0.1:1")
+	s.Require().NoError(err, out)
+
+	info, err := os.Stat(s.configPath)
+	s.Require().NoError(err)
+	s.Equal(os.FileMode(0o600), info.Mode().Perm())
+
+	cfg, err := cli.LoadConfig(s.configPath)
+	s.Require().NoError(err)
+	s.Equal("local", cfg.CurrentProfile)
+	s.Equal(cli.Profile{Server: s.server.URL, Token: "secret"}, cfg.Profiles["local"])
+
+	// the first profile is used by default.
+	_, err = s.run("task", "add", "task 1")
+	s.Require().NoError(err)
+	s.Equal("Bearer secret", s.authorization)
+
+	out, err = s.run("config", "list", "-o", "json")
+	s.Require().NoError(err, out)
+	s.NotContains(out, "secret")
+	s.JSONEq(`[
+		{"name": "local", "server": "`+s.server.URL+`", "has_token": true, "current": true},
+		{"name": "prod", "server": "http://127.0.0.1:1", "has_token": false, "current": false}
+	]`, out)
+
+	_, err = s.run("config", "use", "prod")
+	s.Require().NoError(err)
+	_, err = s.run("task", "list", "--token", "other")
+	s.Error(err, "the prod server is unreachable")
+
+	s.T().Setenv("GOTASKER_PROFILE", "local")
+	_, err = s.run("task", "list", "--token", "other")
+	s.Require().NoError(err)
+	s.Equal("Bearer other", s.authorization)
+
+	_, err = s.run("task", "list", "--profile", "missing")
+	s.ErrorIs(err, cli.ErrProfileNotFound)
+	_, err = s.run("config", "use", "missing")
+	s.ErrorIs(err, cli.ErrProfileNotFound)
+}
+
+func (s *CLISuite) TestCompletion() {
+	out, err := s.run("completion", "bash")
+	s.Require().NoError(err)
+	s.Contains(out, "__start_gotasker")
+
+	_, err = s.run("task", "add", "--server", s.server.URL, "task 1")
+	s.Require().NoError(err)
+	_, err = s.run("task", "add", "--server", s.server.URL, "task 2")
+	s.Require().NoError(err)
+	_, err = s.run("task", "done", "--server", s.server.URL, "1")
+	s.Require().NoError(err)
+
+	out, err = s.run("__complete", "task", "done", "--server", s.server.URL, "")
+	s.Require().NoError(err)
+	s.Equal("2\ttask 2\n:4\n", firstLines(out, 2))
+
+	out, err = s.run("__complete", "task", "rm", "--server", s.server.URL, "1", "")
+	s.Require().NoError(err)
+	s.Equal("2\ttask 2\n:4\n", firstLines(out, 2))
+
+	out, err = s.run("__complete", "task", "list", "--status", "")
+	s.Require().NoError(err)
+	s.Equal("incomplete\ncompleted\n:4\n", firstLines(out, 3))
+}
+
+// firstLines returns the first n lines of the completion output, where the
+// completions are followed by the directive line and debug messages.
+func firstLines(out string, n int) string {
+	lines := strings.SplitAfter(out, "\n")
+	if len(lines) < n {
+		return out
+	}
+
+	return strings.Join(lines[:n], "")
+}
+
+func (s *CLISuite) TestTaskwarrior() {
+	_, err := s.run("task", "add", "--server", s.server.URL, "buy milk")
+	s.Require().NoError(err)
+
+	path := filepath.Join(s.T().TempDir(), "tasks.json")
+	out, err := s.run("tw", "export", "--server", s.server.URL, path)
+	s.Require().NoError(err, out)
+
+	bs, err := os.ReadFile(path)
+	s.Require().NoError(err)
+	s.Contains(string(bs), `"uuid":"676f7461-736b-8000-8000-000000000001"`)
+
+	export := `[
+{"id":1,"description":"buy milk","status":"completed","entry":"20240501T083000Z","end":"20240502T090000Z","uuid":"676f7461-736b-8000-8000-000000000001","urgency":0},
+{"id":2,"description":"call mom","status":"pending","entry":"20240501T083000Z","tags":["phone"],"project":"family","uuid":"5d2a4a57-2e1a-4c1b-9c1f-0e1a2b3c4d5e","urgency":1.9}
+]`
+	s.Require().NoError(os.WriteFile(path, []byte(export), 0o600))
+
+	out, err = s.run("tw", "import", "--server", s.server.URL, "--dry-run", path)
+	s.Require().NoError(err, out)
+	s.Contains(out, "2 tasks: 1 created, 1 overwritten, 0 deleted, 0 skipped, 0 failed (dry run)")
+
+	for range 2 {
+		out, err = s.run("tw", "import", "--server", s.server.URL, path)
+		s.Require().NoError(err, out)
+	}
+	s.Contains(out, "2 tasks: 0 created, 2 overwritten, 0 deleted, 0 skipped, 0 failed")
+	s.Regexp(`(?m)^2\s+overwritten\s+5d2a4a57-2e1a-4c1b-9c1f-0e1a2b3c4d5e\s+2\s*$`, out)
+
+	out, err = s.run("tw", "export", "--server", s.server.URL)
+	s.Require().NoError(err, out)
+	s.Contains(out, `"project":"family"`)
+	s.Contains(out, `"status":"completed"`)
+
+	out, err = s.run("task", "list", "--server", s.server.URL, "-o", "json")
+	s.Require().NoError(err, out)
+
+	var tasks []map[string]any
+	s.Require().NoError(json.Unmarshal([]byte(out), &tasks))
+	s.Len(tasks, 2)
+
+	s.Require().NoError(os.WriteFile(path, []byte(`[{"uuid":"5d2a4a57-2e1a-4c1b-9c1f-0e1a2b3c4d5e","description":"","status":"pending"}]`), 0o600))
+	out, err = s.run("tw", "import", "--server", s.server.URL, path)
+	s.Error(err)
+	s.Contains(out, "task name is required")
+}
+
+func TestCLI(t *testing.T) {
+	suite.Run(t, new(CLISuite))
+}
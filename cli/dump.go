@@ -0,0 +1,246 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/omegaatt36/gotasker/client"
+	"github.com/omegaatt36/gotasker/domain"
+	"github.com/omegaatt36/gotasker/format/dump"
+
+	"github.com/spf13/cobra"
+)
+
+// dumpFormat returns the format of the flag, or else of the extension of the
+// file, NDJSON by default.
+func dumpFormat(flag, path string) (dump.Format, error) {
+	if flag != "" {
+		return dump.ParseFormat(flag)
+	}
+
+	extension := strings.ToLower(filepath.Ext(path))
+	for _, format := range dump.Formats() {
+		if format.Extension() == extension {
+			return format, nil
+		}
+	}
+
+	return dump.FormatNDJSON, nil
+}
+
+func dumpFormatNames() []string {
+	formats := dump.Formats()
+	names := make([]string, len(formats))
+	for index, format := range formats {
+		names[index] = string(format)
+	}
+
+	return names
+}
+
+func importConflictNames() []string {
+	conflicts := domain.ImportConflictValues()
+	names := make([]string, len(conflicts))
+	for index, conflict := range conflicts {
+		names[index] = conflict.String()
+	}
+
+	return names
+}
+
+func newTaskExportCommand(o *options) *cobra.Command {
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "export [FILE]",
+		Short: "Export all tasks as NDJSON, CSV, todo.txt or Markdown",
+		Long: "Export all tasks as NDJSON, CSV, todo.txt or Markdown to the file, or to the standard output.\n" +
+			"The format is taken from --format, or else from the extension of the file, .txt is todo.txt and .md is Markdown.",
+		Example: "  gotasker task export tasks.csv\n  gotasker task export todo.txt\n  gotasker task export --format ndjson > tasks.ndjson",
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var path string
+			if len(args) > 0 {
+				path = args[0]
+			}
+
+			format, err := dumpFormat(format, path)
+			if err != nil {
+				return err
+			}
+
+			c, err := o.client()
+			if err != nil {
+				return err
+			}
+
+			if path == "" {
+				return c.ExportTasks(cmd.Context(), cmd.OutOrStdout(), format)
+			}
+
+			f, err := os.Create(path)
+			if err != nil {
+				return err
+			}
+
+			if err := c.ExportTasks(cmd.Context(), f, format); err != nil {
+				_ = f.Close()
+				return err
+			}
+
+			return f.Close()
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", "", fmt.Sprintf("format of the dump, one of %v", dumpFormatNames()))
+	_ = cmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions(dumpFormatNames(), cobra.ShellCompDirectiveNoFileComp))
+
+	return cmd
+}
+
+// importReportView is the output of an import report.
+type importReportView struct {
+	DryRun      bool            `json:"dry_run"`
+	Total       int             `json:"total"`
+	Created     int             `json:"created"`
+	Overwritten int             `json:"overwritten"`
+	Skipped     int             `json:"skipped"`
+	Failed      int             `json:"failed"`
+	Rows        []importRowView `json:"rows"`
+}
+
+type importRowView struct {
+	Line   int       `json:"line"`
+	Action string    `json:"action,omitempty"`
+	Task   *taskView `json:"task,omitempty"`
+	Error  string    `json:"error,omitempty"`
+}
+
+func newImportReportView(report *client.ImportReport) importReportView {
+	view := importReportView{
+		DryRun:      report.DryRun,
+		Total:       report.Total,
+		Created:     report.Created,
+		Overwritten: report.Overwritten,
+		Skipped:     report.Skipped,
+		Failed:      report.Failed,
+		Rows:        make([]importRowView, len(report.Rows)),
+	}
+
+	for index := range report.Rows {
+		row := &report.Rows[index]
+		view.Rows[index] = importRowView{Line: row.Line, Error: row.Error}
+		if row.Task != nil {
+			task := newTaskView(row.Task)
+			view.Rows[index].Task = &task
+		}
+		if row.Error == "" {
+			view.Rows[index].Action = row.Action.String()
+		}
+	}
+
+	return view
+}
+
+// writeImportReport writes the report as a table of the records followed by
+// a summary, or as a JSON object.
+func (o *options) writeImportReport(w io.Writer, report *client.ImportReport) error {
+	view := newImportReportView(report)
+	if err := o.output.write(w, view, func(t *table) {
+		t.header("LINE", "ACTION", "ID", "NAME", "ERROR")
+		for _, row := range view.Rows {
+			id, name := "", ""
+			if row.Task != nil {
+				name = row.Task.Name
+				if row.Task.ID > 0 {
+					id = strconv.FormatUint(uint64(row.Task.ID), 10)
+				}
+			}
+
+			t.row(strconv.Itoa(row.Line), row.Action, id, name, row.Error)
+		}
+	}); err != nil {
+		return err
+	}
+
+	if o.output == outputJSON {
+		return nil
+	}
+
+	summary := fmt.Sprintf("%d records: %d created, %d overwritten, %d skipped, %d failed",
+		view.Total, view.Created, view.Overwritten, view.Skipped, view.Failed)
+	if view.DryRun {
+		summary += " (dry run)"
+	}
+
+	_, err := fmt.Fprintln(w, summary)
+	return err
+}
+
+func newTaskImportCommand(o *options) *cobra.Command {
+	var (
+		format   string
+		opts     client.ImportOptions
+		conflict string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "import FILE",
+		Short: "Import tasks from NDJSON, CSV, todo.txt or Markdown",
+		Long: "Import the tasks of an NDJSON, CSV, todo.txt or Markdown dump atomically, - reads the standard input.\n" +
+			"The format is taken from --format, or else from the extension of the file, .txt is todo.txt and .md is Markdown.\n" +
+			"Any invalid record fails the import, the report tells the line and error of each of them.",
+		Example: "  gotasker task import --dry-run tasks.csv\n  gotasker task import todo.txt\n  gotasker task import --preserve-ids --conflict overwrite tasks.ndjson",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, err := dumpFormat(format, args[0])
+			if err != nil {
+				return err
+			}
+
+			if opts.Conflict, err = domain.ParseImportConflict(conflict); err != nil {
+				return err
+			}
+
+			r := cmd.InOrStdin()
+			if args[0] != "-" {
+				f, err := os.Open(args[0])
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+
+				r = f
+			}
+
+			c, err := o.client()
+			if err != nil {
+				return err
+			}
+
+			report, err := c.ImportTasks(cmd.Context(), r, format, opts)
+			if report.Rows != nil {
+				if err := o.writeImportReport(cmd.OutOrStdout(), &report); err != nil {
+					return err
+				}
+			}
+
+			return err
+		},
+	}
+
+	conflicts := importConflictNames()
+	cmd.Flags().StringVar(&format, "format", "", fmt.Sprintf("format of the dump, one of %v", dumpFormatNames()))
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "only validate the records and report what the import would do")
+	cmd.Flags().BoolVar(&opts.PreserveIDs, "preserve-ids", false, "keep the IDs of the records instead of creating tasks with new IDs")
+	cmd.Flags().StringVar(&conflict, "conflict", domain.ImportConflictFail.String(),
+		fmt.Sprintf("what to do with preserved IDs of existing tasks, one of %v", conflicts))
+	_ = cmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions(dumpFormatNames(), cobra.ShellCompDirectiveNoFileComp))
+	_ = cmd.RegisterFlagCompletionFunc("conflict", cobra.FixedCompletions(conflicts, cobra.ShellCompDirectiveNoFileComp))
+
+	return cmd
+}
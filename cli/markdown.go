@@ -0,0 +1,532 @@
+package cli
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/omegaatt36/gotasker/client"
+	"github.com/omegaatt36/gotasker/domain"
+	"github.com/omegaatt36/gotasker/format/dump"
+	"github.com/omegaatt36/gotasker/format/markdown"
+	"github.com/omegaatt36/gotasker/service/task"
+
+	"github.com/spf13/cobra"
+)
+
+// errDuplicateMarker is returned for documents with many items of a task.
+var errDuplicateMarker = errors.New("duplicate task marker")
+
+// sides of a sync which win conflicts.
+const (
+	preferServer = "server"
+	preferFile   = "file"
+)
+
+// changes of a synced item.
+const (
+	changeCreated = "created"
+	changePushed  = "pushed"
+	changePulled  = "pulled"
+	changeMerged  = "merged"
+	changeRemoved = "removed"
+)
+
+// syncBase is the state of an item at the last sync, which is kept in the sum
+// of its marker as the check followed by the hash of the name, e.g.
+// x1a2b3c4d.
+type syncBase struct {
+	checked bool
+	name    string
+}
+
+func newSyncBase(checked bool, name string) syncBase {
+	return syncBase{checked: checked, name: nameHash(name)}
+}
+
+func parseSyncBase(sum string) (syncBase, bool) {
+	if len(sum) != 9 || sum[0] != 'x' && sum[0] != 'o' {
+		return syncBase{}, false
+	}
+
+	if _, err := strconv.ParseUint(sum[1:], 16, 32); err != nil {
+		return syncBase{}, false
+	}
+
+	return syncBase{checked: sum[0] == 'x', name: sum[1:]}, true
+}
+
+func (b syncBase) String() string {
+	if b.checked {
+		return "x" + b.name
+	}
+
+	return "o" + b.name
+}
+
+// normalizeName collapses the whitespace of the name as items are written.
+func normalizeName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
+
+func nameHash(name string) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(normalizeName(name)))
+	return fmt.Sprintf("%08x", h.Sum32())
+}
+
+// syncChange is a change of a sync.
+type syncChange struct {
+	Line   int    `json:"line"`
+	Change string `json:"change"`
+	// Conflict reports whether the item and the task were both changed since
+	// the last sync.
+	Conflict bool   `json:"conflict,omitempty"`
+	ID       uint   `json:"id,omitempty"`
+	Name     string `json:"name"`
+}
+
+// syncReport is the output of a sync.
+type syncReport struct {
+	DryRun  bool         `json:"dry_run"`
+	Items   int          `json:"items"`
+	Changes []syncChange `json:"changes"`
+}
+
+// markdownSync reconciles the items of a document with the tasks of a
+// server.
+type markdownSync struct {
+	client   *client.Client
+	document *markdown.Document
+	// preferFile makes the item win conflicts instead of the task.
+	preferFile bool
+	dryRun     bool
+	// batchSize is the number of updates per batch, which can't exceed the
+	// batch size of the server.
+	batchSize int
+}
+
+// merge merges a field of an item and a task. The changed side wins, the
+// preferred one if both are changed. Without a base both are changed.
+func merge[T comparable](fileValue, serverValue T, hasBase, fileChanged, serverChanged, preferFile bool) (value T, push, pull, conflict bool) {
+	if fileValue == serverValue {
+		return fileValue, false, false, false
+	}
+
+	if !hasBase {
+		fileChanged, serverChanged = true, true
+	}
+
+	switch {
+	case fileChanged && !serverChanged:
+		return fileValue, true, false, false
+	case serverChanged && !fileChanged:
+		return serverValue, false, true, false
+	case preferFile:
+		return fileValue, true, false, true
+	default:
+		return serverValue, false, true, true
+	}
+}
+
+// run syncs the document and returns its changes. Items of unknown tasks are
+// created, then the items and the tasks changed since the last sync are
+// merged field by field, and unchanged items of deleted tasks are removed.
+// Tasks without items are left as they are. Updates are pushed in batches,
+// if one fails the document is still updated with the items of the batches
+// applied before it, so it can be written along with the error.
+func (s *markdownSync) run(ctx context.Context) (syncReport, error) {
+	items := s.document.Items()
+	report := syncReport{DryRun: s.dryRun, Items: len(items), Changes: []syncChange{}}
+
+	lines := make(map[uint]int, len(items))
+	for _, item := range items {
+		if item.ID == 0 {
+			continue
+		}
+		if line, ok := lines[item.ID]; ok {
+			return syncReport{}, fmt.Errorf("%w: task %d on lines %d and %d", errDuplicateMarker, item.ID, line, item.Line)
+		}
+		lines[item.ID] = item.Line
+	}
+
+	list, err := s.client.ListTasks(ctx)
+	if err != nil {
+		return syncReport{}, err
+	}
+
+	tasks := make(map[uint]*domain.Task, len(list))
+	for index := range list {
+		tasks[list[index].ID] = &list[index]
+	}
+
+	var (
+		// synced are the items of the document after the sync.
+		synced  []markdown.Item
+		created []markdown.Item
+		ops     []domain.BatchOperation
+		// pushed are the items of ops, which are synced once they're applied.
+		pushed []markdown.Item
+	)
+	for _, item := range items {
+		item.Text = normalizeName(item.Text)
+		if item.Text == "" && item.ID == 0 {
+			// an empty item is yet to be written, tasks have names.
+			continue
+		}
+
+		base, hasBase := parseSyncBase(item.Sum)
+		fileNameChanged := nameHash(item.Text) != base.name
+		fileCheckChanged := item.Checked != base.checked
+
+		t, ok := tasks[item.ID]
+		if !ok {
+			if item.ID > 0 && hasBase && !fileNameChanged && !fileCheckChanged {
+				report.Changes = append(report.Changes, syncChange{Line: item.Line, Change: changeRemoved, ID: item.ID, Name: item.Text})
+				if err := s.document.Remove(item.Line); err != nil {
+					return syncReport{}, err
+				}
+				continue
+			}
+
+			created = append(created, item)
+			continue
+		}
+
+		name, pushName, pullName, nameConflict := merge(item.Text, normalizeName(t.Name), hasBase,
+			fileNameChanged, nameHash(t.Name) != base.name, s.preferFile)
+		checked, pushCheck, pullCheck, checkConflict := merge(item.Checked, t.Status == domain.TaskStatusCompleted, hasBase,
+			fileCheckChanged, (t.Status == domain.TaskStatusCompleted) != base.checked, s.preferFile)
+
+		change := syncChange{Line: item.Line, Conflict: nameConflict || checkConflict, ID: t.ID, Name: name}
+		switch push, pull := pushName || pushCheck, pullName || pullCheck; {
+		case push && pull:
+			change.Change = changeMerged
+		case push:
+			change.Change = changePushed
+		case pull:
+			change.Change = changePulled
+		}
+		if change.Change != "" {
+			report.Changes = append(report.Changes, change)
+		}
+
+		if pushName || pushCheck {
+			op := domain.BatchOperation{Type: domain.BatchOperationTypeUpdate, ID: t.ID}
+			if pushName {
+				op.Update.Name = &name
+			}
+			if pushCheck {
+				status := domain.TaskStatusIncomplete
+				if checked {
+					status = domain.TaskStatusCompleted
+				}
+				op.Update.Status = &status
+			}
+			ops = append(ops, op)
+		}
+
+		item.Text, item.Checked = name, checked
+		item.Sum = newSyncBase(checked, name).String()
+		if pushName || pushCheck {
+			pushed = append(pushed, item)
+		} else {
+			synced = append(synced, item)
+		}
+	}
+
+	if s.dryRun {
+		for _, item := range created {
+			report.Changes = append(report.Changes, syncChange{Line: item.Line, Change: changeCreated, Name: item.Text})
+		}
+		sortChanges(report.Changes)
+
+		return report, nil
+	}
+
+	applied, err := s.pushUpdates(ctx, ops)
+	synced = append(synced, pushed[:applied]...)
+
+	if err == nil {
+		var createdTasks []domain.Task
+		createdTasks, err = s.createTasks(ctx, created)
+		for index, t := range createdTasks {
+			item := created[index]
+			report.Changes = append(report.Changes, syncChange{Line: item.Line, Change: changeCreated, ID: t.ID, Name: item.Text})
+
+			item.ID = t.ID
+			item.Sum = newSyncBase(item.Checked, item.Text).String()
+			synced = append(synced, item)
+		}
+	}
+
+	for _, item := range synced {
+		if err := s.document.Set(item); err != nil {
+			return syncReport{}, err
+		}
+	}
+	if err != nil {
+		return syncReport{}, err
+	}
+	sortChanges(report.Changes)
+
+	return report, nil
+}
+
+// pushUpdates applies the updates in atomic batches of the batch size and
+// returns the number of applied updates, which is short of the updates if a
+// batch fails.
+func (s *markdownSync) pushUpdates(ctx context.Context, ops []domain.BatchOperation) (int, error) {
+	for start := 0; start < len(ops); start += s.batchSize {
+		batch := ops[start:min(start+s.batchSize, len(ops))]
+		results, err := s.client.BatchTasks(ctx, batch)
+		if err != nil {
+			return start, err
+		}
+
+		var errs []error
+		for index, result := range results {
+			if result.Err != nil && !errors.Is(result.Err, domain.ErrBatchRolledBack) {
+				errs = append(errs, fmt.Errorf("update task %d: %w", batch[index].ID, result.Err))
+			}
+		}
+		if len(errs) > 0 {
+			return start, errors.Join(errs...)
+		}
+	}
+
+	return len(ops), nil
+}
+
+func sortChanges(changes []syncChange) {
+	slices.SortFunc(changes, func(a, b syncChange) int {
+		return cmp.Compare(a.Line, b.Line)
+	})
+}
+
+// createTasks creates the tasks of the items atomically, as the checks of the
+// items can't be set by creating tasks in a batch they're imported.
+func (s *markdownSync) createTasks(ctx context.Context, items []markdown.Item) ([]domain.Task, error) {
+	if len(items) == 0 {
+		return nil, nil
+	}
+
+	var buf bytes.Buffer
+	encoder := dump.NewEncoder(&buf, dump.FormatMarkdown)
+	for _, item := range items {
+		status := domain.TaskStatusIncomplete
+		if item.Checked {
+			status = domain.TaskStatusCompleted
+		}
+		if err := encoder.Encode(dump.Record{Name: item.Text, Status: status.String()}); err != nil {
+			return nil, err
+		}
+	}
+
+	report, err := s.client.ImportTasks(ctx, &buf, dump.FormatMarkdown, client.ImportOptions{})
+	if err != nil {
+		for index, row := range report.Rows {
+			if row.Error != "" && row.Error != domain.ErrBatchRolledBack.Error() {
+				return nil, fmt.Errorf("create task of line %d: %s", items[index].Line, row.Error)
+			}
+		}
+
+		return nil, err
+	}
+
+	tasks := make([]domain.Task, len(report.Rows))
+	for index, row := range report.Rows {
+		if row.Task == nil {
+			return nil, fmt.Errorf("create task of line %d: no task reported", items[index].Line)
+		}
+		tasks[index] = *row.Task
+	}
+
+	return tasks, nil
+}
+
+// writeSyncReport writes the report as a table of the changes followed by a
+// summary, or as a JSON object.
+func (o *options) writeSyncReport(w io.Writer, report *syncReport) error {
+	if err := o.output.write(w, report, func(t *table) {
+		t.header("LINE", "CHANGE", "ID", "NAME")
+		for _, change := range report.Changes {
+			id, action := "", change.Change
+			if change.ID > 0 {
+				id = strconv.FormatUint(uint64(change.ID), 10)
+			}
+			if change.Conflict {
+				action += " (conflict)"
+			}
+
+			t.row(strconv.Itoa(change.Line), action, id, change.Name)
+		}
+	}); err != nil {
+		return err
+	}
+
+	if o.output == outputJSON {
+		return nil
+	}
+
+	counts := make(map[string]int, 5)
+	var conflicts int
+	for _, change := range report.Changes {
+		counts[change.Change]++
+		if change.Conflict {
+			conflicts++
+		}
+	}
+
+	summary := fmt.Sprintf("%d items: %d created, %d pushed, %d pulled, %d merged, %d removed, %d conflicts",
+		report.Items, counts[changeCreated], counts[changePushed], counts[changePulled], counts[changeMerged], counts[changeRemoved], conflicts)
+	if report.DryRun {
+		summary += " (dry run)"
+	}
+
+	_, err := fmt.Fprintln(w, summary)
+	return err
+}
+
+// writeFile replaces the content of the file keeping its permissions, see
+// replaceFile.
+func writeFile(path string, content []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	return replaceFile(path, content, info.Mode().Perm())
+}
+
+// replaceFile writes the file of the permissions through a temporary file in
+// the same directory, so the file is never left half written and an existing
+// file takes the permissions too.
+func replaceFile(path string, content []byte, perm os.FileMode) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(content); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Chmod(perm); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
+
+func newMarkdownCommand(o *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "md",
+		Aliases: []string{"markdown"},
+		Short:   "Sync the task lists of Markdown files with a running server",
+	}
+
+	o.addClientFlags(cmd)
+	o.addOutputFlag(cmd)
+
+	cmd.AddCommand(newMarkdownSyncCommand(o))
+
+	return cmd
+}
+
+func newMarkdownSyncCommand(o *options) *cobra.Command {
+	var (
+		prefer    string
+		dryRun    bool
+		batchSize int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "sync FILE",
+		Short: "Sync the task list items of a Markdown file with the tasks",
+		Long: "Sync the task list items (- [ ] and - [x]) of a Markdown file with the tasks of the server.\n\n" +
+			"Items are tied to tasks by markers like <!-- gotasker:1 o1a2b3c4d -->, which also keep the state of the last sync:\n" +
+			"- items without markers are created as tasks, and the markers are added to them.\n" +
+			"- the names and checks changed on either side since the last sync are merged into the other side,\n" +
+			"  fields changed on both sides are conflicts resolved by --prefer.\n" +
+			"- unchanged items of deleted tasks are removed, edited ones are created again.\n" +
+			"Removing an item leaves its task as it is, tasks without items are not added to the file.\n" +
+			"Nested items are synced as tasks of their own, the nesting is kept in the file only.\n\n" +
+			"Updates are pushed in batches of --batch-size, if one fails the items of the batches applied before it are still written.",
+		Example: "  gotasker md sync README.md\n  gotasker md sync --dry-run --prefer file TODO.md",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if prefer != preferServer && prefer != preferFile {
+				return fmt.Errorf("invalid --prefer %q, must be %s or %s", prefer, preferServer, preferFile)
+			}
+			if batchSize <= 0 {
+				return fmt.Errorf("invalid --batch-size %d, must be positive", batchSize)
+			}
+
+			bs, err := os.ReadFile(args[0])
+			if err != nil {
+				return err
+			}
+
+			document, err := markdown.Parse(bytes.NewReader(bs))
+			if err != nil {
+				return err
+			}
+
+			c, err := o.client()
+			if err != nil {
+				return err
+			}
+
+			ms := &markdownSync{
+				client:     c,
+				document:   document,
+				preferFile: prefer == preferFile,
+				dryRun:     dryRun,
+				batchSize:  batchSize,
+			}
+			report, err := ms.run(cmd.Context())
+
+			// the items synced before a failure are written too, so they
+			// aren't pushed again as conflicts.
+			if !dryRun {
+				var buf bytes.Buffer
+				if _, writeErr := document.WriteTo(&buf); writeErr != nil {
+					return errors.Join(err, writeErr)
+				}
+				if !bytes.Equal(buf.Bytes(), bs) {
+					if writeErr := writeFile(args[0], buf.Bytes()); writeErr != nil {
+						return errors.Join(err, writeErr)
+					}
+				}
+			}
+			if err != nil {
+				return err
+			}
+
+			return o.writeSyncReport(cmd.OutOrStdout(), &report)
+		},
+	}
+
+	cmd.Flags().StringVar(&prefer, "prefer", preferServer,
+		fmt.Sprintf("side winning the conflicts of fields changed on both sides, %s or %s", preferServer, preferFile))
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "only report the changes, neither the file nor the tasks are written")
+	cmd.Flags().IntVar(&batchSize, "batch-size", task.DefaultMaxBatchSize, "number of updates pushed per batch, at most the batch max size of the server")
+	_ = cmd.RegisterFlagCompletionFunc("prefer", cobra.FixedCompletions([]string{preferServer, preferFile}, cobra.ShellCompDirectiveNoFileComp))
+
+	return cmd
+}
@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// outputFormat is the format of the output of commands.
+type outputFormat string
+
+// supported output formats.
+const (
+	outputTable outputFormat = "table"
+	outputJSON  outputFormat = "json"
+)
+
+var outputFormats = []string{string(outputTable), string(outputJSON)}
+
+// String implements pflag.Value.
+func (f *outputFormat) String() string {
+	return string(*f)
+}
+
+// Set implements pflag.Value.
+func (f *outputFormat) Set(value string) error {
+	switch outputFormat(value) {
+	case outputTable, outputJSON:
+		*f = outputFormat(value)
+		return nil
+	default:
+		return fmt.Errorf("must be one of [%s]", strings.Join(outputFormats, ", "))
+	}
+}
+
+// Type implements pflag.Value.
+func (f *outputFormat) Type() string {
+	return "format"
+}
+
+// write writes v as indented JSON, or the table built by fn.
+func (f outputFormat) write(w io.Writer, v any, fn func(*table)) error {
+	if f == outputJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	}
+
+	t := &table{writer: tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)}
+	fn(t)
+
+	return t.writer.Flush()
+}
+
+// table writes aligned columns.
+type table struct {
+	writer *tabwriter.Writer
+}
+
+func (t *table) header(columns ...string) {
+	t.row(columns...)
+}
+
+func (t *table) row(columns ...string) {
+	fmt.Fprintln(t.writer, strings.Join(columns, "\t"))
+}
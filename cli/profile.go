@@ -0,0 +1,221 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultProfile is the profile used if no profile is selected.
+const DefaultProfile = "default"
+
+var ErrProfileNotFound = errors.New("profile not found")
+
+// Profile defines the server of a profile and its credentials.
+type Profile struct {
+	Server string `yaml:"server,omitempty"`
+	// Token is sent as a bearer token in the Authorization header.
+	Token string `yaml:"token,omitempty"`
+}
+
+// Config is the config file of the CLI holding the profiles.
+type Config struct {
+	CurrentProfile string             `yaml:"current_profile,omitempty"`
+	Profiles       map[string]Profile `yaml:"profiles,omitempty"`
+}
+
+// defaultConfigPath returns the path of the config file in the user config
+// directory, e.g. ~/.config/gotasker/config.yaml.
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, "gotasker", "config.yaml")
+}
+
+// LoadConfig loads the config file, a missing file is an empty config.
+func LoadConfig(path string) (*Config, error) {
+	cfg := &Config{Profiles: make(map[string]Profile)}
+
+	bs, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(bs, cfg); err != nil {
+		return nil, fmt.Errorf("parse config %s: %w", path, err)
+	}
+	if cfg.Profiles == nil {
+		cfg.Profiles = make(map[string]Profile)
+	}
+
+	return cfg, nil
+}
+
+// Save writes the config file, which is only readable by the user since it
+// holds credentials, even if it was created with looser permissions.
+func (c *Config) Save(path string) error {
+	bs, err := yaml.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	return replaceFile(path, bs, 0o600)
+}
+
+// profileNames returns the names of the profiles in order.
+func (c *Config) profileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	return names
+}
+
+func newConfigCommand(o *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Manage the profiles of the config file",
+	}
+
+	o.addOutputFlag(cmd)
+
+	cmd.AddCommand(
+		newConfigSetCommand(o),
+		newConfigUseCommand(o),
+		newConfigListCommand(o),
+	)
+
+	return cmd
+}
+
+func newConfigSetCommand(o *options) *cobra.Command {
+	var (
+		profile Profile
+		use     bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "set PROFILE",
+		Short: "Create or update a profile",
+		Example: "  gotasker config set default --server http://localhost:8070\n" +
+			"  gotasker config set prod --server https://tasks.example.com --token $TOKEN --use",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: o.completeProfiles,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := LoadConfig(o.configPath)
+			if err != nil {
+				return err
+			}
+
+			existing := cfg.Profiles[args[0]]
+			if cmd.Flags().Changed("server") {
+				existing.Server = strings.TrimSuffix(profile.Server, "/")
+			}
+			if cmd.Flags().Changed("token") {
+				existing.Token = profile.Token
+			}
+			cfg.Profiles[args[0]] = existing
+
+			if use || cfg.CurrentProfile == "" {
+				cfg.CurrentProfile = args[0]
+			}
+
+			return cfg.Save(o.configPath)
+		},
+	}
+
+	cmd.Flags().StringVar(&profile.Server, "server", "", "base URL of the server, e.g. http://localhost:8070")
+	cmd.Flags().StringVar(&profile.Token, "token", "", "bearer token of the server")
+	cmd.Flags().BoolVar(&use, "use", false, "use the profile by default")
+
+	return cmd
+}
+
+func newConfigUseCommand(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:               "use PROFILE",
+		Short:             "Use a profile by default",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: o.completeProfiles,
+		RunE: func(_ *cobra.Command, args []string) error {
+			cfg, err := LoadConfig(o.configPath)
+			if err != nil {
+				return err
+			}
+
+			if _, ok := cfg.Profiles[args[0]]; !ok {
+				return fmt.Errorf("%w: %s", ErrProfileNotFound, args[0])
+			}
+			cfg.CurrentProfile = args[0]
+
+			return cfg.Save(o.configPath)
+		},
+	}
+}
+
+// profileView is the output of a profile, tokens are never printed.
+type profileView struct {
+	Name     string `json:"name"`
+	Server   string `json:"server,omitempty"`
+	HasToken bool   `json:"has_token"`
+	Current  bool   `json:"current"`
+}
+
+func newConfigListCommand(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List the profiles",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cfg, err := LoadConfig(o.configPath)
+			if err != nil {
+				return err
+			}
+
+			names := cfg.profileNames()
+			views := make([]profileView, len(names))
+			for index, name := range names {
+				views[index] = profileView{
+					Name:     name,
+					Server:   cfg.Profiles[name].Server,
+					HasToken: cfg.Profiles[name].Token != "",
+					Current:  name == cfg.CurrentProfile,
+				}
+			}
+
+			return o.output.write(cmd.OutOrStdout(), views, func(t *table) {
+				t.header("CURRENT", "NAME", "SERVER", "TOKEN")
+				for _, view := range views {
+					t.row(mark(view.Current), view.Name, view.Server, mark(view.HasToken))
+				}
+			})
+		},
+	}
+}
+
+func mark(ok bool) string {
+	if ok {
+		return "*"
+	}
+
+	return ""
+}
@@ -0,0 +1,157 @@
+package cli
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/omegaatt36/gotasker/api"
+	"github.com/omegaatt36/gotasker/api/graph"
+	"github.com/omegaatt36/gotasker/api/smtp"
+	"github.com/omegaatt36/gotasker/api/validation"
+	"github.com/omegaatt36/gotasker/logging"
+	"github.com/omegaatt36/gotasker/persistance/database"
+	"github.com/omegaatt36/gotasker/service/inbox"
+	"github.com/omegaatt36/gotasker/service/notification"
+	"github.com/omegaatt36/gotasker/util"
+
+	"github.com/spf13/cobra"
+)
+
+// serveConfig defines the configuration of the server, every flag defaults
+// to its env var.
+type serveConfig struct {
+	appPort  string
+	grpcPort string
+	logLevel string
+	appENV   string
+
+	redisHost     string
+	redisPort     string
+	redisPassword string
+
+	batchMaxSize    int
+	eventReplaySize int
+	changeLogMaxLen int
+
+	graphQLMaxDepth      int
+	graphQLMaxComplexity int
+
+	openapiResponseValidation string
+
+	smtpHost         string
+	smtpPort         string
+	smtpAllowlist    string
+	smtpRateLimit    int
+	smtpRateInterval time.Duration
+
+	notifySMTPHost     string
+	notifySMTPPort     string
+	notifySMTPTLS      string
+	notifySMTPUsername string
+	notifySMTPPassword string
+	notifyFrom         string
+	notifyTemplates    string
+}
+
+func newServeCommand() *cobra.Command {
+	var cfg serveConfig
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Start the API server",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return serve(cmd, &cfg)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.SortFlags = false
+	flags.StringVar(&cfg.appPort, "app-port", util.GetENV("APP_PORT", "8070"), "server port\ndefault to 8070 or the value of the APP_PORT env var, if it is set")
+	flags.StringVar(&cfg.grpcPort, "grpc-port", util.GetENV("GRPC_PORT", "8071"), "gRPC server port, empty disables the gRPC server\ndefault to 8071 or the value of the GRPC_PORT env var, if it is set")
+	flags.StringVar(&cfg.appENV, "app-env", util.GetENV("APP_ENV", "dev"), "app env\nmust be one of [dev, prod]\ndefault to dev or the value of the APP_ENV env var, if it is set")
+	flags.StringVar(&cfg.logLevel, "log-level", util.GetENV("LOG_LEVEL", "debug"), "log level\nmust be one of [debug, info, warn, error, fatal]\ndefault to debug or the value of the LOG_LEVEL env var, if it is set")
+	flags.StringVar(&cfg.redisHost, "redis-host", util.GetENV("REDIS_HOST", "localhost"), "redis host\ndefault to localhost or the value of the REDIS_HOST env var, if it is set")
+	flags.StringVar(&cfg.redisPort, "redis-port", util.GetENV("REDIS_PORT", "6379"), "redis port\ndefault to 6379 or the value of the REDIS_PORT env var, if it is set")
+	flags.StringVar(&cfg.redisPassword, "redis-password", util.GetENV("REDIS_PASSWORD", ""), "redis password\ndefault to the value of the REDIS_PASSWORD env var, if it is set")
+	flags.IntVar(&cfg.batchMaxSize, "batch-max-size", util.GetENVInt("BATCH_MAX_SIZE", 100), "maximum number of operations of a batch request\ndefault to 100 or the value of the BATCH_MAX_SIZE env var, if it is set")
+	flags.IntVar(&cfg.eventReplaySize, "event-replay-size", util.GetENVInt("EVENT_REPLAY_SIZE", 1000), "number of task events kept for event stream clients resuming with Last-Event-ID\ndefault to 1000 or the value of the EVENT_REPLAY_SIZE env var, if it is set")
+	flags.IntVar(&cfg.changeLogMaxLen, "change-log-max-len", util.GetENVInt("CHANGE_LOG_MAX_LEN", 100000), "number of task changes kept in the change log stream\ndefault to 100000 or the value of the CHANGE_LOG_MAX_LEN env var, if it is set")
+	flags.IntVar(&cfg.graphQLMaxDepth, "graphql-max-depth", util.GetENVInt("GRAPHQL_MAX_DEPTH", graph.DefaultMaxDepth), "maximum nesting of the fields of a GraphQL operation\ndefault to 10 or the value of the GRAPHQL_MAX_DEPTH env var, if it is set")
+	flags.IntVar(&cfg.graphQLMaxComplexity, "graphql-max-complexity", util.GetENVInt("GRAPHQL_MAX_COMPLEXITY", graph.DefaultMaxComplexity), "maximum complexity of a GraphQL operation\ndefault to 1000 or the value of the GRAPHQL_MAX_COMPLEXITY env var, if it is set")
+	flags.StringVar(&cfg.smtpHost, "smtp-host", util.GetENV("SMTP_HOST", smtp.DefaultHost), "host the SMTP listener binds, senders are only checked by their unauthenticated MAIL FROM so expose it only to a trusted MTA\ndefault to 127.0.0.1 or the value of the SMTP_HOST env var, if it is set")
+	flags.StringVar(&cfg.smtpPort, "smtp-port", util.GetENV("SMTP_PORT", ""), "port of the SMTP listener creating tasks from emails, empty disables the listener\ndefault to the value of the SMTP_PORT env var, if it is set")
+	flags.StringVar(&cfg.smtpAllowlist, "smtp-allowlist", util.GetENV("SMTP_ALLOWLIST", ""), "comma-separated senders allowed to create tasks by email, @example.com allows the whole domain\ndefault to the value of the SMTP_ALLOWLIST env var, if it is set")
+	flags.IntVar(&cfg.smtpRateLimit, "smtp-rate-limit", util.GetENVInt("SMTP_RATE_LIMIT", inbox.DefaultRateLimit), "number of emails each sender can send in the rate interval\ndefault to 10 or the value of the SMTP_RATE_LIMIT env var, if it is set")
+	flags.DurationVar(&cfg.smtpRateInterval, "smtp-rate-interval", util.GetENVDuration("SMTP_RATE_INTERVAL", inbox.DefaultRateInterval), "interval of the rate limit of each sender\ndefault to 1h or the value of the SMTP_RATE_INTERVAL env var, if it is set")
+	flags.StringVar(&cfg.notifySMTPHost, "notify-smtp-host", util.GetENV("NOTIFY_SMTP_HOST", ""), "host of the SMTP server sending notifications of task events, empty disables sending them\ndefault to the value of the NOTIFY_SMTP_HOST env var, if it is set")
+	flags.StringVar(&cfg.notifySMTPPort, "notify-smtp-port", util.GetENV("NOTIFY_SMTP_PORT", ""), "port of the SMTP server sending notifications\ndefault to the port of the TLS mode or the value of the NOTIFY_SMTP_PORT env var, if it is set")
+	flags.StringVar(&cfg.notifySMTPTLS, "notify-smtp-tls", util.GetENV("NOTIFY_SMTP_TLS", "starttls"), "how connections to the SMTP server sending notifications are secured\nmust be one of [none, starttls, tls]\ndefault to starttls or the value of the NOTIFY_SMTP_TLS env var, if it is set")
+	flags.StringVar(&cfg.notifySMTPUsername, "notify-smtp-username", util.GetENV("NOTIFY_SMTP_USERNAME", ""), "username of the SMTP server sending notifications, empty disables authentication\ndefault to the value of the NOTIFY_SMTP_USERNAME env var, if it is set")
+	flags.StringVar(&cfg.notifySMTPPassword, "notify-smtp-password", util.GetENV("NOTIFY_SMTP_PASSWORD", ""), "password of the SMTP server sending notifications\ndefault to the value of the NOTIFY_SMTP_PASSWORD env var, if it is set")
+	flags.StringVar(&cfg.notifyFrom, "notify-from", util.GetENV("NOTIFY_FROM", ""), "sender address of notifications, e.g. \"GoTasker <tasks@example.com>\"\ndefault to the value of the NOTIFY_FROM env var, if it is set")
+	flags.StringVar(&cfg.notifyTemplates, "notify-templates", util.GetENV("NOTIFY_TEMPLATES", ""), "directory of subject.txt.tmpl, body.txt.tmpl and body.html.tmpl overriding the built-in notification templates\ndefault to the value of the NOTIFY_TEMPLATES env var, if it is set")
+	flags.StringVar(&cfg.openapiResponseValidation, "openapi-response-validation", util.GetENV("OPENAPI_RESPONSE_VALIDATION", "log"), "how responses are validated against the OpenAPI spec in dev env, always off in prod env\nmust be one of [off, log, fail]\ndefault to log or the value of the OPENAPI_RESPONSE_VALIDATION env var, if it is set")
+
+	return cmd
+}
+
+func serve(cmd *cobra.Command, cfg *serveConfig) error {
+	ctx := cmd.Context()
+
+	logging.Init(cfg.appENV == "prod", cfg.logLevel)
+
+	responseValidation, err := validation.ParseResponseMode(cfg.openapiResponseValidation)
+	if err != nil {
+		return err
+	}
+	if cfg.appENV == "prod" {
+		responseValidation = validation.ResponseModeOff
+	}
+
+	notifySMTPTLS, err := notification.ParseTLSMode(cfg.notifySMTPTLS)
+	if err != nil {
+		return err
+	}
+
+	var notifyTemplates fs.FS
+	if cfg.notifyTemplates != "" {
+		notifyTemplates = os.DirFS(cfg.notifyTemplates)
+	}
+
+	database.Initialize(ctx, fmt.Sprintf("%s:%s", cfg.redisHost, cfg.redisPort), cfg.redisPassword)
+
+	stopped := api.NewServer(api.Config{
+		BatchMaxSize:       cfg.batchMaxSize,
+		ResponseValidation: responseValidation,
+		EventReplaySize:    cfg.eventReplaySize,
+		ChangeLogMaxLen:    cfg.changeLogMaxLen,
+		GRPCPort:           cfg.grpcPort,
+		GraphQL: graph.Limits{
+			MaxDepth:      cfg.graphQLMaxDepth,
+			MaxComplexity: cfg.graphQLMaxComplexity,
+		},
+		SMTPHost:         cfg.smtpHost,
+		SMTPPort:         cfg.smtpPort,
+		SMTPAllowlist:    strings.Split(cfg.smtpAllowlist, ","),
+		SMTPRateLimit:    cfg.smtpRateLimit,
+		SMTPRateInterval: cfg.smtpRateInterval,
+		Notification: notification.SMTPConfig{
+			Host:     cfg.notifySMTPHost,
+			Port:     cfg.notifySMTPPort,
+			TLS:      notifySMTPTLS,
+			Username: cfg.notifySMTPUsername,
+			Password: cfg.notifySMTPPassword,
+			From:     cfg.notifyFrom,
+		},
+		NotificationTemplates: notifyTemplates,
+	}).Start(ctx, cfg.appPort)
+	<-stopped
+
+	logging.Info("api stopped")
+
+	return nil
+}
@@ -0,0 +1,354 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/omegaatt36/gotasker/domain"
+
+	"github.com/spf13/cobra"
+)
+
+// taskView is the output of a task, in the format of the v2 API.
+type taskView struct {
+	ID        uint   `json:"id"`
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+	CreatedAt string `json:"created_at,omitempty"`
+	UpdatedAt string `json:"updated_at,omitempty"`
+}
+
+func newTaskView(t *domain.Task) taskView {
+	return taskView{
+		ID:        t.ID,
+		Name:      t.Name,
+		Status:    t.Status.String(),
+		CreatedAt: formatTime(t.CreatedAt),
+		UpdatedAt: formatTime(t.UpdatedAt),
+	}
+}
+
+// formatTime formats the time in RFC 3339, tasks created before timestamps
+// were recorded have none.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.UTC().Format(time.RFC3339)
+}
+
+// writeTasks writes the tasks as a table or a JSON array.
+func (o *options) writeTasks(w io.Writer, tasks []domain.Task) error {
+	views := make([]taskView, len(tasks))
+	for index := range tasks {
+		views[index] = newTaskView(&tasks[index])
+	}
+
+	return o.output.write(w, views, func(t *table) {
+		t.header("ID", "STATUS", "NAME", "UPDATED")
+		for _, view := range views {
+			t.row(strconv.FormatUint(uint64(view.ID), 10), view.Status, view.Name, view.UpdatedAt)
+		}
+	})
+}
+
+// writeTask writes the task as a table or a JSON object.
+func (o *options) writeTask(w io.Writer, task *domain.Task) error {
+	if o.output == outputJSON {
+		return o.output.write(w, newTaskView(task), nil)
+	}
+
+	return o.writeTasks(w, []domain.Task{*task})
+}
+
+func parseTaskID(arg string) (uint, error) {
+	id, err := strconv.ParseUint(arg, 10, 0)
+	if err != nil || id == 0 {
+		return 0, fmt.Errorf("%w: %q", domain.ErrInvalidTaskID, arg)
+	}
+
+	return uint(id), nil
+}
+
+func parseTaskIDs(args []string) ([]uint, error) {
+	ids := make([]uint, len(args))
+	for index, arg := range args {
+		id, err := parseTaskID(arg)
+		if err != nil {
+			return nil, err
+		}
+
+		ids[index] = id
+	}
+
+	return ids, nil
+}
+
+func newTaskCommand(o *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "task",
+		Aliases: []string{"tasks"},
+		Short:   "Manage the tasks of a running server",
+	}
+
+	o.addClientFlags(cmd)
+	o.addOutputFlag(cmd)
+
+	cmd.AddCommand(
+		newTaskAddCommand(o),
+		newTaskListCommand(o),
+		newTaskDoneCommand(o),
+		newTaskEditCommand(o),
+		newTaskRemoveCommand(o),
+		newTaskExportCommand(o),
+		newTaskImportCommand(o),
+	)
+
+	return cmd
+}
+
+func newTaskAddCommand(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:     "add NAME...",
+		Short:   "Create a task, the arguments are joined as its name",
+		Example: "  gotasker task add buy milk",
+		Args:    cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := o.client()
+			if err != nil {
+				return err
+			}
+
+			created, err := c.CreateTask(cmd.Context(), domain.CreateTaskRequest{Name: strings.Join(args, " ")})
+			if err != nil {
+				return err
+			}
+
+			return o.writeTask(cmd.OutOrStdout(), &created)
+		},
+	}
+}
+
+func newTaskListCommand(o *options) *cobra.Command {
+	var (
+		status string
+		search string
+	)
+
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List tasks",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			filter := domain.TaskFilter{NameContains: search}
+			if status != "" {
+				parsed, err := domain.ParseTaskStatus(status)
+				if err != nil {
+					return err
+				}
+
+				filter.Status = &parsed
+			}
+
+			c, err := o.client()
+			if err != nil {
+				return err
+			}
+
+			tasks, err := c.ListTasks(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			matched := make([]domain.Task, 0, len(tasks))
+			for index := range tasks {
+				if filter.Match(&tasks[index]) {
+					matched = append(matched, tasks[index])
+				}
+			}
+
+			return o.writeTasks(cmd.OutOrStdout(), matched)
+		},
+	}
+
+	cmd.Flags().StringVar(&status, "status", "", "list only tasks of the status")
+	cmd.Flags().StringVar(&search, "search", "", "list only tasks whose name contains the text")
+	_ = cmd.RegisterFlagCompletionFunc("status", cobra.FixedCompletions(taskStatusNames(), cobra.ShellCompDirectiveNoFileComp))
+
+	return cmd
+}
+
+func newTaskDoneCommand(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:               "done ID...",
+		Short:             "Complete tasks",
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: o.completeTaskIDs(domain.TaskStatusIncomplete),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ids, err := parseTaskIDs(args)
+			if err != nil {
+				return err
+			}
+
+			c, err := o.client()
+			if err != nil {
+				return err
+			}
+
+			completed := domain.TaskStatusCompleted
+			tasks := make([]domain.Task, len(ids))
+			for index, id := range ids {
+				if err := c.UpdateTask(cmd.Context(), id, domain.UpdateTaskRequest{Status: &completed}); err != nil {
+					return fmt.Errorf("task %d: %w", id, err)
+				}
+
+				if tasks[index], err = c.GetTask(cmd.Context(), id); err != nil {
+					return fmt.Errorf("task %d: %w", id, err)
+				}
+			}
+
+			return o.writeTasks(cmd.OutOrStdout(), tasks)
+		},
+	}
+}
+
+func newTaskEditCommand(o *options) *cobra.Command {
+	var (
+		name   string
+		status string
+	)
+
+	cmd := &cobra.Command{
+		Use:     "edit ID",
+		Short:   "Update the name or status of a task",
+		Example: "  gotasker task edit 1 --name \"buy oat milk\" --status incomplete",
+		Args:    cobra.ExactArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) > 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+
+			return o.completeTaskIDs()(cmd, args, toComplete)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseTaskID(args[0])
+			if err != nil {
+				return err
+			}
+
+			var req domain.UpdateTaskRequest
+			if cmd.Flags().Changed("name") {
+				req.Name = &name
+			}
+			if cmd.Flags().Changed("status") {
+				parsed, err := domain.ParseTaskStatus(status)
+				if err != nil {
+					return err
+				}
+
+				req.Status = &parsed
+			}
+			if req.Name == nil && req.Status == nil {
+				return errors.New("nothing to edit, set --name or --status")
+			}
+
+			c, err := o.client()
+			if err != nil {
+				return err
+			}
+
+			if err := c.UpdateTask(cmd.Context(), id, req); err != nil {
+				return err
+			}
+
+			updated, err := c.GetTask(cmd.Context(), id)
+			if err != nil {
+				return err
+			}
+
+			return o.writeTask(cmd.OutOrStdout(), &updated)
+		},
+	}
+
+	cmd.Flags().StringVar(&name, "name", "", "new name of the task")
+	cmd.Flags().StringVar(&status, "status", "", "new status of the task")
+	_ = cmd.RegisterFlagCompletionFunc("status", cobra.FixedCompletions(taskStatusNames(), cobra.ShellCompDirectiveNoFileComp))
+
+	return cmd
+}
+
+func newTaskRemoveCommand(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:               "rm ID...",
+		Aliases:           []string{"delete"},
+		Short:             "Delete tasks",
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: o.completeTaskIDs(),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ids, err := parseTaskIDs(args)
+			if err != nil {
+				return err
+			}
+
+			c, err := o.client()
+			if err != nil {
+				return err
+			}
+
+			for _, id := range ids {
+				if err := c.DeleteTask(cmd.Context(), id); err != nil {
+					return fmt.Errorf("task %d: %w", id, err)
+				}
+			}
+
+			return nil
+		},
+	}
+}
+
+func taskStatusNames() []string {
+	statuses := domain.TaskStatusValues()
+	names := make([]string, len(statuses))
+	for index, status := range statuses {
+		names[index] = status.String()
+	}
+
+	return names
+}
+
+// completeTaskIDs completes the IDs of the tasks of the statuses, or of all
+// tasks, described by their names.
+func (o *options) completeTaskIDs(statuses ...domain.TaskStatus) cobra.CompletionFunc {
+	return func(cmd *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+		c, err := o.client()
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+
+		tasks, err := c.ListTasks(cmd.Context())
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+
+		var completions []string
+		for _, t := range tasks {
+			if len(statuses) > 0 && !slices.Contains(statuses, t.Status) {
+				continue
+			}
+			if slices.Contains(args, strconv.FormatUint(uint64(t.ID), 10)) {
+				continue
+			}
+
+			completions = append(completions, fmt.Sprintf("%d\t%s", t.ID, t.Name))
+		}
+
+		return completions, cobra.ShellCompDirectiveNoFileComp
+	}
+}
@@ -0,0 +1,183 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/omegaatt36/gotasker/client"
+
+	"github.com/spf13/cobra"
+)
+
+func newTaskwarriorCommand(o *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "tw",
+		Aliases: []string{"taskwarrior"},
+		Short:   "Exchange tasks with Taskwarrior",
+		Long: "Exchange tasks with Taskwarrior by the JSON of `task export` and `task import`.\n" +
+			"Tasks are mapped by their UUIDs, so importing an export again updates the tasks rather than duplicating them.",
+	}
+
+	o.addClientFlags(cmd)
+	o.addOutputFlag(cmd)
+
+	cmd.AddCommand(
+		newTaskwarriorExportCommand(o),
+		newTaskwarriorImportCommand(o),
+	)
+
+	return cmd
+}
+
+func newTaskwarriorExportCommand(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:     "export [FILE]",
+		Short:   "Export all tasks as Taskwarrior JSON",
+		Long:    "Export all tasks as Taskwarrior JSON to the file, or to the standard output.",
+		Example: "  gotasker tw export tasks.json\n  gotasker tw export | task import",
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := o.client()
+			if err != nil {
+				return err
+			}
+
+			if len(args) == 0 {
+				return c.ExportTaskwarriorTasks(cmd.Context(), cmd.OutOrStdout())
+			}
+
+			f, err := os.Create(args[0])
+			if err != nil {
+				return err
+			}
+
+			if err := c.ExportTaskwarriorTasks(cmd.Context(), f); err != nil {
+				_ = f.Close()
+				return err
+			}
+
+			return f.Close()
+		},
+	}
+}
+
+// taskwarriorImportReportView is the output of a Taskwarrior import report.
+type taskwarriorImportReportView struct {
+	DryRun      bool                       `json:"dry_run"`
+	Total       int                        `json:"total"`
+	Created     int                        `json:"created"`
+	Overwritten int                        `json:"overwritten"`
+	Deleted     int                        `json:"deleted"`
+	Skipped     int                        `json:"skipped"`
+	Failed      int                        `json:"failed"`
+	Rows        []taskwarriorImportRowView `json:"rows"`
+}
+
+type taskwarriorImportRowView struct {
+	Index  int    `json:"index"`
+	UUID   string `json:"uuid,omitempty"`
+	Action string `json:"action,omitempty"`
+	TaskID uint   `json:"task_id,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+func newTaskwarriorImportReportView(report *client.TaskwarriorImportReport) taskwarriorImportReportView {
+	view := taskwarriorImportReportView{
+		DryRun:      report.DryRun,
+		Total:       report.Total,
+		Created:     report.Created,
+		Overwritten: report.Overwritten,
+		Deleted:     report.Deleted,
+		Skipped:     report.Skipped,
+		Failed:      report.Failed,
+		Rows:        make([]taskwarriorImportRowView, len(report.Rows)),
+	}
+
+	for index := range report.Rows {
+		row := &report.Rows[index]
+		view.Rows[index] = taskwarriorImportRowView{Index: row.Index, UUID: row.UUID, TaskID: row.TaskID, Error: row.Error}
+		if row.Error == "" {
+			view.Rows[index].Action = row.Action.String()
+		}
+	}
+
+	return view
+}
+
+// writeTaskwarriorImportReport writes the report as a table of the tasks
+// followed by a summary, or as a JSON object.
+func (o *options) writeTaskwarriorImportReport(w io.Writer, report *client.TaskwarriorImportReport) error {
+	view := newTaskwarriorImportReportView(report)
+	if err := o.output.write(w, view, func(t *table) {
+		t.header("INDEX", "ACTION", "UUID", "ID", "ERROR")
+		for _, row := range view.Rows {
+			var id string
+			if row.TaskID > 0 {
+				id = strconv.FormatUint(uint64(row.TaskID), 10)
+			}
+
+			t.row(strconv.Itoa(row.Index), row.Action, row.UUID, id, row.Error)
+		}
+	}); err != nil {
+		return err
+	}
+
+	if o.output == outputJSON {
+		return nil
+	}
+
+	summary := fmt.Sprintf("%d tasks: %d created, %d overwritten, %d deleted, %d skipped, %d failed",
+		view.Total, view.Created, view.Overwritten, view.Deleted, view.Skipped, view.Failed)
+	if view.DryRun {
+		summary += " (dry run)"
+	}
+
+	_, err := fmt.Fprintln(w, summary)
+	return err
+}
+
+func newTaskwarriorImportCommand(o *options) *cobra.Command {
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "import FILE",
+		Short: "Import tasks from Taskwarrior JSON",
+		Long: "Import the tasks of a Taskwarrior export, - reads the standard input.\n" +
+			"Tasks of known UUIDs update their tasks, the others are created, and deleted ones delete their tasks.\n" +
+			"Any invalid task fails the import, the report tells the index and error of each of them.",
+		Example: "  gotasker tw import tasks.json\n  task export | gotasker tw import --dry-run -",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			r := cmd.InOrStdin()
+			if args[0] != "-" {
+				f, err := os.Open(args[0])
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+
+				r = f
+			}
+
+			c, err := o.client()
+			if err != nil {
+				return err
+			}
+
+			report, err := c.ImportTaskwarriorTasks(cmd.Context(), r, dryRun)
+			if report.Rows != nil {
+				if err := o.writeTaskwarriorImportReport(cmd.OutOrStdout(), &report); err != nil {
+					return err
+				}
+			}
+
+			return err
+		},
+	}
+
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "only validate the tasks and report what the import would do")
+
+	return cmd
+}
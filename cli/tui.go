@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"github.com/omegaatt36/gotasker/tui"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+)
+
+func newTUICommand(o *options) *cobra.Command {
+	refreshInterval := tui.DefaultRefreshInterval
+
+	cmd := &cobra.Command{
+		Use:   "tui",
+		Short: "Manage the tasks of a running server in an interactive terminal UI",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			c, err := o.client()
+			if err != nil {
+				return err
+			}
+
+			model := tui.New(cmd.Context(), c, tui.WithRefreshInterval(refreshInterval))
+			_, err = tea.NewProgram(model,
+				tea.WithAltScreen(),
+				tea.WithContext(cmd.Context()),
+				tea.WithInput(cmd.InOrStdin()),
+				tea.WithOutput(cmd.OutOrStdout()),
+			).Run()
+
+			return err
+		},
+	}
+
+	o.addClientFlags(cmd)
+	cmd.Flags().DurationVar(&refreshInterval, "refresh", refreshInterval, "interval of reloading the tasks from the server, 0 disables reloading")
+
+	return cmd
+}
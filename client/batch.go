@@ -0,0 +1,70 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/omegaatt36/gotasker/domain"
+)
+
+// batchOperation defines DTO for domain.BatchOperation.
+type batchOperation struct {
+	Op     string  `json:"op"`
+	ID     uint    `json:"id,omitempty"`
+	Name   *string `json:"name,omitempty"`
+	Status string  `json:"status,omitempty"`
+}
+
+// batchResponse defines DTO for the results of a batch.
+type batchResponse struct {
+	Results []struct {
+		Status int    `json:"status"`
+		Task   *task  `json:"task"`
+		Error  string `json:"error"`
+	} `json:"results"`
+}
+
+// BatchTasks applies the operations atomically, all of them or none. Failed
+// operations are reported by an Error in their results, the others by
+// domain.ErrBatchRolledBack if any of them failed.
+func (c *Client) BatchTasks(ctx context.Context, ops []domain.BatchOperation) ([]domain.BatchResult, error) {
+	operations := make([]batchOperation, len(ops))
+	for index, op := range ops {
+		operations[index] = batchOperation{Op: op.Type.String(), ID: op.ID}
+		switch op.Type {
+		case domain.BatchOperationTypeCreate:
+			operations[index].Name = &op.Create.Name
+		case domain.BatchOperationTypeUpdate:
+			operations[index].Name = op.Update.Name
+			if op.Update.Status != nil {
+				operations[index].Status = op.Update.Status.String()
+			}
+		}
+	}
+
+	var resp batchResponse
+	if err := c.do(ctx, http.MethodPost, "/v2/tasks/batch", map[string]any{
+		"mode":       "atomic",
+		"operations": operations,
+	}, &resp); err != nil {
+		return nil, err
+	}
+
+	results := make([]domain.BatchResult, len(resp.Results))
+	for index, result := range resp.Results {
+		if result.Error != "" {
+			results[index].Err = &Error{StatusCode: result.Status, Message: result.Error}
+			continue
+		}
+
+		if result.Task != nil {
+			t, err := result.Task.toDomain()
+			if err != nil {
+				return nil, err
+			}
+			results[index].Task = t
+		}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,341 @@
+// Package client is a Go client of the gotasker v2 REST API.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/omegaatt36/gotasker/domain"
+	"github.com/omegaatt36/gotasker/service/delivery"
+)
+
+// default client settings.
+const (
+	DefaultMaxRetries = 3
+	DefaultBackoff    = 100 * time.Millisecond
+	DefaultMaxBackoff = 5 * time.Second
+	DefaultTimeout    = 30 * time.Second
+)
+
+// Client is a client of the task API.
+type Client struct {
+	baseURL *url.URL
+	client  *http.Client
+	header  http.Header
+
+	maxRetries int
+	backoff    delivery.Backoff
+}
+
+// Option configures a client.
+type Option func(*Client)
+
+// WithHTTPClient sets the client sending requests.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Client) {
+		if client != nil {
+			c.client = client
+		}
+	}
+}
+
+// WithHeader sets a header sent with every request.
+func WithHeader(key, value string) Option {
+	return func(c *Client) {
+		c.header.Set(key, value)
+	}
+}
+
+// WithBearerToken sets the Authorization header of every request to the
+// bearer token.
+func WithBearerToken(token string) Option {
+	return WithHeader("Authorization", "Bearer "+token)
+}
+
+// WithMaxRetries sets the number of retries of a failed request, zero
+// disables retries.
+func WithMaxRetries(retries int) Option {
+	return func(c *Client) {
+		if retries >= 0 {
+			c.maxRetries = retries
+		}
+	}
+}
+
+// WithBackoff sets the backoff of retried requests, see delivery.Backoff.
+func WithBackoff(base, limit time.Duration) Option {
+	return func(c *Client) {
+		c.backoff = c.backoff.With(base, limit)
+	}
+}
+
+// New creates a new client of the server at the base URL, e.g.
+// http://localhost:8070.
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse base url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("base url must be an absolute http or https url: %q", baseURL)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+
+	c := &Client{
+		baseURL:    u,
+		client:     &http.Client{Timeout: DefaultTimeout},
+		header:     make(http.Header),
+		maxRetries: DefaultMaxRetries,
+		backoff:    delivery.Backoff{Base: DefaultBackoff, Max: DefaultMaxBackoff},
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c, nil
+}
+
+// task defines DTO for domain.Task of the v2 API.
+type task struct {
+	ID        uint   `json:"id"`
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+	CreatedAt string `json:"created_at,omitempty"`
+	UpdatedAt string `json:"updated_at,omitempty"`
+	Notes     string `json:"notes,omitempty"`
+}
+
+func (t *task) toDomain() (domain.Task, error) {
+	status, err := domain.ParseTaskStatus(t.Status)
+	if err != nil {
+		return domain.Task{}, err
+	}
+
+	createdAt, err := parseTime(t.CreatedAt)
+	if err != nil {
+		return domain.Task{}, err
+	}
+
+	updatedAt, err := parseTime(t.UpdatedAt)
+	if err != nil {
+		return domain.Task{}, err
+	}
+
+	return domain.Task{
+		ID:        t.ID,
+		Name:      t.Name,
+		Status:    status,
+		CreatedAt: createdAt,
+		UpdatedAt: updatedAt,
+		Notes:     t.Notes,
+	}, nil
+}
+
+// parseTime parses the time in RFC 3339, tasks created before timestamps
+// were recorded have none.
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	return time.Parse(time.RFC3339, value)
+}
+
+// ListTasks lists all tasks ordered by ID.
+func (c *Client) ListTasks(ctx context.Context) ([]domain.Task, error) {
+	var tasks []task
+	if err := c.do(ctx, http.MethodGet, "/v2/tasks", nil, &tasks); err != nil {
+		return nil, err
+	}
+
+	domainTasks := make([]domain.Task, len(tasks))
+	for index := range tasks {
+		domainTask, err := tasks[index].toDomain()
+		if err != nil {
+			return nil, err
+		}
+
+		domainTasks[index] = domainTask
+	}
+
+	return domainTasks, nil
+}
+
+// GetTask gets a task.
+func (c *Client) GetTask(ctx context.Context, id uint) (domain.Task, error) {
+	if id == 0 {
+		return domain.Task{}, domain.ErrInvalidTaskID
+	}
+
+	var t task
+	if err := c.do(ctx, http.MethodGet, taskPath(id), nil, &t); err != nil {
+		return domain.Task{}, err
+	}
+
+	return t.toDomain()
+}
+
+// createTaskRequest defines DTO for domain.CreateTaskRequest.
+type createTaskRequest struct {
+	Name  string `json:"name"`
+	Notes string `json:"notes,omitempty"`
+}
+
+// CreateTask creates a new task.
+func (c *Client) CreateTask(ctx context.Context, req domain.CreateTaskRequest) (domain.Task, error) {
+	body := createTaskRequest{Name: req.Name, Notes: req.Notes}
+
+	var t task
+	if err := c.do(ctx, http.MethodPost, "/v2/tasks", body, &t); err != nil {
+		return domain.Task{}, err
+	}
+
+	return t.toDomain()
+}
+
+// UpdateTask updates a task, nil fields of the request are left unchanged.
+func (c *Client) UpdateTask(ctx context.Context, id uint, req domain.UpdateTaskRequest) error {
+	if id == 0 {
+		return domain.ErrInvalidTaskID
+	}
+
+	body := make(map[string]any, 2)
+	if req.Name != nil {
+		body["name"] = *req.Name
+	}
+	if req.Status != nil {
+		body["status"] = req.Status.String()
+	}
+
+	return c.do(ctx, http.MethodPut, taskPath(id), body, nil)
+}
+
+// DeleteTask deletes a task.
+func (c *Client) DeleteTask(ctx context.Context, id uint) error {
+	if id == 0 {
+		return domain.ErrInvalidTaskID
+	}
+
+	return c.do(ctx, http.MethodDelete, taskPath(id), nil, nil)
+}
+
+func taskPath(id uint) string {
+	return fmt.Sprintf("/v2/tasks/%d", id)
+}
+
+// do sends the request with the JSON body and decodes the JSON response into
+// out, unless it's nil.
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	req := request{method: method, path: path, accept: "application/json"}
+	if body != nil {
+		bs, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+
+		req.contentType, req.body = "application/json", bs
+	}
+
+	resp, err := c.open(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	return decodeResponse(resp, out)
+}
+
+// request defines a request, the body is sent as it is.
+type request struct {
+	method      string
+	path        string
+	contentType string
+	body        []byte
+	accept      string
+	// reported are the statuses of error responses which are returned
+	// instead of read as an Error.
+	reported []int
+}
+
+// open sends the request and returns the response, error responses are read
+// as an Error. Idempotent requests failing with 5xx or in transport are
+// retried, POST requests aren't as they may have been applied.
+func (c *Client) open(ctx context.Context, req request) (*http.Response, error) {
+	idempotent := req.method != http.MethodPost
+	for attempt := 0; ; attempt++ {
+		resp, err := c.send(ctx, &req)
+
+		retryable := false
+		switch {
+		case err != nil:
+			retryable = ctx.Err() == nil && idempotent
+		case slices.Contains(req.reported, resp.StatusCode):
+			return resp, nil
+		case resp.StatusCode >= http.StatusInternalServerError:
+			retryable = idempotent
+			err = readError(resp)
+		case resp.StatusCode >= http.StatusBadRequest:
+			return nil, readError(resp)
+		default:
+			return resp, nil
+		}
+
+		if !retryable || attempt >= c.maxRetries {
+			return nil, err
+		}
+
+		timer := time.NewTimer(c.backoff.After(attempt + 1))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, errors.Join(err, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+func (c *Client) send(ctx context.Context, r *request) (*http.Response, error) {
+	var body io.Reader
+	if r.body != nil {
+		body = bytes.NewReader(r.body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, r.method, c.baseURL.String()+r.path, body)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, values := range c.header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Accept", r.accept)
+	if r.body != nil {
+		req.Header.Set("Content-Type", r.contentType)
+	}
+
+	return c.client.Do(req)
+}
+
+func decodeResponse(resp *http.Response, out any) error {
+	defer resp.Body.Close()
+
+	if out == nil {
+		_, err := io.Copy(io.Discard, resp.Body)
+		return err
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+
+	return nil
+}
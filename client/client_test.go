@@ -0,0 +1,262 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/omegaatt36/gotasker/api"
+	"github.com/omegaatt36/gotasker/api/validation"
+	"github.com/omegaatt36/gotasker/client"
+	"github.com/omegaatt36/gotasker/domain"
+	"github.com/omegaatt36/gotasker/logging"
+	"github.com/omegaatt36/gotasker/persistance/database"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+)
+
+// proxy records the requests to the server and fails the queued number of
+// them with 503.
+type proxy struct {
+	handler http.Handler
+
+	mu       sync.Mutex
+	failures int
+	requests []*http.Request
+}
+
+func (p *proxy) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	p.mu.Lock()
+	p.requests = append(p.requests, req)
+	fail := p.failures > 0
+	if fail {
+		p.failures--
+	}
+	p.mu.Unlock()
+
+	if fail {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	p.handler.ServeHTTP(w, req)
+}
+
+func (p *proxy) fail(times int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.failures = times
+	p.requests = nil
+}
+
+func (p *proxy) requestCount() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return len(p.requests)
+}
+
+type ClientSuite struct {
+	suite.Suite
+
+	miniredis *miniredis.Miniredis
+	proxy     *proxy
+	server    *httptest.Server
+	client    *client.Client
+}
+
+func (s *ClientSuite) SetupSuite() {
+	gin.SetMode(gin.TestMode)
+	logging.Init(false, "error")
+}
+
+func (s *ClientSuite) SetupTest() {
+	s.miniredis = database.InitializeTestingRedis()
+	database.Initialize(context.Background(), s.miniredis.Addr(), "")
+
+	s.proxy = &proxy{handler: api.NewServer(api.Config{ResponseValidation: validation.ResponseModeFail}).Handler()}
+	s.server = httptest.NewServer(s.proxy)
+
+	c, err := client.New(s.server.URL+"/",
+		client.WithBearerToken("secret"),
+		client.WithHeader("X-Client", "test"),
+		client.WithBackoff(time.Millisecond, 5*time.Millisecond),
+	)
+	s.Require().NoError(err)
+	s.client = c
+}
+
+func (s *ClientSuite) TearDownTest() {
+	s.server.Close()
+	s.miniredis.Close()
+}
+
+func (s *ClientSuite) TestNew() {
+	for _, baseURL := range []string{"", "localhost:8070", "ftp://localhost", "http://"} {
+		_, err := client.New(baseURL)
+		s.Error(err, baseURL)
+	}
+}
+
+func (s *ClientSuite) TestTasks() {
+	ctx := context.Background()
+
+	created, err := s.client.CreateTask(ctx, domain.CreateTaskRequest{Name: "task 1", Notes: "notes 1"})
+	s.Require().NoError(err)
+	s.Equal(uint(1), created.ID)
+	s.Equal("task 1", created.Name)
+	s.Equal("notes 1", created.Notes)
+	s.Equal(domain.TaskStatusIncomplete, created.Status)
+	s.False(created.CreatedAt.IsZero())
+
+	name := "task 1 - updated"
+	status := domain.TaskStatusCompleted
+	s.Require().NoError(s.client.UpdateTask(ctx, created.ID, domain.UpdateTaskRequest{Name: &name, Status: &status}))
+
+	got, err := s.client.GetTask(ctx, created.ID)
+	s.Require().NoError(err)
+	s.Equal(name, got.Name)
+	s.Equal(domain.TaskStatusCompleted, got.Status)
+	s.Equal("notes 1", got.Notes)
+
+	_, err = s.client.CreateTask(ctx, domain.CreateTaskRequest{Name: "task 2"})
+	s.Require().NoError(err)
+
+	tasks, err := s.client.ListTasks(ctx)
+	s.Require().NoError(err)
+	s.Require().Len(tasks, 2)
+	s.Equal(got, tasks[0])
+	s.Equal("task 2", tasks[1].Name)
+
+	s.Require().NoError(s.client.DeleteTask(ctx, created.ID))
+	tasks, err = s.client.ListTasks(ctx)
+	s.Require().NoError(err)
+	s.Len(tasks, 1)
+
+	s.Equal("Bearer secret", s.proxy.requests[0].Header.Get("Authorization"))
+	s.Equal("test", s.proxy.requests[0].Header.Get("X-Client"))
+}
+
+func (s *ClientSuite) TestErrors() {
+	ctx := context.Background()
+
+	_, err := s.client.GetTask(ctx, 1)
+	s.ErrorIs(err, domain.ErrTaskNotFound)
+
+	var apiErr *client.Error
+	s.Require().ErrorAs(err, &apiErr)
+	s.Equal(http.StatusNotFound, apiErr.StatusCode)
+	s.False(apiErr.Temporary())
+
+	s.ErrorIs(s.client.DeleteTask(ctx, 1), domain.ErrTaskNotFound)
+	s.ErrorIs(s.client.UpdateTask(ctx, 0, domain.UpdateTaskRequest{}), domain.ErrInvalidTaskID)
+	s.Equal(2, s.proxy.requestCount(), "invalid IDs are not sent")
+
+	_, err = s.client.CreateTask(ctx, domain.CreateTaskRequest{})
+	s.Require().ErrorAs(err, &apiErr)
+	s.Equal(http.StatusBadRequest, apiErr.StatusCode)
+	s.NotErrorIs(err, domain.ErrTaskNotFound)
+}
+
+func (s *ClientSuite) TestBatchTasks() {
+	ctx := context.Background()
+
+	created, err := s.client.CreateTask(ctx, domain.CreateTaskRequest{Name: "task 1"})
+	s.Require().NoError(err)
+
+	name := "task 1 - updated"
+	status := domain.TaskStatusCompleted
+	results, err := s.client.BatchTasks(ctx, []domain.BatchOperation{
+		{Type: domain.BatchOperationTypeUpdate, ID: created.ID, Update: domain.UpdateTaskRequest{Name: &name, Status: &status}},
+		{Type: domain.BatchOperationTypeCreate, Create: domain.CreateTaskRequest{Name: "task 2"}},
+	})
+	s.Require().NoError(err)
+	s.Require().Len(results, 2)
+	s.NoError(results[0].Err)
+	s.Equal(name, results[0].Task.Name)
+	s.Equal(domain.TaskStatusCompleted, results[0].Task.Status)
+	s.Equal("task 2", results[1].Task.Name)
+
+	results, err = s.client.BatchTasks(ctx, []domain.BatchOperation{
+		{Type: domain.BatchOperationTypeCreate, Create: domain.CreateTaskRequest{Name: "task 3"}},
+		{Type: domain.BatchOperationTypeDelete, ID: 10},
+	})
+	s.Require().NoError(err)
+	s.Require().Len(results, 2)
+	s.ErrorIs(results[0].Err, domain.ErrBatchRolledBack)
+	s.ErrorIs(results[1].Err, domain.ErrTaskNotFound)
+
+	tasks, err := s.client.ListTasks(ctx)
+	s.Require().NoError(err)
+	s.Len(tasks, 2, "a failed batch writes nothing")
+}
+
+func (s *ClientSuite) TestRetries() {
+	ctx := context.Background()
+
+	s.proxy.fail(1)
+	_, err := s.client.CreateTask(ctx, domain.CreateTaskRequest{Name: "task 1"})
+	var apiErr *client.Error
+	s.Require().ErrorAs(err, &apiErr)
+	s.Equal(http.StatusServiceUnavailable, apiErr.StatusCode)
+	s.Equal(1, s.proxy.requestCount(), "POST requests may have been applied and aren't retried")
+
+	created, err := s.client.CreateTask(ctx, domain.CreateTaskRequest{Name: "task 1"})
+	s.Require().NoError(err)
+
+	s.proxy.fail(2)
+	got, err := s.client.GetTask(ctx, created.ID)
+	s.Require().NoError(err)
+	s.Equal(3, s.proxy.requestCount())
+	s.Equal(created.ID, got.ID)
+
+	s.proxy.fail(10)
+	_, err = s.client.GetTask(ctx, created.ID)
+	s.Require().ErrorAs(err, &apiErr)
+	s.Equal(http.StatusServiceUnavailable, apiErr.StatusCode)
+	s.True(apiErr.Temporary())
+	s.Equal(client.DefaultMaxRetries+1, s.proxy.requestCount())
+
+	s.T().Run("canceled", func(t *testing.T) {
+		c, err := client.New(s.server.URL, client.WithBackoff(time.Hour, time.Hour))
+		s.Require().NoError(err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		s.proxy.fail(10)
+		_, err = c.ListTasks(ctx)
+		s.ErrorIs(err, context.DeadlineExceeded)
+		s.Equal(1, s.proxy.requestCount())
+	})
+
+	s.T().Run("disabled", func(t *testing.T) {
+		c, err := client.New(s.server.URL, client.WithMaxRetries(0))
+		s.Require().NoError(err)
+
+		s.proxy.fail(10)
+		_, err = c.ListTasks(ctx)
+		s.Error(err)
+		s.Equal(1, s.proxy.requestCount())
+	})
+
+	s.T().Run("transport", func(t *testing.T) {
+		c, err := client.New("http://127.0.0.1:1", client.WithBackoff(time.Millisecond, time.Millisecond))
+		s.Require().NoError(err)
+
+		_, err = c.ListTasks(ctx)
+		s.Error(err)
+		s.False(errors.Is(err, domain.ErrTaskNotFound))
+	})
+}
+
+func TestClient(t *testing.T) {
+	suite.Run(t, new(ClientSuite))
+}
@@ -0,0 +1,163 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/omegaatt36/gotasker/domain"
+	"github.com/omegaatt36/gotasker/format/dump"
+)
+
+// ExportTasks writes the dump of all tasks in the format to w, as it's
+// streamed by the server. Tasks are in no particular order.
+func (c *Client) ExportTasks(ctx context.Context, w io.Writer, format dump.Format) error {
+	resp, err := c.open(ctx, request{
+		method: http.MethodGet,
+		path:   "/v2/tasks/export?format=" + url.QueryEscape(string(format)),
+		accept: format.MediaType(),
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return fmt.Errorf("read export: %w", err)
+	}
+
+	return nil
+}
+
+// ImportOptions defines the options of importing tasks.
+type ImportOptions struct {
+	// DryRun only validates the records and reports what the import would
+	// do.
+	DryRun bool
+	// PreserveIDs keeps the IDs of the records.
+	PreserveIDs bool
+	// Conflict is the strategy for preserved IDs of existing tasks.
+	Conflict domain.ImportConflict
+}
+
+// ImportReport is the report of an import.
+type ImportReport struct {
+	DryRun      bool
+	Total       int
+	Created     int
+	Overwritten int
+	Skipped     int
+	// Failed is the number of invalid records.
+	Failed int
+	Rows   []ImportRow
+}
+
+// ImportRow is the result of importing a single record.
+type ImportRow struct {
+	Line int
+	// Action is set if the record is imported.
+	Action domain.ImportAction
+	// Task is the task of the record, nil if the record can't be decoded.
+	// Tasks created in a dry run have no ID yet.
+	Task  *domain.Task
+	Error string
+}
+
+// importReport defines DTO for ImportReport.
+type importReport struct {
+	DryRun      bool `json:"dry_run"`
+	Total       int  `json:"total"`
+	Created     int  `json:"created"`
+	Overwritten int  `json:"overwritten"`
+	Skipped     int  `json:"skipped"`
+	Failed      int  `json:"failed"`
+	Rows        []struct {
+		Line   int    `json:"line"`
+		Action string `json:"action"`
+		Task   *task  `json:"task"`
+		Error  string `json:"error"`
+	} `json:"rows"`
+}
+
+func (r *importReport) toReport() (ImportReport, error) {
+	report := ImportReport{
+		DryRun:      r.DryRun,
+		Total:       r.Total,
+		Created:     r.Created,
+		Overwritten: r.Overwritten,
+		Skipped:     r.Skipped,
+		Failed:      r.Failed,
+		Rows:        make([]ImportRow, len(r.Rows)),
+	}
+
+	for index, row := range r.Rows {
+		report.Rows[index] = ImportRow{Line: row.Line, Error: row.Error}
+		if row.Task != nil {
+			t, err := row.Task.toDomain()
+			if err != nil {
+				return ImportReport{}, err
+			}
+			report.Rows[index].Task = &t
+		}
+		if row.Error != "" {
+			continue
+		}
+
+		var err error
+		if report.Rows[index].Action, err = domain.ParseImportAction(row.Action); err != nil {
+			return ImportReport{}, err
+		}
+	}
+
+	return report, nil
+}
+
+// ImportTasks imports the tasks of the dump in the format atomically. The
+// report of a failed import is returned along with an Error of 422
+// Unprocessable Entity, no task is written then.
+func (c *Client) ImportTasks(ctx context.Context, r io.Reader, format dump.Format, opts ImportOptions) (ImportReport, error) {
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return ImportReport{}, fmt.Errorf("read dump: %w", err)
+	}
+
+	query := url.Values{}
+	query.Set("format", string(format))
+	query.Set("dry_run", strconv.FormatBool(opts.DryRun))
+	query.Set("preserve_ids", strconv.FormatBool(opts.PreserveIDs))
+	query.Set("conflict", opts.Conflict.String())
+
+	resp, err := c.open(ctx, request{
+		method:      http.MethodPost,
+		path:        "/v2/tasks/import?" + query.Encode(),
+		contentType: format.MediaType(),
+		body:        body,
+		accept:      "application/json",
+		reported:    []int{http.StatusUnprocessableEntity},
+	})
+	if err != nil {
+		return ImportReport{}, err
+	}
+
+	var dto importReport
+	if err := decodeResponse(resp, &dto); err != nil {
+		return ImportReport{}, err
+	}
+
+	report, err := dto.toReport()
+	if err != nil {
+		return ImportReport{}, err
+	}
+
+	if resp.StatusCode == http.StatusUnprocessableEntity {
+		return report, &Error{
+			StatusCode: resp.StatusCode,
+			Message:    fmt.Sprintf("%d of %d records are invalid", report.Failed, report.Total),
+		}
+	}
+
+	return report, nil
+}
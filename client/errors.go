@@ -0,0 +1,73 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/omegaatt36/gotasker/domain"
+)
+
+// maxErrorBodySize is the number of bytes of error responses read.
+const maxErrorBodySize = 64 << 10
+
+// knownErrors are the errors of the API matched by the message of error
+// responses.
+var knownErrors = []error{
+	domain.ErrTaskNotFound,
+	domain.ErrInvalidTaskID,
+	domain.ErrInvalidTaskStatus,
+	domain.ErrBatchRolledBack,
+}
+
+// Error is an error response of the API. Errors of the domain package are
+// matched by errors.Is, e.g. errors.Is(err, domain.ErrTaskNotFound).
+type Error struct {
+	StatusCode int
+	// Message is the error message of the response.
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("gotasker: %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// Unwrap returns the error of the domain package reported by the response.
+func (e *Error) Unwrap() error {
+	for _, known := range knownErrors {
+		if e.Message == known.Error() {
+			return known
+		}
+	}
+
+	return nil
+}
+
+// Temporary reports whether the request may succeed if retried.
+func (e *Error) Temporary() bool {
+	return e.StatusCode >= http.StatusInternalServerError
+}
+
+// readError reads the error response, the message is a JSON string or the
+// plain body.
+func readError(resp *http.Response) error {
+	defer resp.Body.Close()
+
+	bs, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	if err != nil {
+		return fmt.Errorf("read error response: %w", err)
+	}
+
+	message := strings.TrimSpace(string(bs))
+	var decoded string
+	if err := json.Unmarshal(bs, &decoded); err == nil {
+		message = decoded
+	}
+
+	return &Error{
+		StatusCode: resp.StatusCode,
+		Message:    message,
+	}
+}
@@ -0,0 +1,150 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/omegaatt36/gotasker/domain"
+	"github.com/omegaatt36/gotasker/format/taskwarrior"
+)
+
+// ExportTaskwarriorTasks writes all tasks as a Taskwarrior export to w, as
+// it's streamed by the server. The export can be read by `task import`.
+func (c *Client) ExportTaskwarriorTasks(ctx context.Context, w io.Writer) error {
+	resp, err := c.open(ctx, request{
+		method: http.MethodGet,
+		path:   "/taskwarrior/export",
+		accept: taskwarrior.MediaType,
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return fmt.Errorf("read export: %w", err)
+	}
+
+	return nil
+}
+
+// TaskwarriorImportReport is the report of a Taskwarrior import.
+type TaskwarriorImportReport struct {
+	DryRun      bool
+	Total       int
+	Created     int
+	Overwritten int
+	Deleted     int
+	// Skipped is the number of deleted Taskwarrior tasks without tasks.
+	Skipped int
+	// Failed is the number of invalid Taskwarrior tasks.
+	Failed int
+	Rows   []TaskwarriorImportRow
+}
+
+// TaskwarriorImportRow is the result of importing a single Taskwarrior task.
+type TaskwarriorImportRow struct {
+	// Index is the position of the task in the export, counted from 1.
+	Index int
+	// UUID is empty if the task can't be decoded.
+	UUID string
+	// Action is set if the task is imported.
+	Action domain.ImportAction
+	// TaskID is zero for tasks created in a dry run.
+	TaskID uint
+	Error  string
+}
+
+// taskwarriorImportReport defines DTO for TaskwarriorImportReport.
+type taskwarriorImportReport struct {
+	DryRun      bool `json:"dry_run"`
+	Total       int  `json:"total"`
+	Created     int  `json:"created"`
+	Overwritten int  `json:"overwritten"`
+	Deleted     int  `json:"deleted"`
+	Skipped     int  `json:"skipped"`
+	Failed      int  `json:"failed"`
+	Rows        []struct {
+		Index  int    `json:"index"`
+		UUID   string `json:"uuid"`
+		Action string `json:"action"`
+		TaskID uint   `json:"task_id"`
+		Error  string `json:"error"`
+	} `json:"rows"`
+}
+
+func (r *taskwarriorImportReport) toReport() (TaskwarriorImportReport, error) {
+	report := TaskwarriorImportReport{
+		DryRun:      r.DryRun,
+		Total:       r.Total,
+		Created:     r.Created,
+		Overwritten: r.Overwritten,
+		Deleted:     r.Deleted,
+		Skipped:     r.Skipped,
+		Failed:      r.Failed,
+		Rows:        make([]TaskwarriorImportRow, len(r.Rows)),
+	}
+
+	for index, row := range r.Rows {
+		report.Rows[index] = TaskwarriorImportRow{Index: row.Index, UUID: row.UUID, TaskID: row.TaskID, Error: row.Error}
+		if row.Error != "" {
+			continue
+		}
+
+		var err error
+		if report.Rows[index].Action, err = domain.ParseImportAction(row.Action); err != nil {
+			return TaskwarriorImportReport{}, err
+		}
+	}
+
+	return report, nil
+}
+
+// ImportTaskwarriorTasks imports the tasks of a Taskwarrior export, as
+// written by `task export`. Tasks of known UUIDs update their tasks. The
+// report of a failed import is returned along with an Error of 422
+// Unprocessable Entity, no task is written then.
+func (c *Client) ImportTaskwarriorTasks(ctx context.Context, r io.Reader, dryRun bool) (TaskwarriorImportReport, error) {
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return TaskwarriorImportReport{}, fmt.Errorf("read export: %w", err)
+	}
+
+	query := url.Values{}
+	query.Set("dry_run", strconv.FormatBool(dryRun))
+
+	resp, err := c.open(ctx, request{
+		method:      http.MethodPost,
+		path:        "/taskwarrior/import?" + query.Encode(),
+		contentType: taskwarrior.MediaType,
+		body:        body,
+		accept:      "application/json",
+		reported:    []int{http.StatusUnprocessableEntity},
+	})
+	if err != nil {
+		return TaskwarriorImportReport{}, err
+	}
+
+	var dto taskwarriorImportReport
+	if err := decodeResponse(resp, &dto); err != nil {
+		return TaskwarriorImportReport{}, err
+	}
+
+	report, err := dto.toReport()
+	if err != nil {
+		return TaskwarriorImportReport{}, err
+	}
+
+	if resp.StatusCode == http.StatusUnprocessableEntity {
+		return report, &Error{
+			StatusCode: resp.StatusCode,
+			Message:    fmt.Sprintf("%d of %d tasks are invalid", report.Failed, report.Total),
+		}
+	}
+
+	return report, nil
+}
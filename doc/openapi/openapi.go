@@ -0,0 +1,12 @@
+// Package openapi embeds the OpenAPI document of the API, which is generated
+// from the routes registered by the api package.
+package openapi
+
+//go:generate go test ../../api -run TestServerSuite/TestOpenAPIDocument -update
+
+import _ "embed"
+
+// Spec is the OpenAPI document in YAML.
+//
+//go:embed api.yaml
+var Spec []byte
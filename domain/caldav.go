@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var ErrCalendarObjectNotFound = errors.New("calendar object not found")
+
+// CalendarObject maps a calendar object resource stored by a CalDAV client
+// to its task. Tasks without one are served as DefaultCalendarObjectName
+// with their CalendarUID.
+type CalendarObject struct {
+	// Name is the last segment of the path of the resource, e.g. a.ics.
+	Name   string
+	UID    string
+	TaskID uint
+}
+
+// CalendarObjectRepository represents a repository of calendar objects.
+type CalendarObjectRepository interface {
+	ListCalendarObjects(ctx context.Context) ([]CalendarObject, error)
+	GetCalendarObject(ctx context.Context, name string) (CalendarObject, error)
+	// GetTaskCalendarObject gets the object of the task.
+	GetTaskCalendarObject(ctx context.Context, taskID uint) (CalendarObject, error)
+	// GetCalendarObjectByUID gets the object of the UID.
+	GetCalendarObjectByUID(ctx context.Context, uid string) (CalendarObject, error)
+	// SaveCalendarObject creates or replaces the object of the name.
+	SaveCalendarObject(ctx context.Context, object CalendarObject) error
+	DeleteCalendarObject(ctx context.Context, name string) error
+}
+
+// CalendarUID returns the iCalendar UID of the task.
+func (t *Task) CalendarUID() string {
+	return fmt.Sprintf("task-%d@gotasker", t.ID)
+}
+
+// DefaultCalendarObjectName returns the name of the calendar object resource
+// of a task not stored by a CalDAV client.
+func DefaultCalendarObjectName(taskID uint) string {
+	return fmt.Sprintf("task-%d.ics", taskID)
+}
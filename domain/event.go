@@ -0,0 +1,58 @@
+package domain
+
+// Event is a change of a task, handlers tell the events apart by their type.
+type Event interface {
+	// TaskID is the ID of the changed task.
+	TaskID() uint
+	// Name names the event in logs.
+	Name() string
+}
+
+// TaskCreated is published when a task is created.
+type TaskCreated struct {
+	Task Task
+}
+
+// TaskUpdated is published when a task is updated.
+type TaskUpdated struct {
+	Before Task
+	After  Task
+}
+
+// TaskDeleted is published when a task is deleted.
+type TaskDeleted struct {
+	// Task is the task before it's deleted.
+	Task Task
+}
+
+// TaskStatusChanged is published after TaskUpdated when the update changed
+// the status of the task.
+type TaskStatusChanged struct {
+	Task Task
+	From TaskStatus
+	To   TaskStatus
+}
+
+// TaskID returns the ID of the created task.
+func (e TaskCreated) TaskID() uint { return e.Task.ID }
+
+// Name returns the name of the event.
+func (TaskCreated) Name() string { return "task.created" }
+
+// TaskID returns the ID of the updated task.
+func (e TaskUpdated) TaskID() uint { return e.After.ID }
+
+// Name returns the name of the event.
+func (TaskUpdated) Name() string { return "task.updated" }
+
+// TaskID returns the ID of the deleted task.
+func (e TaskDeleted) TaskID() uint { return e.Task.ID }
+
+// Name returns the name of the event.
+func (TaskDeleted) Name() string { return "task.deleted" }
+
+// TaskID returns the ID of the task.
+func (e TaskStatusChanged) TaskID() uint { return e.Task.ID }
+
+// Name returns the name of the event.
+func (TaskStatusChanged) Name() string { return "task.status_changed" }
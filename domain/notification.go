@@ -0,0 +1,89 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrRecipientNotFound  = errors.New("recipient not found")
+	ErrInvalidRecipientID = errors.New("invalid recipient id")
+)
+
+// Recipient is a user receiving email notifications of the task events they
+// choose.
+type Recipient struct {
+	ID    uint
+	Email string
+	// Name is the display name of the recipient, it may be empty.
+	Name string
+	// Events are the event types the recipient is notified of, empty notifies
+	// of all events.
+	Events    []string
+	Active    bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Notification is an email of an event sent to a recipient, it's retried
+// until it's sent or runs out of attempts. The message is rendered when the
+// event happens, so it tells the task as it was then.
+type Notification struct {
+	ID          uint
+	RecipientID uint
+	// EventID identifies the event.
+	EventID string
+	Event   string
+	TaskID  uint
+	Subject string
+	Text    string
+	// HTML is the HTML alternative of Text.
+	HTML  string
+	State DeliveryState
+	// Attempts are the attempts so far, the latest last.
+	Attempts      []DeliveryAttempt
+	NextAttemptAt time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// CreateRecipientRequest defines the request for creating a recipient.
+type CreateRecipientRequest struct {
+	Email  string
+	Name   string
+	Events []string
+}
+
+// UpdateRecipientRequest defines the request for updating a recipient.
+type UpdateRecipientRequest struct {
+	Email  *string
+	Name   *string
+	Events *[]string
+	Active *bool
+}
+
+// NotificationRepository represents a notification repository, which keeps
+// the recipients, their notification logs and the queue of notifications
+// due for an attempt.
+type NotificationRepository interface {
+	CreateRecipient(ctx context.Context, req CreateRecipientRequest) (Recipient, error)
+	ListRecipients(ctx context.Context) ([]Recipient, error)
+	GetRecipient(ctx context.Context, id uint) (Recipient, error)
+	UpdateRecipient(ctx context.Context, id uint, req UpdateRecipientRequest) (Recipient, error)
+	// DeleteRecipient deletes the recipient and its notification log.
+	DeleteRecipient(ctx context.Context, id uint) error
+
+	// CreateNotification saves a new notification and queues it for
+	// NextAttemptAt.
+	CreateNotification(ctx context.Context, notification Notification) (Notification, error)
+	// SaveNotification saves the notification, pending notifications are
+	// queued for NextAttemptAt.
+	SaveNotification(ctx context.Context, notification Notification) error
+	// ListNotifications lists the notification log of a recipient, the latest
+	// first.
+	ListNotifications(ctx context.Context, recipientID uint) ([]Notification, error)
+	// ClaimDueNotifications claims up to limit notifications due at now by
+	// postponing them for the lease, like ClaimDueDeliveries of webhooks.
+	ClaimDueNotifications(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]Notification, error)
+}
@@ -11,9 +11,22 @@ import (
 
 type task struct {
 	ID        uint
-	CreatedAt int64
+	CreatedAt time.Time
+	UpdatedAt time.Time
 	Name      string
 	Status    domain.TaskStatus
+	Notes     string
+}
+
+func (t task) toDomain() domain.Task {
+	return domain.Task{
+		ID:        t.ID,
+		Name:      t.Name,
+		Status:    t.Status,
+		Notes:     t.Notes,
+		CreatedAt: t.CreatedAt,
+		UpdatedAt: t.UpdatedAt,
+	}
 }
 
 // InMemoryTaskRepository is an stub implementation of in-memory task repository.
@@ -21,8 +34,12 @@ type InMemoryTaskRepository struct {
 	sync.RWMutex
 
 	taskAutoIncrementIDSequence uint
+	version                     uint64
 
 	tasks []task
+	// taskwarriorTasks are the Taskwarrior tasks by UUID, see
+	// InMemoryTaskRepository.ListTaskwarriorTasks.
+	taskwarriorTasks map[string]domain.TaskwarriorTask
 }
 
 // NewInMemoryTaskRepository creates a new in-memory task repository.
@@ -33,19 +50,23 @@ func NewInMemoryTaskRepository() *InMemoryTaskRepository {
 var _ domain.TaskRepository = (*InMemoryTaskRepository)(nil)
 
 // CreateTask creates a new task.
-func (repo *InMemoryTaskRepository) CreateTask(ctx context.Context, req domain.CreateTaskRequest) error {
+func (repo *InMemoryTaskRepository) CreateTask(ctx context.Context, req domain.CreateTaskRequest) (domain.Task, error) {
 	repo.Lock()
 	defer repo.Unlock()
 
+	now := time.Now()
 	repo.taskAutoIncrementIDSequence++
+	repo.version++
 	repo.tasks = append(repo.tasks, task{
 		ID:        repo.taskAutoIncrementIDSequence,
-		CreatedAt: time.Now().Unix(),
+		CreatedAt: now,
+		UpdatedAt: now,
 		Name:      req.Name,
 		Status:    domain.TaskStatusIncomplete,
+		Notes:     req.Notes,
 	})
 
-	return nil
+	return repo.tasks[len(repo.tasks)-1].toDomain(), nil
 }
 
 // ListTasks lists all tasks.
@@ -57,7 +78,7 @@ func (repo *InMemoryTaskRepository) ListTasks(ctx context.Context) ([]domain.Tas
 	copy(tasks, repo.tasks)
 
 	slices.SortStableFunc(tasks, func(left, right task) int {
-		if left.CreatedAt < right.CreatedAt {
+		if left.CreatedAt.Before(right.CreatedAt) {
 			return -1
 		}
 
@@ -66,10 +87,38 @@ func (repo *InMemoryTaskRepository) ListTasks(ctx context.Context) ([]domain.Tas
 
 	result := make([]domain.Task, len(tasks))
 	for index, t := range tasks {
-		result[index] = domain.Task{
-			ID:     t.ID,
-			Name:   t.Name,
-			Status: t.Status,
+		result[index] = t.toDomain()
+	}
+
+	return result, nil
+}
+
+// GetTask gets a task.
+func (repo *InMemoryTaskRepository) GetTask(ctx context.Context, id uint) (domain.Task, error) {
+	repo.RLock()
+	defer repo.RUnlock()
+
+	for _, t := range repo.tasks {
+		if t.ID == id {
+			return t.toDomain(), nil
+		}
+	}
+
+	return domain.Task{}, domain.ErrTaskNotFound
+}
+
+// GetTasks gets the tasks of the IDs.
+func (repo *InMemoryTaskRepository) GetTasks(ctx context.Context, ids []uint) ([]domain.Task, error) {
+	repo.RLock()
+	defer repo.RUnlock()
+
+	result := make([]domain.Task, 0, len(ids))
+	for _, id := range ids {
+		for _, t := range repo.tasks {
+			if t.ID == id {
+				result = append(result, t.toDomain())
+				break
+			}
 		}
 	}
 
@@ -99,6 +148,8 @@ func (repo *InMemoryTaskRepository) UpdateTask(ctx context.Context, id uint, req
 	if req.Status != nil {
 		repo.tasks[*indexOf].Status = *req.Status
 	}
+	repo.tasks[*indexOf].UpdatedAt = time.Now()
+	repo.version++
 
 	return nil
 }
@@ -121,6 +172,222 @@ func (r *InMemoryTaskRepository) DeleteTask(ctx context.Context, id uint) error
 	}
 
 	r.tasks = append(r.tasks[:*indexOf], r.tasks[*indexOf+1:]...)
+	r.version++
+
+	return nil
+}
+
+// BatchTasks applies all operations atomically.
+func (repo *InMemoryTaskRepository) BatchTasks(ctx context.Context, ops []domain.BatchOperation) ([]domain.BatchResult, error) {
+	repo.Lock()
+	defer repo.Unlock()
+
+	sequence := repo.taskAutoIncrementIDSequence
+	tasks := make([]task, len(repo.tasks))
+	copy(tasks, repo.tasks)
+
+	indexOf := func(id uint) int {
+		return slices.IndexFunc(tasks, func(t task) bool { return t.ID == id })
+	}
+
+	var failed bool
+	now := time.Now()
+	results := make([]domain.BatchResult, len(ops))
+	for index, op := range ops {
+		switch op.Type {
+		case domain.BatchOperationTypeCreate:
+			sequence++
+			tasks = append(tasks, task{
+				ID:        sequence,
+				CreatedAt: now,
+				UpdatedAt: now,
+				Name:      op.Create.Name,
+				Status:    domain.TaskStatusIncomplete,
+				Notes:     op.Create.Notes,
+			})
+			results[index].Task = tasks[len(tasks)-1].toDomain()
+
+		case domain.BatchOperationTypeUpdate:
+			i := indexOf(op.ID)
+			if i < 0 {
+				results[index].Err = domain.ErrTaskNotFound
+				failed = true
+				continue
+			}
+
+			results[index].Before = tasks[i].toDomain()
+			if op.Update.Name != nil {
+				tasks[i].Name = *op.Update.Name
+			}
+			if op.Update.Status != nil {
+				tasks[i].Status = *op.Update.Status
+			}
+			tasks[i].UpdatedAt = now
+			results[index].Task = tasks[i].toDomain()
+
+		case domain.BatchOperationTypeDelete:
+			i := indexOf(op.ID)
+			if i < 0 {
+				results[index].Err = domain.ErrTaskNotFound
+				failed = true
+				continue
+			}
+
+			results[index].Task = tasks[i].toDomain()
+			tasks = slices.Delete(tasks, i, i+1)
+
+		default:
+			results[index].Err = domain.ErrInvalidBatchOperationType
+			failed = true
+		}
+	}
+
+	if failed {
+		for index := range results {
+			if results[index].Err == nil {
+				results[index].Err = domain.ErrBatchRolledBack
+			}
+		}
+
+		return results, nil
+	}
+
+	repo.taskAutoIncrementIDSequence = sequence
+	repo.tasks = tasks
+	repo.version++
+
+	return results, nil
+}
+
+// ImportTasks writes the tasks, the deletions and the Taskwarrior tasks
+// atomically.
+func (repo *InMemoryTaskRepository) ImportTasks(ctx context.Context, req domain.ImportTasksRequest) ([]domain.ImportResult, error) {
+	repo.Lock()
+	defer repo.Unlock()
+
+	if req.Taskwarrior != nil {
+		if err := repo.checkTaskwarriorTasks(req.Taskwarrior); err != nil {
+			return nil, err
+		}
+	}
+
+	imported, conflict := req.Tasks, req.Conflict
+
+	sequence := repo.taskAutoIncrementIDSequence
+	for _, t := range imported {
+		sequence = max(sequence, t.ID)
+	}
+
+	tasks := make([]task, len(repo.tasks))
+	copy(tasks, repo.tasks)
+
+	var (
+		written bool
+		failed  bool
+		now     = time.Now()
+	)
+
+	results := make([]domain.ImportResult, len(imported), len(imported)+len(req.Deletions))
+	for index, t := range imported {
+		if t.ID == 0 {
+			sequence++
+			t.ID = sequence
+		}
+		if t.CreatedAt.IsZero() {
+			t.CreatedAt = now
+		}
+		if t.UpdatedAt.IsZero() {
+			t.UpdatedAt = t.CreatedAt
+		}
+
+		row := task{
+			ID:        t.ID,
+			CreatedAt: t.CreatedAt,
+			UpdatedAt: t.UpdatedAt,
+			Name:      t.Name,
+			Status:    t.Status,
+			Notes:     t.Notes,
+		}
+
+		i := slices.IndexFunc(tasks, func(t task) bool { return t.ID == row.ID })
+		if i < 0 {
+			tasks = append(tasks, row)
+			results[index].Task = row.toDomain()
+			written = true
+			continue
+		}
+
+		switch conflict {
+		case domain.ImportConflictSkip:
+			results[index] = domain.ImportResult{Task: tasks[i].toDomain(), Action: domain.ImportActionSkipped}
+		case domain.ImportConflictOverwrite:
+			if row.Notes == "" {
+				row.Notes = tasks[i].Notes
+			}
+			results[index] = domain.ImportResult{Task: row.toDomain(), Before: tasks[i].toDomain(), Action: domain.ImportActionOverwritten}
+			tasks[i] = row
+			written = true
+		default:
+			results[index] = domain.ImportResult{Task: row.toDomain(), Err: domain.ErrTaskConflict}
+			failed = true
+		}
+	}
+
+	for _, id := range req.Deletions {
+		i := slices.IndexFunc(tasks, func(t task) bool { return t.ID == id })
+		if i < 0 {
+			results = append(results, domain.ImportResult{Action: domain.ImportActionSkipped})
+			continue
+		}
+
+		results = append(results, domain.ImportResult{Task: tasks[i].toDomain(), Action: domain.ImportActionDeleted})
+		tasks = slices.Delete(tasks, i, i+1)
+		written = true
+	}
+
+	if failed {
+		for index := range results {
+			if results[index].Err == nil {
+				results[index].Err = domain.ErrBatchRolledBack
+			}
+		}
+
+		return results, nil
+	}
+
+	repo.taskAutoIncrementIDSequence = sequence
+	repo.tasks = tasks
+	if written {
+		repo.version++
+	}
+	if req.Taskwarrior != nil {
+		repo.writeTaskwarriorTasks(req.Taskwarrior, results)
+	}
+
+	return results, nil
+}
+
+// IterateTasks walks through a snapshot of all tasks in chunks.
+func (repo *InMemoryTaskRepository) IterateTasks(ctx context.Context, chunkSize int, fn func([]domain.Task) error) error {
+	tasks, err := repo.ListTasks(ctx)
+	if err != nil {
+		return err
+	}
+
+	chunkSize = max(chunkSize, 1)
+	for start := 0; start < len(tasks); start += chunkSize {
+		if err := fn(tasks[start:min(start+chunkSize, len(tasks))]); err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
+
+// Version returns the version of the task collection.
+func (repo *InMemoryTaskRepository) Version(ctx context.Context) (uint64, error) {
+	repo.RLock()
+	defer repo.RUnlock()
+
+	return repo.version, nil
+}
@@ -0,0 +1,103 @@
+package stub
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/omegaatt36/gotasker/domain"
+)
+
+var _ domain.TaskwarriorRepository = (*InMemoryTaskRepository)(nil)
+
+// ListTaskwarriorTasks lists all Taskwarrior tasks ordered by UUID.
+func (repo *InMemoryTaskRepository) ListTaskwarriorTasks(ctx context.Context) ([]domain.TaskwarriorTask, error) {
+	repo.RLock()
+	defer repo.RUnlock()
+
+	tasks := make([]domain.TaskwarriorTask, 0, len(repo.taskwarriorTasks))
+	for _, t := range repo.taskwarriorTasks {
+		tasks = append(tasks, t)
+	}
+	slices.SortFunc(tasks, func(left, right domain.TaskwarriorTask) int {
+		return cmp.Compare(left.UUID, right.UUID)
+	})
+
+	return tasks, nil
+}
+
+// GetTaskwarriorTasks gets the Taskwarrior tasks of the UUIDs.
+func (repo *InMemoryTaskRepository) GetTaskwarriorTasks(ctx context.Context, uuids []string) ([]domain.TaskwarriorTask, error) {
+	repo.RLock()
+	defer repo.RUnlock()
+
+	var tasks []domain.TaskwarriorTask
+	for _, uuid := range uuids {
+		if t, ok := repo.taskwarriorTasks[uuid]; ok {
+			tasks = append(tasks, t)
+		}
+	}
+
+	return tasks, nil
+}
+
+// SaveTaskwarriorTasks creates or replaces Taskwarrior tasks.
+func (repo *InMemoryTaskRepository) SaveTaskwarriorTasks(ctx context.Context, tasks ...domain.TaskwarriorTask) error {
+	repo.Lock()
+	defer repo.Unlock()
+
+	if repo.taskwarriorTasks == nil {
+		repo.taskwarriorTasks = make(map[string]domain.TaskwarriorTask)
+	}
+	for _, t := range tasks {
+		repo.taskwarriorTasks[t.UUID] = t
+	}
+
+	return nil
+}
+
+// DeleteTaskwarriorTasks deletes Taskwarrior tasks.
+func (repo *InMemoryTaskRepository) DeleteTaskwarriorTasks(ctx context.Context, uuids ...string) error {
+	repo.Lock()
+	defer repo.Unlock()
+
+	for _, uuid := range uuids {
+		delete(repo.taskwarriorTasks, uuid)
+	}
+
+	return nil
+}
+
+// checkTaskwarriorTasks fails if the task IDs of the saved and released UUIDs
+// differ from the ones read by the import.
+func (repo *InMemoryTaskRepository) checkTaskwarriorTasks(imported *domain.TaskwarriorImport) error {
+	uuids := slices.Clone(imported.Released)
+	for _, t := range imported.Saved {
+		uuids = append(uuids, t.UUID)
+	}
+
+	for _, uuid := range uuids {
+		if repo.taskwarriorTasks[uuid].TaskID != imported.Mapped[uuid] {
+			return fmt.Errorf("%w: %s", domain.ErrTaskwarriorTasksChanged, uuid)
+		}
+	}
+
+	return nil
+}
+
+// writeTaskwarriorTasks saves the Taskwarrior tasks of the imported tasks with
+// the IDs of their results, and deletes the released ones.
+func (repo *InMemoryTaskRepository) writeTaskwarriorTasks(imported *domain.TaskwarriorImport, results []domain.ImportResult) {
+	if repo.taskwarriorTasks == nil {
+		repo.taskwarriorTasks = make(map[string]domain.TaskwarriorTask)
+	}
+
+	for index, t := range imported.Saved {
+		t.TaskID = results[index].Task.ID
+		repo.taskwarriorTasks[t.UUID] = t
+	}
+	for _, uuid := range imported.Released {
+		delete(repo.taskwarriorTasks, uuid)
+	}
+}
@@ -1,22 +1,34 @@
-//go:generate go-enum -f=$GOFILE
+//go:generate go-enum -f=$GOFILE --values
 
 package domain
 
 import (
 	"context"
 	"errors"
+	"slices"
+	"strings"
+	"time"
 )
 
 var (
-	ErrTaskNotFound = errors.New("task not found")
-	ErrInvalidTaskID =errors.New("invalid task id")
+	ErrTaskNotFound    = errors.New("task not found")
+	ErrInvalidTaskID   = errors.New("invalid task id")
+	ErrBatchRolledBack = errors.New("rolled back")
+	// ErrTaskConflict is returned when an imported task has the ID of an
+	// existing task.
+	ErrTaskConflict = errors.New("task already exists")
 )
 
 // Task represents a task.
 type Task struct {
-	ID     uint
-	Name   string
-	Status TaskStatus
+	ID        uint
+	Name      string
+	Status    TaskStatus
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	// Notes is free text about the task, e.g. the body of the email the task
+	// was created from.
+	Notes string
 }
 
 // TaskStatus represents a task status.
@@ -25,15 +37,39 @@ type TaskStatus int
 
 // TaskRepository represents a task repository.
 type TaskRepository interface {
-	CreateTask(ctx context.Context, req CreateTaskRequest) error
+	CreateTask(ctx context.Context, req CreateTaskRequest) (Task, error)
 	ListTasks(ctx context.Context) ([]Task, error)
+	GetTask(ctx context.Context, id uint) (Task, error)
+	// GetTasks gets the tasks of the IDs in a single read, in the order of
+	// the IDs. IDs of missing tasks are skipped.
+	GetTasks(ctx context.Context, ids []uint) ([]Task, error)
 	UpdateTask(ctx context.Context, id uint, req UpdateTaskRequest) error
 	DeleteTask(ctx context.Context, id uint) error
+	// BatchTasks applies all operations atomically. Per-operation failures are
+	// reported in the results and cause the whole batch to be rolled back, the
+	// returned error is reserved for storage failures.
+	BatchTasks(ctx context.Context, ops []BatchOperation) ([]BatchResult, error)
+	// IterateTasks walks through all tasks in chunks of about chunkSize tasks
+	// without loading the whole set at once. The iteration stops at the first
+	// error returned by fn.
+	IterateTasks(ctx context.Context, chunkSize int, fn func([]Task) error) error
+	// ImportTasks writes the tasks and deletes the tasks of the deletions
+	// atomically. Tasks of zero ID are created with new IDs, the others keep
+	// their IDs and the auto increment ID is moved past them. Tasks of
+	// existing IDs are handled by the conflict strategy, overwritten tasks
+	// keep their notes if the imported tasks have none. Failures are reported
+	// like BatchTasks, the results of the deletions follow the results of
+	// the tasks.
+	ImportTasks(ctx context.Context, req ImportTasksRequest) ([]ImportResult, error)
+	// Version returns a counter increased on every write to the task
+	// collection.
+	Version(ctx context.Context) (uint64, error)
 }
 
 // CreateTaskRequest defines the request for creating a task.
 type CreateTaskRequest struct {
-	Name string
+	Name  string
+	Notes string
 }
 
 // UpdateTaskRequest defines the request for updating a task.
@@ -41,3 +77,89 @@ type UpdateTaskRequest struct {
 	Name   *string
 	Status *TaskStatus
 }
+
+// TaskFilter defines the criteria of selecting tasks. Criteria are combined
+// with AND, and a zero value criterion matches everything.
+type TaskFilter struct {
+	IDs    []uint
+	Status *TaskStatus
+	// NameContains matches tasks whose name contains the given substring.
+	NameContains string
+}
+
+// IsEmpty returns whether the filter has no criteria.
+func (f *TaskFilter) IsEmpty() bool {
+	return len(f.IDs) == 0 && f.Status == nil && f.NameContains == ""
+}
+
+// Match returns whether the task matches the filter.
+func (f *TaskFilter) Match(task *Task) bool {
+	if len(f.IDs) > 0 && !slices.Contains(f.IDs, task.ID) {
+		return false
+	}
+
+	if f.Status != nil && *f.Status != task.Status {
+		return false
+	}
+
+	if f.NameContains != "" && !strings.Contains(task.Name, f.NameContains) {
+		return false
+	}
+
+	return true
+}
+
+// BatchOperationType represents a batch operation type.
+// ENUM(create, update, delete)
+type BatchOperationType int
+
+// BatchOperation defines a single operation of a batch.
+type BatchOperation struct {
+	Type BatchOperationType
+	// ID is the target task of update and delete operations.
+	ID     uint
+	Create CreateTaskRequest
+	Update UpdateTaskRequest
+}
+
+// BatchResult defines the result of a single batch operation.
+type BatchResult struct {
+	// Task is the created/updated task, or the deleted one.
+	Task Task
+	// Before is the task before an update.
+	Before Task
+	Err    error
+}
+
+// ImportConflict represents the strategy of importing a task whose ID is
+// taken by an existing task.
+// ENUM(fail, skip, overwrite)
+type ImportConflict int
+
+// ImportAction represents what an import did to a task, only Taskwarrior
+// imports delete tasks.
+// ENUM(created, overwritten, skipped, deleted)
+type ImportAction int
+
+// ImportTasksRequest defines the request for importing tasks.
+type ImportTasksRequest struct {
+	Tasks    []Task
+	Conflict ImportConflict
+	// Deletions are the IDs of the tasks deleted by the import, missing
+	// tasks are reported as skipped.
+	Deletions []uint
+	// Taskwarrior is the Taskwarrior tasks written along with the tasks of a
+	// Taskwarrior import, it's nil for other imports.
+	Taskwarrior *TaskwarriorImport
+}
+
+// ImportResult defines the result of importing a single task.
+type ImportResult struct {
+	// Task is the imported task, the existing one if it's skipped, or the
+	// deleted one.
+	Task Task
+	// Before is the existing task before it's overwritten.
+	Before Task
+	Action ImportAction
+	Err    error
+}
@@ -11,6 +11,182 @@ import (
 	"fmt"
 )
 
+const (
+	// BatchOperationTypeCreate is a BatchOperationType of type Create.
+	BatchOperationTypeCreate BatchOperationType = iota
+	// BatchOperationTypeUpdate is a BatchOperationType of type Update.
+	BatchOperationTypeUpdate
+	// BatchOperationTypeDelete is a BatchOperationType of type Delete.
+	BatchOperationTypeDelete
+)
+
+var ErrInvalidBatchOperationType = errors.New("not a valid BatchOperationType")
+
+const _BatchOperationTypeName = "createupdatedelete"
+
+// BatchOperationTypeValues returns a list of the values for BatchOperationType
+func BatchOperationTypeValues() []BatchOperationType {
+	return []BatchOperationType{
+		BatchOperationTypeCreate,
+		BatchOperationTypeUpdate,
+		BatchOperationTypeDelete,
+	}
+}
+
+var _BatchOperationTypeMap = map[BatchOperationType]string{
+	BatchOperationTypeCreate: _BatchOperationTypeName[0:6],
+	BatchOperationTypeUpdate: _BatchOperationTypeName[6:12],
+	BatchOperationTypeDelete: _BatchOperationTypeName[12:18],
+}
+
+// String implements the Stringer interface.
+func (x BatchOperationType) String() string {
+	if str, ok := _BatchOperationTypeMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("BatchOperationType(%d)", x)
+}
+
+// IsValid provides a quick way to determine if the typed value is
+// part of the allowed enumerated values
+func (x BatchOperationType) IsValid() bool {
+	_, ok := _BatchOperationTypeMap[x]
+	return ok
+}
+
+var _BatchOperationTypeValue = map[string]BatchOperationType{
+	_BatchOperationTypeName[0:6]:   BatchOperationTypeCreate,
+	_BatchOperationTypeName[6:12]:  BatchOperationTypeUpdate,
+	_BatchOperationTypeName[12:18]: BatchOperationTypeDelete,
+}
+
+// ParseBatchOperationType attempts to convert a string to a BatchOperationType.
+func ParseBatchOperationType(name string) (BatchOperationType, error) {
+	if x, ok := _BatchOperationTypeValue[name]; ok {
+		return x, nil
+	}
+	return BatchOperationType(0), fmt.Errorf("%s is %w", name, ErrInvalidBatchOperationType)
+}
+
+const (
+	// ImportActionCreated is a ImportAction of type Created.
+	ImportActionCreated ImportAction = iota
+	// ImportActionOverwritten is a ImportAction of type Overwritten.
+	ImportActionOverwritten
+	// ImportActionSkipped is a ImportAction of type Skipped.
+	ImportActionSkipped
+	// ImportActionDeleted is a ImportAction of type Deleted.
+	ImportActionDeleted
+)
+
+var ErrInvalidImportAction = errors.New("not a valid ImportAction")
+
+const _ImportActionName = "createdoverwrittenskippeddeleted"
+
+// ImportActionValues returns a list of the values for ImportAction
+func ImportActionValues() []ImportAction {
+	return []ImportAction{
+		ImportActionCreated,
+		ImportActionOverwritten,
+		ImportActionSkipped,
+		ImportActionDeleted,
+	}
+}
+
+var _ImportActionMap = map[ImportAction]string{
+	ImportActionCreated:     _ImportActionName[0:7],
+	ImportActionOverwritten: _ImportActionName[7:18],
+	ImportActionSkipped:     _ImportActionName[18:25],
+	ImportActionDeleted:     _ImportActionName[25:32],
+}
+
+// String implements the Stringer interface.
+func (x ImportAction) String() string {
+	if str, ok := _ImportActionMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("ImportAction(%d)", x)
+}
+
+// IsValid provides a quick way to determine if the typed value is
+// part of the allowed enumerated values
+func (x ImportAction) IsValid() bool {
+	_, ok := _ImportActionMap[x]
+	return ok
+}
+
+var _ImportActionValue = map[string]ImportAction{
+	_ImportActionName[0:7]:   ImportActionCreated,
+	_ImportActionName[7:18]:  ImportActionOverwritten,
+	_ImportActionName[18:25]: ImportActionSkipped,
+	_ImportActionName[25:32]: ImportActionDeleted,
+}
+
+// ParseImportAction attempts to convert a string to a ImportAction.
+func ParseImportAction(name string) (ImportAction, error) {
+	if x, ok := _ImportActionValue[name]; ok {
+		return x, nil
+	}
+	return ImportAction(0), fmt.Errorf("%s is %w", name, ErrInvalidImportAction)
+}
+
+const (
+	// ImportConflictFail is a ImportConflict of type Fail.
+	ImportConflictFail ImportConflict = iota
+	// ImportConflictSkip is a ImportConflict of type Skip.
+	ImportConflictSkip
+	// ImportConflictOverwrite is a ImportConflict of type Overwrite.
+	ImportConflictOverwrite
+)
+
+var ErrInvalidImportConflict = errors.New("not a valid ImportConflict")
+
+const _ImportConflictName = "failskipoverwrite"
+
+// ImportConflictValues returns a list of the values for ImportConflict
+func ImportConflictValues() []ImportConflict {
+	return []ImportConflict{
+		ImportConflictFail,
+		ImportConflictSkip,
+		ImportConflictOverwrite,
+	}
+}
+
+var _ImportConflictMap = map[ImportConflict]string{
+	ImportConflictFail:      _ImportConflictName[0:4],
+	ImportConflictSkip:      _ImportConflictName[4:8],
+	ImportConflictOverwrite: _ImportConflictName[8:17],
+}
+
+// String implements the Stringer interface.
+func (x ImportConflict) String() string {
+	if str, ok := _ImportConflictMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("ImportConflict(%d)", x)
+}
+
+// IsValid provides a quick way to determine if the typed value is
+// part of the allowed enumerated values
+func (x ImportConflict) IsValid() bool {
+	_, ok := _ImportConflictMap[x]
+	return ok
+}
+
+var _ImportConflictValue = map[string]ImportConflict{
+	_ImportConflictName[0:4]:  ImportConflictFail,
+	_ImportConflictName[4:8]:  ImportConflictSkip,
+	_ImportConflictName[8:17]: ImportConflictOverwrite,
+}
+
+// ParseImportConflict attempts to convert a string to a ImportConflict.
+func ParseImportConflict(name string) (ImportConflict, error) {
+	if x, ok := _ImportConflictValue[name]; ok {
+		return x, nil
+	}
+	return ImportConflict(0), fmt.Errorf("%s is %w", name, ErrInvalidImportConflict)
+}
+
 const (
 	// TaskStatusIncomplete is a TaskStatus of type Incomplete.
 	TaskStatusIncomplete TaskStatus = iota
@@ -22,6 +198,14 @@ var ErrInvalidTaskStatus = errors.New("not a valid TaskStatus")
 
 const _TaskStatusName = "incompletecompleted"
 
+// TaskStatusValues returns a list of the values for TaskStatus
+func TaskStatusValues() []TaskStatus {
+	return []TaskStatus{
+		TaskStatusIncomplete,
+		TaskStatusCompleted,
+	}
+}
+
 var _TaskStatusMap = map[TaskStatus]string{
 	TaskStatusIncomplete: _TaskStatusName[0:10],
 	TaskStatusCompleted:  _TaskStatusName[10:19],
@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// TaskwarriorTask maps the UUID of a Taskwarrior task to its task, and keeps
+// the data a task has no fields for, so that exports give it back.
+type TaskwarriorTask struct {
+	UUID        string
+	TaskID      uint
+	Tags        []string
+	Annotations []TaskwarriorAnnotation
+	// Attributes are the other attributes of the task, e.g. project and due,
+	// as JSON values.
+	Attributes map[string]json.RawMessage
+}
+
+// TaskwarriorAnnotation is a note of a Taskwarrior task.
+type TaskwarriorAnnotation struct {
+	Entry       time.Time
+	Description string
+}
+
+// ErrTaskwarriorTasksChanged is returned when the Taskwarrior tasks of an
+// import are changed by another import before it's written.
+var ErrTaskwarriorTasksChanged = errors.New("taskwarrior tasks changed")
+
+// TaskwarriorImport defines the Taskwarrior tasks written along with the
+// tasks of an import.
+type TaskwarriorImport struct {
+	// Mapped are the task IDs of the saved and released UUIDs as read before
+	// the import, UUIDs without tasks are absent. The import fails with
+	// ErrTaskwarriorTasksChanged if any of them changed meanwhile.
+	Mapped map[string]uint
+	// Saved are the Taskwarrior tasks of the imported tasks in the same
+	// order, they're saved with the IDs of the written tasks.
+	Saved []TaskwarriorTask
+	// Released are the UUIDs of the Taskwarrior tasks deleted by the import.
+	Released []string
+}
+
+// TaskwarriorRepository represents a repository of Taskwarrior tasks.
+type TaskwarriorRepository interface {
+	ListTaskwarriorTasks(ctx context.Context) ([]TaskwarriorTask, error)
+	// GetTaskwarriorTasks gets the tasks of the UUIDs in the order of the
+	// UUIDs, UUIDs of missing tasks are skipped.
+	GetTaskwarriorTasks(ctx context.Context, uuids []string) ([]TaskwarriorTask, error)
+	// SaveTaskwarriorTasks creates or replaces the tasks of their UUIDs.
+	SaveTaskwarriorTasks(ctx context.Context, tasks ...TaskwarriorTask) error
+	DeleteTaskwarriorTasks(ctx context.Context, uuids ...string) error
+}
+
+// TaskwarriorUUIDPrefix is the prefix of the default UUIDs, which are version
+// 8 UUIDs of the task ID in their last 48 bits.
+const TaskwarriorUUIDPrefix = "676f7461-736b-8000-8000-"
+
+// DefaultTaskwarriorUUID returns the UUID of a task not imported from
+// Taskwarrior.
+func DefaultTaskwarriorUUID(taskID uint) string {
+	return fmt.Sprintf("%s%012x", TaskwarriorUUIDPrefix, taskID)
+}
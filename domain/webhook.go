@@ -0,0 +1,99 @@
+//go:generate go-enum -f=$GOFILE --values
+
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrWebhookNotFound  = errors.New("webhook not found")
+	ErrInvalidWebhookID = errors.New("invalid webhook id")
+	ErrDeliveryNotFound = errors.New("delivery not found")
+)
+
+// Webhook represents a subscription of an external URL to task events.
+type Webhook struct {
+	ID  uint
+	URL string
+	// Events are the subscribed event types, empty subscribes to all events.
+	Events []string
+	// Secret is the key signing the deliveries.
+	Secret    string
+	Active    bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// DeliveryState represents the state of a webhook delivery.
+// ENUM(pending, succeeded, failed)
+type DeliveryState int
+
+// Delivery is an event sent to a webhook, it's retried until it succeeds or
+// runs out of attempts.
+type Delivery struct {
+	ID        uint
+	WebhookID uint
+	// EventID identifies the event, it's the same for redeliveries.
+	EventID string
+	Event   string
+	Payload []byte
+	State   DeliveryState
+	// Attempts are the attempts so far, the latest last.
+	Attempts      []DeliveryAttempt
+	NextAttemptAt time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// DeliveryAttempt is a single attempt of a delivery.
+type DeliveryAttempt struct {
+	At time.Time
+	// StatusCode is the response status, or the SMTP reply code of
+	// notifications, 0 if no response was received.
+	StatusCode int
+	Error      string
+	Duration   time.Duration
+}
+
+// CreateWebhookRequest defines the request for creating a webhook.
+type CreateWebhookRequest struct {
+	URL    string
+	Events []string
+	Secret string
+}
+
+// UpdateWebhookRequest defines the request for updating a webhook.
+type UpdateWebhookRequest struct {
+	URL    *string
+	Events *[]string
+	Secret *string
+	Active *bool
+}
+
+// WebhookRepository represents a webhook repository, which also keeps the
+// delivery log and the queue of deliveries due for an attempt.
+type WebhookRepository interface {
+	CreateWebhook(ctx context.Context, req CreateWebhookRequest) (Webhook, error)
+	ListWebhooks(ctx context.Context) ([]Webhook, error)
+	GetWebhook(ctx context.Context, id uint) (Webhook, error)
+	UpdateWebhook(ctx context.Context, id uint, req UpdateWebhookRequest) (Webhook, error)
+	// DeleteWebhook deletes the webhook and its delivery log.
+	DeleteWebhook(ctx context.Context, id uint) error
+
+	// CreateDelivery saves a new delivery and queues it for NextAttemptAt.
+	CreateDelivery(ctx context.Context, delivery Delivery) (Delivery, error)
+	// SaveDelivery saves the delivery, pending deliveries are queued for
+	// NextAttemptAt.
+	SaveDelivery(ctx context.Context, delivery Delivery) error
+	GetDelivery(ctx context.Context, webhookID, id uint) (Delivery, error)
+	// ListDeliveries lists the delivery log of a webhook, the latest first.
+	ListDeliveries(ctx context.Context, webhookID uint) ([]Delivery, error)
+	// ClaimDueDeliveries claims up to limit deliveries due at now by
+	// postponing them for the lease. A delivery is claimed by one caller only,
+	// so several processes may share the queue, and deliveries of a crashed
+	// caller are due again after the lease.
+	ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]Delivery, error)
+}
@@ -0,0 +1,69 @@
+// Code generated by go-enum DO NOT EDIT.
+// Version: 0.6.0
+// Revision: 919e61c0174b91303753ee3898569a01abb32c97
+// Build Date: 2023-12-18T15:54:43Z
+// Built By: goreleaser
+
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	// DeliveryStatePending is a DeliveryState of type Pending.
+	DeliveryStatePending DeliveryState = iota
+	// DeliveryStateSucceeded is a DeliveryState of type Succeeded.
+	DeliveryStateSucceeded
+	// DeliveryStateFailed is a DeliveryState of type Failed.
+	DeliveryStateFailed
+)
+
+var ErrInvalidDeliveryState = errors.New("not a valid DeliveryState")
+
+const _DeliveryStateName = "pendingsucceededfailed"
+
+// DeliveryStateValues returns a list of the values for DeliveryState
+func DeliveryStateValues() []DeliveryState {
+	return []DeliveryState{
+		DeliveryStatePending,
+		DeliveryStateSucceeded,
+		DeliveryStateFailed,
+	}
+}
+
+var _DeliveryStateMap = map[DeliveryState]string{
+	DeliveryStatePending:   _DeliveryStateName[0:7],
+	DeliveryStateSucceeded: _DeliveryStateName[7:16],
+	DeliveryStateFailed:    _DeliveryStateName[16:22],
+}
+
+// String implements the Stringer interface.
+func (x DeliveryState) String() string {
+	if str, ok := _DeliveryStateMap[x]; ok {
+		return str
+	}
+	return fmt.Sprintf("DeliveryState(%d)", x)
+}
+
+// IsValid provides a quick way to determine if the typed value is
+// part of the allowed enumerated values
+func (x DeliveryState) IsValid() bool {
+	_, ok := _DeliveryStateMap[x]
+	return ok
+}
+
+var _DeliveryStateValue = map[string]DeliveryState{
+	_DeliveryStateName[0:7]:   DeliveryStatePending,
+	_DeliveryStateName[7:16]:  DeliveryStateSucceeded,
+	_DeliveryStateName[16:22]: DeliveryStateFailed,
+}
+
+// ParseDeliveryState attempts to convert a string to a DeliveryState.
+func ParseDeliveryState(name string) (DeliveryState, error) {
+	if x, ok := _DeliveryStateValue[name]; ok {
+		return x, nil
+	}
+	return DeliveryState(0), fmt.Errorf("%s is %w", name, ErrInvalidDeliveryState)
+}
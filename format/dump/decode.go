@@ -0,0 +1,193 @@
+package dump
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/omegaatt36/gotasker/format/markdown"
+	"github.com/omegaatt36/gotasker/format/todotxt"
+)
+
+// maxLineSize is the maximum size of an NDJSON line.
+const maxLineSize = 1 << 20
+
+// Decode reads the records of the dump. Records which can't be decoded are
+// reported in their rows, the returned error is reserved for failures of
+// reading the dump as a whole. Blank NDJSON and todo.txt lines are skipped,
+// unknown keys and CSV columns are ignored. Markdown documents are read for
+// the items of their task lists, nested items are flattened.
+//
+// At most maxRows rows are returned if maxRows is positive, NDJSON and CSV
+// dumps are read no further than their last returned row.
+func Decode(r io.Reader, format Format, maxRows int) ([]Row, error) {
+	var (
+		rows []Row
+		err  error
+	)
+	switch format {
+	case FormatNDJSON:
+		return decodeNDJSON(r, maxRows)
+	case FormatCSV:
+		return decodeCSV(r, maxRows)
+	case FormatTodoTxt:
+		rows, err = decodeTodoTxt(r)
+	case FormatMarkdown:
+		rows, err = decodeMarkdown(r)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+	}
+
+	if maxRows > 0 && len(rows) > maxRows {
+		rows = rows[:maxRows]
+	}
+
+	return rows, err
+}
+
+func decodeNDJSON(r io.Reader, maxRows int) ([]Row, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxLineSize)
+
+	var rows []Row
+	for line := 1; (maxRows <= 0 || len(rows) < maxRows) && scanner.Scan(); line++ {
+		bs := bytes.TrimSpace(scanner.Bytes())
+		if len(bs) == 0 {
+			continue
+		}
+
+		row := Row{Line: line}
+		var f fields
+		if err := json.Unmarshal(bs, &f); err != nil {
+			row.Err = fmt.Errorf("%w: %v", ErrMalformed, err)
+		} else {
+			row.Record, row.Err = f.record()
+		}
+		rows = append(rows, row)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+func decodeTodoTxt(r io.Reader) ([]Row, error) {
+	lines, err := todotxt.Decode(r)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]Row, len(lines))
+	for index := range lines {
+		rows[index] = Row{Line: lines[index].Number, Record: todoRecord(&lines[index].Task)}
+	}
+
+	return rows, nil
+}
+
+func decodeMarkdown(r io.Reader) ([]Row, error) {
+	document, err := markdown.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+
+	items := document.Items()
+	rows := make([]Row, len(items))
+	for index := range items {
+		rows[index] = Row{Line: items[index].Line, Record: itemRecord(&items[index])}
+	}
+
+	return rows, nil
+}
+
+func decodeCSV(r io.Reader, maxRows int) ([]Row, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: header: %w", ErrMalformed, err)
+	}
+
+	// columns maps the known columns to their index, spreadsheets may prefix
+	// the header with a byte order mark.
+	header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	columns := make(map[string]int, len(Columns))
+	for index, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if slices.Contains(Columns, name) {
+			columns[name] = index
+		}
+	}
+	if _, ok := columns["name"]; !ok {
+		return nil, fmt.Errorf("%w: header: missing name column", ErrMalformed)
+	}
+
+	var rows []Row
+	for maxRows <= 0 || len(rows) < maxRows {
+		values, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+
+		var parseError *csv.ParseError
+		if errors.As(err, &parseError) {
+			rows = append(rows, Row{Line: parseError.StartLine, Err: fmt.Errorf("%w: %v", ErrMalformed, parseError.Err)})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := Row{Line: line}
+		if len(values) != len(header) {
+			row.Err = fmt.Errorf("%w: %d fields, expected %d", ErrMalformed, len(values), len(header))
+			rows = append(rows, row)
+			continue
+		}
+
+		value := func(name string) string {
+			if index, ok := columns[name]; ok {
+				return values[index]
+			}
+
+			return ""
+		}
+
+		f := fields{
+			Name:      value("name"),
+			Status:    value("status"),
+			CreatedAt: value("created_at"),
+			UpdatedAt: value("updated_at"),
+			Notes:     value("notes"),
+		}
+		if id := value("id"); id != "" {
+			parsed, err := strconv.ParseUint(id, 10, 0)
+			if err != nil {
+				row.Err = fmt.Errorf("%w: id: %q is not an unsigned integer", ErrMalformed, id)
+				rows = append(rows, row)
+				continue
+			}
+
+			f.ID = uint(parsed)
+		}
+
+		row.Record, row.Err = f.record()
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
@@ -0,0 +1,175 @@
+// Package dump encodes and decodes dumps of tasks, a record per task as a
+// line of NDJSON, a row of CSV, a line of todo.txt or an item of a Markdown
+// task list, so large dumps are read and written as streams.
+package dump
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/omegaatt36/gotasker/format/markdown"
+	"github.com/omegaatt36/gotasker/format/todotxt"
+)
+
+// Format is the format of a dump.
+type Format string
+
+// supported formats.
+const (
+	FormatNDJSON Format = "ndjson"
+	FormatCSV    Format = "csv"
+	// FormatTodoTxt and FormatMarkdown are lossy, see NewEncoder.
+	FormatTodoTxt  Format = "todotxt"
+	FormatMarkdown Format = "markdown"
+)
+
+var (
+	// ErrUnknownFormat is returned for formats other than ndjson, csv,
+	// todotxt and markdown.
+	ErrUnknownFormat = errors.New("unknown dump format")
+	// ErrMalformed is returned for records which can't be decoded.
+	ErrMalformed = errors.New("malformed record")
+)
+
+// Columns are the header of CSV dumps and the keys of NDJSON records.
+var Columns = []string{"id", "name", "status", "created_at", "updated_at", "notes"}
+
+// Formats returns the supported formats.
+func Formats() []Format {
+	return []Format{FormatNDJSON, FormatCSV, FormatTodoTxt, FormatMarkdown}
+}
+
+// ParseFormat parses the name of a format.
+func ParseFormat(name string) (Format, error) {
+	switch format := Format(name); format {
+	case FormatNDJSON, FormatCSV, FormatTodoTxt, FormatMarkdown:
+		return format, nil
+	}
+
+	return "", fmt.Errorf("%w: %q", ErrUnknownFormat, name)
+}
+
+// FormatOf returns the format of the media type.
+func FormatOf(mediaType string) (Format, bool) {
+	for _, format := range Formats() {
+		if format.MediaType() == mediaType {
+			return format, true
+		}
+	}
+
+	return "", false
+}
+
+// MediaType returns the media type of the format.
+func (f Format) MediaType() string {
+	switch f {
+	case FormatCSV:
+		return "text/csv"
+	case FormatTodoTxt:
+		return todotxt.MediaType
+	case FormatMarkdown:
+		return markdown.MediaType
+	}
+
+	return "application/x-ndjson"
+}
+
+// Extension returns the file name extension of the format.
+func (f Format) Extension() string {
+	switch f {
+	case FormatTodoTxt:
+		return ".txt"
+	case FormatMarkdown:
+		return ".md"
+	}
+
+	return "." + string(f)
+}
+
+// statuses of the formats which tell only whether a task is completed.
+const (
+	statusCompleted  = "completed"
+	statusIncomplete = "incomplete"
+)
+
+// Record is a task of a dump.
+type Record struct {
+	// ID is zero if the record has none.
+	ID   uint
+	Name string
+	// Status is the name of the status, empty if unset.
+	Status string
+	// CreatedAt and UpdatedAt are zero if unset.
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	// Notes are only kept by NDJSON and CSV, they're empty if unset.
+	Notes string
+}
+
+// Row is a decoded record, or the error of decoding it.
+type Row struct {
+	// Line is the line the record starts at, counted from 1.
+	Line   int
+	Record Record
+	Err    error
+}
+
+// fields are the values of a record as they are written, times are RFC 3339
+// and unset values are empty.
+type fields struct {
+	ID        uint   `json:"id,omitempty"`
+	Name      string `json:"name"`
+	Status    string `json:"status,omitempty"`
+	CreatedAt string `json:"created_at,omitempty"`
+	UpdatedAt string `json:"updated_at,omitempty"`
+	Notes     string `json:"notes,omitempty"`
+}
+
+func newFields(record *Record) fields {
+	return fields{
+		ID:        record.ID,
+		Name:      record.Name,
+		Status:    record.Status,
+		CreatedAt: formatTime(record.CreatedAt),
+		UpdatedAt: formatTime(record.UpdatedAt),
+		Notes:     record.Notes,
+	}
+}
+
+func (f *fields) record() (Record, error) {
+	createdAt, err := parseTime(f.CreatedAt)
+	if err != nil {
+		return Record{}, fmt.Errorf("%w: created_at: %v", ErrMalformed, err)
+	}
+
+	updatedAt, err := parseTime(f.UpdatedAt)
+	if err != nil {
+		return Record{}, fmt.Errorf("%w: updated_at: %v", ErrMalformed, err)
+	}
+
+	return Record{
+		ID:        f.ID,
+		Name:      f.Name,
+		Status:    f.Status,
+		CreatedAt: createdAt,
+		UpdatedAt: updatedAt,
+		Notes:     f.Notes,
+	}, nil
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.UTC().Format(time.RFC3339)
+}
+
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	return time.Parse(time.RFC3339, s)
+}
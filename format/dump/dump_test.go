@@ -0,0 +1,133 @@
+package dump_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/omegaatt36/gotasker/format/dump"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type DumpSuite struct {
+	suite.Suite
+}
+
+func (s *DumpSuite) TestRoundTrip() {
+	created := time.Date(2024, time.May, 1, 8, 30, 0, 0, time.UTC)
+	records := []dump.Record{
+		{ID: 1, Name: "buy milk, eggs", Status: "completed", CreatedAt: created, UpdatedAt: created.Add(time.Hour), Notes: "oat milk,\n6 eggs"},
+		{Name: "say \"hi\"\nto everyone"},
+	}
+
+	for _, format := range []dump.Format{dump.FormatNDJSON, dump.FormatCSV} {
+		s.T().Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			encoder := dump.NewEncoder(&buf, format)
+			s.Require().NoError(encoder.Encode(records[0]))
+			s.Require().NoError(encoder.Encode(records[1]))
+			s.Require().NoError(encoder.Flush())
+
+			rows, err := dump.Decode(&buf, format, 0)
+			s.Require().NoError(err)
+			s.Require().Len(rows, 2)
+			for index, row := range rows {
+				s.NoError(row.Err)
+				s.Equal(records[index], row.Record)
+			}
+		})
+	}
+}
+
+func (s *DumpSuite) TestTodoTxt() {
+	created := time.Date(2024, time.May, 1, 23, 30, 0, 0, time.FixedZone("UTC-1", -3600))
+	var buf bytes.Buffer
+	s.Require().NoError(dump.NewEncoder(&buf, dump.FormatTodoTxt).Encode(
+		dump.Record{ID: 1, Name: "(A) call mom +family due:2024-05-03", Status: "incomplete", CreatedAt: created, UpdatedAt: created},
+		dump.Record{ID: 2, Name: "(B) buy milk", Status: "completed", CreatedAt: created, UpdatedAt: created.Add(24 * time.Hour)},
+		dump.Record{Name: "say hi"},
+	))
+	s.Equal("(A) 2024-05-02 call mom +family due:2024-05-03\n"+
+		"x 2024-05-03 2024-05-02 (B) buy milk\n"+
+		"say hi\n", buf.String())
+
+	rows, err := dump.Decode(&buf, dump.FormatTodoTxt, 0)
+	s.Require().NoError(err)
+	day := func(d int) time.Time { return time.Date(2024, time.May, d, 0, 0, 0, 0, time.UTC) }
+	s.Equal([]dump.Row{
+		{Line: 1, Record: dump.Record{Name: "(A) call mom +family due:2024-05-03", Status: "incomplete", CreatedAt: day(2)}},
+		{Line: 2, Record: dump.Record{Name: "(B) buy milk", Status: "completed", CreatedAt: day(2), UpdatedAt: day(3)}},
+		{Line: 3, Record: dump.Record{Name: "say hi", Status: "incomplete"}},
+	}, rows)
+}
+
+func (s *DumpSuite) TestMarkdown() {
+	var buf bytes.Buffer
+	s.Require().NoError(dump.NewEncoder(&buf, dump.FormatMarkdown).Encode(
+		dump.Record{ID: 1, Name: "buy milk", Status: "completed", CreatedAt: time.Now()},
+		dump.Record{Name: "say hi"},
+	))
+	s.Equal("- [x] buy milk <!-- gotasker:1 -->\n- [ ] say hi\n", buf.String())
+
+	rows, err := dump.Decode(strings.NewReader("# Tasks\n\n"+buf.String()+"  - [ ] nested\n"), dump.FormatMarkdown, 0)
+	s.Require().NoError(err)
+	s.Equal([]dump.Row{
+		{Line: 3, Record: dump.Record{ID: 1, Name: "buy milk", Status: "completed"}},
+		{Line: 4, Record: dump.Record{Name: "say hi", Status: "incomplete"}},
+		{Line: 5, Record: dump.Record{Name: "nested", Status: "incomplete"}},
+	}, rows)
+}
+
+func (s *DumpSuite) TestEncode() {
+	var buf bytes.Buffer
+	s.Require().NoError(dump.NewEncoder(&buf, dump.FormatCSV).Flush())
+	s.Equal("id,name,status,created_at,updated_at,notes\n", buf.String(), "an empty dump has a header")
+
+	buf.Reset()
+	s.Require().NoError(dump.NewEncoder(&buf, dump.FormatNDJSON).Encode(dump.Record{ID: 1, Name: "task 1"}))
+	s.Equal("{\"id\":1,\"name\":\"task 1\"}\n", buf.String())
+}
+
+func (s *DumpSuite) TestDecodeErrors() {
+	rows, err := dump.Decode(strings.NewReader("\ufeffName,ID,Comment\n"+
+		"task 1,1,a\n"+
+		"task 2,x,b\n"+
+		"task 3\n"+
+		"\"task\n4\",4,c\n"), dump.FormatCSV, 0)
+	s.Require().NoError(err)
+	s.Require().Len(rows, 4)
+	s.Equal(dump.Row{Line: 2, Record: dump.Record{ID: 1, Name: "task 1"}}, rows[0])
+	s.Equal(3, rows[1].Line)
+	s.ErrorIs(rows[1].Err, dump.ErrMalformed)
+	s.Equal(4, rows[2].Line)
+	s.ErrorContains(rows[2].Err, "1 fields, expected 3")
+	s.Equal(dump.Row{Line: 5, Record: dump.Record{ID: 4, Name: "task\n4"}}, rows[3])
+
+	rows, err = dump.Decode(strings.NewReader("{\"name\": \"task 1\"}\n"+
+		"\n"+
+		"{\"name\": \"task 2\", \"created_at\": \"yesterday\"}\n"+
+		"[]\n"), dump.FormatNDJSON, 0)
+	s.Require().NoError(err)
+	s.Require().Len(rows, 3)
+	s.NoError(rows[0].Err)
+	s.Equal(3, rows[1].Line)
+	s.ErrorContains(rows[1].Err, "created_at")
+	s.Equal(4, rows[2].Line)
+	s.ErrorIs(rows[2].Err, dump.ErrMalformed)
+
+	rows, err = dump.Decode(strings.NewReader("name\ntask 1\ntask 2\n\"task"), dump.FormatCSV, 2)
+	s.Require().NoError(err, "rows beyond the limit aren't read")
+	s.Len(rows, 2)
+
+	_, err = dump.Decode(strings.NewReader("id,title\n1,task 1\n"), dump.FormatCSV, 0)
+	s.ErrorIs(err, dump.ErrMalformed)
+
+	_, err = dump.Decode(strings.NewReader(""), "xml", 0)
+	s.ErrorIs(err, dump.ErrUnknownFormat)
+}
+
+func TestDump(t *testing.T) {
+	suite.Run(t, new(DumpSuite))
+}
@@ -0,0 +1,104 @@
+package dump
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+
+	"github.com/omegaatt36/gotasker/format/markdown"
+	"github.com/omegaatt36/gotasker/format/todotxt"
+)
+
+// Encoder writes records to a stream.
+type Encoder struct {
+	json *json.Encoder
+	csv  *csv.Writer
+	// lines is the writer of the line based formats of todo.txt and Markdown.
+	lines  io.Writer
+	format Format
+	// header reports whether the CSV header is written.
+	header bool
+}
+
+// NewEncoder creates an encoder writing records of the format to w. todo.txt
+// has neither IDs nor times of day, so todo.txt records lose their IDs and
+// their times are truncated to dates. Markdown task lists have no times, IDs
+// are kept in the markers of the items.
+func NewEncoder(w io.Writer, format Format) *Encoder {
+	e := &Encoder{format: format}
+	switch format {
+	case FormatCSV:
+		e.csv = csv.NewWriter(w)
+	case FormatTodoTxt, FormatMarkdown:
+		e.lines = w
+	default:
+		e.json = json.NewEncoder(w)
+	}
+
+	return e
+}
+
+// Encode writes the records. CSV records are buffered until Flush.
+func (e *Encoder) Encode(records ...Record) error {
+	for index := range records {
+		if e.lines != nil {
+			var err error
+			if e.format == FormatMarkdown {
+				err = markdown.Encode(e.lines, newItem(&records[index]))
+			} else {
+				err = todotxt.Encode(e.lines, newTodo(&records[index]))
+			}
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		f := newFields(&records[index])
+		if e.json != nil {
+			if err := e.json.Encode(f); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := e.writeHeader(); err != nil {
+			return err
+		}
+
+		id := ""
+		if f.ID > 0 {
+			id = strconv.FormatUint(uint64(f.ID), 10)
+		}
+		if err := e.csv.Write([]string{id, f.Name, f.Status, f.CreatedAt, f.UpdatedAt, f.Notes}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Flush writes the buffered records. A CSV dump without records still has a
+// header.
+func (e *Encoder) Flush() error {
+	if e.csv == nil {
+		return nil
+	}
+
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+
+	e.csv.Flush()
+	return e.csv.Error()
+}
+
+func (e *Encoder) writeHeader() error {
+	if e.header {
+		return nil
+	}
+
+	e.header = true
+	return e.csv.Write(Columns)
+}
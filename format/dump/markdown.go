@@ -0,0 +1,30 @@
+package dump
+
+import (
+	"github.com/omegaatt36/gotasker/format/markdown"
+)
+
+// newItem maps the record to an item of a Markdown task list, the ID is kept
+// in the marker of the item.
+func newItem(record *Record) markdown.Item {
+	return markdown.Item{
+		Checked: record.Status == statusCompleted,
+		Text:    record.Name,
+		ID:      record.ID,
+	}
+}
+
+// itemRecord maps the item of a Markdown task list to a record, the reverse
+// of newItem.
+func itemRecord(item *markdown.Item) Record {
+	record := Record{
+		ID:     item.ID,
+		Name:   item.Text,
+		Status: statusIncomplete,
+	}
+	if item.Checked {
+		record.Status = statusCompleted
+	}
+
+	return record
+}
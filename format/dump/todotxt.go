@@ -0,0 +1,56 @@
+package dump
+
+import (
+	"time"
+
+	"github.com/omegaatt36/gotasker/format/todotxt"
+)
+
+// newTodo maps the record to a todo.txt task. A leading priority of the name
+// of an incomplete task is its priority, the creation date is the date of
+// CreatedAt and the completion date is the date of UpdatedAt.
+func newTodo(record *Record) todotxt.Task {
+	t := todotxt.Task{
+		Completed:    record.Status == statusCompleted,
+		CreationDate: date(record.CreatedAt),
+		Description:  record.Name,
+	}
+
+	if t.Completed {
+		t.CompletionDate = date(record.UpdatedAt)
+	} else {
+		t.Priority, t.Description = todotxt.CutPriority(t.Description)
+	}
+
+	return t
+}
+
+// todoRecord maps the todo.txt task to a record, the reverse of newTodo.
+// Projects, contexts, tags and the priority are kept in the name as they
+// can't be represented otherwise.
+func todoRecord(t *todotxt.Task) Record {
+	record := Record{
+		Name:      t.Description,
+		Status:    statusIncomplete,
+		CreatedAt: t.CreationDate,
+	}
+
+	if t.Completed {
+		record.Status = statusCompleted
+		record.UpdatedAt = t.CompletionDate
+	}
+	if t.Priority != 0 {
+		record.Name = "(" + string(t.Priority) + ") " + record.Name
+	}
+
+	return record
+}
+
+func date(t time.Time) time.Time {
+	if t.IsZero() {
+		return t
+	}
+
+	year, month, day := t.UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
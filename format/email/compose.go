@@ -0,0 +1,110 @@
+package email
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// Draft is an email to be sent.
+type Draft struct {
+	From    mail.Address
+	To      mail.Address
+	Subject string
+	// Text is the plain-text body.
+	Text string
+	// HTML is the optional HTML alternative of Text.
+	HTML string
+	Date time.Time
+	// MessageID is the Message-ID without the angle brackets, it's omitted
+	// if empty.
+	MessageID string
+}
+
+// Compose writes the draft as a MIME message, a multipart/alternative one if
+// it has HTML. The bodies are encoded as quoted-printable UTF-8, and line
+// breaks are CRLF.
+func Compose(w io.Writer, draft *Draft) error {
+	bw := bufio.NewWriter(w)
+
+	// the subject is a single line.
+	subject := strings.Join(strings.Fields(draft.Subject), " ")
+
+	header := []string{
+		"From: " + draft.From.String(),
+		"To: " + draft.To.String(),
+		"Subject: " + mime.QEncoding.Encode("utf-8", subject),
+		"Date: " + draft.Date.Format(time.RFC1123Z),
+	}
+	if draft.MessageID != "" {
+		header = append(header, "Message-ID: <"+draft.MessageID+">")
+	}
+	header = append(header, "MIME-Version: 1.0")
+
+	for _, line := range header {
+		if _, err := bw.WriteString(line + "\r\n"); err != nil {
+			return err
+		}
+	}
+
+	if draft.HTML == "" {
+		if _, err := bw.WriteString("Content-Type: text/plain; charset=utf-8\r\n" +
+			"Content-Transfer-Encoding: quoted-printable\r\n\r\n"); err != nil {
+			return err
+		}
+
+		if err := writeBody(bw, draft.Text); err != nil {
+			return err
+		}
+
+		return bw.Flush()
+	}
+
+	writer := multipart.NewWriter(bw)
+	if _, err := fmt.Fprintf(bw, "Content-Type: %s\r\n\r\n",
+		mime.FormatMediaType("multipart/alternative", map[string]string{"boundary": writer.Boundary()})); err != nil {
+		return err
+	}
+
+	for _, part := range []struct{ mediaType, body string }{
+		{"text/plain", draft.Text},
+		{"text/html", draft.HTML},
+	} {
+		pw, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {mime.FormatMediaType(part.mediaType, map[string]string{"charset": "utf-8"})},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := writeBody(pw, part.body); err != nil {
+			return err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	return bw.Flush()
+}
+
+// writeBody writes the body as quoted-printable with CRLF line breaks.
+func writeBody(w io.Writer, body string) error {
+	body = strings.ReplaceAll(body, "\r\n", "\n")
+
+	qw := quotedprintable.NewWriter(w)
+	if _, err := io.WriteString(qw, strings.ReplaceAll(body, "\n", "\r\n")); err != nil {
+		return err
+	}
+
+	return qw.Close()
+}
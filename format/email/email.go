@@ -0,0 +1,160 @@
+// Package email reads the sender, subject and plain-text body of MIME
+// messages, and composes plain-text and HTML ones (RFC 5322, RFC 2045).
+package email
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// maxDepth limits the nesting of multipart bodies.
+const maxDepth = 8
+
+var (
+	// ErrMalformed is returned for messages which can't be parsed.
+	ErrMalformed = errors.New("malformed email")
+	// ErrUnknownCharset is returned for bodies of charsets which can't be
+	// decoded.
+	ErrUnknownCharset = errors.New("unknown charset")
+)
+
+// Message is the content of an email.
+type Message struct {
+	// From is the address of the From header, empty if it has none.
+	From    string
+	Subject string
+	// Text is the first plain-text body which isn't an attachment, empty if
+	// the message has none. Line breaks are \n and the trailing whitespace is
+	// trimmed.
+	Text string
+}
+
+// decoder decodes the encoded words of headers, e.g. =?UTF-8?B?...?=.
+var decoder = &mime.WordDecoder{CharsetReader: charsetReader}
+
+// Parse parses the message.
+func Parse(r io.Reader) (Message, error) {
+	msg, err := mail.ReadMessage(r)
+	if err != nil {
+		return Message{}, fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+
+	var message Message
+	message.Subject = msg.Header.Get("Subject")
+	if subject, err := decoder.DecodeHeader(message.Subject); err == nil {
+		message.Subject = subject
+	}
+
+	parser := mail.AddressParser{WordDecoder: decoder}
+	if from, err := parser.Parse(msg.Header.Get("From")); err == nil {
+		message.From = from.Address
+	}
+
+	text, _, err := readText(textproto.MIMEHeader(msg.Header), msg.Body, 0)
+	if err != nil {
+		return Message{}, err
+	}
+	message.Text = strings.TrimRightFunc(strings.ReplaceAll(text, "\r\n", "\n"), isSpace)
+
+	return message, nil
+}
+
+func isSpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\r' || r == '\n'
+}
+
+// readText returns the first plain-text body of the entity, and whether it
+// has one.
+func readText(header textproto.MIMEHeader, body io.Reader, depth int) (string, bool, error) {
+	if disposition, _, err := mime.ParseMediaType(header.Get("Content-Disposition")); err == nil && disposition == "attachment" {
+		return "", false, nil
+	}
+
+	// entities without a valid type are plain text (RFC 2045 5.2).
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType, params = "text/plain", nil
+	}
+
+	switch {
+	case mediaType == "text/plain":
+		text, err := decodeBody(body, header.Get("Content-Transfer-Encoding"), params["charset"])
+		return text, err == nil, err
+	case strings.HasPrefix(mediaType, "multipart/"):
+		if depth >= maxDepth || params["boundary"] == "" {
+			return "", false, nil
+		}
+
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextRawPart()
+			if errors.Is(err, io.EOF) {
+				return "", false, nil
+			}
+			if err != nil {
+				return "", false, fmt.Errorf("%w: %w", ErrMalformed, err)
+			}
+
+			text, ok, err := readText(part.Header, part, depth+1)
+			if err != nil || ok {
+				return text, ok, err
+			}
+		}
+	default:
+		return "", false, nil
+	}
+}
+
+// decodeBody decodes the body by its transfer encoding and charset.
+func decodeBody(body io.Reader, transferEncoding, charset string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(transferEncoding)) {
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	case "base64":
+		// the decoder skips line breaks.
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	}
+
+	bs, err := io.ReadAll(body)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+
+	r, err := charsetReader(charset, bytes.NewReader(bs))
+	if err != nil {
+		return "", err
+	}
+
+	if bs, err = io.ReadAll(r); err != nil {
+		return "", fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+
+	return string(bs), nil
+}
+
+// charsetReader returns a reader decoding the charset to UTF-8, US-ASCII is
+// the default charset.
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(charset) {
+	case "", "us-ascii", "utf-8", "utf8":
+		return input, nil
+	}
+
+	encoding, err := htmlindex.Get(charset)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownCharset, charset)
+	}
+
+	return encoding.NewDecoder().Reader(input), nil
+}
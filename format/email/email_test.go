@@ -0,0 +1,187 @@
+package email_test
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/omegaatt36/gotasker/format/email"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type EmailSuite struct {
+	suite.Suite
+}
+
+func parse(message string) (email.Message, error) {
+	return email.Parse(strings.NewReader(strings.ReplaceAll(message, "\n", "\r\n")))
+}
+
+func (s *EmailSuite) TestPlain() {
+	message, err := parse(`From: Alice <alice@example.com>
+To: tasks@example.com
+Subject: Fix the printer
+
+The printer on the 2nd floor is jammed.
+
+`)
+	s.Require().NoError(err)
+	s.Equal(email.Message{
+		From:    "alice@example.com",
+		Subject: "Fix the printer",
+		Text:    "The printer on the 2nd floor is jammed.",
+	}, message)
+}
+
+func (s *EmailSuite) TestEncoded() {
+	message, err := parse(`From: =?UTF-8?B?5bCP5piO?= <ming@example.com>
+Subject: =?UTF-8?B?5L+u55CG5Y2w6KGo5qmf?=
+Content-Type: text/plain; charset=big5
+Content-Transfer-Encoding: base64
+
+vdCt17J6pkyq7b73oUM=
+`)
+	s.Require().NoError(err)
+	s.Equal("ming@example.com", message.From)
+	s.Equal("修理印表機", message.Subject)
+	s.Equal("請修理印表機。", message.Text)
+
+	message, err = parse(`Subject: =?ISO-8859-1?Q?Caf=E9?=
+Content-Type: text/plain; charset=iso-8859-1
+Content-Transfer-Encoding: quoted-printable
+
+Caf=E9 au lait, a long line which is =
+soft wrapped.
+`)
+	s.Require().NoError(err)
+	s.Equal("Café", message.Subject)
+	s.Equal("Café au lait, a long line which is soft wrapped.", message.Text)
+
+	_, err = parse(`Subject: unknown
+Content-Type: text/plain; charset=x-unknown
+
+body
+`)
+	s.ErrorIs(err, email.ErrUnknownCharset)
+}
+
+func (s *EmailSuite) TestMultipart() {
+	message, err := parse(`From: alice@example.com
+Subject: Fwd: Report
+MIME-Version: 1.0
+Content-Type: multipart/mixed; boundary="outer"
+
+--outer
+Content-Type: text/plain; name="report.txt"
+Content-Disposition: attachment; filename="report.txt"
+
+the attachment
+--outer
+Content-Type: multipart/alternative; boundary="inner"
+
+--inner
+Content-Type: text/html
+
+<p>the html</p>
+--inner
+Content-Type: text/plain; charset=utf-8
+
+the text
+--inner--
+--outer--
+`)
+	s.Require().NoError(err)
+	s.Equal("Fwd: Report", message.Subject)
+	s.Equal("the text", message.Text)
+
+	// messages without plain text have no text.
+	message, err = parse(`Subject: html
+Content-Type: multipart/alternative; boundary="b"
+
+--b
+Content-Type: text/html
+
+<p>the html</p>
+--b--
+`)
+	s.Require().NoError(err)
+	s.Empty(message.Text)
+}
+
+func (s *EmailSuite) TestMalformed() {
+	_, err := parse("not a header\n")
+	s.ErrorIs(err, email.ErrMalformed)
+}
+
+func (s *EmailSuite) TestCompose() {
+	draft := email.Draft{
+		From:      mail.Address{Name: "GoTasker", Address: "tasks@example.com"},
+		To:        mail.Address{Name: "小明", Address: "ming@example.com"},
+		Subject:   "Task renamed:\n修理印表機",
+		Text:      "The task is renamed.\nA long line " + strings.Repeat("=", 80),
+		Date:      time.Date(2024, time.May, 1, 8, 0, 0, 0, time.UTC),
+		MessageID: "1.2@example.com",
+	}
+
+	var buf bytes.Buffer
+	s.Require().NoError(email.Compose(&buf, &draft))
+	s.NotContains(strings.ReplaceAll(buf.String(), "\r\n", ""), "\n")
+
+	message, err := email.Parse(bytes.NewReader(buf.Bytes()))
+	s.Require().NoError(err)
+	s.Equal(email.Message{
+		From:    "tasks@example.com",
+		Subject: "Task renamed: 修理印表機",
+		Text:    draft.Text,
+	}, message)
+
+	msg, err := mail.ReadMessage(bytes.NewReader(buf.Bytes()))
+	s.Require().NoError(err)
+	s.Equal("<1.2@example.com>", msg.Header.Get("Message-ID"))
+	s.Equal("Wed, 01 May 2024 08:00:00 +0000", msg.Header.Get("Date"))
+	to, err := (&mail.AddressParser{WordDecoder: new(mime.WordDecoder)}).Parse(msg.Header.Get("To"))
+	s.Require().NoError(err)
+	s.Equal(draft.To, *to)
+
+	draft.HTML = "<p>The task is renamed.</p>"
+	buf.Reset()
+	s.Require().NoError(email.Compose(&buf, &draft))
+
+	message, err = email.Parse(bytes.NewReader(buf.Bytes()))
+	s.Require().NoError(err)
+	s.Equal(draft.Text, message.Text)
+
+	msg, err = mail.ReadMessage(bytes.NewReader(buf.Bytes()))
+	s.Require().NoError(err)
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	s.Require().NoError(err)
+	s.Equal("multipart/alternative", mediaType)
+
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	var parts []string
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		s.Require().NoError(err)
+
+		body, err := io.ReadAll(part)
+		s.Require().NoError(err)
+		parts = append(parts, part.Header.Get("Content-Type")+" "+string(body))
+	}
+	s.Equal([]string{
+		"text/plain; charset=utf-8 " + strings.ReplaceAll(draft.Text, "\n", "\r\n"),
+		"text/html; charset=utf-8 " + draft.HTML,
+	}, parts)
+}
+
+func TestEmailSuite(t *testing.T) {
+	suite.Run(t, new(EmailSuite))
+}
@@ -0,0 +1,177 @@
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Decode reads the VTODOs of the VCALENDARs of r. Folded lines are unfolded
+// and TEXT values unescaped. Properties other than UID, SUMMARY, STATUS,
+// CREATED, LAST-MODIFIED and COMPLETED, and components other than VTODO, are
+// skipped.
+func Decode(r io.Reader) ([]Todo, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		todos []Todo
+		// components are the names of the open components.
+		components []string
+		todo       *Todo
+	)
+	for _, line := range lines {
+		if line.text == "" {
+			continue
+		}
+
+		name, value, err := parseLine(line.text)
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrMalformed, line.number, err)
+		}
+
+		switch name {
+		case "BEGIN":
+			component := strings.ToUpper(value)
+			if len(components) == 0 && component != "VCALENDAR" {
+				return nil, fmt.Errorf("%w: line %d: expected BEGIN:VCALENDAR", ErrMalformed, line.number)
+			}
+
+			components = append(components, component)
+			if component == "VTODO" && len(components) == 2 {
+				todo = &Todo{}
+			}
+			continue
+		case "END":
+			if len(components) == 0 || !strings.EqualFold(components[len(components)-1], value) {
+				return nil, fmt.Errorf("%w: line %d: unexpected END:%s", ErrMalformed, line.number, value)
+			}
+
+			if todo != nil && len(components) == 2 {
+				todos = append(todos, *todo)
+				todo = nil
+			}
+			components = components[:len(components)-1]
+			continue
+		}
+
+		if len(components) == 0 {
+			return nil, fmt.Errorf("%w: line %d: property outside of VCALENDAR", ErrMalformed, line.number)
+		}
+
+		// properties of nested components like VALARM aren't of the todo.
+		if todo == nil || len(components) != 2 {
+			continue
+		}
+
+		if err := todo.set(name, value); err != nil {
+			return nil, fmt.Errorf("%w: line %d: %s: %v", ErrMalformed, line.number, name, err)
+		}
+	}
+
+	if len(components) > 0 {
+		return nil, fmt.Errorf("%w: missing END:%s", ErrMalformed, components[len(components)-1])
+	}
+
+	return todos, nil
+}
+
+func (todo *Todo) set(name, value string) error {
+	var err error
+	switch name {
+	case "UID":
+		todo.UID = unescapeText(value)
+	case "SUMMARY":
+		todo.Summary = unescapeText(value)
+	case "STATUS":
+		todo.Status = strings.ToUpper(value)
+	case "CREATED":
+		todo.Created, err = parseDateTime(value)
+	case "LAST-MODIFIED":
+		todo.LastModified, err = parseDateTime(value)
+	case "COMPLETED":
+		todo.Completed, err = parseDateTime(value)
+	}
+
+	return err
+}
+
+type contentLine struct {
+	// number is the number of the first physical line.
+	number int
+	text   string
+}
+
+// unfold joins the lines starting with a space or a tab to the previous
+// line. Lines may end with CRLF or LF.
+func unfold(r io.Reader) ([]contentLine, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+
+	var (
+		lines  []contentLine
+		number int
+	)
+	for scanner.Scan() {
+		number++
+		text := strings.TrimSuffix(scanner.Text(), "\r")
+
+		if len(text) > 0 && (text[0] == ' ' || text[0] == '\t') {
+			if len(lines) == 0 {
+				return nil, fmt.Errorf("%w: line %d: continuation of no line", ErrMalformed, number)
+			}
+
+			lines[len(lines)-1].text += text[1:]
+			continue
+		}
+
+		lines = append(lines, contentLine{number: number, text: text})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return lines, nil
+}
+
+// parseLine returns the upper cased name and the value of a content line,
+// the parameters are skipped. Quoted parameter values may contain colons.
+func parseLine(line string) (string, string, error) {
+	var quoted bool
+	for index := 0; index < len(line); index++ {
+		switch line[index] {
+		case '"':
+			quoted = !quoted
+		case ':':
+			if quoted {
+				continue
+			}
+
+			name, _, _ := strings.Cut(line[:index], ";")
+			if name == "" {
+				return "", "", errors.New("missing name")
+			}
+
+			return strings.ToUpper(name), line[index+1:], nil
+		}
+	}
+
+	return "", "", errors.New("missing value")
+}
+
+// parseDateTime parses DATE-TIME values in UTC or floating time, which is
+// read as UTC, and DATE values.
+func parseDateTime(value string) (time.Time, error) {
+	for _, layout := range []string{dateTimeFormat, "20060102T150405", "20060102"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid date-time %q", value)
+}
@@ -0,0 +1,96 @@
+package ical
+
+import (
+	"bufio"
+	"io"
+	"time"
+	"unicode/utf8"
+)
+
+// Encode writes a VCALENDAR of the todos to w. Lines longer than 75 octets
+// are folded without splitting UTF-8 sequences.
+func Encode(w io.Writer, todos []Todo) error {
+	e := encoder{w: bufio.NewWriter(w)}
+
+	e.line("BEGIN", "VCALENDAR")
+	e.line("VERSION", "2.0")
+	e.line("PRODID", ProductID)
+	for _, todo := range todos {
+		e.todo(todo)
+	}
+	e.line("END", "VCALENDAR")
+
+	if e.err != nil {
+		return e.err
+	}
+
+	return e.w.Flush()
+}
+
+type encoder struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (e *encoder) todo(todo Todo) {
+	e.line("BEGIN", "VTODO")
+	e.line("UID", escapeText(todo.UID))
+	// without a METHOD, DTSTAMP is the time the todo was last revised.
+	e.line("DTSTAMP", formatDateTime(dateStamp(todo)))
+	if !todo.Created.IsZero() {
+		e.line("CREATED", formatDateTime(todo.Created))
+	}
+	if !todo.LastModified.IsZero() {
+		e.line("LAST-MODIFIED", formatDateTime(todo.LastModified))
+	}
+	e.line("SUMMARY", escapeText(todo.Summary))
+	if todo.Status != "" {
+		e.line("STATUS", todo.Status)
+	}
+	if !todo.Completed.IsZero() {
+		e.line("COMPLETED", formatDateTime(todo.Completed))
+	}
+	e.line("END", "VTODO")
+}
+
+func dateStamp(todo Todo) time.Time {
+	switch {
+	case !todo.LastModified.IsZero():
+		return todo.LastModified
+	case !todo.Created.IsZero():
+		return todo.Created
+	default:
+		return time.Now()
+	}
+}
+
+// line writes a content line of the escaped value, folded at 75 octets.
+func (e *encoder) line(name, value string) {
+	if e.err != nil {
+		return
+	}
+
+	line := name + ":" + value
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+
+		e.write(line[:cut], "\r\n ")
+		line = line[cut:]
+		// the leading space of continuation lines counts.
+		limit = maxLineOctets - 1
+	}
+	e.write(line, "\r\n")
+}
+
+func (e *encoder) write(ss ...string) {
+	for _, s := range ss {
+		if e.err != nil {
+			return
+		}
+		_, e.err = e.w.WriteString(s)
+	}
+}
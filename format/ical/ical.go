@@ -0,0 +1,100 @@
+// Package ical encodes and decodes the VTODO components of iCalendar
+// (RFC 5545) documents.
+package ical
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// MediaType is the media type of iCalendar documents.
+const MediaType = "text/calendar"
+
+// ProductID is the PRODID of encoded calendars.
+const ProductID = "-//omegaatt36//gotasker//EN"
+
+// values of the STATUS property of VTODOs.
+const (
+	StatusNeedsAction = "NEEDS-ACTION"
+	StatusCompleted   = "COMPLETED"
+	StatusInProcess   = "IN-PROCESS"
+	StatusCancelled   = "CANCELLED"
+)
+
+// ErrMalformed is returned for documents which are not valid iCalendar.
+var ErrMalformed = errors.New("malformed iCalendar")
+
+// maxLineOctets is the maximum length of a content line, excluding the line
+// break.
+const maxLineOctets = 75
+
+const dateTimeFormat = "20060102T150405Z"
+
+// Todo is a VTODO component.
+type Todo struct {
+	UID     string
+	Summary string
+	// Status is one of the Status constants, empty if unset.
+	Status       string
+	Created      time.Time
+	LastModified time.Time
+	// Completed is the time the todo was completed, zero if it isn't.
+	Completed time.Time
+}
+
+// escapeText escapes a TEXT value, line breaks are written as \n.
+func escapeText(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for index := 0; index < len(s); index++ {
+		switch c := s[index]; c {
+		case '\\', ';', ',':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case '\r':
+			if index+1 < len(s) && s[index+1] == '\n' {
+				index++
+			}
+			b.WriteString(`\n`)
+		case '\n':
+			b.WriteString(`\n`)
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return b.String()
+}
+
+// unescapeText reverses escapeText, unknown escapes keep the escaped
+// character.
+func unescapeText(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+
+	var b strings.Builder
+	b.Grow(len(s))
+	for index := 0; index < len(s); index++ {
+		c := s[index]
+		if c != '\\' || index+1 == len(s) {
+			b.WriteByte(c)
+			continue
+		}
+
+		index++
+		switch next := s[index]; next {
+		case 'n', 'N':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(next)
+		}
+	}
+
+	return b.String()
+}
+
+func formatDateTime(t time.Time) string {
+	return t.UTC().Format(dateTimeFormat)
+}
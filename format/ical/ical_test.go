@@ -0,0 +1,124 @@
+package ical_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/omegaatt36/gotasker/format/ical"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type ICalSuite struct {
+	suite.Suite
+}
+
+func (s *ICalSuite) TestEncode() {
+	created := time.Date(2024, time.May, 1, 8, 30, 0, 0, time.UTC)
+	modified := created.Add(time.Hour)
+
+	var buf bytes.Buffer
+	s.Require().NoError(ical.Encode(&buf, []ical.Todo{{
+		UID:          "task-1@gotasker",
+		Summary:      "buy milk, eggs; and\\or bread\nquickly",
+		Status:       ical.StatusCompleted,
+		Created:      created,
+		LastModified: modified,
+		Completed:    modified,
+	}}))
+
+	s.Equal("BEGIN:VCALENDAR\r\n"+
+		"VERSION:2.0\r\n"+
+		"PRODID:-//omegaatt36//gotasker//EN\r\n"+
+		"BEGIN:VTODO\r\n"+
+		"UID:task-1@gotasker\r\n"+
+		"DTSTAMP:20240501T093000Z\r\n"+
+		"CREATED:20240501T083000Z\r\n"+
+		"LAST-MODIFIED:20240501T093000Z\r\n"+
+		"SUMMARY:buy milk\\, eggs\\; and\\\\or bread\\nquickly\r\n"+
+		"STATUS:COMPLETED\r\n"+
+		"COMPLETED:20240501T093000Z\r\n"+
+		"END:VTODO\r\n"+
+		"END:VCALENDAR\r\n", buf.String())
+}
+
+func (s *ICalSuite) TestFolding() {
+	summary := strings.Repeat("任務", 40) + strings.Repeat("a", 100)
+
+	var buf bytes.Buffer
+	s.Require().NoError(ical.Encode(&buf, []ical.Todo{{UID: "1", Summary: summary}}))
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n")
+	var folded int
+	for _, line := range lines {
+		s.LessOrEqual(len(line), 75, line)
+		s.True(utf8.ValidString(line), line)
+		if strings.HasPrefix(line, " ") {
+			folded++
+		}
+	}
+	s.Positive(folded)
+
+	todos, err := ical.Decode(&buf)
+	s.Require().NoError(err)
+	s.Require().Len(todos, 1)
+	s.Equal(summary, todos[0].Summary)
+}
+
+func (s *ICalSuite) TestDecode() {
+	todos, err := ical.Decode(strings.NewReader("BEGIN:VCALENDAR\n" +
+		"VERSION:2.0\n" +
+		"BEGIN:VEVENT\n" +
+		"SUMMARY:not a todo\n" +
+		"END:VEVENT\n" +
+		"begin:vtodo\r\n" +
+		"UID:abc\r\n" +
+		"SUMMARY;LANGUAGE=en;X-NOTE=\"a:b\":first\\, line\\N\r\n" +
+		"\tsecond \r\n" +
+		" line\r\n" +
+		"status:needs-action\r\n" +
+		"CREATED:20240501T083000Z\r\n" +
+		"DUE;VALUE=DATE:20240510\r\n" +
+		"BEGIN:VALARM\r\n" +
+		"SUMMARY:alarm\r\n" +
+		"END:VALARM\r\n" +
+		"END:VTODO\r\n" +
+		"BEGIN:VTODO\r\n" +
+		"SUMMARY:second\r\n" +
+		"COMPLETED:20240502\r\n" +
+		"END:VTODO\r\n" +
+		"END:VCALENDAR\r\n"))
+	s.Require().NoError(err)
+	s.Require().Len(todos, 2)
+
+	s.Equal("abc", todos[0].UID)
+	s.Equal("first, line\nsecond line", todos[0].Summary)
+	s.Equal(ical.StatusNeedsAction, todos[0].Status)
+	s.True(todos[0].Created.Equal(time.Date(2024, time.May, 1, 8, 30, 0, 0, time.UTC)))
+
+	s.Equal("second", todos[1].Summary)
+	s.True(todos[1].Completed.Equal(time.Date(2024, time.May, 2, 0, 0, 0, 0, time.UTC)))
+}
+
+func (s *ICalSuite) TestDecodeMalformed() {
+	for name, document := range map[string]string{
+		"not a calendar":    "BEGIN:VTODO\r\nEND:VTODO\r\n",
+		"missing end":       "BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\n",
+		"mismatched end":    "BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nEND:VCALENDAR\r\n",
+		"without colon":     "BEGIN:VCALENDAR\r\nSUMMARY\r\nEND:VCALENDAR\r\n",
+		"leading fold":      " BEGIN:VCALENDAR\r\n",
+		"invalid date-time": "BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nCREATED:yesterday\r\nEND:VTODO\r\nEND:VCALENDAR\r\n",
+	} {
+		s.T().Run(name, func(t *testing.T) {
+			_, err := ical.Decode(strings.NewReader(document))
+			s.ErrorIs(err, ical.ErrMalformed)
+		})
+	}
+}
+
+func TestICal(t *testing.T) {
+	suite.Run(t, new(ICalSuite))
+}
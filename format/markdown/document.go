@@ -0,0 +1,169 @@
+package markdown
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// line is a line of a document, and the item on it.
+type line struct {
+	text    string
+	item    *Item
+	indent  string
+	bullet  string
+	removed bool
+}
+
+// Document is a Markdown document whose task list items can be changed
+// without touching the rest of it.
+type Document struct {
+	lines []line
+	// bom is the byte order mark the document starts with, if any.
+	bom string
+	// newline is the line break of the document.
+	newline string
+	// trailingNewline reports whether the last line ends with a line break.
+	trailingNewline bool
+}
+
+// Parse reads the document. Items in fenced code blocks are ignored.
+func Parse(r io.Reader) (*Document, error) {
+	bs, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Document{newline: "\n"}
+	content := string(bs)
+	if after, ok := strings.CutPrefix(content, "\ufeff"); ok {
+		d.bom, content = "\ufeff", after
+	}
+	if strings.Contains(content, "\r\n") {
+		d.newline = "\r\n"
+	}
+	content, d.trailingNewline = strings.CutSuffix(content, d.newline)
+	if content == "" && !d.trailingNewline {
+		return d, nil
+	}
+
+	var (
+		// fence is the opening fence of the current code block.
+		fence string
+		// indents are the widths of the indentation of the open items.
+		indents []int
+	)
+	for index, text := range strings.Split(content, d.newline) {
+		l := line{text: text}
+		switch trimmed := strings.TrimLeft(text, " "); {
+		case fence != "":
+			if closesFence(trimmed, fence) {
+				fence = ""
+			}
+		case len(text)-len(trimmed) <= 3 && opensFence(trimmed) != "":
+			fence = opensFence(trimmed)
+			indents = nil
+		default:
+			item, indent, bullet, ok := parseItem(text)
+			if !ok {
+				// a paragraph at the top level ends the list.
+				if text != "" && text[0] != ' ' && text[0] != '\t' {
+					indents = nil
+				}
+				break
+			}
+
+			columns := width(indent)
+			for len(indents) > 0 && indents[len(indents)-1] >= columns {
+				indents = indents[:len(indents)-1]
+			}
+			item.Line, item.Depth = index+1, len(indents)
+			indents = append(indents, columns)
+
+			l.item, l.indent, l.bullet = &item, indent, bullet
+		}
+
+		d.lines = append(d.lines, l)
+	}
+
+	return d, nil
+}
+
+func opensFence(trimmed string) string {
+	for _, c := range []string{"`", "~"} {
+		if strings.HasPrefix(trimmed, strings.Repeat(c, 3)) {
+			return trimmed[:len(trimmed)-len(strings.TrimLeft(trimmed, c))]
+		}
+	}
+
+	return ""
+}
+
+func closesFence(trimmed, fence string) bool {
+	return strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]+" \t") == ""
+}
+
+// Items returns the items of the document in order.
+func (d *Document) Items() []Item {
+	var items []Item
+	for _, l := range d.lines {
+		if l.item != nil && !l.removed {
+			items = append(items, *l.item)
+		}
+	}
+
+	return items
+}
+
+// Set replaces the item on the line of item.Line, its indentation and bullet
+// are kept.
+func (d *Document) Set(item Item) error {
+	l, err := d.itemLine(item.Line)
+	if err != nil {
+		return err
+	}
+
+	item.Depth = l.item.Depth
+	l.item = &item
+	l.text = item.format(l.indent, l.bullet)
+
+	return nil
+}
+
+// Remove removes the item on the line. Line numbers of the other items are
+// kept.
+func (d *Document) Remove(number int) error {
+	l, err := d.itemLine(number)
+	if err != nil {
+		return err
+	}
+
+	l.removed = true
+	return nil
+}
+
+func (d *Document) itemLine(number int) (*line, error) {
+	if number < 1 || number > len(d.lines) || d.lines[number-1].item == nil || d.lines[number-1].removed {
+		return nil, fmt.Errorf("%w on line %d", ErrNoItem, number)
+	}
+
+	return &d.lines[number-1], nil
+}
+
+// WriteTo writes the document with its line breaks.
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	texts := make([]string, 0, len(d.lines))
+	for _, l := range d.lines {
+		if !l.removed {
+			texts = append(texts, l.text)
+		}
+	}
+
+	content := strings.Join(texts, d.newline)
+	if d.trailingNewline && len(texts) > 0 {
+		content += d.newline
+	}
+
+	n, err := io.WriteString(w, d.bom+content)
+	return int64(n), err
+}
@@ -0,0 +1,116 @@
+// Package markdown reads and writes the task lists of GitHub-flavored
+// Markdown documents. Items are tied to tasks by markers in HTML comments
+// like <!-- gotasker:1 -->, the rest of a document is kept as it is.
+package markdown
+
+import (
+	"errors"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// MediaType is the media type of Markdown documents.
+const MediaType = "text/markdown"
+
+// ErrNoItem is returned for changing lines without items.
+var ErrNoItem = errors.New("no task list item")
+
+// Item is an item of a task list.
+type Item struct {
+	// Line is the line of the item, counted from 1. It's zero for items which
+	// aren't read from a document.
+	Line int
+	// Depth is the nesting level of the item, zero at the top level.
+	Depth   int
+	Checked bool
+	// Text is the text of the item without the marker.
+	Text string
+	// ID is the task ID of the marker, zero if the item has none.
+	ID uint
+	// Sum is the checksum of the marker, it's opaque to this package and
+	// empty if the marker has none.
+	Sum string
+}
+
+var (
+	// itemPattern matches an item of a task list, the groups are the
+	// indentation, the bullet, the check and the text.
+	itemPattern = regexp.MustCompile(`^([ \t]*)([-*+]|\d{1,9}[.)])[ \t]+\[([ xX])\](?:[ \t]+(.*?))?[ \t]*$`)
+	// markerPattern matches a marker at the end of the text of an item.
+	markerPattern = regexp.MustCompile(`[ \t]*<!--[ \t]*gotasker:(\d+)(?:[ \t]+([0-9A-Za-z]+))?[ \t]*-->$`)
+)
+
+// parseItem parses an item, the line and depth are left to the caller.
+func parseItem(line string) (item Item, indent, bullet string, ok bool) {
+	matches := itemPattern.FindStringSubmatch(line)
+	if matches == nil {
+		return Item{}, "", "", false
+	}
+
+	item = Item{Checked: matches[3] != " ", Text: matches[4]}
+	if marker := markerPattern.FindStringSubmatchIndex(item.Text); marker != nil {
+		if id, err := strconv.ParseUint(item.Text[marker[2]:marker[3]], 10, 0); err == nil && id > 0 {
+			item.ID = uint(id)
+			if marker[4] >= 0 {
+				item.Sum = item.Text[marker[4]:marker[5]]
+			}
+			item.Text = item.Text[:marker[0]]
+		}
+	}
+
+	return item, matches[1], matches[2], true
+}
+
+// format formats the item with the indentation and bullet, line breaks of the
+// text are replaced by spaces.
+func (i *Item) format(indent, bullet string) string {
+	check := " "
+	if i.Checked {
+		check = "x"
+	}
+
+	var b strings.Builder
+	b.WriteString(indent + bullet + " [" + check + "]")
+	if text := strings.Join(strings.Fields(i.Text), " "); text != "" {
+		b.WriteString(" " + text)
+	}
+	if i.ID > 0 {
+		b.WriteString(" <!-- gotasker:" + strconv.FormatUint(uint64(i.ID), 10))
+		if i.Sum != "" {
+			b.WriteString(" " + i.Sum)
+		}
+		b.WriteString(" -->")
+	}
+
+	return b.String()
+}
+
+// Encode writes the items as a task list, nested by their depths.
+func Encode(w io.Writer, items ...Item) error {
+	for index := range items {
+		item := &items[index]
+		if _, err := io.WriteString(w, item.format(strings.Repeat("  ", item.Depth), "-")+"\n"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// width returns the width of the indentation, a tab advances to the next
+// multiple of 4 columns.
+func width(indent string) int {
+	var columns int
+	for _, r := range indent {
+		if r == '\t' {
+			columns += 4 - columns%4
+			continue
+		}
+
+		columns++
+	}
+
+	return columns
+}
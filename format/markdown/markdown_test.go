@@ -0,0 +1,78 @@
+package markdown_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/omegaatt36/gotasker/format/markdown"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type MarkdownSuite struct {
+	suite.Suite
+}
+
+const document = "# Project\r\n" +
+	"\r\n" +
+	"- [ ] write docs <!-- gotasker:1 -->\r\n" +
+	"  - [x] intro\r\n" +
+	"\t* [X]   usage   <!-- gotasker:2 o1a2b3c4d -->\r\n" +
+	"- [ ] release\r\n" +
+	"1. [ ] ordered\r\n" +
+	"- plain item\r\n" +
+	"```md\r\n" +
+	"- [ ] in a code block\r\n" +
+	"```\r\n" +
+	"Done:\r\n" +
+	"  - [x] after a paragraph <!-- gotasker:x -->"
+
+func (s *MarkdownSuite) TestParse() {
+	d, err := markdown.Parse(strings.NewReader(document))
+	s.Require().NoError(err)
+
+	s.Equal([]markdown.Item{
+		{Line: 3, Text: "write docs", ID: 1},
+		{Line: 4, Depth: 1, Checked: true, Text: "intro"},
+		{Line: 5, Depth: 2, Checked: true, Text: "usage", ID: 2, Sum: "o1a2b3c4d"},
+		{Line: 6, Text: "release"},
+		{Line: 7, Text: "ordered"},
+		{Line: 13, Checked: true, Text: "after a paragraph <!-- gotasker:x -->"},
+	}, d.Items())
+
+	var buf bytes.Buffer
+	_, err = d.WriteTo(&buf)
+	s.Require().NoError(err)
+	s.Equal(document, buf.String(), "an unchanged document is written as it is")
+}
+
+func (s *MarkdownSuite) TestChange() {
+	d, err := markdown.Parse(strings.NewReader("\ufeff- [ ] a\n  - [ ] b <!-- gotasker:2 -->\n\ntext\n"))
+	s.Require().NoError(err)
+
+	s.Require().NoError(d.Set(markdown.Item{Line: 1, Checked: true, Text: "a\nrenamed", ID: 3, Sum: "x1"}))
+	s.Require().NoError(d.Remove(2))
+	s.ErrorIs(d.Remove(2), markdown.ErrNoItem)
+	s.ErrorIs(d.Set(markdown.Item{Line: 4}), markdown.ErrNoItem)
+	s.ErrorIs(d.Set(markdown.Item{Line: 5}), markdown.ErrNoItem)
+
+	var buf bytes.Buffer
+	_, err = d.WriteTo(&buf)
+	s.Require().NoError(err)
+	s.Equal("\ufeff- [x] a renamed <!-- gotasker:3 x1 -->\n\ntext\n", buf.String())
+	s.Equal([]markdown.Item{{Line: 1, Checked: true, Text: "a\nrenamed", ID: 3, Sum: "x1"}}, d.Items())
+}
+
+func (s *MarkdownSuite) TestEncode() {
+	var buf bytes.Buffer
+	s.Require().NoError(markdown.Encode(&buf,
+		markdown.Item{Text: "a", ID: 1},
+		markdown.Item{Depth: 1, Checked: true, Text: "b"},
+	))
+	s.Equal("- [ ] a <!-- gotasker:1 -->\n  - [x] b\n", buf.String())
+}
+
+func TestMarkdown(t *testing.T) {
+	suite.Run(t, new(MarkdownSuite))
+}
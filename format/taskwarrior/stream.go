@@ -0,0 +1,125 @@
+package taskwarrior
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Row is a decoded task, or the error of decoding it.
+type Row struct {
+	// Index is the position of the task in the export, counted from 1.
+	Index int
+	Task  Task
+	Err   error
+}
+
+// Decode reads the tasks of an export, which is a JSON array of tasks, or a
+// task per line as written by Taskwarrior before 2.6. Tasks which can't be
+// decoded are reported in their rows, the returned error is reserved for
+// malformed JSON.
+func Decode(r io.Reader) ([]Row, error) {
+	reader := bufio.NewReader(r)
+	decoder := json.NewDecoder(reader)
+
+	first, err := peekToken(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	var values []json.RawMessage
+	if first == '[' {
+		if err := decoder.Decode(&values); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+		}
+	} else {
+		for {
+			var value json.RawMessage
+			err := decoder.Decode(&value)
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+			}
+			values = append(values, value)
+		}
+	}
+
+	rows := make([]Row, len(values))
+	for index, value := range values {
+		rows[index] = Row{Index: index + 1}
+		rows[index].Err = json.Unmarshal(value, &rows[index].Task)
+	}
+
+	return rows, nil
+}
+
+// peekToken returns the first byte of the JSON after a byte order mark and
+// whitespace, zero if there is none.
+func peekToken(reader *bufio.Reader) (byte, error) {
+	if bom, _ := reader.Peek(3); bytes.Equal(bom, []byte("\ufeff")) {
+		_, _ = reader.Discard(3)
+	}
+
+	for {
+		b, err := reader.ReadByte()
+		if errors.Is(err, io.EOF) {
+			return 0, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		if b != ' ' && b != '\t' && b != '\r' && b != '\n' {
+			return b, reader.UnreadByte()
+		}
+	}
+}
+
+// Encoder writes the tasks of an export as a JSON array, a task per line.
+type Encoder struct {
+	w io.Writer
+	// count is the number of written tasks.
+	count int
+}
+
+// NewEncoder creates an encoder writing an export to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// Encode writes the tasks.
+func (e *Encoder) Encode(tasks ...Task) error {
+	for index := range tasks {
+		bs, err := json.Marshal(&tasks[index])
+		if err != nil {
+			return err
+		}
+
+		separator := ",\n"
+		if e.count == 0 {
+			separator = "[\n"
+		}
+		if _, err := io.WriteString(e.w, separator+string(bs)); err != nil {
+			return err
+		}
+		e.count++
+	}
+
+	return nil
+}
+
+// Close ends the array, an export without tasks is an empty array.
+func (e *Encoder) Close() error {
+	end := "\n]\n"
+	if e.count == 0 {
+		end = "[]\n"
+	}
+
+	_, err := io.WriteString(e.w, end)
+	return err
+}
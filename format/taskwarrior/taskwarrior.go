@@ -0,0 +1,208 @@
+// Package taskwarrior encodes and decodes the JSON of Taskwarrior tasks, as
+// written by `task export` and read by `task import`, see
+// https://taskwarrior.org/docs/design/task.
+package taskwarrior
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MediaType is the media type of exports.
+const MediaType = "application/json"
+
+// TimeFormat is the format of the times of tasks.
+const TimeFormat = "20060102T150405Z"
+
+var (
+	// ErrMalformed is returned for tasks which can't be decoded.
+	ErrMalformed = errors.New("malformed taskwarrior task")
+	// ErrUnknownStatus is returned for statuses other than the known ones.
+	ErrUnknownStatus = errors.New("unknown taskwarrior status")
+)
+
+// Status is the status of a task.
+type Status string
+
+// statuses of tasks.
+const (
+	StatusPending   Status = "pending"
+	StatusCompleted Status = "completed"
+	StatusDeleted   Status = "deleted"
+	StatusWaiting   Status = "waiting"
+	StatusRecurring Status = "recurring"
+)
+
+// Annotation is a note of a task.
+type Annotation struct {
+	Entry       time.Time
+	Description string
+}
+
+// Task is a Taskwarrior task.
+type Task struct {
+	UUID        string
+	Description string
+	Status      Status
+	// Entry, Modified and End are zero if unset.
+	Entry    time.Time
+	Modified time.Time
+	End      time.Time
+	Tags     []string
+	// Annotations are ordered by their entries.
+	Annotations []Annotation
+	// Attributes are the other attributes as they are, e.g. project and due.
+	// The working set id and the urgency are left out, as they're computed
+	// by Taskwarrior.
+	Attributes map[string]json.RawMessage
+}
+
+// fields are the attributes of the Task fields.
+var fields = []string{"uuid", "description", "status", "entry", "modified", "end", "tags", "annotations"}
+
+// computed are the attributes computed by Taskwarrior.
+var computed = []string{"id", "urgency"}
+
+// annotation defines the JSON of Annotation.
+type annotation struct {
+	Entry       string `json:"entry"`
+	Description string `json:"description"`
+}
+
+// MarshalJSON encodes the task as a JSON object, the attributes of the fields
+// take precedence over Attributes.
+func (t *Task) MarshalJSON() ([]byte, error) {
+	object := make(map[string]any, len(t.Attributes)+len(fields))
+	for key, value := range t.Attributes {
+		if !slices.Contains(computed, key) {
+			object[key] = value
+		}
+	}
+
+	object["uuid"] = t.UUID
+	object["description"] = t.Description
+	object["status"] = t.Status
+	for key, value := range map[string]time.Time{"entry": t.Entry, "modified": t.Modified, "end": t.End} {
+		delete(object, key)
+		if !value.IsZero() {
+			object[key] = value.UTC().Format(TimeFormat)
+		}
+	}
+
+	delete(object, "tags")
+	if len(t.Tags) > 0 {
+		object["tags"] = t.Tags
+	}
+
+	delete(object, "annotations")
+	if len(t.Annotations) > 0 {
+		annotations := make([]annotation, len(t.Annotations))
+		for index, a := range t.Annotations {
+			annotations[index] = annotation{Entry: a.Entry.UTC().Format(TimeFormat), Description: a.Description}
+		}
+		object["annotations"] = annotations
+	}
+
+	// maps are encoded in the order of their keys.
+	return json.Marshal(object)
+}
+
+// UnmarshalJSON decodes the task from a JSON object. The UUID and the status
+// are validated, the description may be empty.
+func (t *Task) UnmarshalJSON(bs []byte) error {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(bs, &object); err != nil {
+		return fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+
+	var decoded Task
+	for key, target := range map[string]any{
+		"uuid":        &decoded.UUID,
+		"description": &decoded.Description,
+		"status":      &decoded.Status,
+		"tags":        &decoded.Tags,
+	} {
+		value, ok := object[key]
+		if !ok {
+			continue
+		}
+		if err := json.Unmarshal(value, target); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrMalformed, key, err)
+		}
+	}
+
+	if err := uuid.Validate(decoded.UUID); err != nil {
+		return fmt.Errorf("%w: uuid: %q is not a UUID", ErrMalformed, decoded.UUID)
+	}
+	switch decoded.Status {
+	case StatusPending, StatusCompleted, StatusDeleted, StatusWaiting, StatusRecurring:
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownStatus, decoded.Status)
+	}
+
+	for key, target := range map[string]*time.Time{"entry": &decoded.Entry, "modified": &decoded.Modified, "end": &decoded.End} {
+		var value string
+		if raw, ok := object[key]; ok {
+			if err := json.Unmarshal(raw, &value); err != nil {
+				return fmt.Errorf("%w: %s: %v", ErrMalformed, key, err)
+			}
+		}
+
+		var err error
+		if *target, err = ParseTime(value); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrMalformed, key, err)
+		}
+	}
+
+	if raw, ok := object["annotations"]; ok {
+		var annotations []annotation
+		if err := json.Unmarshal(raw, &annotations); err != nil {
+			return fmt.Errorf("%w: annotations: %v", ErrMalformed, err)
+		}
+
+		for _, a := range annotations {
+			at, err := ParseTime(a.Entry)
+			if err != nil {
+				return fmt.Errorf("%w: annotations: %v", ErrMalformed, err)
+			}
+			decoded.Annotations = append(decoded.Annotations, Annotation{Entry: at, Description: a.Description})
+		}
+	}
+
+	for key, value := range object {
+		if slices.Contains(fields, key) || slices.Contains(computed, key) {
+			continue
+		}
+		if decoded.Attributes == nil {
+			decoded.Attributes = make(map[string]json.RawMessage)
+		}
+		decoded.Attributes[key] = value
+	}
+
+	*t = decoded
+	return nil
+}
+
+// ParseTime parses a time of a task, empty for the zero time. Taskwarrior
+// writes times in UTC like 20240501T083000Z, RFC 3339 is also accepted.
+func ParseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.Parse(TimeFormat, value); err == nil {
+		return t, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is not a time like %s", value, TimeFormat)
+	}
+
+	return t.UTC(), nil
+}
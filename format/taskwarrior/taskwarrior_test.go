@@ -0,0 +1,119 @@
+package taskwarrior_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/omegaatt36/gotasker/format/taskwarrior"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type TaskwarriorSuite struct {
+	suite.Suite
+}
+
+const exported = `[
+{"id":1,"description":"call mom","entry":"20240501T083000Z","modified":"20240502T090000Z","project":"family","status":"pending","tags":["phone"],"urgency":4.9,"uuid":"5d2a4a57-2e1a-4c1b-9c1f-0e1a2b3c4d5e","annotations":[{"entry":"20240501T084500Z","description":"after lunch"}]},
+{"id":0,"description":"pay bills","end":"20240503T100000Z","entry":"20240501T083000Z","modified":"20240503T100000Z","status":"completed","uuid":"7b1c2d3e-4f50-4a6b-8c7d-9e0f1a2b3c4d"}
+]`
+
+func (s *TaskwarriorSuite) TestDecode() {
+	rows, err := taskwarrior.Decode(strings.NewReader("\ufeff" + exported))
+	s.Require().NoError(err)
+	s.Require().Len(rows, 2)
+
+	s.Require().NoError(rows[0].Err)
+	s.Equal(1, rows[0].Index)
+	s.Equal(taskwarrior.Task{
+		UUID:        "5d2a4a57-2e1a-4c1b-9c1f-0e1a2b3c4d5e",
+		Description: "call mom",
+		Status:      taskwarrior.StatusPending,
+		Entry:       time.Date(2024, time.May, 1, 8, 30, 0, 0, time.UTC),
+		Modified:    time.Date(2024, time.May, 2, 9, 0, 0, 0, time.UTC),
+		Tags:        []string{"phone"},
+		Annotations: []taskwarrior.Annotation{{Entry: time.Date(2024, time.May, 1, 8, 45, 0, 0, time.UTC), Description: "after lunch"}},
+		Attributes:  map[string]json.RawMessage{"project": json.RawMessage(`"family"`)},
+	}, rows[0].Task)
+
+	s.Require().NoError(rows[1].Err)
+	s.Equal(taskwarrior.StatusCompleted, rows[1].Task.Status)
+	s.Equal(time.Date(2024, time.May, 3, 10, 0, 0, 0, time.UTC), rows[1].Task.End)
+	s.Nil(rows[1].Task.Attributes)
+
+	// a task per line, as written by older versions.
+	rows, err = taskwarrior.Decode(strings.NewReader(`{"uuid":"5d2a4a57-2e1a-4c1b-9c1f-0e1a2b3c4d5e","description":"a","status":"pending"}
+{"uuid":"7b1c2d3e-4f50-4a6b-8c7d-9e0f1a2b3c4d","description":"b","status":"waiting","entry":"2024-05-01T10:30:00+02:00"}
+`))
+	s.Require().NoError(err)
+	s.Require().Len(rows, 2)
+	s.Equal(taskwarrior.StatusWaiting, rows[1].Task.Status)
+	s.Equal(time.Date(2024, time.May, 1, 8, 30, 0, 0, time.UTC), rows[1].Task.Entry)
+
+	rows, err = taskwarrior.Decode(strings.NewReader("  "))
+	s.Require().NoError(err)
+	s.Empty(rows)
+
+	_, err = taskwarrior.Decode(strings.NewReader(`[{"uuid":`))
+	s.ErrorIs(err, taskwarrior.ErrMalformed)
+}
+
+func (s *TaskwarriorSuite) TestDecodeInvalid() {
+	rows, err := taskwarrior.Decode(strings.NewReader(`[
+{"uuid":"not a uuid","description":"a","status":"pending"},
+{"uuid":"5d2a4a57-2e1a-4c1b-9c1f-0e1a2b3c4d5e","description":"b","status":"done"},
+{"uuid":"5d2a4a57-2e1a-4c1b-9c1f-0e1a2b3c4d5e","description":"c","status":"pending","entry":"yesterday"},
+{"uuid":"5d2a4a57-2e1a-4c1b-9c1f-0e1a2b3c4d5e","description":["d"],"status":"pending"},
+"e",
+{"uuid":"5d2a4a57-2e1a-4c1b-9c1f-0e1a2b3c4d5e","status":"deleted"}
+]`))
+	s.Require().NoError(err)
+	s.Require().Len(rows, 6)
+
+	s.ErrorIs(rows[0].Err, taskwarrior.ErrMalformed)
+	s.ErrorIs(rows[1].Err, taskwarrior.ErrUnknownStatus)
+	s.ErrorIs(rows[2].Err, taskwarrior.ErrMalformed)
+	s.ErrorIs(rows[3].Err, taskwarrior.ErrMalformed)
+	s.ErrorIs(rows[4].Err, taskwarrior.ErrMalformed)
+	s.NoError(rows[5].Err)
+	s.Equal(6, rows[5].Index)
+}
+
+func (s *TaskwarriorSuite) TestEncode() {
+	var b bytes.Buffer
+	encoder := taskwarrior.NewEncoder(&b)
+	s.Require().NoError(encoder.Close())
+	s.Equal("[]\n", b.String())
+
+	rows, err := taskwarrior.Decode(strings.NewReader(exported))
+	s.Require().NoError(err)
+
+	b.Reset()
+	encoder = taskwarrior.NewEncoder(&b)
+	for _, row := range rows {
+		s.Require().NoError(encoder.Encode(row.Task))
+	}
+	s.Require().NoError(encoder.Close())
+
+	s.Equal(`[
+{"annotations":[{"entry":"20240501T084500Z","description":"after lunch"}],"description":"call mom","entry":"20240501T083000Z","modified":"20240502T090000Z","project":"family","status":"pending","tags":["phone"],"uuid":"5d2a4a57-2e1a-4c1b-9c1f-0e1a2b3c4d5e"},
+{"description":"pay bills","end":"20240503T100000Z","entry":"20240501T083000Z","modified":"20240503T100000Z","status":"completed","uuid":"7b1c2d3e-4f50-4a6b-8c7d-9e0f1a2b3c4d"}
+]
+`, b.String())
+
+	// the attributes don't override the fields.
+	bs, err := json.Marshal(&taskwarrior.Task{
+		UUID:       "5d2a4a57-2e1a-4c1b-9c1f-0e1a2b3c4d5e",
+		Status:     taskwarrior.StatusPending,
+		Attributes: map[string]json.RawMessage{"status": json.RawMessage(`"deleted"`), "end": json.RawMessage(`"x"`), "urgency": json.RawMessage(`1`)},
+	})
+	s.Require().NoError(err)
+	s.JSONEq(`{"uuid":"5d2a4a57-2e1a-4c1b-9c1f-0e1a2b3c4d5e","description":"","status":"pending"}`, string(bs))
+}
+
+func TestTaskwarriorSuite(t *testing.T) {
+	suite.Run(t, new(TaskwarriorSuite))
+}
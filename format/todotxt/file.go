@@ -0,0 +1,53 @@
+package todotxt
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// maxLineSize is the maximum size of a line.
+const maxLineSize = 1 << 20
+
+// Line is a task of a file.
+type Line struct {
+	// Number is the number of the line, counted from 1.
+	Number int
+	Task   Task
+}
+
+// Decode reads the tasks of a file, blank lines are skipped.
+func Decode(r io.Reader) ([]Line, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxLineSize)
+
+	var lines []Line
+	for number := 1; scanner.Scan(); number++ {
+		text := scanner.Text()
+		if number == 1 {
+			text = strings.TrimPrefix(text, "\ufeff")
+		}
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		lines = append(lines, Line{Number: number, Task: Parse(text)})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return lines, nil
+}
+
+// Encode writes the tasks a line each.
+func Encode(w io.Writer, tasks ...Task) error {
+	for index := range tasks {
+		if _, err := io.WriteString(w, tasks[index].String()+"\n"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,155 @@
+// Package todotxt parses and writes the lines of todo.txt files, see
+// https://github.com/todotxt/todo.txt.
+package todotxt
+
+import (
+	"strings"
+	"time"
+)
+
+// MediaType is the media type of todo.txt files.
+const MediaType = "text/plain"
+
+// DateFormat is the format of the dates of tasks.
+const DateFormat = "2006-01-02"
+
+// Task is a line of a todo.txt file.
+type Task struct {
+	Completed bool
+	// Priority is a letter from A to Z, zero if unset.
+	Priority byte
+	// CompletionDate is zero if unset, or if the task isn't completed.
+	CompletionDate time.Time
+	// CreationDate is zero if unset. A completed task has one only if it has
+	// a completion date, as the first date of a completed task is its
+	// completion date.
+	CreationDate time.Time
+	// Description is the rest of the line, the projects, contexts and tags
+	// are kept in place.
+	Description string
+}
+
+// Tag is a key:value extension of a description.
+type Tag struct {
+	Key   string
+	Value string
+}
+
+// Parse parses a line, every line is a valid task. Leading and trailing
+// whitespace is trimmed.
+func Parse(line string) Task {
+	var t Task
+	rest := strings.TrimSpace(line)
+
+	if after, ok := strings.CutPrefix(rest, "x "); ok {
+		t.Completed = true
+		rest = strings.TrimLeft(after, " ")
+	}
+
+	if !t.Completed {
+		t.Priority, rest = CutPriority(rest)
+	}
+
+	// the creation date of a completed task follows its completion date, a
+	// single date is the completion date.
+	if t.Completed {
+		t.CompletionDate, rest = cutDate(rest)
+		if !t.CompletionDate.IsZero() {
+			t.CreationDate, rest = cutDate(rest)
+		}
+	} else {
+		t.CreationDate, rest = cutDate(rest)
+	}
+
+	t.Description = rest
+	return t
+}
+
+// String formats the task as a line, line breaks of the description are
+// replaced by spaces. The priority of a completed task and its creation date
+// without a completion date can't be written, so they're left out.
+func (t *Task) String() string {
+	var b strings.Builder
+	if t.Completed {
+		b.WriteString("x ")
+	}
+	if t.Priority != 0 && !t.Completed {
+		b.WriteString("(")
+		b.WriteByte(t.Priority)
+		b.WriteString(") ")
+	}
+	if t.Completed && !t.CompletionDate.IsZero() {
+		b.WriteString(t.CompletionDate.Format(DateFormat))
+		b.WriteString(" ")
+	}
+	if !t.CreationDate.IsZero() && (!t.Completed || !t.CompletionDate.IsZero()) {
+		b.WriteString(t.CreationDate.Format(DateFormat))
+		b.WriteString(" ")
+	}
+	b.WriteString(strings.Join(strings.Fields(t.Description), " "))
+
+	return strings.TrimRight(b.String(), " ")
+}
+
+// Projects returns the +project tokens of the description, without the
+// sign.
+func (t *Task) Projects() []string {
+	return t.tokens('+')
+}
+
+// Contexts returns the @context tokens of the description, without the
+// sign.
+func (t *Task) Contexts() []string {
+	return t.tokens('@')
+}
+
+// Tags returns the key:value extensions of the description in order. Keys
+// and values contain neither whitespace nor colons, so URLs aren't tags.
+func (t *Task) Tags() []Tag {
+	var tags []Tag
+	for _, field := range strings.Fields(t.Description) {
+		key, value, ok := strings.Cut(field, ":")
+		if !ok || key == "" || value == "" || strings.Contains(value, ":") || strings.HasPrefix(value, "//") {
+			continue
+		}
+
+		tags = append(tags, Tag{Key: key, Value: value})
+	}
+
+	return tags
+}
+
+func (t *Task) tokens(sign byte) []string {
+	var tokens []string
+	for _, field := range strings.Fields(t.Description) {
+		if len(field) > 1 && field[0] == sign {
+			tokens = append(tokens, field[1:])
+		}
+	}
+
+	return tokens
+}
+
+// CutPriority cuts a leading priority like "(A) " from s, the priority is
+// zero if s has none.
+func CutPriority(s string) (byte, string) {
+	if len(s) < 4 || s[0] != '(' || s[1] < 'A' || s[1] > 'Z' || s[2] != ')' || s[3] != ' ' {
+		return 0, s
+	}
+
+	return s[1], strings.TrimLeft(s[4:], " ")
+}
+
+// cutDate cuts a leading date followed by a space or the end of s.
+func cutDate(s string) (time.Time, string) {
+	if len(s) < len(DateFormat) || len(s) > len(DateFormat) && s[len(DateFormat)] != ' ' {
+		return time.Time{}, s
+	}
+
+	date, err := time.Parse(DateFormat, s[:len(DateFormat)])
+	if err != nil {
+		return time.Time{}, s
+	}
+
+	return date, strings.TrimLeft(s[len(DateFormat):], " ")
+}
@@ -0,0 +1,76 @@
+package todotxt_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/omegaatt36/gotasker/format/todotxt"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type TodoTxtSuite struct {
+	suite.Suite
+}
+
+func day(d int) time.Time {
+	return time.Date(2024, time.May, d, 0, 0, 0, 0, time.UTC)
+}
+
+func (s *TodoTxtSuite) TestParse() {
+	for line, expected := range map[string]todotxt.Task{
+		"call mom":                               {Description: "call mom"},
+		"(A) 2024-05-01 call mom +family @phone": {Priority: 'A', CreationDate: day(1), Description: "call mom +family @phone"},
+		"x 2024-05-02 2024-05-01 call mom":       {Completed: true, CompletionDate: day(2), CreationDate: day(1), Description: "call mom"},
+		"x 2024-05-01 call mom":                  {Completed: true, CompletionDate: day(1), Description: "call mom"},
+		"x (A) call mom":                         {Completed: true, Description: "(A) call mom"},
+		"(a) call mom":                           {Description: "(a) call mom"},
+		"xylophone lesson":                       {Description: "xylophone lesson"},
+		"2024-13-01 call mom":                    {Description: "2024-13-01 call mom"},
+		"2024-05-01T08:00 call mom":              {Description: "2024-05-01T08:00 call mom"},
+		"  (B)   2024-05-01   call   mom  ":      {Priority: 'B', CreationDate: day(1), Description: "call   mom"},
+	} {
+		s.Equal(expected, todotxt.Parse(line), line)
+	}
+}
+
+func (s *TodoTxtSuite) TestString() {
+	for expected, t := range map[string]todotxt.Task{
+		"(A) 2024-05-01 call mom +family":  {Priority: 'A', CreationDate: day(1), Description: "call mom +family"},
+		"x 2024-05-02 2024-05-01 call mom": {Completed: true, Priority: 'A', CompletionDate: day(2), CreationDate: day(1), Description: "call mom"},
+		"x 2024-05-02 call mom":            {Completed: true, CompletionDate: day(2), Description: "call mom"},
+		"x call mom":                       {Completed: true, CreationDate: day(1), Description: "call mom"},
+		"call mom and dad":                 {Description: "call mom\nand  dad"},
+	} {
+		s.Equal(expected, t.String())
+		// lines are parsed back to themselves.
+		parsed := todotxt.Parse(expected)
+		s.Equal(expected, parsed.String())
+	}
+}
+
+func (s *TodoTxtSuite) TestTokens() {
+	t := todotxt.Parse("(A) call +mom about @home due:2024-05-03 see https://example.com +family @ a:b:c")
+	s.Equal([]string{"mom", "family"}, t.Projects())
+	s.Equal([]string{"home"}, t.Contexts())
+	s.Equal([]todotxt.Tag{{Key: "due", Value: "2024-05-03"}}, t.Tags())
+}
+
+func (s *TodoTxtSuite) TestDecodeEncode() {
+	lines, err := todotxt.Decode(strings.NewReader("\ufeff(A) call mom\n\n  \nx 2024-05-02 2024-05-01 buy milk\n"))
+	s.Require().NoError(err)
+	s.Equal([]todotxt.Line{
+		{Number: 1, Task: todotxt.Task{Priority: 'A', Description: "call mom"}},
+		{Number: 4, Task: todotxt.Task{Completed: true, CompletionDate: day(2), CreationDate: day(1), Description: "buy milk"}},
+	}, lines)
+
+	var buf bytes.Buffer
+	s.Require().NoError(todotxt.Encode(&buf, lines[0].Task, lines[1].Task))
+	s.Equal("(A) call mom\nx 2024-05-02 2024-05-01 buy milk\n", buf.String())
+}
+
+func TestTodoTxt(t *testing.T) {
+	suite.Run(t, new(TodoTxtSuite))
+}
@@ -0,0 +1,169 @@
+package persistance
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/omegaatt36/gotasker/domain"
+	"github.com/omegaatt36/gotasker/persistance/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisCalendarObjectRepo represents a redis calendar object repository.
+type RedisCalendarObjectRepo struct {
+	client *redis.Client
+}
+
+// NewRedisCalendarObjectRepo creates a new redis calendar object repository.
+func NewRedisCalendarObjectRepo(client *redis.Client) *RedisCalendarObjectRepo {
+	return &RedisCalendarObjectRepo{client: client}
+}
+
+var _ domain.CalendarObjectRepository = (*RedisCalendarObjectRepo)(nil)
+
+// ListCalendarObjects lists all calendar objects ordered by name.
+func (r *RedisCalendarObjectRepo) ListCalendarObjects(ctx context.Context) ([]domain.CalendarObject, error) {
+	objects, err := r.client.HGetAll(ctx, models.KeyCalendarObjectHMap).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list calendar objects: %w", err)
+	}
+
+	result := make([]domain.CalendarObject, 0, len(objects))
+	for _, object := range objects {
+		var modelObject models.CalendarObject
+		if err := json.Unmarshal([]byte(object), &modelObject); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal calendar object: %w", err)
+		}
+		result = append(result, domain.CalendarObject(modelObject))
+	}
+
+	slices.SortFunc(result, func(left, right domain.CalendarObject) int {
+		return cmp.Compare(left.Name, right.Name)
+	})
+
+	return result, nil
+}
+
+// GetCalendarObject gets a calendar object.
+func (r *RedisCalendarObjectRepo) GetCalendarObject(ctx context.Context, name string) (domain.CalendarObject, error) {
+	modelObject, err := getModelCalendarObject(ctx, r.client, name)
+	if err != nil {
+		return domain.CalendarObject{}, err
+	}
+
+	return domain.CalendarObject(modelObject), nil
+}
+
+// GetTaskCalendarObject gets the calendar object of a task.
+func (r *RedisCalendarObjectRepo) GetTaskCalendarObject(ctx context.Context, taskID uint) (domain.CalendarObject, error) {
+	modelObject := models.CalendarObject{TaskID: taskID}
+
+	return r.getIndexedCalendarObject(ctx, models.KeyCalendarObjectTaskHMap, modelObject.TaskKey())
+}
+
+// GetCalendarObjectByUID gets the calendar object of a UID.
+func (r *RedisCalendarObjectRepo) GetCalendarObjectByUID(ctx context.Context, uid string) (domain.CalendarObject, error) {
+	return r.getIndexedCalendarObject(ctx, models.KeyCalendarObjectUIDHMap, uid)
+}
+
+// getIndexedCalendarObject gets the calendar object named by the field of
+// the index.
+func (r *RedisCalendarObjectRepo) getIndexedCalendarObject(ctx context.Context, index, field string) (domain.CalendarObject, error) {
+	name, err := r.client.HGet(ctx, index, field).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return domain.CalendarObject{}, domain.ErrCalendarObjectNotFound
+		}
+
+		return domain.CalendarObject{}, fmt.Errorf("failed to get calendar object: %w", err)
+	}
+
+	return r.GetCalendarObject(ctx, name)
+}
+
+func getModelCalendarObject(ctx context.Context, client redis.Cmdable, name string) (models.CalendarObject, error) {
+	modelObject := models.CalendarObject{Name: name}
+
+	bs, err := client.HGet(ctx, models.KeyCalendarObjectHMap, modelObject.Key()).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return models.CalendarObject{}, domain.ErrCalendarObjectNotFound
+		}
+
+		return models.CalendarObject{}, fmt.Errorf("failed to get calendar object: %w", err)
+	}
+
+	if err := json.Unmarshal(bs, &modelObject); err != nil {
+		return models.CalendarObject{}, fmt.Errorf("failed to unmarshal calendar object: %w", err)
+	}
+
+	return modelObject, nil
+}
+
+// SaveCalendarObject creates or replaces a calendar object, the indexes of
+// its task and UID are written in the same transaction.
+func (r *RedisCalendarObjectRepo) SaveCalendarObject(ctx context.Context, object domain.CalendarObject) error {
+	modelObject := models.CalendarObject(object)
+
+	bs, err := json.Marshal(modelObject)
+	if err != nil {
+		return fmt.Errorf("failed to marshal calendar object: %w", err)
+	}
+
+	return r.writeCalendarObject(ctx, object.Name, func(pipe redis.Pipeliner) {
+		pipe.HSet(ctx, models.KeyCalendarObjectHMap, modelObject.Key(), string(bs))
+		pipe.HSet(ctx, models.KeyCalendarObjectTaskHMap, modelObject.TaskKey(), modelObject.Name)
+		pipe.HSet(ctx, models.KeyCalendarObjectUIDHMap, modelObject.UID, modelObject.Name)
+	})
+}
+
+// DeleteCalendarObject deletes a calendar object, deleting a missing one is
+// not an error.
+func (r *RedisCalendarObjectRepo) DeleteCalendarObject(ctx context.Context, name string) error {
+	modelObject := models.CalendarObject{Name: name}
+
+	return r.writeCalendarObject(ctx, name, func(pipe redis.Pipeliner) {
+		pipe.HDel(ctx, models.KeyCalendarObjectHMap, modelObject.Key())
+	})
+}
+
+// writeCalendarObject removes the indexes of the stored object of the name
+// and writes the object by write in a transaction, which is retried if the
+// object is written concurrently.
+func (r *RedisCalendarObjectRepo) writeCalendarObject(ctx context.Context, name string, write func(redis.Pipeliner)) error {
+	for range maxBatchRetries {
+		err := r.client.Watch(ctx, func(tx *redis.Tx) error {
+			stored, err := getModelCalendarObject(ctx, tx, name)
+			if err != nil && !errors.Is(err, domain.ErrCalendarObjectNotFound) {
+				return err
+			}
+
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				if stored.Name != "" {
+					pipe.HDel(ctx, models.KeyCalendarObjectTaskHMap, stored.TaskKey())
+					pipe.HDel(ctx, models.KeyCalendarObjectUIDHMap, stored.UID)
+				}
+				write(pipe)
+
+				return nil
+			})
+
+			return err
+		}, models.KeyCalendarObjectHMap)
+		if errors.Is(err, redis.TxFailedErr) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write calendar object: %w", err)
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("failed to write calendar object: %w", redis.TxFailedErr)
+}
@@ -0,0 +1,256 @@
+package persistance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/omegaatt36/gotasker/domain"
+	"github.com/omegaatt36/gotasker/logging"
+	"github.com/omegaatt36/gotasker/persistance/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// fields of the entries of the change log.
+const (
+	changeFieldEvent  = "event"
+	changeFieldTask   = "task"
+	changeFieldBefore = "before"
+)
+
+// appendChange appends the change to the change log in the transaction of
+// the change, so the log never misses or invents a change.
+func (r *RedisRepo) appendChange(ctx context.Context, pipe redis.Pipeliner, event domain.Event) error {
+	var task, before *domain.Task
+	switch event := event.(type) {
+	case domain.TaskCreated:
+		task = &event.Task
+	case domain.TaskUpdated:
+		task, before = &event.After, &event.Before
+	case domain.TaskDeleted:
+		task = &event.Task
+	default:
+		return fmt.Errorf("unsupported change %T", event)
+	}
+
+	values := []string{changeFieldEvent, event.Name()}
+	for _, field := range []struct {
+		name string
+		task *domain.Task
+	}{{changeFieldTask, task}, {changeFieldBefore, before}} {
+		if field.task == nil {
+			continue
+		}
+
+		bs, err := json.Marshal(toModelTask(field.task))
+		if err != nil {
+			return fmt.Errorf("failed to marshal task: %w", err)
+		}
+		values = append(values, field.name, string(bs))
+	}
+
+	pipe.XAdd(ctx, &redis.XAddArgs{
+		Stream: models.KeyTaskChangeLog,
+		MaxLen: r.changeLogMaxLen,
+		Approx: true,
+		Values: values,
+	})
+
+	return nil
+}
+
+func toModelTask(task *domain.Task) models.Task {
+	return models.Task{
+		ID:        task.ID,
+		Name:      task.Name,
+		Status:    int(task.Status),
+		CreatedAt: task.CreatedAt,
+		UpdatedAt: task.UpdatedAt,
+	}
+}
+
+// TaskChange is an entry of the change log of tasks, the event is one of
+// domain.TaskCreated, domain.TaskUpdated and domain.TaskDeleted.
+type TaskChange struct {
+	// ID is the ID of the stream entry, which is acknowledged once the
+	// change is handled.
+	ID         string
+	OccurredAt time.Time
+	Event      domain.Event
+}
+
+// ChangeLogReader reads the change log of tasks as a consumer of a consumer
+// group. Every change is delivered to one consumer of the group, and stays
+// pending until it's acknowledged.
+type ChangeLogReader struct {
+	client   *redis.Client
+	group    string
+	consumer string
+}
+
+// NewChangeLogReader creates a reader of the consumer, the group is created
+// if it doesn't exist and starts from the oldest change in the log.
+func NewChangeLogReader(ctx context.Context, client *redis.Client, group, consumer string) (*ChangeLogReader, error) {
+	err := client.XGroupCreateMkStream(ctx, models.KeyTaskChangeLog, group, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return nil, fmt.Errorf("failed to create consumer group: %w", err)
+	}
+
+	return &ChangeLogReader{
+		client:   client,
+		group:    group,
+		consumer: consumer,
+	}, nil
+}
+
+// Read reads at most count changes never delivered to the group. It waits
+// up to block for new changes if there is none, block <= 0 doesn't wait.
+func (r *ChangeLogReader) Read(ctx context.Context, count int64, block time.Duration) ([]TaskChange, error) {
+	if block <= 0 {
+		// go-redis omits BLOCK for negative durations.
+		block = -1
+	}
+
+	return r.read(ctx, ">", count, block)
+}
+
+// Pending reads at most count changes delivered to the consumer but not
+// acknowledged yet, for example before the consumer restarted.
+func (r *ChangeLogReader) Pending(ctx context.Context, count int64) ([]TaskChange, error) {
+	return r.read(ctx, "0", count, -1)
+}
+
+func (r *ChangeLogReader) read(ctx context.Context, id string, count int64, block time.Duration) ([]TaskChange, error) {
+	streams, err := r.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    r.group,
+		Consumer: r.consumer,
+		Streams:  []string{models.KeyTaskChangeLog, id},
+		Count:    count,
+		Block:    block,
+	}).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("failed to read change log: %w", err)
+	}
+
+	var messages []redis.XMessage
+	for _, stream := range streams {
+		messages = append(messages, stream.Messages...)
+	}
+
+	return r.toTaskChanges(ctx, messages)
+}
+
+// Ack acknowledges handled changes, they are never delivered again.
+func (r *ChangeLogReader) Ack(ctx context.Context, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	if err := r.client.XAck(ctx, models.KeyTaskChangeLog, r.group, ids...).Err(); err != nil {
+		return fmt.Errorf("failed to ack changes: %w", err)
+	}
+
+	return nil
+}
+
+// Reclaim takes over at most count changes which have been pending for at
+// least minIdle in any consumer of the group, so the changes of a crashed
+// consumer are handled by another one.
+func (r *ChangeLogReader) Reclaim(ctx context.Context, minIdle time.Duration, count int64) ([]TaskChange, error) {
+	messages, _, err := r.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+		Stream:   models.KeyTaskChangeLog,
+		Group:    r.group,
+		Consumer: r.consumer,
+		MinIdle:  minIdle,
+		Start:    "0-0",
+		Count:    count,
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to reclaim changes: %w", err)
+	}
+
+	return r.toTaskChanges(ctx, messages)
+}
+
+// toTaskChanges decodes the messages. Pending messages which were trimmed
+// from the log have no values, they are acknowledged and skipped. Messages
+// which can't be decoded are logged, acknowledged and skipped too, otherwise
+// they would stay pending and fail every read of them.
+func (r *ChangeLogReader) toTaskChanges(ctx context.Context, messages []redis.XMessage) ([]TaskChange, error) {
+	var (
+		changes []TaskChange
+		skipped []string
+	)
+	for _, message := range messages {
+		if len(message.Values) == 0 {
+			skipped = append(skipped, message.ID)
+			continue
+		}
+
+		change, err := toTaskChange(message)
+		if err != nil {
+			logging.ErrorfCtx(ctx, "skipped change log entry: %v", err)
+			skipped = append(skipped, message.ID)
+			continue
+		}
+		changes = append(changes, change)
+	}
+
+	if err := r.Ack(ctx, skipped...); err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+func toTaskChange(message redis.XMessage) (TaskChange, error) {
+	decode := func(field string) (domain.Task, error) {
+		value, _ := message.Values[field].(string)
+
+		var modelTask models.Task
+		if err := json.Unmarshal([]byte(value), &modelTask); err != nil {
+			return domain.Task{}, fmt.Errorf("failed to unmarshal %s of change %s: %w", field, message.ID, err)
+		}
+
+		return toDomainTask(&modelTask), nil
+	}
+
+	change := TaskChange{ID: message.ID}
+
+	// entry IDs start with the unix milliseconds of the change.
+	ms, _, _ := strings.Cut(message.ID, "-")
+	if ms, err := strconv.ParseInt(ms, 10, 64); err == nil {
+		change.OccurredAt = time.UnixMilli(ms)
+	}
+
+	task, err := decode(changeFieldTask)
+	if err != nil {
+		return TaskChange{}, err
+	}
+
+	switch name, _ := message.Values[changeFieldEvent].(string); name {
+	case domain.TaskCreated{}.Name():
+		change.Event = domain.TaskCreated{Task: task}
+	case domain.TaskUpdated{}.Name():
+		before, err := decode(changeFieldBefore)
+		if err != nil {
+			return TaskChange{}, err
+		}
+		change.Event = domain.TaskUpdated{Before: before, After: task}
+	case domain.TaskDeleted{}.Name():
+		change.Event = domain.TaskDeleted{Task: task}
+	default:
+		return TaskChange{}, fmt.Errorf("unknown event %q of change %s", name, message.ID)
+	}
+
+	return change, nil
+}
@@ -0,0 +1,222 @@
+package persistance_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/omegaatt36/gotasker/domain"
+	"github.com/omegaatt36/gotasker/logging"
+	"github.com/omegaatt36/gotasker/persistance"
+	"github.com/omegaatt36/gotasker/persistance/database"
+	"github.com/omegaatt36/gotasker/persistance/models"
+	"github.com/omegaatt36/gotasker/util"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/suite"
+)
+
+type ChangeLogSuite struct {
+	suite.Suite
+}
+
+func (s *ChangeLogSuite) SetupSuite() {
+	logging.Init(false, "error")
+}
+
+func (s *ChangeLogSuite) TestChangeLog() {
+	miniredis := database.InitializeTestingRedis()
+	defer miniredis.Close()
+
+	database.Initialize(context.Background(), miniredis.Addr(), "")
+	ctx := context.Background()
+	repo := persistance.NewRedisRepo(database.Redis())
+
+	reader, err := persistance.NewChangeLogReader(ctx, database.Redis(), "downstream", "consumer-1")
+	s.Require().NoError(err)
+
+	changes, err := reader.Read(ctx, 10, 0)
+	s.Require().NoError(err)
+	s.Empty(changes)
+
+	created, err := repo.CreateTask(ctx, domain.CreateTaskRequest{Name: "task 1"})
+	s.Require().NoError(err)
+	s.Require().NoError(repo.UpdateTask(ctx, created.ID, domain.UpdateTaskRequest{
+		Status: util.Pointer(domain.TaskStatusCompleted),
+	}))
+	_, err = repo.BatchTasks(ctx, []domain.BatchOperation{
+		{Type: domain.BatchOperationTypeCreate, Create: domain.CreateTaskRequest{Name: "task 2"}},
+		{Type: domain.BatchOperationTypeDelete, ID: created.ID},
+	})
+	s.Require().NoError(err)
+
+	changes, err = reader.Read(ctx, 10, time.Millisecond)
+	s.Require().NoError(err)
+	s.Require().Len(changes, 4)
+
+	s.Require().IsType(domain.TaskCreated{}, changes[0].Event)
+	s.Equal(created.ID, changes[0].Event.TaskID())
+	s.True(created.CreatedAt.Equal(changes[0].Event.(domain.TaskCreated).Task.CreatedAt))
+	s.False(changes[0].OccurredAt.IsZero())
+
+	updated := changes[1].Event.(domain.TaskUpdated)
+	s.Equal(domain.TaskStatusIncomplete, updated.Before.Status)
+	s.Equal(domain.TaskStatusCompleted, updated.After.Status)
+
+	s.Equal("task 2", changes[2].Event.(domain.TaskCreated).Task.Name)
+	s.Require().IsType(domain.TaskDeleted{}, changes[3].Event)
+	s.Equal(created.ID, changes[3].Event.TaskID())
+	s.Equal(domain.TaskStatusCompleted, changes[3].Event.(domain.TaskDeleted).Task.Status)
+
+	s.Run("pending", func() {
+		s.Require().NoError(reader.Ack(ctx, changes[0].ID, changes[1].ID))
+
+		pending, err := reader.Pending(ctx, 10)
+		s.Require().NoError(err)
+		s.Require().Len(pending, 2)
+		s.Equal(changes[2].ID, pending[0].ID)
+
+		// another consumer takes over the changes idle for long enough.
+		other, err := persistance.NewChangeLogReader(ctx, database.Redis(), "downstream", "consumer-2")
+		s.Require().NoError(err)
+
+		reclaimed, err := other.Reclaim(ctx, time.Hour, 10)
+		s.Require().NoError(err)
+		s.Empty(reclaimed)
+
+		miniredis.SetTime(time.Now().Add(2 * time.Hour))
+		reclaimed, err = other.Reclaim(ctx, time.Hour, 10)
+		s.Require().NoError(err)
+		s.Require().Len(reclaimed, 2)
+		s.Require().NoError(other.Ack(ctx, reclaimed[0].ID, reclaimed[1].ID))
+
+		pending, err = reader.Pending(ctx, 10)
+		s.Require().NoError(err)
+		s.Empty(pending)
+	})
+
+	s.Run("groups", func() {
+		// every group receives every change.
+		another, err := persistance.NewChangeLogReader(ctx, database.Redis(), "another", "consumer-1")
+		s.Require().NoError(err)
+
+		changes, err := another.Read(ctx, 10, 0)
+		s.Require().NoError(err)
+		s.Len(changes, 4)
+	})
+}
+
+func (s *ChangeLogSuite) TestUndecodableChanges() {
+	miniredis := database.InitializeTestingRedis()
+	defer miniredis.Close()
+
+	database.Initialize(context.Background(), miniredis.Addr(), "")
+	ctx := context.Background()
+	repo := persistance.NewRedisRepo(database.Redis())
+
+	reader, err := persistance.NewChangeLogReader(ctx, database.Redis(), "downstream", "consumer-1")
+	s.Require().NoError(err)
+
+	s.Require().NoError(database.Redis().XAdd(ctx, &redis.XAddArgs{
+		Stream: models.KeyTaskChangeLog,
+		Values: []string{"event", domain.TaskCreated{}.Name(), "task", "{"},
+	}).Err())
+	_, err = repo.CreateTask(ctx, domain.CreateTaskRequest{Name: "task 1"})
+	s.Require().NoError(err)
+
+	// the undecodable change is skipped and doesn't stay pending.
+	changes, err := reader.Read(ctx, 10, 0)
+	s.Require().NoError(err)
+	s.Require().Len(changes, 1)
+	s.Equal("task 1", changes[0].Event.(domain.TaskCreated).Task.Name)
+
+	pending, err := reader.Pending(ctx, 10)
+	s.Require().NoError(err)
+	s.Require().Len(pending, 1)
+	s.Equal(changes[0].ID, pending[0].ID)
+}
+
+func (s *ChangeLogSuite) TestChangeLogMaxLen() {
+	miniredis := database.InitializeTestingRedis()
+	defer miniredis.Close()
+
+	database.Initialize(context.Background(), miniredis.Addr(), "")
+	ctx := context.Background()
+	repo := persistance.NewRedisRepo(database.Redis(), persistance.WithChangeLogMaxLen(3))
+
+	for range 5 {
+		_, err := repo.CreateTask(ctx, domain.CreateTaskRequest{Name: "task"})
+		s.Require().NoError(err)
+	}
+
+	length, err := database.Redis().XLen(ctx, models.KeyTaskChangeLog).Result()
+	s.Require().NoError(err)
+	s.EqualValues(3, length)
+}
+
+func (s *ChangeLogSuite) TestConcurrentUpdateAndDelete() {
+	miniredis := database.InitializeTestingRedis()
+	defer miniredis.Close()
+
+	database.Initialize(context.Background(), miniredis.Addr(), "")
+	ctx := context.Background()
+	repo := persistance.NewRedisRepo(database.Redis())
+
+	created, err := repo.CreateTask(ctx, domain.CreateTaskRequest{Name: "task 1"})
+	s.Require().NoError(err)
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		deleted int
+	)
+	for index := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			var err error
+			if index%2 == 0 {
+				err = repo.DeleteTask(ctx, created.ID)
+			} else {
+				err = repo.UpdateTask(ctx, created.ID, domain.UpdateTaskRequest{Name: util.Pointer("task 2")})
+			}
+			if !errors.Is(err, domain.ErrTaskNotFound) {
+				s.NoError(err)
+			}
+			if err == nil && index%2 == 0 {
+				mu.Lock()
+				deleted++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	s.Equal(1, deleted)
+	_, err = repo.GetTask(ctx, created.ID)
+	s.ErrorIs(err, domain.ErrTaskNotFound)
+
+	reader, err := persistance.NewChangeLogReader(ctx, database.Redis(), "downstream", "consumer-1")
+	s.Require().NoError(err)
+	changes, err := reader.Read(ctx, 100, 0)
+	s.Require().NoError(err)
+
+	// the delete is logged once and last, every update is logged with the
+	// task as it was before it.
+	s.Require().IsType(domain.TaskDeleted{}, changes[len(changes)-1].Event)
+	previous := changes[0].Event.(domain.TaskCreated).Task
+	for _, change := range changes[1 : len(changes)-1] {
+		updated, ok := change.Event.(domain.TaskUpdated)
+		s.Require().True(ok)
+		s.True(previous.UpdatedAt.Equal(updated.Before.UpdatedAt))
+		previous = updated.After
+	}
+	s.True(previous.UpdatedAt.Equal(changes[len(changes)-1].Event.(domain.TaskDeleted).Task.UpdatedAt))
+}
+
+func TestChangeLog(t *testing.T) {
+	suite.Run(t, new(ChangeLogSuite))
+}
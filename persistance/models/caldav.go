@@ -0,0 +1,32 @@
+package models
+
+import "fmt"
+
+// calendar object related constants
+const (
+	// KeyCalendarObjectHMap maps the names of calendar object resources to
+	// the objects.
+	KeyCalendarObjectHMap = "caldav_objects_map"
+	// KeyCalendarObjectTaskHMap maps the task IDs of the objects to their
+	// names.
+	KeyCalendarObjectTaskHMap = "caldav_object_tasks_map"
+	// KeyCalendarObjectUIDHMap maps the UIDs of the objects to their names.
+	KeyCalendarObjectUIDHMap = "caldav_object_uids_map"
+)
+
+// CalendarObject represents a calendar object.
+type CalendarObject struct {
+	Name   string `json:"name"`
+	UID    string `json:"uid"`
+	TaskID uint   `json:"task_id"`
+}
+
+// Key returns key.
+func (o *CalendarObject) Key() string {
+	return o.Name
+}
+
+// TaskKey returns the key of the object in KeyCalendarObjectTaskHMap.
+func (o *CalendarObject) TaskKey() string {
+	return fmt.Sprintf("%d", o.TaskID)
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// notification related constants
+const (
+	KeyRecipientAutoIncrementID = "notification_recipients_auto_increment_id"
+	KeyRecipientHMap            = "notification_recipients_map"
+
+	KeyNotificationAutoIncrementID = "notifications_auto_increment_id"
+	KeyNotificationHMap            = "notifications_map"
+	// KeyNotificationQueue is a sorted set of the IDs of pending
+	// notifications scored by the unix milliseconds of their next attempt.
+	KeyNotificationQueue = "notification_queue"
+)
+
+// Recipient represents a notification recipient.
+type Recipient struct {
+	ID        uint      `json:"id"`
+	Email     string    `json:"email"`
+	Name      string    `json:"name"`
+	Events    []string  `json:"events"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Key returns key.
+func (r *Recipient) Key() string {
+	return fmt.Sprintf("%d", r.ID)
+}
+
+// NotificationsKey returns the key of the sorted set of the notification IDs
+// of the recipient.
+func (r *Recipient) NotificationsKey() string {
+	return fmt.Sprintf("recipient_notifications:%d", r.ID)
+}
+
+// Notification represents an email notification.
+type Notification struct {
+	ID            uint              `json:"id"`
+	RecipientID   uint              `json:"recipient_id"`
+	EventID       string            `json:"event_id"`
+	Event         string            `json:"event"`
+	TaskID        uint              `json:"task_id"`
+	Subject       string            `json:"subject"`
+	Text          string            `json:"text"`
+	HTML          string            `json:"html"`
+	State         int               `json:"state"`
+	Attempts      []DeliveryAttempt `json:"attempts"`
+	NextAttemptAt time.Time         `json:"next_attempt_at"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+}
+
+// Key returns key.
+func (n *Notification) Key() string {
+	return fmt.Sprintf("%d", n.ID)
+}
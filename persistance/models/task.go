@@ -1,18 +1,29 @@
 package models
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 // task related constants
 const (
 	KeyTaskAutoIncrementID = "tasks_auto_increment_id"
 	KeyTaskHMap            = "tasks_map"
+	// KeyTaskVersion is increased on every write to the task collection.
+	KeyTaskVersion = "tasks_version"
+	// KeyTaskChangeLog is a stream of the changes of tasks, appended in the
+	// same transaction as the changes.
+	KeyTaskChangeLog = "tasks_change_log"
 )
 
 // Task represents a task.
 type Task struct {
-	ID     uint   `json:"id"`
-	Name   string `json:"name"`
-	Status int    `json:"status"`
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	Status    int       `json:"status"`
+	Notes     string    `json:"notes,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 // Key returns key.
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// taskwarrior task related constants
+const (
+	// KeyTaskwarriorTaskHMap maps the UUIDs of Taskwarrior tasks to the
+	// tasks.
+	KeyTaskwarriorTaskHMap = "taskwarrior_tasks_map"
+)
+
+// TaskwarriorTask represents a Taskwarrior task.
+type TaskwarriorTask struct {
+	UUID        string                     `json:"uuid"`
+	TaskID      uint                       `json:"task_id"`
+	Tags        []string                   `json:"tags,omitempty"`
+	Annotations []TaskwarriorAnnotation    `json:"annotations,omitempty"`
+	Attributes  map[string]json.RawMessage `json:"attributes,omitempty"`
+}
+
+// TaskwarriorAnnotation represents an annotation of a Taskwarrior task.
+type TaskwarriorAnnotation struct {
+	Entry       time.Time `json:"entry"`
+	Description string    `json:"description"`
+}
+
+// Key returns key.
+func (t *TaskwarriorTask) Key() string {
+	return t.UUID
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// webhook related constants
+const (
+	KeyWebhookAutoIncrementID = "webhooks_auto_increment_id"
+	KeyWebhookHMap            = "webhooks_map"
+
+	KeyDeliveryAutoIncrementID = "webhook_deliveries_auto_increment_id"
+	KeyDeliveryHMap            = "webhook_deliveries_map"
+	// KeyDeliveryQueue is a sorted set of the IDs of pending deliveries
+	// scored by the unix milliseconds of their next attempt.
+	KeyDeliveryQueue = "webhook_delivery_queue"
+)
+
+// Webhook represents a webhook.
+type Webhook struct {
+	ID        uint      `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Secret    string    `json:"secret"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Key returns key.
+func (w *Webhook) Key() string {
+	return fmt.Sprintf("%d", w.ID)
+}
+
+// DeliveriesKey returns the key of the sorted set of the delivery IDs of the
+// webhook.
+func (w *Webhook) DeliveriesKey() string {
+	return fmt.Sprintf("webhook_deliveries:%d", w.ID)
+}
+
+// Delivery represents a webhook delivery.
+type Delivery struct {
+	ID            uint              `json:"id"`
+	WebhookID     uint              `json:"webhook_id"`
+	EventID       string            `json:"event_id"`
+	Event         string            `json:"event"`
+	Payload       []byte            `json:"payload"`
+	State         int               `json:"state"`
+	Attempts      []DeliveryAttempt `json:"attempts"`
+	NextAttemptAt time.Time         `json:"next_attempt_at"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+}
+
+// DeliveryAttempt represents an attempt of a webhook delivery.
+type DeliveryAttempt struct {
+	At         time.Time     `json:"at"`
+	StatusCode int           `json:"status_code"`
+	Error      string        `json:"error"`
+	Duration   time.Duration `json:"duration"`
+}
+
+// Key returns key.
+func (d *Delivery) Key() string {
+	return fmt.Sprintf("%d", d.ID)
+}
@@ -0,0 +1,292 @@
+package persistance
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/omegaatt36/gotasker/domain"
+	"github.com/omegaatt36/gotasker/persistance/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisNotificationRepo represents a redis notification repository.
+type RedisNotificationRepo struct {
+	client        *redis.Client
+	notifications *deliveryQueue
+}
+
+// NewRedisNotificationRepo creates a new redis notification repository.
+func NewRedisNotificationRepo(client *redis.Client) *RedisNotificationRepo {
+	return &RedisNotificationRepo{
+		client: client,
+		notifications: &deliveryQueue{
+			client:           client,
+			name:             "notification",
+			autoIncrementKey: models.KeyNotificationAutoIncrementID,
+			hashKey:          models.KeyNotificationHMap,
+			queueKey:         models.KeyNotificationQueue,
+		},
+	}
+}
+
+var _ domain.NotificationRepository = (*RedisNotificationRepo)(nil)
+
+// CreateRecipient creates a new recipient.
+func (r *RedisNotificationRepo) CreateRecipient(ctx context.Context, req domain.CreateRecipientRequest) (domain.Recipient, error) {
+	id, err := r.client.Incr(ctx, models.KeyRecipientAutoIncrementID).Result()
+	if err != nil {
+		return domain.Recipient{}, fmt.Errorf("failed to create recipient: %w", err)
+	}
+
+	now := time.Now()
+	modelRecipient := models.Recipient{
+		ID:        uint(id),
+		Email:     req.Email,
+		Name:      req.Name,
+		Events:    req.Events,
+		Active:    true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := r.saveRecipient(ctx, &modelRecipient); err != nil {
+		return domain.Recipient{}, fmt.Errorf("failed to create recipient: %w", err)
+	}
+
+	return toDomainRecipient(&modelRecipient), nil
+}
+
+// ListRecipients lists all recipients.
+func (r *RedisNotificationRepo) ListRecipients(ctx context.Context) ([]domain.Recipient, error) {
+	recipients, err := r.client.HGetAll(ctx, models.KeyRecipientHMap).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list recipients: %w", err)
+	}
+
+	result := make([]domain.Recipient, 0, len(recipients))
+	for _, recipient := range recipients {
+		var modelRecipient models.Recipient
+		if err := json.Unmarshal([]byte(recipient), &modelRecipient); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal recipient: %w", err)
+		}
+		result = append(result, toDomainRecipient(&modelRecipient))
+	}
+
+	slices.SortFunc(result, func(left, right domain.Recipient) int {
+		return cmp.Compare(left.ID, right.ID)
+	})
+
+	return result, nil
+}
+
+// GetRecipient gets a recipient.
+func (r *RedisNotificationRepo) GetRecipient(ctx context.Context, id uint) (domain.Recipient, error) {
+	modelRecipient, err := r.getRecipient(ctx, id)
+	if err != nil {
+		return domain.Recipient{}, err
+	}
+
+	return toDomainRecipient(modelRecipient), nil
+}
+
+// UpdateRecipient updates a recipient.
+func (r *RedisNotificationRepo) UpdateRecipient(ctx context.Context, id uint, req domain.UpdateRecipientRequest) (domain.Recipient, error) {
+	modelRecipient, err := r.getRecipient(ctx, id)
+	if err != nil {
+		return domain.Recipient{}, err
+	}
+
+	if req.Email != nil {
+		modelRecipient.Email = *req.Email
+	}
+	if req.Name != nil {
+		modelRecipient.Name = *req.Name
+	}
+	if req.Events != nil {
+		modelRecipient.Events = *req.Events
+	}
+	if req.Active != nil {
+		modelRecipient.Active = *req.Active
+	}
+	modelRecipient.UpdatedAt = time.Now()
+
+	if err := r.saveRecipient(ctx, modelRecipient); err != nil {
+		return domain.Recipient{}, fmt.Errorf("failed to update recipient: %w", err)
+	}
+
+	return toDomainRecipient(modelRecipient), nil
+}
+
+// DeleteRecipient deletes a recipient and its notification log.
+func (r *RedisNotificationRepo) DeleteRecipient(ctx context.Context, id uint) error {
+	modelRecipient, err := r.getRecipient(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	ids, err := r.notifications.logIDs(ctx, modelRecipient.NotificationsKey())
+	if err != nil {
+		return err
+	}
+
+	if _, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HDel(ctx, models.KeyRecipientHMap, modelRecipient.Key())
+		r.notifications.deleteLog(ctx, pipe, modelRecipient.NotificationsKey(), ids)
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to delete recipient: %w", err)
+	}
+
+	return nil
+}
+
+func (r *RedisNotificationRepo) getRecipient(ctx context.Context, id uint) (*models.Recipient, error) {
+	modelRecipient := models.Recipient{
+		ID: id,
+	}
+
+	bs, err := r.client.HGet(ctx, models.KeyRecipientHMap, modelRecipient.Key()).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, domain.ErrRecipientNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get recipient: %w", err)
+	}
+
+	if err := json.Unmarshal(bs, &modelRecipient); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal recipient: %w", err)
+	}
+
+	return &modelRecipient, nil
+}
+
+func (r *RedisNotificationRepo) saveRecipient(ctx context.Context, modelRecipient *models.Recipient) error {
+	bs, err := json.Marshal(modelRecipient)
+	if err != nil {
+		return fmt.Errorf("failed to marshal recipient: %w", err)
+	}
+
+	return r.client.HSet(ctx, models.KeyRecipientHMap, modelRecipient.Key(), string(bs)).Err()
+}
+
+// CreateNotification saves a new notification and queues it. The oldest
+// notifications of the recipient beyond the log size are removed once they
+// aren't pending.
+func (r *RedisNotificationRepo) CreateNotification(ctx context.Context, notification domain.Notification) (domain.Notification, error) {
+	id, err := r.notifications.nextID(ctx)
+	if err != nil {
+		return domain.Notification{}, err
+	}
+
+	now := time.Now()
+	notification.ID = id
+	notification.CreatedAt = now
+	notification.UpdatedAt = now
+
+	if err := r.notifications.create(ctx, toNotificationEntry(&notification)); err != nil {
+		return domain.Notification{}, err
+	}
+
+	return notification, nil
+}
+
+// SaveNotification saves the notification, it's queued while pending.
+// Notifications removed from the log, by trimming or deleting the recipient,
+// aren't saved.
+func (r *RedisNotificationRepo) SaveNotification(ctx context.Context, notification domain.Notification) error {
+	notification.UpdatedAt = time.Now()
+
+	return r.notifications.save(ctx, toNotificationEntry(&notification))
+}
+
+// ListNotifications lists the notification log of a recipient, the latest
+// first.
+func (r *RedisNotificationRepo) ListNotifications(ctx context.Context, recipientID uint) ([]domain.Notification, error) {
+	recipient := models.Recipient{ID: recipientID}
+	values, err := r.notifications.list(ctx, recipient.NotificationsKey())
+	if err != nil {
+		return nil, err
+	}
+
+	return unmarshalEntries(r.notifications, values, toDomainNotification)
+}
+
+// ClaimDueNotifications claims up to limit notifications due at now by
+// postponing them in the queue for the lease.
+func (r *RedisNotificationRepo) ClaimDueNotifications(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]domain.Notification, error) {
+	values, err := r.notifications.claim(ctx, now, lease, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return unmarshalEntries(r.notifications, values, toDomainNotification)
+}
+
+func toDomainRecipient(modelRecipient *models.Recipient) domain.Recipient {
+	return domain.Recipient{
+		ID:        modelRecipient.ID,
+		Email:     modelRecipient.Email,
+		Name:      modelRecipient.Name,
+		Events:    modelRecipient.Events,
+		Active:    modelRecipient.Active,
+		CreatedAt: modelRecipient.CreatedAt,
+		UpdatedAt: modelRecipient.UpdatedAt,
+	}
+}
+
+func toNotificationEntry(notification *domain.Notification) *queueEntry {
+	modelNotification := toModelNotification(notification)
+	recipient := models.Recipient{ID: notification.RecipientID}
+
+	return &queueEntry{
+		ID:            modelNotification.ID,
+		Key:           modelNotification.Key(),
+		LogKey:        recipient.NotificationsKey(),
+		Value:         modelNotification,
+		Pending:       notification.State == domain.DeliveryStatePending,
+		NextAttemptAt: notification.NextAttemptAt,
+	}
+}
+
+func toModelNotification(notification *domain.Notification) models.Notification {
+	return models.Notification{
+		ID:            notification.ID,
+		RecipientID:   notification.RecipientID,
+		EventID:       notification.EventID,
+		Event:         notification.Event,
+		TaskID:        notification.TaskID,
+		Subject:       notification.Subject,
+		Text:          notification.Text,
+		HTML:          notification.HTML,
+		State:         int(notification.State),
+		Attempts:      toModelAttempts(notification.Attempts),
+		NextAttemptAt: notification.NextAttemptAt,
+		CreatedAt:     notification.CreatedAt,
+		UpdatedAt:     notification.UpdatedAt,
+	}
+}
+
+func toDomainNotification(modelNotification *models.Notification) domain.Notification {
+	return domain.Notification{
+		ID:            modelNotification.ID,
+		RecipientID:   modelNotification.RecipientID,
+		EventID:       modelNotification.EventID,
+		Event:         modelNotification.Event,
+		TaskID:        modelNotification.TaskID,
+		Subject:       modelNotification.Subject,
+		Text:          modelNotification.Text,
+		HTML:          modelNotification.HTML,
+		State:         domain.DeliveryState(modelNotification.State),
+		Attempts:      toDomainAttempts(modelNotification.Attempts),
+		NextAttemptAt: modelNotification.NextAttemptAt,
+		CreatedAt:     modelNotification.CreatedAt,
+		UpdatedAt:     modelNotification.UpdatedAt,
+	}
+}
@@ -0,0 +1,94 @@
+package persistance_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/omegaatt36/gotasker/domain"
+	"github.com/omegaatt36/gotasker/persistance"
+	"github.com/omegaatt36/gotasker/persistance/database"
+	"github.com/omegaatt36/gotasker/persistance/models"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type NotificationRepoSuite struct {
+	suite.Suite
+}
+
+func (s *NotificationRepoSuite) TestTrimNotifications() {
+	miniredis := database.InitializeTestingRedis()
+	defer miniredis.Close()
+
+	database.Initialize(context.Background(), miniredis.Addr(), "")
+	ctx := context.Background()
+	repo := persistance.NewRedisNotificationRepo(database.Redis())
+
+	recipient, err := repo.CreateRecipient(ctx, domain.CreateRecipientRequest{Email: "alice@example.com"})
+	s.Require().NoError(err)
+
+	var notifications []domain.Notification
+	for range 150 {
+		notification, err := repo.CreateNotification(ctx, domain.Notification{
+			RecipientID:   recipient.ID,
+			State:         domain.DeliveryStatePending,
+			NextAttemptAt: time.Now(),
+		})
+		s.Require().NoError(err)
+		notifications = append(notifications, notification)
+	}
+
+	// pending notifications are kept beyond the log size.
+	logged, err := repo.ListNotifications(ctx, recipient.ID)
+	s.Require().NoError(err)
+	s.Len(logged, 150)
+
+	for _, notification := range notifications[:100] {
+		notification.State = domain.DeliveryStateSucceeded
+		s.Require().NoError(repo.SaveNotification(ctx, notification))
+	}
+
+	_, err = repo.CreateNotification(ctx, domain.Notification{
+		RecipientID:   recipient.ID,
+		State:         domain.DeliveryStatePending,
+		NextAttemptAt: time.Now(),
+	})
+	s.Require().NoError(err)
+
+	logged, err = repo.ListNotifications(ctx, recipient.ID)
+	s.Require().NoError(err)
+	s.Len(logged, 100)
+	s.Equal(notifications[51].ID, logged[len(logged)-1].ID)
+
+	due, err := repo.ClaimDueNotifications(ctx, time.Now(), time.Minute, 100)
+	s.Require().NoError(err)
+	s.Len(due, 51)
+
+	s.Run("save trimmed", func() {
+		trimmed := notifications[0]
+		trimmed.State = domain.DeliveryStatePending
+		s.Require().NoError(repo.SaveNotification(ctx, trimmed))
+
+		exists, err := database.Redis().HExists(ctx, models.KeyNotificationHMap, (&models.Notification{ID: trimmed.ID}).Key()).Result()
+		s.Require().NoError(err)
+		s.False(exists)
+	})
+
+	s.Run("save deleted", func() {
+		s.Require().NoError(repo.DeleteRecipient(ctx, recipient.ID))
+		s.Require().NoError(repo.SaveNotification(ctx, notifications[149]))
+
+		exists, err := database.Redis().HExists(ctx, models.KeyNotificationHMap, (&models.Notification{ID: notifications[149].ID}).Key()).Result()
+		s.Require().NoError(err)
+		s.False(exists)
+
+		due, err := repo.ClaimDueNotifications(ctx, time.Now().Add(time.Hour), time.Minute, 100)
+		s.Require().NoError(err)
+		s.Empty(due)
+	})
+}
+
+func TestNotificationRepo(t *testing.T) {
+	suite.Run(t, new(NotificationRepoSuite))
+}
@@ -0,0 +1,235 @@
+package persistance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// maxQueueLogSize is the number of entries kept in the log of an owner,
+// older ones are removed when new entries are created.
+const maxQueueLogSize = 100
+
+// deliveryQueue stores retried deliveries, webhook deliveries and email
+// notifications, in Redis. Entries are JSON values in a hash keyed by their
+// IDs, pending ones are in a queue shared by all processes, scored by the
+// unix milliseconds of their next attempt, and every owner, a webhook or a
+// recipient, has a log of its entries.
+type deliveryQueue struct {
+	client *redis.Client
+	// name of the entries in errors.
+	name string
+
+	autoIncrementKey string
+	hashKey          string
+	queueKey         string
+}
+
+// queueEntry is an entry written to a delivery queue.
+type queueEntry struct {
+	ID  uint
+	Key string
+	// LogKey is the key of the log of the owner.
+	LogKey string
+	// Value is marshaled to JSON.
+	Value         any
+	Pending       bool
+	NextAttemptAt time.Time
+}
+
+// nextID returns the ID of a new entry.
+func (q *deliveryQueue) nextID(ctx context.Context) (uint, error) {
+	id, err := q.client.Incr(ctx, q.autoIncrementKey).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to create %s: %w", q.name, err)
+	}
+
+	return uint(id), nil
+}
+
+// create saves a new entry, adds it to the log of its owner and queues it
+// if pending. The oldest entries of the log beyond the log size are removed
+// once they aren't pending.
+func (q *deliveryQueue) create(ctx context.Context, entry *queueEntry) error {
+	bs, err := json.Marshal(entry.Value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", q.name, err)
+	}
+
+	if _, err := q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, q.hashKey, entry.Key, string(bs))
+		if entry.Pending {
+			pipe.ZAdd(ctx, q.queueKey, redis.Z{
+				Score:  float64(entry.NextAttemptAt.UnixMilli()),
+				Member: entry.Key,
+			})
+		}
+		pipe.ZAdd(ctx, entry.LogKey, redis.Z{Score: float64(entry.ID), Member: entry.Key})
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to create %s: %w", q.name, err)
+	}
+
+	if err := trimScript.Run(ctx, q.client,
+		[]string{entry.LogKey, q.hashKey, q.queueKey}, maxQueueLogSize).Err(); err != nil {
+		return fmt.Errorf("failed to trim %s log: %w", q.name, err)
+	}
+
+	return nil
+}
+
+// trimScript removes the oldest entries of a log beyond the log size, which
+// are in a final state. Pending entries are in the queue and kept until they
+// are, so no scheduled attempt is dropped.
+var trimScript = redis.NewScript(`
+local ids = redis.call('ZRANGE', KEYS[1], 0, -tonumber(ARGV[1]) - 1)
+for _, id in ipairs(ids) do
+	if not redis.call('ZSCORE', KEYS[3], id) then
+		redis.call('ZREM', KEYS[1], id)
+		redis.call('HDEL', KEYS[2], id)
+	end
+end
+return 0
+`)
+
+// saveScript saves an entry of a log and queues it if a score is given, it
+// does nothing if the entry was removed from the log, so removed entries
+// aren't written back.
+var saveScript = redis.NewScript(`
+if not redis.call('ZSCORE', KEYS[1], ARGV[1]) then
+	return 0
+end
+redis.call('HSET', KEYS[2], ARGV[1], ARGV[2])
+if ARGV[3] == '' then
+	redis.call('ZREM', KEYS[3], ARGV[1])
+else
+	redis.call('ZADD', KEYS[3], ARGV[3], ARGV[1])
+end
+return 1
+`)
+
+// save saves an entry, it's queued while pending. Entries removed from the
+// log, by trimming or deleting the owner, aren't saved.
+func (q *deliveryQueue) save(ctx context.Context, entry *queueEntry) error {
+	bs, err := json.Marshal(entry.Value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", q.name, err)
+	}
+
+	var score string
+	if entry.Pending {
+		score = strconv.FormatInt(entry.NextAttemptAt.UnixMilli(), 10)
+	}
+
+	if err := saveScript.Run(ctx, q.client,
+		[]string{entry.LogKey, q.hashKey, q.queueKey},
+		entry.Key, string(bs), score).Err(); err != nil {
+		return fmt.Errorf("failed to save %s: %w", q.name, err)
+	}
+
+	return nil
+}
+
+// get gets the value of an entry, it returns redis.Nil if it's missing.
+func (q *deliveryQueue) get(ctx context.Context, key string) ([]byte, error) {
+	return q.client.HGet(ctx, q.hashKey, key).Bytes()
+}
+
+// list lists the values of the log of an owner, the latest first.
+func (q *deliveryQueue) list(ctx context.Context, logKey string) ([]string, error) {
+	ids, err := q.client.ZRevRange(ctx, logKey, 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s log: %w", q.name, err)
+	}
+
+	return q.getAll(ctx, ids)
+}
+
+// claimScript postpones the due entries in a single step, so an entry is
+// claimed by one caller only.
+var claimScript = redis.NewScript(`
+local ids = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[2])
+for _, id in ipairs(ids) do
+	redis.call('ZADD', KEYS[1], ARGV[3], id)
+end
+return ids
+`)
+
+// claim claims the values of up to limit entries due at now by postponing
+// them in the queue for the lease.
+func (q *deliveryQueue) claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]string, error) {
+	ids, err := claimScript.Run(ctx, q.client, []string{q.queueKey},
+		now.UnixMilli(), limit, now.Add(lease).UnixMilli()).StringSlice()
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim %s: %w", q.name, err)
+	}
+
+	return q.getAll(ctx, ids)
+}
+
+// getAll gets the values of entries in the order of ids, missing ones are
+// skipped.
+func (q *deliveryQueue) getAll(ctx context.Context, ids []string) ([]string, error) {
+	result := make([]string, 0, len(ids))
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	values, err := q.client.HMGet(ctx, q.hashKey, ids...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s: %w", q.name, err)
+	}
+
+	for _, value := range values {
+		if s, ok := value.(string); ok {
+			result = append(result, s)
+		}
+	}
+
+	return result, nil
+}
+
+// logIDs lists the IDs in the log of an owner, which deleteLog deletes.
+func (q *deliveryQueue) logIDs(ctx context.Context, logKey string) ([]string, error) {
+	ids, err := q.client.ZRange(ctx, logKey, 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s log: %w", q.name, err)
+	}
+
+	return ids, nil
+}
+
+// deleteLog deletes the log of an owner and its entries in the transaction
+// deleting the owner.
+func (q *deliveryQueue) deleteLog(ctx context.Context, pipe redis.Pipeliner, logKey string, ids []string) {
+	pipe.Del(ctx, logKey)
+	if len(ids) == 0 {
+		return
+	}
+
+	members := make([]any, len(ids))
+	for index, id := range ids {
+		members[index] = id
+	}
+	pipe.HDel(ctx, q.hashKey, ids...)
+	pipe.ZRem(ctx, q.queueKey, members...)
+}
+
+// unmarshalEntries unmarshals the values of entries of the model type M and
+// converts them to the domain type D.
+func unmarshalEntries[M, D any](q *deliveryQueue, values []string, toDomain func(*M) D) ([]D, error) {
+	result := make([]D, 0, len(values))
+	for _, value := range values {
+		var model M
+		if err := json.Unmarshal([]byte(value), &model); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal %s: %w", q.name, err)
+		}
+		result = append(result, toDomain(&model))
+	}
+
+	return result, nil
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"slices"
+	"time"
 
 	"github.com/omegaatt36/gotasker/domain"
 	"github.com/omegaatt36/gotasker/persistance/models"
@@ -13,39 +14,75 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
+// DefaultChangeLogMaxLen is the default number of changes kept in the change
+// log.
+const DefaultChangeLogMaxLen = 100000
+
 // RedisRepo represents a redis repository.
 type RedisRepo struct {
 	client *redis.Client
+
+	changeLogMaxLen int64
+}
+
+// RepoOption configures the redis repository.
+type RepoOption func(*RedisRepo)
+
+// WithChangeLogMaxLen sets the number of changes kept in the change log. The
+// log is trimmed approximately, so it may hold slightly more changes.
+func WithChangeLogMaxLen(maxLen int64) RepoOption {
+	return func(r *RedisRepo) {
+		if maxLen > 0 {
+			r.changeLogMaxLen = maxLen
+		}
+	}
 }
 
 // NewRedisRepo creates a new redis repository.
-func NewRedisRepo(client *redis.Client) *RedisRepo {
-	return &RedisRepo{client: client}
+func NewRedisRepo(client *redis.Client, opts ...RepoOption) *RedisRepo {
+	r := &RedisRepo{
+		client:          client,
+		changeLogMaxLen: DefaultChangeLogMaxLen,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
 }
 
 // CreateTask creates a new task.
-func (r *RedisRepo) CreateTask(ctx context.Context, req domain.CreateTaskRequest) error {
+func (r *RedisRepo) CreateTask(ctx context.Context, req domain.CreateTaskRequest) (domain.Task, error) {
 	id, err := r.client.Incr(ctx, models.KeyTaskAutoIncrementID).Result()
 	if err != nil {
-		return fmt.Errorf("failed to create task: %w", err)
+		return domain.Task{}, fmt.Errorf("failed to create task: %w", err)
 	}
 
+	now := time.Now()
 	modelTask := models.Task{
-		ID:     uint(id),
-		Name:   req.Name,
-		Status: int(domain.TaskStatusIncomplete),
+		ID:        uint(id),
+		Name:      req.Name,
+		Status:    int(domain.TaskStatusIncomplete),
+		Notes:     req.Notes,
+		CreatedAt: now,
+		UpdatedAt: now,
 	}
 
 	bs, err := json.Marshal(modelTask)
 	if err != nil {
-		return fmt.Errorf("failed to marshal task: %w", err)
+		return domain.Task{}, fmt.Errorf("failed to marshal task: %w", err)
 	}
 
-	if err := r.client.HSet(ctx, models.KeyTaskHMap, modelTask.Key(), string(bs)).Err(); err != nil {
-		return fmt.Errorf("failed to create task: %w", err)
+	task := toDomainTask(&modelTask)
+	if _, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, models.KeyTaskHMap, modelTask.Key(), string(bs))
+		pipe.Incr(ctx, models.KeyTaskVersion)
+		return r.appendChange(ctx, pipe, domain.TaskCreated{Task: task})
+	}); err != nil {
+		return domain.Task{}, fmt.Errorf("failed to create task: %w", err)
 	}
 
-	return nil
+	return task, nil
 }
 
 // ListTasks lists all tasks.
@@ -73,37 +110,37 @@ func (r *RedisRepo) ListTasks(ctx context.Context) ([]domain.Task, error) {
 	})
 
 	result := make([]domain.Task, len(modelTasks))
-	for index, t := range modelTasks {
-		result[index] = domain.Task{
-			ID:     t.ID,
-			Name:   t.Name,
-			Status: domain.TaskStatus(t.Status),
-		}
+	for index := range modelTasks {
+		result[index] = toDomainTask(&modelTasks[index])
 	}
 
 	return result, nil
 }
 
-// UpdateTask updates a task.
+// UpdateTask updates a task. The task hash is watched, so the task isn't
+// written back if it's deleted or updated concurrently.
 func (r *RedisRepo) UpdateTask(ctx context.Context, id uint, req domain.UpdateTaskRequest) error {
-
-	modelTask := models.Task{
-		ID: id,
-	}
-
-	bs, err := r.client.HGet(ctx, models.KeyTaskHMap, modelTask.Key()).Bytes()
-	if err != nil {
-		if errors.Is(err, redis.Nil) {
-			return domain.ErrTaskNotFound
+	for range maxBatchRetries {
+		err := r.client.Watch(ctx, func(tx *redis.Tx) error {
+			return r.updateTask(ctx, tx, id, req)
+		}, models.KeyTaskHMap)
+		if errors.Is(err, redis.TxFailedErr) {
+			continue
 		}
 
-		return fmt.Errorf("failed to get task: %w", err)
+		return err
 	}
 
-	if err := json.Unmarshal(bs, &modelTask); err != nil {
-		return fmt.Errorf("failed to unmarshal task: %w", err)
+	return fmt.Errorf("failed to update task: %w", redis.TxFailedErr)
+}
+
+func (r *RedisRepo) updateTask(ctx context.Context, tx *redis.Tx, id uint, req domain.UpdateTaskRequest) error {
+	modelTask, err := getModelTask(ctx, tx, id)
+	if err != nil {
+		return err
 	}
 
+	before := toDomainTask(&modelTask)
 	if req.Name != nil {
 		modelTask.Name = *req.Name
 	}
@@ -111,41 +148,566 @@ func (r *RedisRepo) UpdateTask(ctx context.Context, id uint, req domain.UpdateTa
 	if req.Status != nil {
 		modelTask.Status = int(*req.Status)
 	}
+	modelTask.UpdatedAt = time.Now()
 
-	bs, err = json.Marshal(modelTask)
+	bs, err := json.Marshal(modelTask)
 	if err != nil {
 		return fmt.Errorf("failed to marshal task: %w", err)
 	}
 
-	if err := r.client.HSet(ctx, models.KeyTaskHMap, modelTask.Key(), string(bs)).Err(); err != nil {
+	if _, err := tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, models.KeyTaskHMap, modelTask.Key(), string(bs))
+		pipe.Incr(ctx, models.KeyTaskVersion)
+		return r.appendChange(ctx, pipe, domain.TaskUpdated{Before: before, After: toDomainTask(&modelTask)})
+	}); err != nil {
 		return fmt.Errorf("failed to update task: %w", err)
 	}
 
 	return nil
 }
 
-// DeleteTask deletes a task.
+// DeleteTask deletes a task. The task hash is watched, so concurrent deletes
+// of a task log a single change.
 func (r *RedisRepo) DeleteTask(ctx context.Context, id uint) error {
+	for range maxBatchRetries {
+		err := r.client.Watch(ctx, func(tx *redis.Tx) error {
+			return r.deleteTask(ctx, tx, id)
+		}, models.KeyTaskHMap)
+		if errors.Is(err, redis.TxFailedErr) {
+			continue
+		}
+
+		return err
+	}
+
+	return fmt.Errorf("failed to delete task: %w", redis.TxFailedErr)
+}
+
+func (r *RedisRepo) deleteTask(ctx context.Context, tx *redis.Tx, id uint) error {
+	modelTask, err := getModelTask(ctx, tx, id)
+	if err != nil {
+		return err
+	}
 
+	if _, err := tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HDel(ctx, models.KeyTaskHMap, modelTask.Key())
+		pipe.Incr(ctx, models.KeyTaskVersion)
+		return r.appendChange(ctx, pipe, domain.TaskDeleted{Task: toDomainTask(&modelTask)})
+	}); err != nil {
+		return fmt.Errorf("failed to delete task: %w", err)
+	}
+
+	return nil
+}
+
+// getModelTask reads a task of the watched hash.
+func getModelTask(ctx context.Context, tx *redis.Tx, id uint) (models.Task, error) {
 	modelTask := models.Task{
 		ID: id,
 	}
 
-	_, err := r.client.HGet(ctx, models.KeyTaskHMap, modelTask.Key()).Bytes()
+	bs, err := tx.HGet(ctx, models.KeyTaskHMap, modelTask.Key()).Bytes()
 	if err != nil {
 		if errors.Is(err, redis.Nil) {
-			return domain.ErrTaskNotFound
+			return models.Task{}, domain.ErrTaskNotFound
 		}
 
-		return fmt.Errorf("failed to get task: %w", err)
+		return models.Task{}, fmt.Errorf("failed to get task: %w", err)
+	}
+
+	if err := json.Unmarshal(bs, &modelTask); err != nil {
+		return models.Task{}, fmt.Errorf("failed to unmarshal task: %w", err)
+	}
+
+	return modelTask, nil
+}
+
+// GetTask gets a task.
+func (r *RedisRepo) GetTask(ctx context.Context, id uint) (domain.Task, error) {
+	modelTask := models.Task{
+		ID: id,
 	}
 
-	if err := r.client.HDel(ctx, models.KeyTaskHMap, modelTask.Key()).Err(); err != nil {
+	bs, err := r.client.HGet(ctx, models.KeyTaskHMap, modelTask.Key()).Bytes()
+	if err != nil {
 		if errors.Is(err, redis.Nil) {
-			return domain.ErrTaskNotFound
+			return domain.Task{}, domain.ErrTaskNotFound
 		}
-		return fmt.Errorf("failed to delete task: %w", err)
+
+		return domain.Task{}, fmt.Errorf("failed to get task: %w", err)
 	}
 
-	return nil
+	if err := json.Unmarshal(bs, &modelTask); err != nil {
+		return domain.Task{}, fmt.Errorf("failed to unmarshal task: %w", err)
+	}
+
+	return toDomainTask(&modelTask), nil
+}
+
+// GetTasks gets the tasks of the IDs with a single HMGET.
+func (r *RedisRepo) GetTasks(ctx context.Context, ids []uint) ([]domain.Task, error) {
+	if len(ids) == 0 {
+		return []domain.Task{}, nil
+	}
+
+	keys := make([]string, len(ids))
+	for index, id := range ids {
+		keys[index] = (&models.Task{ID: id}).Key()
+	}
+
+	values, err := r.client.HMGet(ctx, models.KeyTaskHMap, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tasks: %w", err)
+	}
+
+	tasks := make([]domain.Task, 0, len(values))
+	for _, value := range values {
+		s, ok := value.(string)
+		if !ok {
+			continue
+		}
+
+		var modelTask models.Task
+		if err := json.Unmarshal([]byte(s), &modelTask); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal task: %w", err)
+		}
+		tasks = append(tasks, toDomainTask(&modelTask))
+	}
+
+	return tasks, nil
+}
+
+// Version returns the version of the task collection.
+func (r *RedisRepo) Version(ctx context.Context) (uint64, error) {
+	version, err := r.client.Get(ctx, models.KeyTaskVersion).Uint64()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return 0, fmt.Errorf("failed to get version: %w", err)
+	}
+
+	return version, nil
+}
+
+func toDomainTask(modelTask *models.Task) domain.Task {
+	return domain.Task{
+		ID:        modelTask.ID,
+		Name:      modelTask.Name,
+		Status:    domain.TaskStatus(modelTask.Status),
+		Notes:     modelTask.Notes,
+		CreatedAt: modelTask.CreatedAt,
+		UpdatedAt: modelTask.UpdatedAt,
+	}
+}
+
+// maxBatchRetries limits the retries of an optimistic transaction which
+// failed because of concurrent writes.
+const maxBatchRetries = 10
+
+// BatchTasks applies all operations in a single MULTI/EXEC transaction. The
+// task hash and the auto increment key are watched, so the transaction is
+// retried if another client writes to them in the meantime.
+func (r *RedisRepo) BatchTasks(ctx context.Context, ops []domain.BatchOperation) ([]domain.BatchResult, error) {
+	for range maxBatchRetries {
+		var results []domain.BatchResult
+		err := r.client.Watch(ctx, func(tx *redis.Tx) error {
+			var err error
+			results, err = r.batchTasks(ctx, tx, ops)
+			return err
+		}, models.KeyTaskAutoIncrementID, models.KeyTaskHMap)
+		if errors.Is(err, redis.TxFailedErr) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to batch tasks: %w", err)
+		}
+
+		return results, nil
+	}
+
+	return nil, fmt.Errorf("failed to batch tasks: %w", redis.TxFailedErr)
+}
+
+func (r *RedisRepo) batchTasks(ctx context.Context, tx *redis.Tx, ops []domain.BatchOperation) ([]domain.BatchResult, error) {
+	lastID, err := tx.Get(ctx, models.KeyTaskAutoIncrementID).Uint64()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("failed to get auto increment id: %w", err)
+	}
+
+	// load every task referenced by update and delete operations.
+	var keys []string
+	for _, op := range ops {
+		if op.Type != domain.BatchOperationTypeCreate {
+			keys = append(keys, (&models.Task{ID: op.ID}).Key())
+		}
+	}
+
+	tasks := make(map[uint]*models.Task, len(keys))
+	if len(keys) > 0 {
+		values, err := tx.HMGet(ctx, models.KeyTaskHMap, keys...).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get tasks: %w", err)
+		}
+
+		for _, value := range values {
+			s, ok := value.(string)
+			if !ok {
+				continue
+			}
+
+			var modelTask models.Task
+			if err := json.Unmarshal([]byte(s), &modelTask); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal task: %w", err)
+			}
+			tasks[modelTask.ID] = &modelTask
+		}
+	}
+
+	var (
+		created uint64
+		touched []uint
+		failed  bool
+		now     = time.Now()
+	)
+
+	results := make([]domain.BatchResult, len(ops))
+	for index, op := range ops {
+		switch op.Type {
+		case domain.BatchOperationTypeCreate:
+			created++
+			modelTask := &models.Task{
+				ID:        uint(lastID + created),
+				Name:      op.Create.Name,
+				Status:    int(domain.TaskStatusIncomplete),
+				Notes:     op.Create.Notes,
+				CreatedAt: now,
+				UpdatedAt: now,
+			}
+			tasks[modelTask.ID] = modelTask
+			touched = append(touched, modelTask.ID)
+			results[index].Task = toDomainTask(modelTask)
+
+		case domain.BatchOperationTypeUpdate:
+			modelTask := tasks[op.ID]
+			if modelTask == nil {
+				results[index].Err = domain.ErrTaskNotFound
+				failed = true
+				continue
+			}
+
+			results[index].Before = toDomainTask(modelTask)
+			if op.Update.Name != nil {
+				modelTask.Name = *op.Update.Name
+			}
+			if op.Update.Status != nil {
+				modelTask.Status = int(*op.Update.Status)
+			}
+			modelTask.UpdatedAt = now
+			touched = append(touched, op.ID)
+			results[index].Task = toDomainTask(modelTask)
+
+		case domain.BatchOperationTypeDelete:
+			modelTask := tasks[op.ID]
+			if modelTask == nil {
+				results[index].Err = domain.ErrTaskNotFound
+				failed = true
+				continue
+			}
+
+			results[index].Task = toDomainTask(modelTask)
+			tasks[op.ID] = nil
+			touched = append(touched, op.ID)
+
+		default:
+			results[index].Err = domain.ErrInvalidBatchOperationType
+			failed = true
+		}
+	}
+
+	if failed {
+		for index := range results {
+			if results[index].Err == nil {
+				results[index].Err = domain.ErrBatchRolledBack
+			}
+		}
+
+		return results, nil
+	}
+
+	_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		if created > 0 {
+			pipe.IncrBy(ctx, models.KeyTaskAutoIncrementID, int64(created))
+		}
+		pipe.Incr(ctx, models.KeyTaskVersion)
+
+		slices.Sort(touched)
+		for _, id := range slices.Compact(touched) {
+			modelTask := tasks[id]
+			if modelTask == nil {
+				pipe.HDel(ctx, models.KeyTaskHMap, (&models.Task{ID: id}).Key())
+				continue
+			}
+
+			bs, err := json.Marshal(modelTask)
+			if err != nil {
+				return fmt.Errorf("failed to marshal task: %w", err)
+			}
+			pipe.HSet(ctx, models.KeyTaskHMap, modelTask.Key(), string(bs))
+		}
+
+		// the changes are logged in the order of the operations.
+		for index, op := range ops {
+			var event domain.Event
+			switch op.Type {
+			case domain.BatchOperationTypeCreate:
+				event = domain.TaskCreated{Task: results[index].Task}
+			case domain.BatchOperationTypeUpdate:
+				event = domain.TaskUpdated{Before: results[index].Before, After: results[index].Task}
+			case domain.BatchOperationTypeDelete:
+				event = domain.TaskDeleted{Task: results[index].Task}
+			}
+
+			if err := r.appendChange(ctx, pipe, event); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// ImportTasks writes the tasks in a single MULTI/EXEC transaction, which is
+// retried on concurrent writes like BatchTasks. The Taskwarrior tasks of the
+// request are written in the same transaction.
+func (r *RedisRepo) ImportTasks(ctx context.Context, req domain.ImportTasksRequest) ([]domain.ImportResult, error) {
+	keys := []string{models.KeyTaskAutoIncrementID, models.KeyTaskHMap}
+	if req.Taskwarrior != nil {
+		keys = append(keys, models.KeyTaskwarriorTaskHMap)
+	}
+
+	for range maxBatchRetries {
+		var results []domain.ImportResult
+		err := r.client.Watch(ctx, func(tx *redis.Tx) error {
+			var err error
+			results, err = r.importTasks(ctx, tx, req)
+			return err
+		}, keys...)
+		if errors.Is(err, redis.TxFailedErr) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to import tasks: %w", err)
+		}
+
+		return results, nil
+	}
+
+	return nil, fmt.Errorf("failed to import tasks: %w", redis.TxFailedErr)
+}
+
+func (r *RedisRepo) importTasks(ctx context.Context, tx *redis.Tx, req domain.ImportTasksRequest) ([]domain.ImportResult, error) {
+	if req.Taskwarrior != nil {
+		if err := checkTaskwarriorTasks(ctx, tx, req.Taskwarrior); err != nil {
+			return nil, err
+		}
+	}
+
+	lastID, err := tx.Get(ctx, models.KeyTaskAutoIncrementID).Uint64()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("failed to get auto increment id: %w", err)
+	}
+
+	// load the existing tasks of the given IDs, new IDs follow the largest
+	// given one.
+	var keys []string
+	maxID := uint(lastID)
+	for index := range req.Tasks {
+		if req.Tasks[index].ID > 0 {
+			keys = append(keys, (&models.Task{ID: req.Tasks[index].ID}).Key())
+			maxID = max(maxID, req.Tasks[index].ID)
+		}
+	}
+	for _, id := range req.Deletions {
+		keys = append(keys, (&models.Task{ID: id}).Key())
+	}
+
+	existing := make(map[uint]*models.Task, len(keys))
+	if len(keys) > 0 {
+		values, err := tx.HMGet(ctx, models.KeyTaskHMap, keys...).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get tasks: %w", err)
+		}
+
+		for _, value := range values {
+			s, ok := value.(string)
+			if !ok {
+				continue
+			}
+
+			var modelTask models.Task
+			if err := json.Unmarshal([]byte(s), &modelTask); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal task: %w", err)
+			}
+			existing[modelTask.ID] = &modelTask
+		}
+	}
+
+	var (
+		written []*models.Task
+		deleted []*models.Task
+		failed  bool
+		now     = time.Now()
+	)
+
+	results := make([]domain.ImportResult, len(req.Tasks), len(req.Tasks)+len(req.Deletions))
+	for index, task := range req.Tasks {
+		if task.ID == 0 {
+			maxID++
+			task.ID = maxID
+		}
+		if task.CreatedAt.IsZero() {
+			task.CreatedAt = now
+		}
+		if task.UpdatedAt.IsZero() {
+			task.UpdatedAt = task.CreatedAt
+		}
+
+		if before := existing[task.ID]; before != nil {
+			switch req.Conflict {
+			case domain.ImportConflictSkip:
+				results[index] = domain.ImportResult{Task: toDomainTask(before), Action: domain.ImportActionSkipped}
+				continue
+			case domain.ImportConflictOverwrite:
+				results[index].Before = toDomainTask(before)
+				results[index].Action = domain.ImportActionOverwritten
+				// formats without notes don't erase them.
+				if task.Notes == "" {
+					task.Notes = before.Notes
+				}
+			default:
+				results[index] = domain.ImportResult{Task: task, Err: domain.ErrTaskConflict}
+				failed = true
+				continue
+			}
+		}
+
+		modelTask := &models.Task{
+			ID:        task.ID,
+			Name:      task.Name,
+			Status:    int(task.Status),
+			Notes:     task.Notes,
+			CreatedAt: task.CreatedAt,
+			UpdatedAt: task.UpdatedAt,
+		}
+		written = append(written, modelTask)
+		results[index].Task = toDomainTask(modelTask)
+	}
+
+	for _, id := range req.Deletions {
+		before := existing[id]
+		if before == nil {
+			results = append(results, domain.ImportResult{Action: domain.ImportActionSkipped})
+			continue
+		}
+
+		deleted = append(deleted, before)
+		results = append(results, domain.ImportResult{Task: toDomainTask(before), Action: domain.ImportActionDeleted})
+	}
+
+	if failed {
+		for index := range results {
+			if results[index].Err == nil {
+				results[index].Err = domain.ErrBatchRolledBack
+			}
+		}
+
+		return results, nil
+	}
+
+	_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		if maxID > uint(lastID) {
+			pipe.Set(ctx, models.KeyTaskAutoIncrementID, uint64(maxID), 0)
+		}
+		if req.Taskwarrior != nil {
+			if err := writeTaskwarriorTasks(ctx, pipe, req.Taskwarrior, results); err != nil {
+				return err
+			}
+		}
+		if len(written) == 0 && len(deleted) == 0 {
+			return nil
+		}
+		pipe.Incr(ctx, models.KeyTaskVersion)
+
+		for _, modelTask := range written {
+			bs, err := json.Marshal(modelTask)
+			if err != nil {
+				return fmt.Errorf("failed to marshal task: %w", err)
+			}
+			pipe.HSet(ctx, models.KeyTaskHMap, modelTask.Key(), string(bs))
+		}
+		for _, modelTask := range deleted {
+			pipe.HDel(ctx, models.KeyTaskHMap, modelTask.Key())
+		}
+
+		for index := range results {
+			var event domain.Event
+			switch results[index].Action {
+			case domain.ImportActionCreated:
+				event = domain.TaskCreated{Task: results[index].Task}
+			case domain.ImportActionOverwritten:
+				event = domain.TaskUpdated{Before: results[index].Before, After: results[index].Task}
+			case domain.ImportActionDeleted:
+				event = domain.TaskDeleted{Task: results[index].Task}
+			default:
+				continue
+			}
+
+			if err := r.appendChange(ctx, pipe, event); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// IterateTasks walks through all tasks with HSCAN, so Redis is never blocked
+// by a single large command.
+func (r *RedisRepo) IterateTasks(ctx context.Context, chunkSize int, fn func([]domain.Task) error) error {
+	var cursor uint64
+	for {
+		kvs, next, err := r.client.HScan(ctx, models.KeyTaskHMap, cursor, "", int64(chunkSize)).Result()
+		if err != nil {
+			return fmt.Errorf("failed to scan tasks: %w", err)
+		}
+
+		// HSCAN replies with a flat list of field and value pairs.
+		tasks := make([]domain.Task, 0, len(kvs)/2)
+		for index := 1; index < len(kvs); index += 2 {
+			var modelTask models.Task
+			if err := json.Unmarshal([]byte(kvs[index]), &modelTask); err != nil {
+				return fmt.Errorf("failed to unmarshal task: %w", err)
+			}
+			tasks = append(tasks, toDomainTask(&modelTask))
+		}
+
+		if len(tasks) > 0 {
+			if err := fn(tasks); err != nil {
+				return err
+			}
+		}
+
+		if next == 0 {
+			return nil
+		}
+		cursor = next
+	}
 }
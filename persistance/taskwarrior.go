@@ -0,0 +1,202 @@
+package persistance
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/omegaatt36/gotasker/domain"
+	"github.com/omegaatt36/gotasker/persistance/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisTaskwarriorRepo represents a redis Taskwarrior task repository.
+type RedisTaskwarriorRepo struct {
+	client *redis.Client
+}
+
+// NewRedisTaskwarriorRepo creates a new redis Taskwarrior task repository.
+func NewRedisTaskwarriorRepo(client *redis.Client) *RedisTaskwarriorRepo {
+	return &RedisTaskwarriorRepo{client: client}
+}
+
+var _ domain.TaskwarriorRepository = (*RedisTaskwarriorRepo)(nil)
+
+func toDomainTaskwarriorTask(modelTask models.TaskwarriorTask) domain.TaskwarriorTask {
+	t := domain.TaskwarriorTask{
+		UUID:       modelTask.UUID,
+		TaskID:     modelTask.TaskID,
+		Tags:       modelTask.Tags,
+		Attributes: modelTask.Attributes,
+	}
+	for _, annotation := range modelTask.Annotations {
+		t.Annotations = append(t.Annotations, domain.TaskwarriorAnnotation(annotation))
+	}
+
+	return t
+}
+
+func toModelTaskwarriorTask(t domain.TaskwarriorTask) models.TaskwarriorTask {
+	modelTask := models.TaskwarriorTask{
+		UUID:       t.UUID,
+		TaskID:     t.TaskID,
+		Tags:       t.Tags,
+		Attributes: t.Attributes,
+	}
+	for _, annotation := range t.Annotations {
+		modelTask.Annotations = append(modelTask.Annotations, models.TaskwarriorAnnotation(annotation))
+	}
+
+	return modelTask
+}
+
+// ListTaskwarriorTasks lists all Taskwarrior tasks ordered by UUID.
+func (r *RedisTaskwarriorRepo) ListTaskwarriorTasks(ctx context.Context) ([]domain.TaskwarriorTask, error) {
+	tasks, err := r.client.HGetAll(ctx, models.KeyTaskwarriorTaskHMap).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list taskwarrior tasks: %w", err)
+	}
+
+	result := make([]domain.TaskwarriorTask, 0, len(tasks))
+	for _, t := range tasks {
+		var modelTask models.TaskwarriorTask
+		if err := json.Unmarshal([]byte(t), &modelTask); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal taskwarrior task: %w", err)
+		}
+		result = append(result, toDomainTaskwarriorTask(modelTask))
+	}
+
+	slices.SortFunc(result, func(left, right domain.TaskwarriorTask) int {
+		return cmp.Compare(left.UUID, right.UUID)
+	})
+
+	return result, nil
+}
+
+// GetTaskwarriorTasks gets the Taskwarrior tasks of the UUIDs in a single
+// read.
+func (r *RedisTaskwarriorRepo) GetTaskwarriorTasks(ctx context.Context, uuids []string) ([]domain.TaskwarriorTask, error) {
+	if len(uuids) == 0 {
+		return nil, nil
+	}
+
+	values, err := r.client.HMGet(ctx, models.KeyTaskwarriorTaskHMap, uuids...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get taskwarrior tasks: %w", err)
+	}
+
+	result := make([]domain.TaskwarriorTask, 0, len(values))
+	for _, value := range values {
+		s, ok := value.(string)
+		if !ok {
+			continue
+		}
+
+		var modelTask models.TaskwarriorTask
+		if err := json.Unmarshal([]byte(s), &modelTask); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal taskwarrior task: %w", err)
+		}
+		result = append(result, toDomainTaskwarriorTask(modelTask))
+	}
+
+	return result, nil
+}
+
+// SaveTaskwarriorTasks creates or replaces Taskwarrior tasks.
+func (r *RedisTaskwarriorRepo) SaveTaskwarriorTasks(ctx context.Context, tasks ...domain.TaskwarriorTask) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	values := make([]any, 0, 2*len(tasks))
+	for _, t := range tasks {
+		modelTask := toModelTaskwarriorTask(t)
+
+		bs, err := json.Marshal(modelTask)
+		if err != nil {
+			return fmt.Errorf("failed to marshal taskwarrior task: %w", err)
+		}
+		values = append(values, modelTask.Key(), string(bs))
+	}
+
+	if err := r.client.HSet(ctx, models.KeyTaskwarriorTaskHMap, values...).Err(); err != nil {
+		return fmt.Errorf("failed to save taskwarrior tasks: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteTaskwarriorTasks deletes Taskwarrior tasks, deleting missing ones is
+// not an error.
+func (r *RedisTaskwarriorRepo) DeleteTaskwarriorTasks(ctx context.Context, uuids ...string) error {
+	if len(uuids) == 0 {
+		return nil
+	}
+
+	if err := r.client.HDel(ctx, models.KeyTaskwarriorTaskHMap, uuids...).Err(); err != nil {
+		return fmt.Errorf("failed to delete taskwarrior tasks: %w", err)
+	}
+
+	return nil
+}
+
+// checkTaskwarriorTasks fails with domain.ErrTaskwarriorTasksChanged if the
+// task IDs of the saved and released UUIDs differ from the ones read by the
+// import.
+func checkTaskwarriorTasks(ctx context.Context, tx *redis.Tx, imported *domain.TaskwarriorImport) error {
+	uuids := slices.Clone(imported.Released)
+	for _, t := range imported.Saved {
+		uuids = append(uuids, t.UUID)
+	}
+	if len(uuids) == 0 {
+		return nil
+	}
+
+	values, err := tx.HMGet(ctx, models.KeyTaskwarriorTaskHMap, uuids...).Result()
+	if err != nil {
+		return fmt.Errorf("failed to get taskwarrior tasks: %w", err)
+	}
+
+	for index, value := range values {
+		var modelTask models.TaskwarriorTask
+		if s, ok := value.(string); ok {
+			if err := json.Unmarshal([]byte(s), &modelTask); err != nil {
+				return fmt.Errorf("failed to unmarshal taskwarrior task: %w", err)
+			}
+		}
+
+		if modelTask.TaskID != imported.Mapped[uuids[index]] {
+			return fmt.Errorf("%w: %s", domain.ErrTaskwarriorTasksChanged, uuids[index])
+		}
+	}
+
+	return nil
+}
+
+// writeTaskwarriorTasks saves the Taskwarrior tasks of the imported tasks with
+// the IDs of their results, and deletes the released ones.
+func writeTaskwarriorTasks(ctx context.Context, pipe redis.Pipeliner, imported *domain.TaskwarriorImport, results []domain.ImportResult) error {
+	values := make([]any, 0, 2*len(imported.Saved))
+	for index, t := range imported.Saved {
+		t.TaskID = results[index].Task.ID
+		modelTask := toModelTaskwarriorTask(t)
+
+		bs, err := json.Marshal(modelTask)
+		if err != nil {
+			return fmt.Errorf("failed to marshal taskwarrior task: %w", err)
+		}
+		values = append(values, modelTask.Key(), string(bs))
+	}
+
+	if len(values) > 0 {
+		pipe.HSet(ctx, models.KeyTaskwarriorTaskHMap, values...)
+	}
+	if len(imported.Released) > 0 {
+		pipe.HDel(ctx, models.KeyTaskwarriorTaskHMap, imported.Released...)
+	}
+
+	return nil
+}
@@ -0,0 +1,337 @@
+package persistance
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/omegaatt36/gotasker/domain"
+	"github.com/omegaatt36/gotasker/persistance/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisWebhookRepo represents a redis webhook repository.
+type RedisWebhookRepo struct {
+	client     *redis.Client
+	deliveries *deliveryQueue
+}
+
+// NewRedisWebhookRepo creates a new redis webhook repository.
+func NewRedisWebhookRepo(client *redis.Client) *RedisWebhookRepo {
+	return &RedisWebhookRepo{
+		client: client,
+		deliveries: &deliveryQueue{
+			client:           client,
+			name:             "delivery",
+			autoIncrementKey: models.KeyDeliveryAutoIncrementID,
+			hashKey:          models.KeyDeliveryHMap,
+			queueKey:         models.KeyDeliveryQueue,
+		},
+	}
+}
+
+var _ domain.WebhookRepository = (*RedisWebhookRepo)(nil)
+
+// CreateWebhook creates a new webhook.
+func (r *RedisWebhookRepo) CreateWebhook(ctx context.Context, req domain.CreateWebhookRequest) (domain.Webhook, error) {
+	id, err := r.client.Incr(ctx, models.KeyWebhookAutoIncrementID).Result()
+	if err != nil {
+		return domain.Webhook{}, fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	now := time.Now()
+	modelWebhook := models.Webhook{
+		ID:        uint(id),
+		URL:       req.URL,
+		Events:    req.Events,
+		Secret:    req.Secret,
+		Active:    true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := r.saveWebhook(ctx, &modelWebhook); err != nil {
+		return domain.Webhook{}, fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	return toDomainWebhook(&modelWebhook), nil
+}
+
+// ListWebhooks lists all webhooks.
+func (r *RedisWebhookRepo) ListWebhooks(ctx context.Context) ([]domain.Webhook, error) {
+	webhooks, err := r.client.HGetAll(ctx, models.KeyWebhookHMap).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+
+	result := make([]domain.Webhook, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		var modelWebhook models.Webhook
+		if err := json.Unmarshal([]byte(webhook), &modelWebhook); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal webhook: %w", err)
+		}
+		result = append(result, toDomainWebhook(&modelWebhook))
+	}
+
+	slices.SortFunc(result, func(left, right domain.Webhook) int {
+		return cmp.Compare(left.ID, right.ID)
+	})
+
+	return result, nil
+}
+
+// GetWebhook gets a webhook.
+func (r *RedisWebhookRepo) GetWebhook(ctx context.Context, id uint) (domain.Webhook, error) {
+	modelWebhook, err := r.getWebhook(ctx, id)
+	if err != nil {
+		return domain.Webhook{}, err
+	}
+
+	return toDomainWebhook(modelWebhook), nil
+}
+
+// UpdateWebhook updates a webhook.
+func (r *RedisWebhookRepo) UpdateWebhook(ctx context.Context, id uint, req domain.UpdateWebhookRequest) (domain.Webhook, error) {
+	modelWebhook, err := r.getWebhook(ctx, id)
+	if err != nil {
+		return domain.Webhook{}, err
+	}
+
+	if req.URL != nil {
+		modelWebhook.URL = *req.URL
+	}
+	if req.Events != nil {
+		modelWebhook.Events = *req.Events
+	}
+	if req.Secret != nil {
+		modelWebhook.Secret = *req.Secret
+	}
+	if req.Active != nil {
+		modelWebhook.Active = *req.Active
+	}
+	modelWebhook.UpdatedAt = time.Now()
+
+	if err := r.saveWebhook(ctx, modelWebhook); err != nil {
+		return domain.Webhook{}, fmt.Errorf("failed to update webhook: %w", err)
+	}
+
+	return toDomainWebhook(modelWebhook), nil
+}
+
+// DeleteWebhook deletes a webhook and its delivery log.
+func (r *RedisWebhookRepo) DeleteWebhook(ctx context.Context, id uint) error {
+	modelWebhook, err := r.getWebhook(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	ids, err := r.deliveries.logIDs(ctx, modelWebhook.DeliveriesKey())
+	if err != nil {
+		return err
+	}
+
+	if _, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HDel(ctx, models.KeyWebhookHMap, modelWebhook.Key())
+		r.deliveries.deleteLog(ctx, pipe, modelWebhook.DeliveriesKey(), ids)
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+
+	return nil
+}
+
+func (r *RedisWebhookRepo) getWebhook(ctx context.Context, id uint) (*models.Webhook, error) {
+	modelWebhook := models.Webhook{
+		ID: id,
+	}
+
+	bs, err := r.client.HGet(ctx, models.KeyWebhookHMap, modelWebhook.Key()).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, domain.ErrWebhookNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get webhook: %w", err)
+	}
+
+	if err := json.Unmarshal(bs, &modelWebhook); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal webhook: %w", err)
+	}
+
+	return &modelWebhook, nil
+}
+
+func (r *RedisWebhookRepo) saveWebhook(ctx context.Context, modelWebhook *models.Webhook) error {
+	bs, err := json.Marshal(modelWebhook)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook: %w", err)
+	}
+
+	return r.client.HSet(ctx, models.KeyWebhookHMap, modelWebhook.Key(), string(bs)).Err()
+}
+
+// CreateDelivery saves a new delivery and queues it. The oldest deliveries
+// of the webhook beyond the log size are removed once they aren't pending.
+func (r *RedisWebhookRepo) CreateDelivery(ctx context.Context, delivery domain.Delivery) (domain.Delivery, error) {
+	id, err := r.deliveries.nextID(ctx)
+	if err != nil {
+		return domain.Delivery{}, err
+	}
+
+	now := time.Now()
+	delivery.ID = id
+	delivery.CreatedAt = now
+	delivery.UpdatedAt = now
+
+	if err := r.deliveries.create(ctx, toDeliveryEntry(&delivery)); err != nil {
+		return domain.Delivery{}, err
+	}
+
+	return delivery, nil
+}
+
+// SaveDelivery saves the delivery, it's queued while pending. Deliveries
+// removed from the log, by trimming or deleting the webhook, aren't saved.
+func (r *RedisWebhookRepo) SaveDelivery(ctx context.Context, delivery domain.Delivery) error {
+	delivery.UpdatedAt = time.Now()
+
+	return r.deliveries.save(ctx, toDeliveryEntry(&delivery))
+}
+
+// GetDelivery gets a delivery of a webhook.
+func (r *RedisWebhookRepo) GetDelivery(ctx context.Context, webhookID, id uint) (domain.Delivery, error) {
+	modelDelivery := models.Delivery{
+		ID: id,
+	}
+
+	bs, err := r.deliveries.get(ctx, modelDelivery.Key())
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return domain.Delivery{}, domain.ErrDeliveryNotFound
+		}
+
+		return domain.Delivery{}, fmt.Errorf("failed to get delivery: %w", err)
+	}
+
+	if err := json.Unmarshal(bs, &modelDelivery); err != nil {
+		return domain.Delivery{}, fmt.Errorf("failed to unmarshal delivery: %w", err)
+	}
+
+	if modelDelivery.WebhookID != webhookID {
+		return domain.Delivery{}, domain.ErrDeliveryNotFound
+	}
+
+	return toDomainDelivery(&modelDelivery), nil
+}
+
+// ListDeliveries lists the delivery log of a webhook, the latest first.
+func (r *RedisWebhookRepo) ListDeliveries(ctx context.Context, webhookID uint) ([]domain.Delivery, error) {
+	webhook := models.Webhook{ID: webhookID}
+	values, err := r.deliveries.list(ctx, webhook.DeliveriesKey())
+	if err != nil {
+		return nil, err
+	}
+
+	return unmarshalEntries(r.deliveries, values, toDomainDelivery)
+}
+
+// ClaimDueDeliveries claims up to limit deliveries due at now by postponing
+// them in the queue for the lease.
+func (r *RedisWebhookRepo) ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]domain.Delivery, error) {
+	values, err := r.deliveries.claim(ctx, now, lease, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return unmarshalEntries(r.deliveries, values, toDomainDelivery)
+}
+
+func toDomainWebhook(modelWebhook *models.Webhook) domain.Webhook {
+	return domain.Webhook{
+		ID:        modelWebhook.ID,
+		URL:       modelWebhook.URL,
+		Events:    modelWebhook.Events,
+		Secret:    modelWebhook.Secret,
+		Active:    modelWebhook.Active,
+		CreatedAt: modelWebhook.CreatedAt,
+		UpdatedAt: modelWebhook.UpdatedAt,
+	}
+}
+
+func toModelAttempts(attempts []domain.DeliveryAttempt) []models.DeliveryAttempt {
+	result := make([]models.DeliveryAttempt, len(attempts))
+	for index, attempt := range attempts {
+		result[index] = models.DeliveryAttempt{
+			At:         attempt.At,
+			StatusCode: attempt.StatusCode,
+			Error:      attempt.Error,
+			Duration:   attempt.Duration,
+		}
+	}
+
+	return result
+}
+
+func toDomainAttempts(attempts []models.DeliveryAttempt) []domain.DeliveryAttempt {
+	result := make([]domain.DeliveryAttempt, len(attempts))
+	for index, attempt := range attempts {
+		result[index] = domain.DeliveryAttempt{
+			At:         attempt.At,
+			StatusCode: attempt.StatusCode,
+			Error:      attempt.Error,
+			Duration:   attempt.Duration,
+		}
+	}
+
+	return result
+}
+
+func toDeliveryEntry(delivery *domain.Delivery) *queueEntry {
+	modelDelivery := toModelDelivery(delivery)
+	webhook := models.Webhook{ID: delivery.WebhookID}
+
+	return &queueEntry{
+		ID:            modelDelivery.ID,
+		Key:           modelDelivery.Key(),
+		LogKey:        webhook.DeliveriesKey(),
+		Value:         modelDelivery,
+		Pending:       delivery.State == domain.DeliveryStatePending,
+		NextAttemptAt: delivery.NextAttemptAt,
+	}
+}
+
+func toModelDelivery(delivery *domain.Delivery) models.Delivery {
+	return models.Delivery{
+		ID:            delivery.ID,
+		WebhookID:     delivery.WebhookID,
+		EventID:       delivery.EventID,
+		Event:         delivery.Event,
+		Payload:       delivery.Payload,
+		State:         int(delivery.State),
+		Attempts:      toModelAttempts(delivery.Attempts),
+		NextAttemptAt: delivery.NextAttemptAt,
+		CreatedAt:     delivery.CreatedAt,
+		UpdatedAt:     delivery.UpdatedAt,
+	}
+}
+
+func toDomainDelivery(modelDelivery *models.Delivery) domain.Delivery {
+	return domain.Delivery{
+		ID:            modelDelivery.ID,
+		WebhookID:     modelDelivery.WebhookID,
+		EventID:       modelDelivery.EventID,
+		Event:         modelDelivery.Event,
+		Payload:       modelDelivery.Payload,
+		State:         domain.DeliveryState(modelDelivery.State),
+		Attempts:      toDomainAttempts(modelDelivery.Attempts),
+		NextAttemptAt: modelDelivery.NextAttemptAt,
+		CreatedAt:     modelDelivery.CreatedAt,
+		UpdatedAt:     modelDelivery.UpdatedAt,
+	}
+}
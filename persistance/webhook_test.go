@@ -0,0 +1,91 @@
+package persistance_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/omegaatt36/gotasker/domain"
+	"github.com/omegaatt36/gotasker/persistance"
+	"github.com/omegaatt36/gotasker/persistance/database"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type WebhookRepoSuite struct {
+	suite.Suite
+}
+
+func (s *WebhookRepoSuite) TestTrimDeliveries() {
+	miniredis := database.InitializeTestingRedis()
+	defer miniredis.Close()
+
+	database.Initialize(context.Background(), miniredis.Addr(), "")
+	ctx := context.Background()
+	repo := persistance.NewRedisWebhookRepo(database.Redis())
+
+	hook, err := repo.CreateWebhook(ctx, domain.CreateWebhookRequest{URL: "http://example.com"})
+	s.Require().NoError(err)
+
+	var deliveries []domain.Delivery
+	for range 150 {
+		delivery, err := repo.CreateDelivery(ctx, domain.Delivery{
+			WebhookID:     hook.ID,
+			State:         domain.DeliveryStatePending,
+			NextAttemptAt: time.Now(),
+		})
+		s.Require().NoError(err)
+		deliveries = append(deliveries, delivery)
+	}
+
+	// pending deliveries are kept beyond the log size.
+	logged, err := repo.ListDeliveries(ctx, hook.ID)
+	s.Require().NoError(err)
+	s.Len(logged, 150)
+
+	for _, delivery := range deliveries[:100] {
+		delivery.State = domain.DeliveryStateSucceeded
+		s.Require().NoError(repo.SaveDelivery(ctx, delivery))
+	}
+
+	_, err = repo.CreateDelivery(ctx, domain.Delivery{
+		WebhookID:     hook.ID,
+		State:         domain.DeliveryStatePending,
+		NextAttemptAt: time.Now(),
+	})
+	s.Require().NoError(err)
+
+	logged, err = repo.ListDeliveries(ctx, hook.ID)
+	s.Require().NoError(err)
+	s.Len(logged, 100)
+	s.Equal(deliveries[51].ID, logged[len(logged)-1].ID)
+
+	due, err := repo.ClaimDueDeliveries(ctx, time.Now(), time.Minute, 100)
+	s.Require().NoError(err)
+	s.Len(due, 51)
+
+	s.Run("save trimmed", func() {
+		trimmed := deliveries[0]
+		trimmed.State = domain.DeliveryStatePending
+		s.Require().NoError(repo.SaveDelivery(ctx, trimmed))
+
+		_, err := repo.GetDelivery(ctx, hook.ID, trimmed.ID)
+		s.ErrorIs(err, domain.ErrDeliveryNotFound)
+	})
+
+	s.Run("save deleted", func() {
+		s.Require().NoError(repo.DeleteWebhook(ctx, hook.ID))
+		s.Require().NoError(repo.SaveDelivery(ctx, deliveries[149]))
+
+		_, err := repo.GetDelivery(ctx, hook.ID, deliveries[149].ID)
+		s.ErrorIs(err, domain.ErrDeliveryNotFound)
+
+		due, err := repo.ClaimDueDeliveries(ctx, time.Now().Add(time.Hour), time.Minute, 100)
+		s.Require().NoError(err)
+		s.Empty(due)
+	})
+}
+
+func TestWebhookRepo(t *testing.T) {
+	suite.Run(t, new(WebhookRepoSuite))
+}
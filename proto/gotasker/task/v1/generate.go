@@ -0,0 +1,4 @@
+// Package taskv1 contains the generated code of the gRPC task service.
+package taskv1
+
+//go:generate protoc -I ../../.. --go_out=../../.. --go_opt=paths=source_relative --go-grpc_out=../../.. --go-grpc_opt=paths=source_relative gotasker/task/v1/task.proto
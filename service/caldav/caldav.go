@@ -0,0 +1,351 @@
+package caldav
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/omegaatt36/gotasker/domain"
+	"github.com/omegaatt36/gotasker/format/ical"
+	"github.com/omegaatt36/gotasker/service/eventbus"
+	"github.com/omegaatt36/gotasker/service/task"
+)
+
+var (
+	ErrInvalidObjectName = errors.New("calendar object name must end with .ics")
+	ErrUIDRequired       = errors.New("VTODO UID is required")
+	// ErrUIDConflict is returned if the UID of a todo is used by another
+	// object, or differs from the UID of the object it replaces.
+	ErrUIDConflict = errors.New("VTODO UID conflicts with another calendar object")
+)
+
+// Object is a calendar object resource of a task.
+type Object struct {
+	Name string
+	UID  string
+	Task domain.Task
+}
+
+// Todo returns the VTODO of the object, completed tasks are regarded as
+// completed at their last update.
+func (o *Object) Todo() ical.Todo {
+	todo := ical.Todo{
+		UID:          o.UID,
+		Summary:      o.Task.Name,
+		Status:       ical.StatusNeedsAction,
+		Created:      o.Task.CreatedAt,
+		LastModified: o.Task.UpdatedAt,
+	}
+	if o.Task.Status == domain.TaskStatusCompleted {
+		todo.Status = ical.StatusCompleted
+		todo.Completed = o.Task.UpdatedAt
+	}
+
+	return todo
+}
+
+// Service represents a CalDAV service, which serves every task as a calendar
+// object of a single task collection. Objects stored by clients keep their
+// names and UIDs, the other tasks are served by their default ones.
+type Service struct {
+	tasks   *task.Service
+	objects domain.CalendarObjectRepository
+}
+
+// NewService creates a new CalDAV service.
+func NewService(tasks *task.Service, objects domain.CalendarObjectRepository) *Service {
+	return &Service{
+		tasks:   tasks,
+		objects: objects,
+	}
+}
+
+// Start deletes the objects of the tasks deleted from now on, so IDs of
+// imported tasks don't reuse them. The returned channel is closed once it
+// has stopped after the context is canceled.
+func (s *Service) Start(ctx context.Context) <-chan struct{} {
+	subscription := s.tasks.EventBus().SubscribeAsync("caldav", s.HandleEvent, eventbus.AsyncOptions{})
+
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+
+		<-ctx.Done()
+		subscription.Close()
+	}()
+
+	return stopped
+}
+
+// HandleEvent deletes the object of the task of a TaskDeleted event.
+func (s *Service) HandleEvent(ctx context.Context, envelope eventbus.Envelope) error {
+	event, ok := envelope.Event.(domain.TaskDeleted)
+	if !ok {
+		return nil
+	}
+
+	object, err := s.objects.GetTaskCalendarObject(ctx, event.Task.ID)
+	if err != nil {
+		if errors.Is(err, domain.ErrCalendarObjectNotFound) {
+			return nil
+		}
+
+		return err
+	}
+
+	return s.objects.DeleteCalendarObject(ctx, object.Name)
+}
+
+// Version returns a counter increased on every change of the collection.
+func (s *Service) Version(ctx context.Context) (uint64, error) {
+	return s.tasks.Version(ctx)
+}
+
+// ListObjects lists the objects of all tasks ordered by task ID.
+func (s *Service) ListObjects(ctx context.Context) ([]Object, error) {
+	tasks, err := s.tasks.ListTasks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := s.objects.ListCalendarObjects(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	storedByTask := make(map[uint]domain.CalendarObject, len(stored))
+	for _, object := range stored {
+		storedByTask[object.TaskID] = object
+	}
+
+	objects := make([]Object, len(tasks))
+	for index, t := range tasks {
+		objects[index] = Object{
+			Name: domain.DefaultCalendarObjectName(t.ID),
+			UID:  t.CalendarUID(),
+			Task: t,
+		}
+		if object, ok := storedByTask[t.ID]; ok {
+			objects[index].Name = object.Name
+			objects[index].UID = object.UID
+		}
+	}
+
+	return objects, nil
+}
+
+// GetObject gets the object of the name.
+func (s *Service) GetObject(ctx context.Context, name string) (Object, error) {
+	if !strings.HasSuffix(name, ".ics") {
+		return Object{}, domain.ErrCalendarObjectNotFound
+	}
+
+	stored, err := s.objects.GetCalendarObject(ctx, name)
+	if err == nil {
+		t, err := s.tasks.GetTask(ctx, stored.TaskID)
+		if err != nil {
+			// the task was deleted by another API.
+			if errors.Is(err, domain.ErrTaskNotFound) {
+				return Object{}, domain.ErrCalendarObjectNotFound
+			}
+
+			return Object{}, err
+		}
+
+		return Object{Name: stored.Name, UID: stored.UID, Task: t}, nil
+	}
+	if !errors.Is(err, domain.ErrCalendarObjectNotFound) {
+		return Object{}, err
+	}
+
+	id, ok := parseDefaultName(name)
+	if !ok {
+		return Object{}, domain.ErrCalendarObjectNotFound
+	}
+
+	return s.getDefaultObject(ctx, id)
+}
+
+// getDefaultObject gets the object of the task by its default name and UID,
+// tasks stored by a client aren't served by them.
+func (s *Service) getDefaultObject(ctx context.Context, id uint) (Object, error) {
+	_, err := s.objects.GetTaskCalendarObject(ctx, id)
+	if err == nil {
+		return Object{}, domain.ErrCalendarObjectNotFound
+	}
+	if !errors.Is(err, domain.ErrCalendarObjectNotFound) {
+		return Object{}, err
+	}
+
+	t, err := s.tasks.GetTask(ctx, id)
+	if err != nil {
+		if errors.Is(err, domain.ErrTaskNotFound) {
+			return Object{}, domain.ErrCalendarObjectNotFound
+		}
+
+		return Object{}, err
+	}
+
+	return Object{Name: domain.DefaultCalendarObjectName(t.ID), UID: t.CalendarUID(), Task: t}, nil
+}
+
+// PutObject updates the task of the object of the name by the todo, or
+// creates a task if there is no such object. It reports whether the object
+// was created.
+func (s *Service) PutObject(ctx context.Context, name string, todo ical.Todo) (Object, bool, error) {
+	if !strings.HasSuffix(name, ".ics") {
+		return Object{}, false, ErrInvalidObjectName
+	}
+	if todo.UID == "" {
+		return Object{}, false, ErrUIDRequired
+	}
+	if todo.Summary == "" {
+		return Object{}, false, task.ErrTaskNameRequired
+	}
+
+	status := domain.TaskStatusIncomplete
+	if todo.Status == ical.StatusCompleted || (todo.Status == "" && !todo.Completed.IsZero()) {
+		status = domain.TaskStatusCompleted
+	}
+
+	object, err := s.GetObject(ctx, name)
+	if err == nil {
+		if object.UID != todo.UID {
+			return Object{}, false, ErrUIDConflict
+		}
+
+		if err := s.tasks.UpdateTask(ctx, object.Task.ID, task.UpdateTaskRequest{
+			Name:   &todo.Summary,
+			Status: &status,
+		}); err != nil {
+			return Object{}, false, err
+		}
+
+		if object.Task, err = s.tasks.GetTask(ctx, object.Task.ID); err != nil {
+			return Object{}, false, err
+		}
+
+		return object, false, nil
+	}
+	if !errors.Is(err, domain.ErrCalendarObjectNotFound) {
+		return Object{}, false, err
+	}
+
+	if err := s.checkUID(ctx, todo.UID); err != nil {
+		return Object{}, false, err
+	}
+
+	created, err := s.createTask(ctx, todo.Summary, status)
+	if err != nil {
+		return Object{}, false, err
+	}
+
+	object = Object{Name: name, UID: todo.UID, Task: created}
+	if name != domain.DefaultCalendarObjectName(created.ID) || todo.UID != created.CalendarUID() {
+		if err := s.objects.SaveCalendarObject(ctx, domain.CalendarObject{
+			Name:   name,
+			UID:    todo.UID,
+			TaskID: created.ID,
+		}); err != nil {
+			// the task would show up as a default object otherwise.
+			if deleteErr := s.tasks.DeleteTask(ctx, created.ID); deleteErr != nil {
+				return Object{}, false, errors.Join(err, deleteErr)
+			}
+
+			return Object{}, false, err
+		}
+	}
+
+	return object, true, nil
+}
+
+// createTask creates a task of the name and status in a single write, so no
+// client sees it with the default status.
+func (s *Service) createTask(ctx context.Context, name string, status domain.TaskStatus) (domain.Task, error) {
+	results, err := s.tasks.ImportTasks(ctx, task.ImportTasksRequest{
+		Tasks: []domain.Task{{Name: name, Status: status}},
+	})
+	if err != nil {
+		return domain.Task{}, err
+	}
+	if results[0].Err != nil {
+		return domain.Task{}, results[0].Err
+	}
+
+	return results[0].Task, nil
+}
+
+// checkUID returns ErrUIDConflict if the UID is used by an object.
+func (s *Service) checkUID(ctx context.Context, uid string) error {
+	_, err := s.objects.GetCalendarObjectByUID(ctx, uid)
+	if err == nil {
+		return ErrUIDConflict
+	}
+	if !errors.Is(err, domain.ErrCalendarObjectNotFound) {
+		return err
+	}
+
+	id, ok := parseDefaultUID(uid)
+	if !ok {
+		return nil
+	}
+
+	_, err = s.getDefaultObject(ctx, id)
+	if err == nil {
+		return ErrUIDConflict
+	}
+	if !errors.Is(err, domain.ErrCalendarObjectNotFound) {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteObject deletes the object of the name and its task.
+func (s *Service) DeleteObject(ctx context.Context, name string) error {
+	object, err := s.GetObject(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	if err := s.tasks.DeleteTask(ctx, object.Task.ID); err != nil {
+		return err
+	}
+
+	return s.objects.DeleteCalendarObject(ctx, name)
+}
+
+// parseDefaultName returns the task ID of a default object name.
+func parseDefaultName(name string) (uint, bool) {
+	id, ok := strings.CutPrefix(strings.TrimSuffix(name, ".ics"), "task-")
+	if !ok {
+		return 0, false
+	}
+
+	return parseTaskID(id)
+}
+
+// parseDefaultUID returns the task ID of a default UID.
+func parseDefaultUID(uid string) (uint, bool) {
+	id, ok := strings.CutPrefix(uid, "task-")
+	if !ok {
+		return 0, false
+	}
+
+	id, ok = strings.CutSuffix(id, "@gotasker")
+	if !ok {
+		return 0, false
+	}
+
+	return parseTaskID(id)
+}
+
+func parseTaskID(id string) (uint, bool) {
+	parsed, err := strconv.ParseUint(id, 10, 0)
+	if err != nil || parsed == 0 {
+		return 0, false
+	}
+
+	return uint(parsed), true
+}
@@ -0,0 +1,176 @@
+package caldav_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/omegaatt36/gotasker/domain"
+	"github.com/omegaatt36/gotasker/domain/stub"
+	"github.com/omegaatt36/gotasker/format/ical"
+	"github.com/omegaatt36/gotasker/persistance"
+	"github.com/omegaatt36/gotasker/persistance/database"
+	"github.com/omegaatt36/gotasker/service/caldav"
+	"github.com/omegaatt36/gotasker/service/eventbus"
+	"github.com/omegaatt36/gotasker/service/task"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type CalDAVServiceSuite struct {
+	suite.Suite
+
+	repo    *stub.InMemoryTaskRepository
+	tasks   *task.Service
+	service *caldav.Service
+}
+
+func (s *CalDAVServiceSuite) SetupTest() {
+	mr := database.InitializeTestingRedis()
+	s.T().Cleanup(mr.Close)
+	database.Initialize(context.Background(), mr.Addr(), "")
+
+	s.repo = stub.NewInMemoryTaskRepository()
+	s.tasks = task.NewService(s.repo)
+	s.service = caldav.NewService(s.tasks, persistance.NewRedisCalendarObjectRepo(database.Redis()))
+}
+
+func (s *CalDAVServiceSuite) TestDefaultObjects() {
+	ctx := context.Background()
+
+	t, err := s.repo.CreateTask(ctx, domain.CreateTaskRequest{Name: "task 1"})
+	s.Require().NoError(err)
+
+	object, err := s.service.GetObject(ctx, "task-1.ics")
+	s.Require().NoError(err)
+	s.Equal(t.CalendarUID(), object.UID)
+	s.Equal(ical.StatusNeedsAction, object.Todo().Status)
+
+	for _, name := range []string{"task-1", "task-2.ics", "task-0.ics", "task-x.ics", "other.ics"} {
+		_, err := s.service.GetObject(ctx, name)
+		s.ErrorIs(err, domain.ErrCalendarObjectNotFound, name)
+	}
+
+	object, created, err := s.service.PutObject(ctx, "task-1.ics", ical.Todo{
+		UID:     t.CalendarUID(),
+		Summary: "renamed",
+		Status:  ical.StatusCompleted,
+	})
+	s.Require().NoError(err)
+	s.False(created)
+	s.Equal("renamed", object.Task.Name)
+	s.Equal(domain.TaskStatusCompleted, object.Task.Status)
+
+	_, _, err = s.service.PutObject(ctx, "task-1.ics", ical.Todo{UID: "other", Summary: "renamed"})
+	s.ErrorIs(err, caldav.ErrUIDConflict)
+}
+
+func (s *CalDAVServiceSuite) TestClientObjects() {
+	ctx := context.Background()
+
+	object, created, err := s.service.PutObject(ctx, "client.ics", ical.Todo{UID: "client-uid", Summary: "from client"})
+	s.Require().NoError(err)
+	s.True(created)
+	s.Equal(uint(1), object.Task.ID)
+
+	// the task is no longer served by its default name.
+	_, err = s.service.GetObject(ctx, "task-1.ics")
+	s.ErrorIs(err, domain.ErrCalendarObjectNotFound)
+
+	objects, err := s.service.ListObjects(ctx)
+	s.Require().NoError(err)
+	s.Require().Len(objects, 1)
+	s.Equal("client.ics", objects[0].Name)
+	s.Equal("client-uid", objects[0].UID)
+
+	_, _, err = s.service.PutObject(ctx, "task-2.ics", ical.Todo{UID: "client-uid", Summary: "duplicated"})
+	s.ErrorIs(err, caldav.ErrUIDConflict)
+
+	// the task is deleted by another API.
+	s.Require().NoError(s.repo.DeleteTask(ctx, object.Task.ID))
+	_, err = s.service.GetObject(ctx, "client.ics")
+	s.ErrorIs(err, domain.ErrCalendarObjectNotFound)
+}
+
+func (s *CalDAVServiceSuite) TestDeletedTasks() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stopped := s.service.Start(ctx)
+
+	object, _, err := s.service.PutObject(ctx, "client.ics", ical.Todo{UID: "client-uid", Summary: "from client"})
+	s.Require().NoError(err)
+
+	s.Require().NoError(s.tasks.DeleteTask(ctx, object.Task.ID))
+	cancel()
+	// stopping waits for the queued events to be handled.
+	<-stopped
+
+	// the object of the deleted task doesn't keep its UID.
+	_, created, err := s.service.PutObject(context.Background(), "other.ics",
+		ical.Todo{UID: "client-uid", Summary: "from client"})
+	s.Require().NoError(err)
+	s.True(created)
+
+	_, err = s.service.GetObject(context.Background(), "client.ics")
+	s.ErrorIs(err, domain.ErrCalendarObjectNotFound)
+
+	t, err := s.tasks.CreateTask(context.Background(), task.CreateTaskRequest{Name: "task 3"})
+	s.Require().NoError(err)
+	_, _, err = s.service.PutObject(context.Background(), "new.ics", ical.Todo{UID: t.CalendarUID(), Summary: "task 3"})
+	s.ErrorIs(err, caldav.ErrUIDConflict, "the default UID is used by the task")
+}
+
+// failingObjectRepo fails to save objects.
+type failingObjectRepo struct {
+	domain.CalendarObjectRepository
+}
+
+func (failingObjectRepo) SaveCalendarObject(context.Context, domain.CalendarObject) error {
+	return errors.New("save failed")
+}
+
+func (s *CalDAVServiceSuite) TestCreatedTasks() {
+	ctx := context.Background()
+
+	var events []domain.Event
+	s.tasks.EventBus().Subscribe("test", func(_ context.Context, envelope eventbus.Envelope) error {
+		events = append(events, envelope.Event)
+		return nil
+	})
+
+	// the task is created with its status at once.
+	object, _, err := s.service.PutObject(ctx, "done.ics", ical.Todo{UID: "done-uid", Summary: "done", Status: ical.StatusCompleted})
+	s.Require().NoError(err)
+	s.Equal(domain.TaskStatusCompleted, object.Task.Status)
+	s.Require().Len(events, 1)
+	s.Equal(domain.TaskStatusCompleted, events[0].(domain.TaskCreated).Task.Status)
+
+	// the task isn't left behind if its object can't be saved.
+	service := caldav.NewService(s.tasks, failingObjectRepo{persistance.NewRedisCalendarObjectRepo(database.Redis())})
+	_, _, err = service.PutObject(ctx, "client.ics", ical.Todo{UID: "client-uid", Summary: "from client"})
+	s.ErrorContains(err, "save failed")
+
+	tasks, err := s.tasks.ListTasks(ctx)
+	s.Require().NoError(err)
+	s.Len(tasks, 1)
+}
+
+func (s *CalDAVServiceSuite) TestInvalidObjects() {
+	ctx := context.Background()
+
+	_, _, err := s.service.PutObject(ctx, "client", ical.Todo{UID: "uid", Summary: "name"})
+	s.ErrorIs(err, caldav.ErrInvalidObjectName)
+
+	_, _, err = s.service.PutObject(ctx, "client.ics", ical.Todo{Summary: "name"})
+	s.ErrorIs(err, caldav.ErrUIDRequired)
+
+	_, _, err = s.service.PutObject(ctx, "client.ics", ical.Todo{UID: "uid"})
+	s.ErrorIs(err, task.ErrTaskNameRequired)
+
+	s.ErrorIs(s.service.DeleteObject(ctx, "client.ics"), domain.ErrCalendarObjectNotFound)
+}
+
+func TestCalDAVService(t *testing.T) {
+	suite.Run(t, new(CalDAVServiceSuite))
+}
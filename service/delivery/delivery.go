@@ -0,0 +1,113 @@
+// Package delivery implements the retries of deliveries, the webhook
+// deliveries and email notifications attempted from a queue shared by all
+// processes, and the requests of the API client.
+package delivery
+
+import (
+	"context"
+	"time"
+
+	"github.com/omegaatt36/gotasker/domain"
+	"github.com/omegaatt36/gotasker/logging"
+)
+
+// Backoff is an exponential backoff, the delay after the first failed
+// attempt is Base and doubles on every further attempt up to Max.
+type Backoff struct {
+	Base time.Duration
+	Max  time.Duration
+}
+
+// With returns the backoff with the given base and limit, non-positive ones
+// are left unchanged.
+func (b Backoff) With(base, limit time.Duration) Backoff {
+	if base > 0 {
+		b.Base = base
+	}
+	if limit > 0 {
+		b.Max = limit
+	}
+
+	return b
+}
+
+// After returns the delay after the given number of failed attempts.
+func (b Backoff) After(attempts int) time.Duration {
+	backoff := b.Base
+	for range attempts - 1 {
+		backoff *= 2
+		if backoff >= b.Max {
+			return b.Max
+		}
+	}
+
+	return min(backoff, b.Max)
+}
+
+// Queue holds the settings of attempting the deliveries of a queue.
+type Queue struct {
+	// MaxAttempts is the number of attempts before a delivery fails.
+	MaxAttempts  int
+	Backoff      Backoff
+	PollInterval time.Duration
+}
+
+// SetMaxAttempts sets the number of attempts, non-positive ones are ignored.
+func (q *Queue) SetMaxAttempts(attempts int) {
+	if attempts > 0 {
+		q.MaxAttempts = attempts
+	}
+}
+
+// SetPollInterval sets the poll interval, non-positive ones are ignored.
+func (q *Queue) SetPollInterval(interval time.Duration) {
+	if interval > 0 {
+		q.PollInterval = interval
+	}
+}
+
+// Result is the result of an attempt.
+type Result int
+
+// results of attempts.
+const (
+	// Retry is a failed attempt which is retried while attempts remain.
+	Retry Result = iota
+	Succeeded
+	// Failed is a failed attempt which isn't retried, like a rejected
+	// message or one to an inactive receiver.
+	Failed
+)
+
+// Next returns the state of a delivery after its attempts, the last of which
+// had the result, and the time of its next attempt if it's still pending.
+// Failed attempts are retried with the backoff until the attempts run out.
+func (q *Queue) Next(result Result, attempts int, now time.Time) (domain.DeliveryState, time.Time) {
+	switch {
+	case result == Succeeded:
+		return domain.DeliveryStateSucceeded, time.Time{}
+	case result == Failed, attempts >= q.MaxAttempts:
+		return domain.DeliveryStateFailed, time.Time{}
+	default:
+		return domain.DeliveryStatePending, now.Add(q.Backoff.After(attempts))
+	}
+}
+
+// Poll attempts the due deliveries by attemptDue every poll interval until
+// the context is canceled, errors are logged as failures to attempt the
+// named deliveries.
+func (q *Queue) Poll(ctx context.Context, name string, attemptDue func(context.Context) (int, error)) {
+	ticker := time.NewTicker(q.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := attemptDue(ctx); err != nil && ctx.Err() == nil {
+				logging.ErrorfCtx(ctx, "attempt %s failed: %v", name, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package delivery_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/omegaatt36/gotasker/domain"
+	"github.com/omegaatt36/gotasker/service/delivery"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type DeliverySuite struct {
+	suite.Suite
+}
+
+func (s *DeliverySuite) TestBackoff() {
+	backoff := delivery.Backoff{Base: time.Second, Max: 5 * time.Second}
+	s.Equal(time.Second, backoff.After(1))
+	s.Equal(2*time.Second, backoff.After(2))
+	s.Equal(4*time.Second, backoff.After(3))
+	s.Equal(5*time.Second, backoff.After(4))
+	s.Equal(5*time.Second, backoff.After(100))
+
+	s.Equal(delivery.Backoff{Base: time.Minute, Max: 5 * time.Second}, backoff.With(time.Minute, 0))
+	s.Equal(backoff, backoff.With(-1, -1))
+}
+
+func (s *DeliverySuite) TestNext() {
+	now := time.Now()
+	queue := delivery.Queue{
+		MaxAttempts: 3,
+		Backoff:     delivery.Backoff{Base: time.Second, Max: time.Minute},
+	}
+
+	state, _ := queue.Next(delivery.Succeeded, 1, now)
+	s.Equal(domain.DeliveryStateSucceeded, state)
+
+	state, _ = queue.Next(delivery.Failed, 1, now)
+	s.Equal(domain.DeliveryStateFailed, state)
+
+	state, next := queue.Next(delivery.Retry, 2, now)
+	s.Equal(domain.DeliveryStatePending, state)
+	s.Equal(now.Add(2*time.Second), next)
+
+	state, _ = queue.Next(delivery.Retry, 3, now)
+	s.Equal(domain.DeliveryStateFailed, state)
+}
+
+func TestDelivery(t *testing.T) {
+	suite.Run(t, new(DeliverySuite))
+}
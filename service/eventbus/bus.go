@@ -0,0 +1,206 @@
+package eventbus
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/omegaatt36/gotasker/domain"
+	"github.com/omegaatt36/gotasker/logging"
+)
+
+// default settings of async subscriptions.
+const (
+	DefaultWorkers   = 4
+	DefaultQueueSize = 256
+)
+
+// stripes is the number of locks ordering the events of tasks, events of
+// tasks sharing a stripe are ordered too.
+const stripes = 64
+
+// Envelope carries an event with its metadata.
+type Envelope struct {
+	// ID identifies the event, it's unique across restarts.
+	ID         string
+	OccurredAt time.Time
+	Event      domain.Event
+}
+
+// Handler handles an event. A returned error is logged, it doesn't affect
+// the publisher or the other handlers.
+type Handler func(ctx context.Context, envelope Envelope) error
+
+// Bus dispatches domain events to the handlers subscribed in the same
+// process. Events of the same task are delivered to every handler in the
+// order they are published.
+type Bus struct {
+	mu            sync.RWMutex
+	subscriptions []*Subscription
+
+	// epoch tells events of different processes apart.
+	epoch string
+	seq   atomic.Uint64
+
+	stripes [stripes]sync.Mutex
+}
+
+// NewBus creates a new event bus.
+func NewBus() *Bus {
+	return &Bus{
+		epoch: strconv.FormatInt(time.Now().UnixNano(), 36),
+	}
+}
+
+// Subscription is a handler subscribed to the bus.
+type Subscription struct {
+	bus     *Bus
+	name    string
+	handler Handler
+
+	// queues of async handlers, one per worker.
+	queues    []chan queuedEnvelope
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+}
+
+type queuedEnvelope struct {
+	ctx      context.Context
+	envelope Envelope
+}
+
+// Subscribe subscribes a handler which is called by the publisher before
+// Publish returns. Slow handlers slow down the writes of tasks, and they
+// must not publish or subscribe themselves, which would deadlock.
+func (b *Bus) Subscribe(name string, handler Handler) *Subscription {
+	subscription := &Subscription{bus: b, name: name, handler: handler}
+	b.add(subscription)
+
+	return subscription
+}
+
+// AsyncOptions defines the workers of an async subscription.
+type AsyncOptions struct {
+	// Workers is the number of goroutines calling the handler, events are
+	// distributed among them by task, so events of a task are handled one by
+	// one.
+	Workers int
+	// QueueSize is the number of events queued per worker. Publishers block
+	// while the queue is full, so no event is dropped.
+	QueueSize int
+}
+
+// SubscribeAsync subscribes a handler which is called by background workers.
+func (b *Bus) SubscribeAsync(name string, handler Handler, opts AsyncOptions) *Subscription {
+	if opts.Workers < 1 {
+		opts.Workers = DefaultWorkers
+	}
+	if opts.QueueSize < 1 {
+		opts.QueueSize = DefaultQueueSize
+	}
+
+	subscription := &Subscription{
+		bus:     b,
+		name:    name,
+		handler: handler,
+		queues:  make([]chan queuedEnvelope, opts.Workers),
+	}
+	for index := range subscription.queues {
+		queue := make(chan queuedEnvelope, opts.QueueSize)
+		subscription.queues[index] = queue
+
+		subscription.wg.Add(1)
+		go func() {
+			defer subscription.wg.Done()
+			for queued := range queue {
+				subscription.handle(queued.ctx, queued.envelope)
+			}
+		}()
+	}
+	b.add(subscription)
+
+	return subscription
+}
+
+func (b *Bus) add(subscription *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.subscriptions = append(b.subscriptions, subscription)
+}
+
+// Close unsubscribes the handler. The events queued for an async handler are
+// handled before Close returns.
+func (s *Subscription) Close() {
+	s.closeOnce.Do(func() {
+		b := s.bus
+		b.mu.Lock()
+		for index, subscription := range b.subscriptions {
+			if subscription == s {
+				b.subscriptions = append(b.subscriptions[:index:index], b.subscriptions[index+1:]...)
+				break
+			}
+		}
+		b.mu.Unlock()
+
+		for _, queue := range s.queues {
+			close(queue)
+		}
+		s.wg.Wait()
+	})
+}
+
+// Publish publishes the events in order. Sync handlers are called before it
+// returns, async handlers receive the events with a context which is not
+// canceled with ctx.
+func (b *Bus) Publish(ctx context.Context, events ...domain.Event) {
+	for _, event := range events {
+		b.publish(ctx, event)
+	}
+}
+
+func (b *Bus) publish(ctx context.Context, event domain.Event) {
+	taskID := event.TaskID()
+
+	// the stripe keeps concurrent publishers of a task from interleaving.
+	stripe := &b.stripes[taskID%stripes]
+	stripe.Lock()
+	defer stripe.Unlock()
+
+	envelope := Envelope{
+		ID:         fmt.Sprintf("%s-%d", b.epoch, b.seq.Add(1)),
+		OccurredAt: time.Now(),
+		Event:      event,
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, subscription := range b.subscriptions {
+		if subscription.queues == nil {
+			subscription.handle(ctx, envelope)
+			continue
+		}
+
+		queue := subscription.queues[taskID%uint(len(subscription.queues))]
+		queue <- queuedEnvelope{ctx: context.WithoutCancel(ctx), envelope: envelope}
+	}
+}
+
+// handle calls the handler, errors and panics are logged.
+func (s *Subscription) handle(ctx context.Context, envelope Envelope) {
+	defer func() {
+		if r := recover(); r != nil {
+			logging.ErrorfCtx(ctx, "event handler %s panicked on %s %s: %v",
+				s.name, envelope.Event.Name(), envelope.ID, r)
+		}
+	}()
+
+	if err := s.handler(ctx, envelope); err != nil {
+		logging.ErrorfCtx(ctx, "event handler %s failed on %s %s: %v",
+			s.name, envelope.Event.Name(), envelope.ID, err)
+	}
+}
@@ -0,0 +1,119 @@
+package eventbus_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/omegaatt36/gotasker/domain"
+	"github.com/omegaatt36/gotasker/logging"
+	"github.com/omegaatt36/gotasker/service/eventbus"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type EventBusSuite struct {
+	suite.Suite
+}
+
+func (s *EventBusSuite) SetupSuite() {
+	logging.Init(false, "error")
+}
+
+func (s *EventBusSuite) TestSubscribe() {
+	bus := eventbus.NewBus()
+
+	var envelopes []eventbus.Envelope
+	subscription := bus.Subscribe("test", func(_ context.Context, envelope eventbus.Envelope) error {
+		envelopes = append(envelopes, envelope)
+		return nil
+	})
+
+	bus.Publish(context.Background(),
+		domain.TaskCreated{Task: domain.Task{ID: 1}},
+		domain.TaskDeleted{Task: domain.Task{ID: 1}},
+	)
+	s.Require().Len(envelopes, 2)
+	s.IsType(domain.TaskCreated{}, envelopes[0].Event)
+	s.IsType(domain.TaskDeleted{}, envelopes[1].Event)
+	s.NotEqual(envelopes[0].ID, envelopes[1].ID)
+	s.False(envelopes[0].OccurredAt.IsZero())
+
+	subscription.Close()
+	bus.Publish(context.Background(), domain.TaskCreated{Task: domain.Task{ID: 2}})
+	s.Len(envelopes, 2)
+}
+
+func (s *EventBusSuite) TestSubscribeAsync() {
+	bus := eventbus.NewBus()
+
+	var (
+		mu       sync.Mutex
+		received = make(map[uint][]string)
+	)
+	subscription := bus.SubscribeAsync("test", func(_ context.Context, envelope eventbus.Envelope) error {
+		mu.Lock()
+		defer mu.Unlock()
+		updated := envelope.Event.(domain.TaskUpdated)
+		received[updated.TaskID()] = append(received[updated.TaskID()], updated.After.Name)
+		return nil
+	}, eventbus.AsyncOptions{Workers: 3, QueueSize: 1})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	for id := uint(1); id <= 5; id++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := 0; index < 50; index++ {
+				bus.Publish(ctx, domain.TaskUpdated{After: domain.Task{ID: id, Name: string(rune('a' + index))}})
+			}
+		}()
+	}
+	wg.Wait()
+	// the queued events are handled even if the publisher is gone.
+	cancel()
+	subscription.Close()
+
+	s.Len(received, 5)
+	for id, names := range received {
+		s.Require().Len(names, 50, "task %d", id)
+		for index, name := range names {
+			s.Equal(string(rune('a'+index)), name, "task %d", id)
+		}
+	}
+}
+
+func (s *EventBusSuite) TestFailureIsolation() {
+	bus := eventbus.NewBus()
+
+	bus.Subscribe("failing", func(context.Context, eventbus.Envelope) error {
+		return errors.New("failed")
+	})
+	bus.Subscribe("panicking", func(context.Context, eventbus.Envelope) error {
+		panic("panicked")
+	})
+	async := bus.SubscribeAsync("async panicking", func(context.Context, eventbus.Envelope) error {
+		panic("panicked")
+	}, eventbus.AsyncOptions{})
+
+	var handled int
+	bus.Subscribe("test", func(context.Context, eventbus.Envelope) error {
+		handled++
+		return nil
+	})
+
+	s.NotPanics(func() {
+		bus.Publish(context.Background(),
+			domain.TaskCreated{Task: domain.Task{ID: 1}},
+			domain.TaskCreated{Task: domain.Task{ID: 2}},
+		)
+	})
+	async.Close()
+	s.Equal(2, handled)
+}
+
+func TestEventBus(t *testing.T) {
+	suite.Run(t, new(EventBusSuite))
+}
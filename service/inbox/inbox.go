@@ -0,0 +1,212 @@
+package inbox
+
+import (
+	"context"
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/omegaatt36/gotasker/domain"
+	"github.com/omegaatt36/gotasker/format/email"
+	"github.com/omegaatt36/gotasker/service/task"
+)
+
+var (
+	// ErrSenderNotAllowed is returned when the sender of a message isn't in
+	// the allowlist.
+	ErrSenderNotAllowed = errors.New("sender is not allowed")
+	// ErrRateLimited is returned when a sender sends more messages than
+	// allowed in the rate interval.
+	ErrRateLimited = errors.New("sender exceeded the rate limit")
+)
+
+// default rate limit of each sender.
+const (
+	DefaultRateLimit    = 10
+	DefaultRateInterval = time.Hour
+)
+
+// forwardPrefix matches the prefixes of forwarded subjects, e.g. "Fwd: " and
+// "FW: ".
+var forwardPrefix = regexp.MustCompile(`(?i)^\s*(fwd?|fw)\s*:\s*`)
+
+// Service represents an inbox service, which creates a task for every email
+// of an allowed sender. The subject is the name of the task and the
+// plain-text body is its notes.
+type Service struct {
+	tasks *task.Service
+
+	// addresses and domains are the allowed senders, domains are like
+	// "@example.com".
+	addresses map[string]struct{}
+	domains   map[string]struct{}
+
+	rateLimit    int
+	rateInterval time.Duration
+
+	mu sync.Mutex
+	// received is the times of the messages of each sender within the rate
+	// interval, oldest first. Senders without messages in the interval are
+	// swept once it has doubled since the last sweep, so the senders of an
+	// allowed domain don't pile up.
+	received map[string][]time.Time
+	sweepAt  int
+}
+
+// Option configures an inbox service.
+type Option func(*Service)
+
+// WithAllowlist sets the allowed senders, entries like "@example.com" allow
+// every address of the domain. No sender is allowed by default.
+func WithAllowlist(senders ...string) Option {
+	return func(s *Service) {
+		for _, sender := range senders {
+			sender = strings.ToLower(strings.TrimSpace(sender))
+			switch {
+			case sender == "":
+			case strings.HasPrefix(sender, "@"):
+				s.domains[sender] = struct{}{}
+			default:
+				s.addresses[sender] = struct{}{}
+			}
+		}
+	}
+}
+
+// WithRateLimit sets the number of messages each sender can send in the
+// interval, the senders allowed by a domain entry are limited separately.
+func WithRateLimit(limit int, interval time.Duration) Option {
+	return func(s *Service) {
+		if limit > 0 {
+			s.rateLimit = limit
+		}
+		if interval > 0 {
+			s.rateInterval = interval
+		}
+	}
+}
+
+// NewService creates a new inbox service creating tasks by the task service.
+func NewService(tasks *task.Service, opts ...Option) *Service {
+	s := &Service{
+		tasks:        tasks,
+		addresses:    make(map[string]struct{}),
+		domains:      make(map[string]struct{}),
+		rateLimit:    DefaultRateLimit,
+		rateInterval: DefaultRateInterval,
+		received:     make(map[string][]time.Time),
+		sweepAt:      minSweepSize,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// CheckSender returns ErrSenderNotAllowed if the sender address isn't in
+// the allowlist, addresses are case-insensitive. The sender is the envelope
+// sender, which isn't authenticated, so the allowlist only keeps out the
+// senders of MTAs verifying them, see smtp.Server.
+func (s *Service) CheckSender(sender string) error {
+	sender = normalizeSender(sender)
+	if _, ok := s.addresses[sender]; ok {
+		return nil
+	}
+
+	if at := strings.LastIndex(sender, "@"); at > 0 {
+		if _, ok := s.domains[sender[at:]]; ok {
+			return nil
+		}
+	}
+
+	return ErrSenderNotAllowed
+}
+
+// normalizeSender returns the sender address as it's compared with the
+// allowlist and limited.
+func normalizeSender(sender string) string {
+	return strings.ToLower(strings.TrimSpace(sender))
+}
+
+// Receive creates a task of the message sent by the sender, which is the
+// envelope sender rather than the From header as forwarded messages keep
+// the From of their original sender. Messages exceeding the rate limit of
+// the sender are rejected before creating the task.
+func (s *Service) Receive(ctx context.Context, sender string, r io.Reader) (domain.Task, error) {
+	if err := s.CheckSender(sender); err != nil {
+		return domain.Task{}, err
+	}
+
+	msg, err := email.Parse(r)
+	if err != nil {
+		return domain.Task{}, err
+	}
+
+	name := TaskName(msg.Subject)
+	if name == "" {
+		return domain.Task{}, task.ErrTaskNameRequired
+	}
+
+	if !s.allow(normalizeSender(sender), time.Now()) {
+		return domain.Task{}, ErrRateLimited
+	}
+
+	return s.tasks.CreateTask(ctx, task.CreateTaskRequest{
+		Name:  name,
+		Notes: msg.Text,
+	})
+}
+
+// minSweepSize is the number of senders recorded before the first sweep.
+const minSweepSize = 64
+
+// allow records a message of the sender at now, unless the sender has
+// reached the rate limit.
+func (s *Service) allow(sender string, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	since := now.Add(-s.rateInterval)
+	received := s.received[sender]
+	for len(received) > 0 && !received[0].After(since) {
+		received = received[1:]
+	}
+
+	if len(received) >= s.rateLimit {
+		s.received[sender] = received
+		return false
+	}
+
+	// the slice is copied as the expired times are only sliced off.
+	s.received[sender] = append(received[:len(received):len(received)], now)
+
+	if len(s.received) >= s.sweepAt {
+		for sender, received := range s.received {
+			if !received[len(received)-1].After(since) {
+				delete(s.received, sender)
+			}
+		}
+		s.sweepAt = max(2*len(s.received), minSweepSize)
+	}
+
+	return true
+}
+
+// TaskName returns the task name of the subject, without the prefixes of
+// forwarded messages and with the whitespace collapsed.
+func TaskName(subject string) string {
+	for {
+		trimmed := forwardPrefix.ReplaceAllString(subject, "")
+		if trimmed == subject {
+			break
+		}
+		subject = trimmed
+	}
+
+	return strings.Join(strings.Fields(subject), " ")
+}
@@ -0,0 +1,116 @@
+package inbox_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/omegaatt36/gotasker/domain/stub"
+	"github.com/omegaatt36/gotasker/format/email"
+	"github.com/omegaatt36/gotasker/service/inbox"
+	"github.com/omegaatt36/gotasker/service/task"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type InboxSuite struct {
+	suite.Suite
+
+	repo    *stub.InMemoryTaskRepository
+	service *inbox.Service
+}
+
+func (s *InboxSuite) SetupTest() {
+	s.repo = stub.NewInMemoryTaskRepository()
+	s.service = inbox.NewService(task.NewService(s.repo),
+		inbox.WithAllowlist("Alice@example.com", "@support.example.com"),
+		inbox.WithRateLimit(2, time.Hour),
+	)
+}
+
+func message(subject, body string) *strings.Reader {
+	return strings.NewReader("From: someone@example.org\r\nSubject: " + subject + "\r\n\r\n" + body)
+}
+
+func (s *InboxSuite) TestReceive() {
+	ctx := context.Background()
+
+	t, err := s.service.Receive(ctx, "alice@Example.com", message("Fwd: FW:  Fix   the printer", "It's jammed.\r\n"))
+	s.Require().NoError(err)
+	s.Equal("Fix the printer", t.Name)
+	s.Equal("It's jammed.", t.Notes)
+
+	tasks, err := s.repo.ListTasks(ctx)
+	s.Require().NoError(err)
+	s.Require().Len(tasks, 1)
+	s.Equal("It's jammed.", tasks[0].Notes)
+
+	_, err = s.service.Receive(ctx, "bob@support.example.com", message("Call back", ""))
+	s.NoError(err)
+
+	_, err = s.service.Receive(ctx, "alice@example.com", message("Fwd:", "no subject"))
+	s.ErrorIs(err, task.ErrTaskNameRequired)
+
+	_, err = s.service.Receive(ctx, "alice@example.com", strings.NewReader("not a header\r\n"))
+	s.ErrorIs(err, email.ErrMalformed)
+}
+
+func (s *InboxSuite) TestAllowlist() {
+	s.NoError(s.service.CheckSender("ALICE@example.com"))
+	s.NoError(s.service.CheckSender("carol@support.example.com"))
+
+	for _, sender := range []string{"", "bob@example.com", "alice@example.com.evil", "carol@evil.support.example.com"} {
+		s.ErrorIs(s.service.CheckSender(sender), inbox.ErrSenderNotAllowed, sender)
+	}
+
+	_, err := s.service.Receive(context.Background(), "bob@example.com", message("task", ""))
+	s.ErrorIs(err, inbox.ErrSenderNotAllowed)
+
+	// no sender is allowed without an allowlist.
+	s.ErrorIs(inbox.NewService(task.NewService(s.repo)).CheckSender("alice@example.com"), inbox.ErrSenderNotAllowed)
+}
+
+func (s *InboxSuite) TestRateLimit() {
+	ctx := context.Background()
+
+	for range 2 {
+		_, err := s.service.Receive(ctx, "alice@example.com", message("task", ""))
+		s.Require().NoError(err)
+	}
+
+	_, err := s.service.Receive(ctx, "Alice@example.com", message("task", ""))
+	s.ErrorIs(err, inbox.ErrRateLimited)
+
+	// senders are limited separately, including the senders of a domain.
+	for range 2 {
+		_, err = s.service.Receive(ctx, "bob@support.example.com", message("task", ""))
+		s.NoError(err)
+		_, err = s.service.Receive(ctx, "carol@support.example.com", message("task", ""))
+		s.NoError(err)
+	}
+	_, err = s.service.Receive(ctx, " Bob@Support.example.com", message("task", ""))
+	s.ErrorIs(err, inbox.ErrRateLimited)
+
+	tasks, err := s.repo.ListTasks(ctx)
+	s.Require().NoError(err)
+	s.Len(tasks, 6)
+
+	service := inbox.NewService(task.NewService(s.repo),
+		inbox.WithAllowlist("alice@example.com"),
+		inbox.WithRateLimit(1, 50*time.Millisecond),
+	)
+	_, err = service.Receive(ctx, "alice@example.com", message("task", ""))
+	s.Require().NoError(err)
+	_, err = service.Receive(ctx, "alice@example.com", message("task", ""))
+	s.ErrorIs(err, inbox.ErrRateLimited)
+
+	s.Eventually(func() bool {
+		_, err := service.Receive(ctx, "alice@example.com", message("task", ""))
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestInboxSuite(t *testing.T) {
+	suite.Run(t, new(InboxSuite))
+}
@@ -0,0 +1,376 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"slices"
+	"time"
+
+	"github.com/omegaatt36/gotasker/domain"
+	"github.com/omegaatt36/gotasker/logging"
+	"github.com/omegaatt36/gotasker/service/delivery"
+	"github.com/omegaatt36/gotasker/service/eventbus"
+	"github.com/omegaatt36/gotasker/service/task"
+
+	gosmtp "github.com/emersion/go-smtp"
+)
+
+var (
+	ErrInvalidEmail = errors.New("recipient email must be a plain address like alice@example.com")
+	ErrInvalidEvent = errors.New("invalid notification event")
+)
+
+// Event is a task event recipients can be notified of.
+type Event string
+
+// events of notifications.
+const (
+	EventCreated   Event = "created"
+	EventRenamed   Event = "renamed"
+	EventCompleted Event = "completed"
+)
+
+// Events are the events recipients can be notified of.
+var Events = []Event{EventCreated, EventRenamed, EventCompleted}
+
+// default delivery settings.
+const (
+	DefaultMaxAttempts  = 8
+	DefaultBackoff      = 30 * time.Second
+	DefaultMaxBackoff   = time.Hour
+	DefaultPollInterval = time.Second
+)
+
+// claimLimit is the number of notifications sent per poll, they're sent one
+// by one to go easy on the SMTP server.
+const claimLimit = 10
+
+// replyOK is the SMTP reply code of accepted messages.
+const replyOK = 250
+
+// Service represents a notification service. It renders an email for every
+// task event and recipient notified of it, and sends them from a Redis queue
+// shared by all processes.
+type Service struct {
+	repo      domain.NotificationRepository
+	tasks     *task.Service
+	sender    Sender
+	templates *Templates
+
+	queue delivery.Queue
+	// lease is the time a claimed notification isn't claimed again, it
+	// outlasts sending all the claimed notifications.
+	lease time.Duration
+}
+
+// Option configures a notification service.
+type Option func(*Service)
+
+// WithTemplates sets the templates of the messages.
+func WithTemplates(templates *Templates) Option {
+	return func(s *Service) {
+		if templates != nil {
+			s.templates = templates
+		}
+	}
+}
+
+// WithMaxAttempts sets the number of attempts before a notification fails.
+func WithMaxAttempts(attempts int) Option {
+	return func(s *Service) {
+		s.queue.SetMaxAttempts(attempts)
+	}
+}
+
+// WithBackoff sets the backoff of retried notifications, see
+// delivery.Backoff.
+func WithBackoff(base, limit time.Duration) Option {
+	return func(s *Service) {
+		s.queue.Backoff = s.queue.Backoff.With(base, limit)
+	}
+}
+
+// WithPollInterval sets the interval of polling the notification queue.
+func WithPollInterval(interval time.Duration) Option {
+	return func(s *Service) {
+		s.queue.SetPollInterval(interval)
+	}
+}
+
+// NewService creates a new notification service sending the notifications
+// of task events by the sender.
+func NewService(repo domain.NotificationRepository, tasks *task.Service, sender Sender, opts ...Option) *Service {
+	s := &Service{
+		repo:      repo,
+		tasks:     tasks,
+		sender:    sender,
+		templates: DefaultTemplates(),
+		queue: delivery.Queue{
+			MaxAttempts:  DefaultMaxAttempts,
+			Backoff:      delivery.Backoff{Base: DefaultBackoff, Max: DefaultMaxBackoff},
+			PollInterval: DefaultPollInterval,
+		},
+		lease: claimLimit*DefaultSMTPTimeout + time.Minute,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// CreateRecipientRequest defines the request for creating a recipient.
+type CreateRecipientRequest struct {
+	Email string
+	Name  string
+	// Events are the events the recipient is notified of, empty notifies of
+	// all events.
+	Events []string
+}
+
+// CreateRecipient creates a new recipient.
+func (s *Service) CreateRecipient(ctx context.Context, req CreateRecipientRequest) (domain.Recipient, error) {
+	if err := validateEmail(req.Email); err != nil {
+		return domain.Recipient{}, err
+	}
+
+	if err := validateEvents(req.Events); err != nil {
+		return domain.Recipient{}, err
+	}
+
+	return s.repo.CreateRecipient(ctx, domain.CreateRecipientRequest{
+		Email:  req.Email,
+		Name:   req.Name,
+		Events: req.Events,
+	})
+}
+
+// ListRecipients lists all recipients.
+func (s *Service) ListRecipients(ctx context.Context) ([]domain.Recipient, error) {
+	return s.repo.ListRecipients(ctx)
+}
+
+// GetRecipient gets a recipient.
+func (s *Service) GetRecipient(ctx context.Context, id uint) (domain.Recipient, error) {
+	return s.repo.GetRecipient(ctx, id)
+}
+
+// UpdateRecipientRequest defines the request for updating a recipient.
+type UpdateRecipientRequest struct {
+	Email  *string
+	Name   *string
+	Events *[]string
+	// Active pauses the notifications of the recipient if false.
+	Active *bool
+}
+
+// UpdateRecipient updates a recipient.
+func (s *Service) UpdateRecipient(ctx context.Context, id uint, req UpdateRecipientRequest) (domain.Recipient, error) {
+	if req.Email != nil {
+		if err := validateEmail(*req.Email); err != nil {
+			return domain.Recipient{}, err
+		}
+	}
+
+	if req.Events != nil {
+		if err := validateEvents(*req.Events); err != nil {
+			return domain.Recipient{}, err
+		}
+	}
+
+	return s.repo.UpdateRecipient(ctx, id, domain.UpdateRecipientRequest{
+		Email:  req.Email,
+		Name:   req.Name,
+		Events: req.Events,
+		Active: req.Active,
+	})
+}
+
+// DeleteRecipient deletes a recipient and its notification log.
+func (s *Service) DeleteRecipient(ctx context.Context, id uint) error {
+	return s.repo.DeleteRecipient(ctx, id)
+}
+
+// ListNotifications lists the notification log of a recipient, the latest
+// first.
+func (s *Service) ListNotifications(ctx context.Context, recipientID uint) ([]domain.Notification, error) {
+	if _, err := s.repo.GetRecipient(ctx, recipientID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.ListNotifications(ctx, recipientID)
+}
+
+// Start records the notifications of task events published from now on and
+// sends the due ones in the background. The returned channel is closed once
+// it has stopped after the context is canceled.
+func (s *Service) Start(ctx context.Context) <-chan struct{} {
+	subscription := s.tasks.EventBus().SubscribeAsync("notifications", s.RecordEvent, eventbus.AsyncOptions{})
+
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+
+		s.queue.Poll(ctx, "notifications", s.SendDue)
+		subscription.Close()
+	}()
+
+	return stopped
+}
+
+// RecordEvent queues a notification of the event to every active recipient
+// notified of it, it's the handler subscribed to the event bus. Updates
+// changing the name are renamed events, and status changes to completed are
+// completed events.
+func (s *Service) RecordEvent(ctx context.Context, envelope eventbus.Envelope) error {
+	var data TemplateData
+	switch event := envelope.Event.(type) {
+	case domain.TaskCreated:
+		data.Event, data.Task = EventCreated, event.Task
+	case domain.TaskUpdated:
+		if event.Before.Name == event.After.Name {
+			return nil
+		}
+		data.Event, data.Task, data.PreviousName = EventRenamed, event.After, event.Before.Name
+	case domain.TaskStatusChanged:
+		if event.To != domain.TaskStatusCompleted {
+			return nil
+		}
+		data.Event, data.Task = EventCompleted, event.Task
+	default:
+		return nil
+	}
+
+	recipients, err := s.repo.ListRecipients(ctx)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, recipient := range recipients {
+		if !recipient.Active || (len(recipient.Events) > 0 && !slices.Contains(recipient.Events, string(data.Event))) {
+			continue
+		}
+
+		data.Recipient = recipient
+		subject, text, html, err := s.templates.Render(&data)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("recipient %d: %w", recipient.ID, err))
+			continue
+		}
+
+		if _, err := s.repo.CreateNotification(ctx, domain.Notification{
+			RecipientID:   recipient.ID,
+			EventID:       envelope.ID,
+			Event:         string(data.Event),
+			TaskID:        data.Task.ID,
+			Subject:       subject,
+			Text:          text,
+			HTML:          html,
+			State:         domain.DeliveryStatePending,
+			NextAttemptAt: time.Now(),
+		}); err != nil {
+			errs = append(errs, fmt.Errorf("recipient %d: %w", recipient.ID, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// SendDue attempts the notifications which are due and returns the number
+// of attempts.
+func (s *Service) SendDue(ctx context.Context) (int, error) {
+	notifications, err := s.repo.ClaimDueNotifications(ctx, time.Now(), s.lease, claimLimit)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, notification := range notifications {
+		if err := s.send(ctx, notification); err != nil {
+			logging.ErrorfCtx(ctx, "send notification %d to recipient %d failed: %v",
+				notification.ID, notification.RecipientID, err)
+		}
+	}
+
+	return len(notifications), nil
+}
+
+// send attempts the notification and saves the result, messages rejected
+// permanently and notifications of inactive recipients fail without being
+// retried.
+func (s *Service) send(ctx context.Context, notification domain.Notification) error {
+	recipient, err := s.repo.GetRecipient(ctx, notification.RecipientID)
+	if err != nil {
+		// the notification log is deleted with the recipient.
+		if errors.Is(err, domain.ErrRecipientNotFound) {
+			return nil
+		}
+
+		return err
+	}
+
+	var attempt domain.DeliveryAttempt
+	if recipient.Active {
+		attempt = s.attempt(ctx, &recipient, &notification)
+	} else {
+		attempt = domain.DeliveryAttempt{At: time.Now(), Error: "recipient is inactive"}
+	}
+	notification.Attempts = append(notification.Attempts, attempt)
+
+	result := delivery.Retry
+	switch {
+	case attempt.StatusCode == replyOK:
+		result = delivery.Succeeded
+	case !recipient.Active, attempt.StatusCode >= 500:
+		result = delivery.Failed
+	}
+	notification.State, notification.NextAttemptAt = s.queue.Next(result, len(notification.Attempts), time.Now())
+
+	return s.repo.SaveNotification(ctx, notification)
+}
+
+func (s *Service) attempt(ctx context.Context, recipient *domain.Recipient, notification *domain.Notification) domain.DeliveryAttempt {
+	attempt := domain.DeliveryAttempt{At: time.Now()}
+
+	err := s.sender.Send(ctx, Message{
+		To:      mail.Address{Name: recipient.Name, Address: recipient.Email},
+		Subject: notification.Subject,
+		Text:    notification.Text,
+		HTML:    notification.HTML,
+	})
+	attempt.Duration = time.Since(attempt.At)
+	if err != nil {
+		var smtpErr *gosmtp.SMTPError
+		if errors.As(err, &smtpErr) {
+			attempt.StatusCode = smtpErr.Code
+		}
+		attempt.Error = err.Error()
+		return attempt
+	}
+
+	attempt.StatusCode = replyOK
+
+	return attempt
+}
+
+func validateEmail(email string) error {
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Name != "" || address.Address != email {
+		return ErrInvalidEmail
+	}
+
+	return nil
+}
+
+func validateEvents(events []string) error {
+	for _, event := range events {
+		if !slices.Contains(Events, Event(event)) {
+			return fmt.Errorf("%w: %s", ErrInvalidEvent, event)
+		}
+	}
+
+	return nil
+}
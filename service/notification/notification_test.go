@@ -0,0 +1,267 @@
+package notification_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/omegaatt36/gotasker/domain"
+	"github.com/omegaatt36/gotasker/domain/stub"
+	"github.com/omegaatt36/gotasker/format/email"
+	"github.com/omegaatt36/gotasker/logging"
+	"github.com/omegaatt36/gotasker/persistance"
+	"github.com/omegaatt36/gotasker/persistance/database"
+	"github.com/omegaatt36/gotasker/service/eventbus"
+	"github.com/omegaatt36/gotasker/service/notification"
+	"github.com/omegaatt36/gotasker/service/task"
+	"github.com/omegaatt36/gotasker/util"
+
+	gosmtp "github.com/emersion/go-smtp"
+	"github.com/stretchr/testify/suite"
+)
+
+type NotificationServiceSuite struct {
+	suite.Suite
+}
+
+func (s *NotificationServiceSuite) SetupSuite() {
+	logging.Init(false, "error")
+}
+
+// newService creates a service sending by a local sink.
+func (s *NotificationServiceSuite) newService(opts ...notification.Option) (*notification.Service, *task.Service, *sink, func()) {
+	miniredis := database.InitializeTestingRedis()
+	database.Initialize(context.Background(), miniredis.Addr(), "")
+
+	sink := newSink(s.T(), "", "", nil)
+	host, port := sink.hostPort()
+	sender, err := notification.NewSMTPSender(notification.SMTPConfig{
+		Host: host,
+		Port: port,
+		TLS:  notification.TLSModeNone,
+		From: "GoTasker <tasks@example.com>",
+	})
+	s.Require().NoError(err)
+
+	tasks := task.NewService(stub.NewInMemoryTaskRepository())
+	service := notification.NewService(persistance.NewRedisNotificationRepo(database.Redis()), tasks, sender, opts...)
+
+	return service, tasks, sink, miniredis.Close
+}
+
+func (s *NotificationServiceSuite) TestCreateRecipient() {
+	service, _, _, closeRedis := s.newService()
+	defer closeRedis()
+
+	ctx := context.Background()
+
+	for _, address := range []string{"", "alice", "Alice <alice@example.com>", " alice@example.com"} {
+		_, err := service.CreateRecipient(ctx, notification.CreateRecipientRequest{Email: address})
+		s.ErrorIs(err, notification.ErrInvalidEmail, address)
+	}
+
+	_, err := service.CreateRecipient(ctx, notification.CreateRecipientRequest{Email: "alice@example.com", Events: []string{"deleted"}})
+	s.ErrorIs(err, notification.ErrInvalidEvent)
+
+	created, err := service.CreateRecipient(ctx, notification.CreateRecipientRequest{Email: "alice@example.com", Name: "Alice"})
+	s.Require().NoError(err)
+	s.True(created.Active)
+	s.Empty(created.Events)
+
+	updated, err := service.UpdateRecipient(ctx, created.ID, notification.UpdateRecipientRequest{
+		Events: &[]string{"completed"},
+		Active: util.Pointer(false),
+	})
+	s.Require().NoError(err)
+	s.Equal([]string{"completed"}, updated.Events)
+	s.False(updated.Active)
+	s.Equal("Alice", updated.Name)
+
+	_, err = service.UpdateRecipient(ctx, created.ID, notification.UpdateRecipientRequest{Email: util.Pointer("bob")})
+	s.ErrorIs(err, notification.ErrInvalidEmail)
+
+	s.Require().NoError(service.DeleteRecipient(ctx, created.ID))
+	_, err = service.GetRecipient(ctx, created.ID)
+	s.ErrorIs(err, domain.ErrRecipientNotFound)
+	_, err = service.ListNotifications(ctx, created.ID)
+	s.ErrorIs(err, domain.ErrRecipientNotFound)
+}
+
+func (s *NotificationServiceSuite) TestRecordEvent() {
+	service, tasks, _, closeRedis := s.newService()
+	defer closeRedis()
+
+	ctx := context.Background()
+	all, err := service.CreateRecipient(ctx, notification.CreateRecipientRequest{Email: "alice@example.com", Name: "Alice"})
+	s.Require().NoError(err)
+	completedOnly, err := service.CreateRecipient(ctx, notification.CreateRecipientRequest{Email: "bob@example.com", Events: []string{"completed"}})
+	s.Require().NoError(err)
+	inactive, err := service.CreateRecipient(ctx, notification.CreateRecipientRequest{Email: "carol@example.com"})
+	s.Require().NoError(err)
+	_, err = service.UpdateRecipient(ctx, inactive.ID, notification.UpdateRecipientRequest{Active: util.Pointer(false)})
+	s.Require().NoError(err)
+
+	created, err := tasks.CreateTask(ctx, task.CreateTaskRequest{Name: "task 1"})
+	s.Require().NoError(err)
+	renamed := created
+	renamed.Name = "task <1>"
+	completed := renamed
+	completed.Status = domain.TaskStatusCompleted
+
+	for _, envelope := range []eventbus.Envelope{
+		{ID: "event-1", Event: domain.TaskCreated{Task: created}},
+		{ID: "event-2", Event: domain.TaskUpdated{Before: created, After: renamed}},
+		// updates other than renaming aren't notified.
+		{ID: "event-3", Event: domain.TaskUpdated{Before: renamed, After: renamed}},
+		{ID: "event-4", Event: domain.TaskStatusChanged{Task: completed, From: domain.TaskStatusIncomplete, To: domain.TaskStatusCompleted}},
+		{ID: "event-5", Event: domain.TaskStatusChanged{Task: renamed, From: domain.TaskStatusCompleted, To: domain.TaskStatusIncomplete}},
+		{ID: "event-6", Event: domain.TaskDeleted{Task: renamed}},
+	} {
+		s.Require().NoError(service.RecordEvent(ctx, envelope))
+	}
+
+	notifications, err := service.ListNotifications(ctx, all.ID)
+	s.Require().NoError(err)
+	s.Require().Len(notifications, 3)
+	s.Equal([]string{"completed", "renamed", "created"},
+		[]string{notifications[0].Event, notifications[1].Event, notifications[2].Event})
+	s.Equal("event-2", notifications[1].EventID)
+	s.Equal(created.ID, notifications[1].TaskID)
+	s.Equal(domain.DeliveryStatePending, notifications[1].State)
+	s.Equal("Task renamed: task <1>", notifications[1].Subject)
+	s.Contains(notifications[1].Text, "Hi Alice,")
+	s.Contains(notifications[1].Text, `"task 1" was renamed to "task <1>".`)
+	s.Contains(notifications[1].HTML, "<strong>task &lt;1&gt;</strong>")
+
+	notifications, err = service.ListNotifications(ctx, completedOnly.ID)
+	s.Require().NoError(err)
+	s.Require().Len(notifications, 1)
+	s.Equal("completed", notifications[0].Event)
+	s.Equal("Task completed: task <1>", notifications[0].Subject)
+
+	notifications, err = service.ListNotifications(ctx, inactive.ID)
+	s.Require().NoError(err)
+	s.Empty(notifications)
+}
+
+func (s *NotificationServiceSuite) TestSendDue() {
+	service, tasks, sink, closeRedis := s.newService(notification.WithBackoff(time.Millisecond, 10*time.Millisecond))
+	defer closeRedis()
+
+	sink.errs = []error{&gosmtp.SMTPError{Code: 451, Message: "try again later"}}
+
+	ctx := context.Background()
+	recipient, err := service.CreateRecipient(ctx, notification.CreateRecipientRequest{Email: "alice@example.com"})
+	s.Require().NoError(err)
+
+	created, err := tasks.CreateTask(ctx, task.CreateTaskRequest{Name: "task 1", Notes: "buy milk"})
+	s.Require().NoError(err)
+	s.Require().NoError(service.RecordEvent(ctx, eventbus.Envelope{ID: "event-1", Event: domain.TaskCreated{Task: created}}))
+
+	// the first attempt is deferred with 451, the retry succeeds.
+	s.Eventually(func() bool {
+		_, err := service.SendDue(ctx)
+		s.Require().NoError(err)
+		return len(sink.received()) == 1
+	}, time.Second, 5*time.Millisecond)
+
+	received := sink.received()[0]
+	s.Equal([]string{"alice@example.com"}, received.to)
+	parsed, err := email.Parse(bytes.NewReader(received.data))
+	s.Require().NoError(err)
+	s.Equal("Task created: task 1", parsed.Subject)
+	s.Contains(parsed.Text, `Task #1 "task 1" was created.`)
+	s.Contains(parsed.Text, "buy milk")
+
+	notifications, err := service.ListNotifications(ctx, recipient.ID)
+	s.Require().NoError(err)
+	s.Require().Len(notifications, 1)
+	s.Equal(domain.DeliveryStateSucceeded, notifications[0].State)
+	s.Require().Len(notifications[0].Attempts, 2)
+	s.Equal(451, notifications[0].Attempts[0].StatusCode)
+	s.NotEmpty(notifications[0].Attempts[0].Error)
+	s.Equal(250, notifications[0].Attempts[1].StatusCode)
+
+	n, err := service.SendDue(ctx)
+	s.Require().NoError(err)
+	s.Zero(n)
+}
+
+func (s *NotificationServiceSuite) TestSendFailed() {
+	service, tasks, sink, closeRedis := s.newService(
+		notification.WithBackoff(time.Millisecond, time.Millisecond),
+		notification.WithMaxAttempts(2),
+		notification.WithPollInterval(time.Millisecond),
+	)
+	defer closeRedis()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	deferred, err := service.CreateRecipient(ctx, notification.CreateRecipientRequest{Email: "alice@example.com"})
+	s.Require().NoError(err)
+	rejected, err := service.CreateRecipient(ctx, notification.CreateRecipientRequest{Email: "bob@example.com"})
+	s.Require().NoError(err)
+
+	stopped := service.Start(ctx)
+
+	// a 5xx reply fails the notification at once, 4xx ones until the
+	// attempts run out.
+	sink.mu.Lock()
+	sink.errs = []error{
+		&gosmtp.SMTPError{Code: 550, Message: "mailbox unavailable"},
+		&gosmtp.SMTPError{Code: 451, Message: "try again later"},
+		&gosmtp.SMTPError{Code: 451, Message: "try again later"},
+	}
+	sink.mu.Unlock()
+	_, err = tasks.CreateTask(ctx, task.CreateTaskRequest{Name: "task 1"})
+	s.Require().NoError(err)
+
+	failed := func(recipientID uint) bool {
+		notifications, err := service.ListNotifications(ctx, recipientID)
+		s.Require().NoError(err)
+		return len(notifications) == 1 && notifications[0].State == domain.DeliveryStateFailed
+	}
+	s.Eventually(func() bool {
+		return failed(deferred.ID) && failed(rejected.ID)
+	}, time.Second, 5*time.Millisecond)
+
+	cancel()
+	<-stopped
+
+	s.Empty(sink.received())
+
+	attempts := 0
+	for _, id := range []uint{deferred.ID, rejected.ID} {
+		notifications, err := service.ListNotifications(context.Background(), id)
+		s.Require().NoError(err)
+		attempts += len(notifications[0].Attempts)
+	}
+	s.Equal(3, attempts)
+}
+
+func (s *NotificationServiceSuite) TestParseTemplates() {
+	templates, err := notification.ParseTemplates(fstest.MapFS{
+		notification.SubjectTemplate: {Data: []byte("[{{.Event}}]\n{{.Task.Name}}\n")},
+	})
+	s.Require().NoError(err)
+
+	subject, text, html, err := templates.Render(&notification.TemplateData{
+		Event: notification.EventCompleted,
+		Task:  domain.Task{ID: 1, Name: "task 1"},
+	})
+	s.Require().NoError(err)
+	s.Equal("[completed] task 1", subject)
+	// the missing templates fall back to the built-in ones.
+	s.Contains(text, `Task #1 "task 1" was completed.`)
+	s.Contains(html, "<strong>task 1</strong> was completed.")
+
+	_, err = notification.ParseTemplates(fstest.MapFS{
+		notification.TextTemplate: {Data: []byte("{{.Task.Name")},
+	})
+	s.Error(err)
+}
+
+func TestNotificationServiceSuite(t *testing.T) {
+	suite.Run(t, new(NotificationServiceSuite))
+}
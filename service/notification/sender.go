@@ -0,0 +1,207 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/omegaatt36/gotasker/format/email"
+
+	"github.com/emersion/go-sasl"
+	gosmtp "github.com/emersion/go-smtp"
+)
+
+// ErrInvalidSender is returned for SMTP configs without a valid From
+// address.
+var ErrInvalidSender = errors.New("invalid sender address")
+
+// DefaultSMTPTimeout is the default timeout of sending a message.
+const DefaultSMTPTimeout = 30 * time.Second
+
+// Message is an email to a recipient.
+type Message struct {
+	To      mail.Address
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Sender sends messages. Errors of SMTP replies are *smtp.SMTPError of
+// github.com/emersion/go-smtp, permanent ones aren't retried.
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// TLSMode defines how SMTP connections are secured.
+type TLSMode string
+
+// TLS modes
+const (
+	// TLSModeNone sends messages and credentials in plain text, it's only
+	// meant for local relays.
+	TLSModeNone TLSMode = "none"
+	// TLSModeStartTLS upgrades the connection by STARTTLS, usually on port
+	// 587.
+	TLSModeStartTLS TLSMode = "starttls"
+	// TLSModeTLS connects by implicit TLS, usually on port 465.
+	TLSModeTLS TLSMode = "tls"
+)
+
+// ParseTLSMode parses a TLS mode.
+func ParseTLSMode(s string) (TLSMode, error) {
+	switch mode := TLSMode(s); mode {
+	case TLSModeNone, TLSModeStartTLS, TLSModeTLS:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid tls mode: %s", s)
+	}
+}
+
+// SMTPConfig defines the SMTP server messages are sent by.
+type SMTPConfig struct {
+	Host string
+	// Port defaults to 587 for TLSModeStartTLS, 465 for TLSModeTLS and 25
+	// for TLSModeNone.
+	Port string
+	// TLS defaults to TLSModeStartTLS.
+	TLS TLSMode
+	// TLSConfig is optional, e.g. to trust a private CA.
+	TLSConfig *tls.Config
+	// Username and Password authenticate by PLAIN if Username isn't empty.
+	Username string
+	Password string
+	// From is the sender of the messages, e.g. "GoTasker <tasks@example.com>".
+	From string
+	// Timeout limits sending a message, DefaultSMTPTimeout if zero.
+	Timeout time.Duration
+}
+
+// SMTPSender sends messages by a SMTP server, a connection per message.
+type SMTPSender struct {
+	cfg  SMTPConfig
+	from *mail.Address
+}
+
+var _ Sender = (*SMTPSender)(nil)
+
+// NewSMTPSender creates a sender of the SMTP server.
+func NewSMTPSender(cfg SMTPConfig) (*SMTPSender, error) {
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSender, err)
+	}
+
+	if cfg.TLS == "" {
+		cfg.TLS = TLSModeStartTLS
+	}
+	if _, err := ParseTLSMode(string(cfg.TLS)); err != nil {
+		return nil, err
+	}
+
+	if cfg.Port == "" {
+		switch cfg.TLS {
+		case TLSModeStartTLS:
+			cfg.Port = "587"
+		case TLSModeTLS:
+			cfg.Port = "465"
+		default:
+			cfg.Port = "25"
+		}
+	}
+
+	if cfg.TLSConfig == nil {
+		cfg.TLSConfig = &tls.Config{}
+	}
+	cfg.TLSConfig = cfg.TLSConfig.Clone()
+	if cfg.TLSConfig.ServerName == "" {
+		cfg.TLSConfig.ServerName = cfg.Host
+	}
+
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultSMTPTimeout
+	}
+
+	return &SMTPSender{cfg: cfg, from: from}, nil
+}
+
+// Send sends the message, it returns once the server accepts it.
+func (s *SMTPSender) Send(ctx context.Context, msg Message) error {
+	var buf bytes.Buffer
+	if err := email.Compose(&buf, &email.Draft{
+		From:      *s.from,
+		To:        msg.To,
+		Subject:   msg.Subject,
+		Text:      msg.Text,
+		HTML:      msg.HTML,
+		Date:      time.Now(),
+		MessageID: s.messageID(),
+	}); err != nil {
+		return fmt.Errorf("failed to compose message: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.Timeout)
+	defer cancel()
+
+	client, err := s.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if s.cfg.Username != "" {
+		if err := client.Auth(sasl.NewPlainClient("", s.cfg.Username, s.cfg.Password)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.SendMail(s.from.Address, []string{msg.To.Address}, &buf); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+// dial connects to the server, the connection is closed once ctx is done.
+func (s *SMTPSender) dial(ctx context.Context) (*gosmtp.Client, error) {
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(s.cfg.Host, s.cfg.Port))
+	if err != nil {
+		return nil, err
+	}
+
+	// commands block on the connection, closing it ends them.
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	var client *gosmtp.Client
+	switch s.cfg.TLS {
+	case TLSModeTLS:
+		client = gosmtp.NewClient(tls.Client(conn, s.cfg.TLSConfig))
+	case TLSModeStartTLS:
+		if client, err = gosmtp.NewClientStartTLS(conn, s.cfg.TLSConfig); err != nil {
+			stop()
+			_ = conn.Close()
+			return nil, err
+		}
+	default:
+		client = gosmtp.NewClient(conn)
+	}
+
+	return client, nil
+}
+
+// messageID returns a random Message-ID of the domain of the sender.
+func (s *SMTPSender) messageID() string {
+	bs := make([]byte, 16)
+	_, _ = rand.Read(bs)
+
+	domain := s.from.Address[strings.LastIndex(s.from.Address, "@")+1:]
+
+	return hex.EncodeToString(bs) + "@" + domain
+}
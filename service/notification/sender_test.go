@@ -0,0 +1,256 @@
+package notification_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/omegaatt36/gotasker/format/email"
+	"github.com/omegaatt36/gotasker/logging"
+	"github.com/omegaatt36/gotasker/service/notification"
+
+	"github.com/emersion/go-sasl"
+	gosmtp "github.com/emersion/go-smtp"
+	"github.com/stretchr/testify/suite"
+)
+
+// sink is a local SMTP server recording the messages it receives. It
+// rejects the messages with the queued errors, and accepts them once they
+// run out.
+type sink struct {
+	mu       sync.Mutex
+	errs     []error
+	messages []sunkMessage
+
+	username string
+	password string
+
+	server *gosmtp.Server
+	addr   string
+}
+
+type sunkMessage struct {
+	from string
+	to   []string
+	data []byte
+}
+
+// newSink starts a sink requiring authentication if username isn't empty,
+// and supporting STARTTLS if tlsConfig isn't nil.
+func newSink(t *testing.T, username, password string, tlsConfig *tls.Config) *sink {
+	sink := &sink{username: username, password: password}
+
+	sink.server = gosmtp.NewServer(sink)
+	sink.server.Domain = "localhost"
+	sink.server.AllowInsecureAuth = true
+	sink.server.TLSConfig = tlsConfig
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink.addr = listener.Addr().String()
+
+	go func() { _ = sink.server.Serve(listener) }()
+	t.Cleanup(func() { _ = sink.server.Close() })
+
+	return sink
+}
+
+func (s *sink) NewSession(*gosmtp.Conn) (gosmtp.Session, error) {
+	return &sinkSession{sink: s, authenticated: s.username == ""}, nil
+}
+
+func (s *sink) received() []sunkMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]sunkMessage(nil), s.messages...)
+}
+
+func (s *sink) hostPort() (string, string) {
+	host, port, _ := net.SplitHostPort(s.addr)
+	return host, port
+}
+
+type sinkSession struct {
+	sink          *sink
+	authenticated bool
+	from          string
+	to            []string
+}
+
+func (s *sinkSession) AuthMechanisms() []string {
+	return []string{sasl.Plain}
+}
+
+func (s *sinkSession) Auth(string) (sasl.Server, error) {
+	return sasl.NewPlainServer(func(_, username, password string) error {
+		if username != s.sink.username || password != s.sink.password {
+			return errors.New("invalid credentials")
+		}
+
+		s.authenticated = true
+		return nil
+	}), nil
+}
+
+func (s *sinkSession) Mail(from string, _ *gosmtp.MailOptions) error {
+	if !s.authenticated {
+		return gosmtp.ErrAuthRequired
+	}
+
+	s.from = from
+	return nil
+}
+
+func (s *sinkSession) Rcpt(to string, _ *gosmtp.RcptOptions) error {
+	s.to = append(s.to, to)
+	return nil
+}
+
+func (s *sinkSession) Data(r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	s.sink.mu.Lock()
+	defer s.sink.mu.Unlock()
+
+	if len(s.sink.errs) > 0 {
+		err, s.sink.errs = s.sink.errs[0], s.sink.errs[1:]
+		return err
+	}
+
+	s.sink.messages = append(s.sink.messages, sunkMessage{from: s.from, to: s.to, data: data})
+	return nil
+}
+
+func (s *sinkSession) Reset() {
+	s.from, s.to = "", nil
+}
+
+func (s *sinkSession) Logout() error {
+	return nil
+}
+
+type SenderSuite struct {
+	suite.Suite
+}
+
+func (s *SenderSuite) SetupSuite() {
+	logging.Init(false, "error")
+}
+
+func (s *SenderSuite) TestNewSMTPSender() {
+	_, err := notification.NewSMTPSender(notification.SMTPConfig{Host: "localhost"})
+	s.ErrorIs(err, notification.ErrInvalidSender)
+
+	_, err = notification.NewSMTPSender(notification.SMTPConfig{Host: "localhost", From: "tasks@example.com", TLS: "ssl"})
+	s.Error(err)
+
+	_, err = notification.ParseTLSMode("starttls")
+	s.NoError(err)
+}
+
+func (s *SenderSuite) TestSend() {
+	sink := newSink(s.T(), "", "", nil)
+	host, port := sink.hostPort()
+
+	sender, err := notification.NewSMTPSender(notification.SMTPConfig{
+		Host: host,
+		Port: port,
+		TLS:  notification.TLSModeNone,
+		From: "GoTasker <tasks@example.com>",
+	})
+	s.Require().NoError(err)
+
+	msg := notification.Message{
+		To:      mail.Address{Name: "Alice", Address: "alice@example.com"},
+		Subject: "Task created: 買牛奶",
+		Text:    "Task #1 \"買牛奶\" was created.\n",
+		HTML:    "<p>Task #1 <strong>買牛奶</strong> was created.</p>",
+	}
+	s.Require().NoError(sender.Send(context.Background(), msg))
+
+	received := sink.received()
+	s.Require().Len(received, 1)
+	s.Equal("tasks@example.com", received[0].from)
+	s.Equal([]string{"alice@example.com"}, received[0].to)
+
+	parsed, err := email.Parse(bytes.NewReader(received[0].data))
+	s.Require().NoError(err)
+	s.Equal("tasks@example.com", parsed.From)
+	s.Equal(msg.Subject, parsed.Subject)
+	s.Equal("Task #1 \"買牛奶\" was created.", parsed.Text)
+
+	s.T().Run("rejected", func(t *testing.T) {
+		sink.mu.Lock()
+		sink.errs = []error{&gosmtp.SMTPError{Code: 550, Message: "mailbox unavailable"}}
+		sink.mu.Unlock()
+
+		var smtpErr *gosmtp.SMTPError
+		s.Require().ErrorAs(sender.Send(context.Background(), msg), &smtpErr)
+		s.Equal(550, smtpErr.Code)
+	})
+
+	s.T().Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		s.Error(sender.Send(ctx, msg))
+	})
+}
+
+func (s *SenderSuite) TestSendStartTLS() {
+	// borrows the certificate of 127.0.0.1 of httptest.
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	certificates := server.TLS.Certificates
+	rootCAs := server.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs
+	server.Close()
+
+	sink := newSink(s.T(), "alice", "secret", &tls.Config{Certificates: certificates})
+	host, port := sink.hostPort()
+
+	cfg := notification.SMTPConfig{
+		Host:      host,
+		Port:      port,
+		TLSConfig: &tls.Config{RootCAs: rootCAs},
+		Username:  "alice",
+		Password:  "wrong",
+		From:      "tasks@example.com",
+		Timeout:   time.Second,
+	}
+	msg := notification.Message{To: mail.Address{Address: "alice@example.com"}, Subject: "hello", Text: "hello"}
+
+	sender, err := notification.NewSMTPSender(cfg)
+	s.Require().NoError(err)
+	s.Error(sender.Send(context.Background(), msg))
+	s.Empty(sink.received())
+
+	cfg.Password = "secret"
+	sender, err = notification.NewSMTPSender(cfg)
+	s.Require().NoError(err)
+	s.Require().NoError(sender.Send(context.Background(), msg))
+	s.Len(sink.received(), 1)
+
+	// the certificate isn't trusted without the root CAs.
+	cfg.TLSConfig = nil
+	sender, err = notification.NewSMTPSender(cfg)
+	s.Require().NoError(err)
+	s.Error(sender.Send(context.Background(), msg))
+}
+
+func TestSenderSuite(t *testing.T) {
+	suite.Run(t, new(SenderSuite))
+}
@@ -0,0 +1,124 @@
+package notification
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/omegaatt36/gotasker/domain"
+)
+
+// names of the template files.
+const (
+	SubjectTemplate = "subject.txt.tmpl"
+	TextTemplate    = "body.txt.tmpl"
+	HTMLTemplate    = "body.html.tmpl"
+)
+
+//go:embed templates/*.tmpl
+var defaultTemplateFS embed.FS
+
+// TemplateData is the data the templates are executed with.
+type TemplateData struct {
+	Event     Event
+	Recipient domain.Recipient
+	// Task is the task after the event.
+	Task domain.Task
+	// PreviousName is the name of a renamed task before the event.
+	PreviousName string
+}
+
+// Templates render the messages of notifications, the subject and the text
+// are text/template templates and the HTML is a html/template one.
+type Templates struct {
+	subject *texttemplate.Template
+	text    *texttemplate.Template
+	html    *htmltemplate.Template
+}
+
+// DefaultTemplates returns the built-in templates.
+func DefaultTemplates() *Templates {
+	templates, err := ParseTemplates(nil)
+	if err != nil {
+		panic(err)
+	}
+
+	return templates
+}
+
+// ParseTemplates parses the templates of SubjectTemplate, TextTemplate and
+// HTMLTemplate in fsys, the built-in ones are used for the missing files or
+// a nil fsys.
+func ParseTemplates(fsys fs.FS) (*Templates, error) {
+	read := func(name string) (string, error) {
+		if fsys != nil {
+			bs, err := fs.ReadFile(fsys, name)
+			if err == nil {
+				return string(bs), nil
+			}
+			if !errors.Is(err, fs.ErrNotExist) {
+				return "", fmt.Errorf("failed to read template: %w", err)
+			}
+		}
+
+		bs, err := defaultTemplateFS.ReadFile("templates/" + name)
+		return string(bs), err
+	}
+
+	var templates Templates
+
+	subject, err := read(SubjectTemplate)
+	if err != nil {
+		return nil, err
+	}
+	if templates.subject, err = texttemplate.New(SubjectTemplate).Parse(subject); err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	text, err := read(TextTemplate)
+	if err != nil {
+		return nil, err
+	}
+	if templates.text, err = texttemplate.New(TextTemplate).Parse(text); err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	html, err := read(HTMLTemplate)
+	if err != nil {
+		return nil, err
+	}
+	if templates.html, err = htmltemplate.New(HTMLTemplate).Parse(html); err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	return &templates, nil
+}
+
+// Render renders the subject, the text and the HTML of a notification.
+func (t *Templates) Render(data *TemplateData) (subject, text, html string, err error) {
+	var buf bytes.Buffer
+	if err := t.subject.Execute(&buf, data); err != nil {
+		return "", "", "", fmt.Errorf("failed to render subject: %w", err)
+	}
+	// the subject is a single line.
+	subject = strings.Join(strings.Fields(buf.String()), " ")
+
+	buf.Reset()
+	if err := t.text.Execute(&buf, data); err != nil {
+		return "", "", "", fmt.Errorf("failed to render text: %w", err)
+	}
+	text = buf.String()
+
+	buf.Reset()
+	if err := t.html.Execute(&buf, data); err != nil {
+		return "", "", "", fmt.Errorf("failed to render html: %w", err)
+	}
+	html = buf.String()
+
+	return subject, text, html, nil
+}
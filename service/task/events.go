@@ -0,0 +1,203 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/omegaatt36/gotasker/domain"
+	"github.com/omegaatt36/gotasker/service/eventbus"
+)
+
+// EventType is the type of a task event.
+type EventType string
+
+// event types
+const (
+	EventTypeCreated EventType = "created"
+	EventTypeUpdated EventType = "updated"
+	EventTypeDeleted EventType = "deleted"
+)
+
+// DefaultEventReplaySize is the default number of events kept for
+// subscribers resuming from a previous event.
+const DefaultEventReplaySize = 1000
+
+// subscriptionBufferSize is the number of events a subscriber may lag behind
+// before it's disconnected.
+const subscriptionBufferSize = 64
+
+// Event is a change of a task.
+type Event struct {
+	// ID identifies the event for resuming, it's unique across restarts.
+	ID   string
+	Type EventType
+	// Task is the task after the change, or before it's deleted.
+	Task domain.Task
+
+	seq uint64
+}
+
+// Broker fans out task events to subscribers and keeps the latest events in
+// a bounded replay buffer. It's fed by the event bus of the service, events
+// are kept in memory, they are only seen by subscribers of the same process.
+type Broker struct {
+	mu sync.Mutex
+
+	// epoch tells events of different processes apart, sequences restart
+	// from 1 on every start.
+	epoch      string
+	seq        uint64
+	replay     []Event
+	replaySize int
+
+	subscriptions map[*Subscription]struct{}
+}
+
+// NewBroker creates a broker keeping the latest replaySize events.
+func NewBroker(replaySize int) *Broker {
+	return &Broker{
+		epoch:         strconv.FormatInt(time.Now().UnixNano(), 36),
+		replaySize:    replaySize,
+		subscriptions: make(map[*Subscription]struct{}),
+	}
+}
+
+// Publish publishes an event to all subscribers. Subscribers lagging behind
+// by more than their buffer are disconnected instead of blocking writers,
+// they may resume from the replay buffer.
+func (b *Broker) Publish(eventType EventType, task domain.Task) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.seq++
+	event := Event{
+		ID:   fmt.Sprintf("%s-%d", b.epoch, b.seq),
+		Type: eventType,
+		Task: task,
+		seq:  b.seq,
+	}
+
+	if b.replaySize > 0 {
+		if len(b.replay) == b.replaySize {
+			b.replay = append(b.replay[:0], b.replay[1:]...)
+		}
+		b.replay = append(b.replay, event)
+	}
+
+	for subscription := range b.subscriptions {
+		select {
+		case subscription.events <- event:
+		default:
+			b.unsubscribe(subscription)
+		}
+	}
+}
+
+// Handle publishes the domain event as a task event, it's the handler
+// subscribed to the event bus. TaskStatusChanged is covered by the updated
+// event.
+func (b *Broker) Handle(_ context.Context, envelope eventbus.Envelope) error {
+	switch event := envelope.Event.(type) {
+	case domain.TaskCreated:
+		b.Publish(EventTypeCreated, event.Task)
+	case domain.TaskUpdated:
+		b.Publish(EventTypeUpdated, event.After)
+	case domain.TaskDeleted:
+		b.Publish(EventTypeDeleted, event.Task)
+	}
+
+	return nil
+}
+
+// Subscription receives the events published after it's created.
+type Subscription struct {
+	// Replay are the buffered events published after the last event ID
+	// given on subscribing.
+	Replay []Event
+	// Missed reports that some events after the last event ID are no longer
+	// buffered, the subscriber should reload the tasks.
+	Missed bool
+
+	broker *Broker
+	events chan Event
+}
+
+// Subscribe subscribes to events. A non-empty lastEventID resumes after the
+// event, the buffered events since then are returned in Replay.
+func (b *Broker) Subscribe(lastEventID string) *Subscription {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	subscription := &Subscription{
+		broker: b,
+		events: make(chan Event, subscriptionBufferSize),
+	}
+	b.subscriptions[subscription] = struct{}{}
+
+	if lastEventID == "" {
+		return subscription
+	}
+
+	seq, ok := b.parseEventID(lastEventID)
+	if !ok {
+		subscription.Missed = true
+		return subscription
+	}
+
+	// the oldest buffered event must directly follow the last one.
+	oldest := b.seq - uint64(len(b.replay)) + 1
+	if seq+1 < oldest {
+		subscription.Missed = true
+		return subscription
+	}
+
+	for _, event := range b.replay {
+		if event.seq > seq {
+			subscription.Replay = append(subscription.Replay, event)
+		}
+	}
+
+	return subscription
+}
+
+// parseEventID returns the sequence of an event ID published by this broker.
+func (b *Broker) parseEventID(id string) (uint64, bool) {
+	epoch, s, ok := strings.Cut(id, "-")
+	if !ok || epoch != b.epoch {
+		return 0, false
+	}
+
+	seq, err := strconv.ParseUint(s, 10, 64)
+	if err != nil || seq > b.seq {
+		return 0, false
+	}
+
+	return seq, true
+}
+
+func (b *Broker) unsubscribe(subscription *Subscription) {
+	if _, ok := b.subscriptions[subscription]; !ok {
+		return
+	}
+
+	delete(b.subscriptions, subscription)
+	close(subscription.events)
+}
+
+// Events returns the channel of events. It's closed when the subscription is
+// closed or the subscriber lagged behind.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Close stops receiving events.
+func (s *Subscription) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+
+	s.broker.unsubscribe(s)
+}
@@ -0,0 +1,222 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/omegaatt36/gotasker/domain"
+)
+
+var (
+	// ErrImportSizeExceeded is returned when an import contains more tasks
+	// than allowed.
+	ErrImportSizeExceeded = errors.New("import size exceeded")
+	// ErrDuplicateTaskID is returned when an import contains a task ID more
+	// than once.
+	ErrDuplicateTaskID = errors.New("duplicate task id")
+)
+
+// DefaultMaxImportSize is the default maximum number of tasks of an import.
+const DefaultMaxImportSize = 10000
+
+// WithMaxImportSize sets the maximum number of tasks of an import.
+func WithMaxImportSize(size int) Option {
+	return func(s *Service) {
+		if size > 0 {
+			s.maxImportSize = size
+		}
+	}
+}
+
+// ImportTasksRequest defines the request for importing tasks.
+type ImportTasksRequest struct {
+	Tasks []domain.Task
+	// PreserveIDs keeps the IDs of the tasks, otherwise every task is created
+	// with a new ID.
+	PreserveIDs bool
+	// Conflict is the strategy for preserved IDs of existing tasks.
+	Conflict domain.ImportConflict
+	// DryRun only validates the tasks and reports what the import would do,
+	// created tasks have no IDs yet.
+	DryRun bool
+	// Deletions are the IDs of the tasks deleted by the import, see
+	// domain.ImportTasksRequest.
+	Deletions []uint
+	// Taskwarrior is written along with the tasks, see
+	// domain.ImportTasksRequest.
+	Taskwarrior *domain.TaskwarriorImport
+}
+
+// ImportTasks imports the tasks atomically and returns the result of each
+// task in request order, followed by the results of the deletions. Like
+// atomic batches, an invalid task fails the import and the other tasks are
+// reported as rolled back.
+func (s *Service) ImportTasks(ctx context.Context, req ImportTasksRequest) ([]domain.ImportResult, error) {
+	if size := len(req.Tasks) + len(req.Deletions); size > s.maxImportSize {
+		return nil, fmt.Errorf("%w: %d > %d", ErrImportSizeExceeded, size, s.maxImportSize)
+	}
+	if !req.Conflict.IsValid() {
+		return nil, domain.ErrInvalidImportConflict
+	}
+
+	tasks := make([]domain.Task, len(req.Tasks))
+	results := make([]domain.ImportResult, len(tasks)+len(req.Deletions))
+	seen := make(map[uint]struct{}, len(tasks))
+	var ids []uint
+	var invalid bool
+	for index, t := range req.Tasks {
+		if !req.PreserveIDs {
+			t.ID = 0
+		}
+		tasks[index] = t
+
+		if err := validateImportedTask(&t, seen); err != nil {
+			results[index] = domain.ImportResult{Task: t, Err: err}
+			invalid = true
+			continue
+		}
+
+		if t.ID > 0 {
+			seen[t.ID] = struct{}{}
+			ids = append(ids, t.ID)
+		}
+	}
+
+	if invalid {
+		rollBackImport(results)
+		return results, nil
+	}
+
+	unlock := s.locks.lock(slices.Concat(ids, req.Deletions)...)
+	defer unlock()
+
+	if req.DryRun {
+		return s.planImport(ctx, tasks, ids, req.Deletions, req.Conflict)
+	}
+
+	results, err := s.repo.ImportTasks(ctx, domain.ImportTasksRequest{
+		Tasks:       tasks,
+		Conflict:    req.Conflict,
+		Deletions:   req.Deletions,
+		Taskwarrior: req.Taskwarrior,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var events []domain.Event
+	for _, result := range results {
+		if result.Err != nil {
+			continue
+		}
+
+		switch result.Action {
+		case domain.ImportActionCreated:
+			events = append(events, domain.TaskCreated{Task: result.Task})
+		case domain.ImportActionOverwritten:
+			events = append(events, updatedEvents(result.Before, result.Task)...)
+		case domain.ImportActionDeleted:
+			events = append(events, domain.TaskDeleted{Task: result.Task})
+		}
+	}
+	s.bus.Publish(ctx, events...)
+
+	return results, nil
+}
+
+// planImport reports what importing the tasks and deleting the tasks of the
+// deletions would do without writing them.
+func (s *Service) planImport(ctx context.Context, tasks []domain.Task, ids, deletions []uint, conflict domain.ImportConflict) ([]domain.ImportResult, error) {
+	existing, err := s.repo.GetTasks(ctx, slices.Concat(ids, deletions))
+	if err != nil {
+		return nil, err
+	}
+
+	existingByID := make(map[uint]domain.Task, len(existing))
+	for _, t := range existing {
+		existingByID[t.ID] = t
+	}
+
+	var failed bool
+	results := make([]domain.ImportResult, len(tasks), len(tasks)+len(deletions))
+	for index, t := range tasks {
+		before, ok := existingByID[t.ID]
+		switch {
+		case t.ID == 0 || !ok:
+			results[index] = domain.ImportResult{Task: t, Action: domain.ImportActionCreated}
+		case conflict == domain.ImportConflictSkip:
+			results[index] = domain.ImportResult{Task: before, Action: domain.ImportActionSkipped}
+		case conflict == domain.ImportConflictOverwrite:
+			if t.Notes == "" {
+				t.Notes = before.Notes
+			}
+			results[index] = domain.ImportResult{Task: t, Before: before, Action: domain.ImportActionOverwritten}
+		default:
+			results[index] = domain.ImportResult{Task: t, Err: domain.ErrTaskConflict}
+			failed = true
+		}
+	}
+
+	for _, id := range deletions {
+		if before, ok := existingByID[id]; ok {
+			results = append(results, domain.ImportResult{Task: before, Action: domain.ImportActionDeleted})
+		} else {
+			results = append(results, domain.ImportResult{Action: domain.ImportActionSkipped})
+		}
+	}
+
+	if failed {
+		rollBackImport(results)
+	}
+
+	return results, nil
+}
+
+func validateImportedTask(t *domain.Task, seen map[uint]struct{}) error {
+	if t.Name == "" {
+		return ErrTaskNameRequired
+	}
+	if !t.Status.IsValid() {
+		return ErrInvalidStatus
+	}
+	if _, ok := seen[t.ID]; ok {
+		return fmt.Errorf("%w: %d", ErrDuplicateTaskID, t.ID)
+	}
+
+	return nil
+}
+
+// rollBackImport marks the tasks without errors as rolled back.
+func rollBackImport(results []domain.ImportResult) {
+	for index := range results {
+		if results[index].Err == nil {
+			results[index].Err = domain.ErrBatchRolledBack
+		}
+	}
+}
+
+// ExportTasks walks through all tasks in chunks without loading the whole
+// set at once, see domain.TaskRepository.IterateTasks. Tasks are passed in no
+// particular order, and each task only once.
+func (s *Service) ExportTasks(ctx context.Context, fn func([]domain.Task) error) error {
+	seen := make(map[uint]struct{})
+
+	return s.repo.IterateTasks(ctx, s.maxBatchSize, func(tasks []domain.Task) error {
+		chunk := tasks[:0]
+		for _, t := range tasks {
+			if _, ok := seen[t.ID]; ok {
+				continue
+			}
+			seen[t.ID] = struct{}{}
+			chunk = append(chunk, t)
+		}
+
+		if len(chunk) == 0 {
+			return nil
+		}
+
+		return fn(chunk)
+	})
+}
@@ -0,0 +1,38 @@
+package task
+
+import (
+	"slices"
+	"sync"
+)
+
+// lockStripes is the number of locks serializing the writes of tasks, tasks
+// sharing a stripe are serialized together.
+const lockStripes = 64
+
+// taskLocks serializes the writes of a task with the publishing of their
+// events, so the events are published in the order of the writes. It only
+// orders the writes of the same process.
+type taskLocks struct {
+	stripes [lockStripes]sync.Mutex
+}
+
+// lock locks the tasks and returns the function unlocking them. Stripes are
+// locked in ascending order, so concurrent callers can't deadlock.
+func (l *taskLocks) lock(ids ...uint) func() {
+	stripes := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		stripes = append(stripes, id%lockStripes)
+	}
+	slices.Sort(stripes)
+	stripes = slices.Compact(stripes)
+
+	for _, stripe := range stripes {
+		l.stripes[stripe].Lock()
+	}
+
+	return func() {
+		for _, stripe := range stripes {
+			l.stripes[stripe].Unlock()
+		}
+	}
+}
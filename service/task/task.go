@@ -3,18 +3,108 @@ package task
 import (
 	"context"
 	"errors"
+	"fmt"
+	"slices"
 
 	"github.com/omegaatt36/gotasker/domain"
+	"github.com/omegaatt36/gotasker/service/eventbus"
 )
 
+var (
+	ErrTaskNameRequired = errors.New("task name is required")
+	ErrInvalidStatus    = errors.New("invalid status")
+	// ErrBatchSizeExceeded is returned when a batch contains more operations
+	// than allowed.
+	ErrBatchSizeExceeded = errors.New("batch size exceeded")
+	// ErrEmptyFilter is returned when a bulk operation has no filter criteria.
+	ErrEmptyFilter = errors.New("filter is required")
+)
+
+// DefaultMaxBatchSize is the default maximum number of operations of a batch.
+// It's also the chunk size of bulk operations.
+const DefaultMaxBatchSize = 100
+
 // Service represents a task service.
 type Service struct {
-	repo domain.TaskRepository
+	repo   domain.TaskRepository
+	bus    *eventbus.Bus
+	events *Broker
+	locks  taskLocks
+
+	maxBatchSize    int
+	maxImportSize   int
+	eventReplaySize int
+}
+
+// Option configures a task service.
+type Option func(*Service)
+
+// WithMaxBatchSize sets the maximum number of operations of a batch.
+func WithMaxBatchSize(size int) Option {
+	return func(s *Service) {
+		if size > 0 {
+			s.maxBatchSize = size
+		}
+	}
+}
+
+// WithEventReplaySize sets the number of events kept for subscribers
+// resuming from a previous event.
+func WithEventReplaySize(size int) Option {
+	return func(s *Service) {
+		if size > 0 {
+			s.eventReplaySize = size
+		}
+	}
+}
+
+// WithEventBus sets the bus publishing the domain events of task writes, a
+// bus of the service is created otherwise.
+func WithEventBus(bus *eventbus.Bus) Option {
+	return func(s *Service) {
+		if bus != nil {
+			s.bus = bus
+		}
+	}
 }
 
 // NewService creates a new task service.
-func NewService(repo domain.TaskRepository) *Service {
-	return &Service{repo: repo}
+func NewService(repo domain.TaskRepository, opts ...Option) *Service {
+	s := &Service{
+		repo:            repo,
+		maxBatchSize:    DefaultMaxBatchSize,
+		maxImportSize:   DefaultMaxImportSize,
+		eventReplaySize: DefaultEventReplaySize,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.bus == nil {
+		s.bus = eventbus.NewBus()
+	}
+
+	s.events = NewBroker(s.eventReplaySize)
+	s.bus.Subscribe("event-stream", s.events.Handle)
+
+	return s
+}
+
+// MaxImportSize returns the maximum number of tasks of an import.
+func (s *Service) MaxImportSize() int {
+	return s.maxImportSize
+}
+
+// EventBus returns the bus publishing the domain events of task writes.
+func (s *Service) EventBus() *eventbus.Bus {
+	return s.bus
+}
+
+// SubscribeEvents subscribes to the stream of task changes, see
+// Broker.Subscribe.
+func (s *Service) SubscribeEvents(lastEventID string) *Subscription {
+	return s.events.Subscribe(lastEventID)
 }
 
 // ListTasks lists all tasks.
@@ -22,20 +112,60 @@ func (s *Service) ListTasks(ctx context.Context) ([]domain.Task, error) {
 	return s.repo.ListTasks(ctx)
 }
 
+// GetTask gets a task.
+func (s *Service) GetTask(ctx context.Context, id uint) (domain.Task, error) {
+	return s.repo.GetTask(ctx, id)
+}
+
+// GetTasks gets the tasks of the IDs in a single read, IDs of missing tasks
+// are skipped.
+func (s *Service) GetTasks(ctx context.Context, ids []uint) ([]domain.Task, error) {
+	return s.repo.GetTasks(ctx, ids)
+}
+
+// FindTasks lists the tasks matching the filter, an empty filter matches all
+// tasks.
+func (s *Service) FindTasks(ctx context.Context, filter domain.TaskFilter) ([]domain.Task, error) {
+	tasks, err := s.repo.ListTasks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return slices.DeleteFunc(tasks, func(task domain.Task) bool {
+		return !filter.Match(&task)
+	}), nil
+}
+
+// Version returns the version of the task collection, which changes on every
+// write.
+func (s *Service) Version(ctx context.Context) (uint64, error) {
+	return s.repo.Version(ctx)
+}
+
 // CreateTaskRequest defines the request for creating a task.
 type CreateTaskRequest struct {
 	Name string
+	// Notes is optional.
+	Notes string
 }
 
 // CreateTask creates a new task.
-func (s *Service) CreateTask(ctx context.Context, req CreateTaskRequest) error {
+func (s *Service) CreateTask(ctx context.Context, req CreateTaskRequest) (domain.Task, error) {
 	if req.Name == "" {
-		return errors.New("task name is required")
+		return domain.Task{}, ErrTaskNameRequired
 	}
 
-	return s.repo.CreateTask(ctx, domain.CreateTaskRequest{
-		Name: req.Name,
+	task, err := s.repo.CreateTask(ctx, domain.CreateTaskRequest{
+		Name:  req.Name,
+		Notes: req.Notes,
 	})
+	if err != nil {
+		return domain.Task{}, err
+	}
+
+	s.bus.Publish(ctx, domain.TaskCreated{Task: task})
+
+	return task, nil
 }
 
 // UpdateTaskRequest defines the request for updating a task.
@@ -47,16 +177,333 @@ type UpdateTaskRequest struct {
 // UpdateTask updates a task.
 func (s *Service) UpdateTask(ctx context.Context, id uint, req UpdateTaskRequest) error {
 	if req.Status != nil && !req.Status.IsValid() {
-		return errors.New("invalid status")
+		return ErrInvalidStatus
 	}
 
-	return s.repo.UpdateTask(ctx, id, domain.UpdateTaskRequest{
+	unlock := s.locks.lock(id)
+	defer unlock()
+
+	before, err := s.repo.GetTask(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.UpdateTask(ctx, id, domain.UpdateTaskRequest{
 		Name:   req.Name,
 		Status: req.Status,
-	})
+	}); err != nil {
+		return err
+	}
+
+	// a task deleted in the meantime publishes its own event.
+	if after, err := s.repo.GetTask(ctx, id); err == nil {
+		s.bus.Publish(ctx, updatedEvents(before, after)...)
+	}
+
+	return nil
 }
 
 // DeleteTask deletes a task.
 func (s *Service) DeleteTask(ctx context.Context, id uint) error {
-	return s.repo.DeleteTask(ctx, id)
+	unlock := s.locks.lock(id)
+	defer unlock()
+
+	task, err := s.repo.GetTask(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteTask(ctx, id); err != nil {
+		return err
+	}
+
+	s.bus.Publish(ctx, domain.TaskDeleted{Task: task})
+
+	return nil
+}
+
+// updatedEvents returns the events of an update, TaskStatusChanged follows
+// TaskUpdated if the status changed.
+func updatedEvents(before, after domain.Task) []domain.Event {
+	events := []domain.Event{domain.TaskUpdated{Before: before, After: after}}
+	if before.Status != after.Status {
+		events = append(events, domain.TaskStatusChanged{Task: after, From: before.Status, To: after.Status})
+	}
+
+	return events
+}
+
+// publishBatch publishes the events of the applied operations of a batch.
+func (s *Service) publishBatch(ctx context.Context, ops []domain.BatchOperation, results []domain.BatchResult) {
+	var events []domain.Event
+	for index, result := range results {
+		if result.Err != nil {
+			continue
+		}
+
+		switch ops[index].Type {
+		case domain.BatchOperationTypeCreate:
+			events = append(events, domain.TaskCreated{Task: result.Task})
+		case domain.BatchOperationTypeUpdate:
+			events = append(events, updatedEvents(result.Before, result.Task)...)
+		case domain.BatchOperationTypeDelete:
+			events = append(events, domain.TaskDeleted{Task: result.Task})
+		}
+	}
+
+	s.bus.Publish(ctx, events...)
+}
+
+// lockOperations locks the tasks targeted by the operations, see taskLocks.
+func (s *Service) lockOperations(ops []domain.BatchOperation) func() {
+	ids := make([]uint, 0, len(ops))
+	for _, op := range ops {
+		if op.Type != domain.BatchOperationTypeCreate {
+			ids = append(ids, op.ID)
+		}
+	}
+
+	return s.locks.lock(ids...)
+}
+
+// BatchOperation defines a single operation of a batch.
+type BatchOperation struct {
+	Type   domain.BatchOperationType
+	ID     uint
+	Create CreateTaskRequest
+	Update UpdateTaskRequest
+}
+
+// BatchTasksRequest defines the request for batching task operations.
+type BatchTasksRequest struct {
+	// Atomic applies all operations or none of them, otherwise every
+	// operation is applied on its own.
+	Atomic     bool
+	Operations []BatchOperation
+}
+
+// BatchTasks applies a batch of operations and returns the result of each
+// operation in request order.
+func (s *Service) BatchTasks(ctx context.Context, req BatchTasksRequest) ([]domain.BatchResult, error) {
+	if len(req.Operations) > s.maxBatchSize {
+		return nil, fmt.Errorf("%w: %d > %d", ErrBatchSizeExceeded, len(req.Operations), s.maxBatchSize)
+	}
+
+	results := make([]domain.BatchResult, len(req.Operations))
+	ops := make([]domain.BatchOperation, len(req.Operations))
+	var invalid bool
+	for index, op := range req.Operations {
+		if err := validateBatchOperation(op); err != nil {
+			results[index].Err = err
+			invalid = true
+		}
+
+		ops[index] = domain.BatchOperation{
+			Type: op.Type,
+			ID:   op.ID,
+			Create: domain.CreateTaskRequest{
+				Name:  op.Create.Name,
+				Notes: op.Create.Notes,
+			},
+			Update: domain.UpdateTaskRequest{
+				Name:   op.Update.Name,
+				Status: op.Update.Status,
+			},
+		}
+	}
+
+	if req.Atomic {
+		if invalid {
+			for index := range results {
+				if results[index].Err == nil {
+					results[index].Err = domain.ErrBatchRolledBack
+				}
+			}
+
+			return results, nil
+		}
+
+		unlock := s.lockOperations(ops)
+		defer unlock()
+
+		results, err := s.repo.BatchTasks(ctx, ops)
+		if err != nil {
+			return nil, err
+		}
+
+		s.publishBatch(ctx, ops, results)
+
+		return results, nil
+	}
+
+	for index, op := range ops {
+		if results[index].Err != nil {
+			continue
+		}
+
+		s.applyOperation(ctx, op, &results[index])
+	}
+
+	return results, nil
+}
+
+// applyOperation applies a single operation of a non-atomic batch.
+func (s *Service) applyOperation(ctx context.Context, op domain.BatchOperation, result *domain.BatchResult) {
+	ops := []domain.BatchOperation{op}
+	unlock := s.lockOperations(ops)
+	defer unlock()
+
+	results, err := s.repo.BatchTasks(ctx, ops)
+	if err != nil {
+		result.Err = err
+		return
+	}
+
+	*result = results[0]
+	s.publishBatch(ctx, ops, results)
+}
+
+func validateBatchOperation(op BatchOperation) error {
+	switch op.Type {
+	case domain.BatchOperationTypeCreate:
+		if op.Create.Name == "" {
+			return ErrTaskNameRequired
+		}
+	case domain.BatchOperationTypeUpdate:
+		if op.ID < 1 {
+			return domain.ErrInvalidTaskID
+		}
+		if op.Update.Status != nil && !op.Update.Status.IsValid() {
+			return ErrInvalidStatus
+		}
+	case domain.BatchOperationTypeDelete:
+		if op.ID < 1 {
+			return domain.ErrInvalidTaskID
+		}
+	default:
+		return domain.ErrInvalidBatchOperationType
+	}
+
+	return nil
+}
+
+// BulkUpdateTasksRequest defines the request for updating tasks by filter.
+type BulkUpdateTasksRequest struct {
+	Filter domain.TaskFilter
+	Patch  UpdateTaskRequest
+	// DryRun only reports the matching tasks without updating them.
+	DryRun bool
+}
+
+// BulkDeleteTasksRequest defines the request for deleting tasks by filter.
+type BulkDeleteTasksRequest struct {
+	Filter domain.TaskFilter
+	// DryRun only reports the matching tasks without deleting them.
+	DryRun bool
+}
+
+// BulkResult defines the result of a bulk operation.
+type BulkResult struct {
+	// IDs are the IDs of affected tasks, or the matching ones in dry run.
+	IDs []uint
+}
+
+// BulkUpdateTasks updates all tasks matching the filter.
+func (s *Service) BulkUpdateTasks(ctx context.Context, req BulkUpdateTasksRequest) (BulkResult, error) {
+	if req.Patch.Status != nil && !req.Patch.Status.IsValid() {
+		return BulkResult{}, ErrInvalidStatus
+	}
+
+	return s.bulkApply(ctx, req.Filter, req.DryRun, domain.BatchOperation{
+		Type: domain.BatchOperationTypeUpdate,
+		Update: domain.UpdateTaskRequest{
+			Name:   req.Patch.Name,
+			Status: req.Patch.Status,
+		},
+	})
+}
+
+// BulkDeleteTasks deletes all tasks matching the filter.
+func (s *Service) BulkDeleteTasks(ctx context.Context, req BulkDeleteTasksRequest) (BulkResult, error) {
+	return s.bulkApply(ctx, req.Filter, req.DryRun, domain.BatchOperation{
+		Type: domain.BatchOperationTypeDelete,
+	})
+}
+
+// bulkApply scans tasks chunk by chunk and applies the operation to the
+// matching tasks of every chunk in its own atomic batch, so neither the scan
+// nor the writes block Redis for long.
+func (s *Service) bulkApply(ctx context.Context, filter domain.TaskFilter, dryRun bool, op domain.BatchOperation) (BulkResult, error) {
+	if filter.IsEmpty() {
+		return BulkResult{}, ErrEmptyFilter
+	}
+
+	seen := make(map[uint]struct{})
+	result := BulkResult{IDs: []uint{}}
+
+	err := s.repo.IterateTasks(ctx, s.maxBatchSize, func(tasks []domain.Task) error {
+		var ops []domain.BatchOperation
+		for index := range tasks {
+			if _, ok := seen[tasks[index].ID]; ok || !filter.Match(&tasks[index]) {
+				continue
+			}
+			seen[tasks[index].ID] = struct{}{}
+
+			op.ID = tasks[index].ID
+			ops = append(ops, op)
+		}
+
+		if !dryRun {
+			var err error
+			if ops, err = s.applyChunk(ctx, ops); err != nil {
+				return err
+			}
+		}
+
+		for _, op := range ops {
+			result.IDs = append(result.IDs, op.ID)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return BulkResult{}, err
+	}
+
+	slices.Sort(result.IDs)
+
+	return result, nil
+}
+
+// applyChunk applies operations atomically and returns the applied ones.
+// Tasks deleted concurrently since the scan are dropped from the chunk.
+func (s *Service) applyChunk(ctx context.Context, ops []domain.BatchOperation) ([]domain.BatchOperation, error) {
+	unlock := s.lockOperations(ops)
+	defer unlock()
+
+	for len(ops) > 0 {
+		results, err := s.repo.BatchTasks(ctx, ops)
+		if err != nil {
+			return nil, err
+		}
+
+		remaining := ops[:0]
+		for index, result := range results {
+			switch {
+			case result.Err == nil, errors.Is(result.Err, domain.ErrBatchRolledBack):
+				remaining = append(remaining, ops[index])
+			case errors.Is(result.Err, domain.ErrTaskNotFound):
+			default:
+				return nil, result.Err
+			}
+		}
+
+		if len(remaining) == len(ops) {
+			s.publishBatch(ctx, ops, results)
+			return ops, nil
+		}
+		ops = remaining
+	}
+
+	return ops, nil
 }
@@ -7,6 +7,7 @@ import (
 
 	"github.com/omegaatt36/gotasker/domain"
 	"github.com/omegaatt36/gotasker/domain/stub"
+	"github.com/omegaatt36/gotasker/service/eventbus"
 	"github.com/omegaatt36/gotasker/service/task"
 	"github.com/omegaatt36/gotasker/util"
 
@@ -29,14 +30,16 @@ func (s *TaskServiceTaskSuite) TestCreateTask() {
 	s.Empty(tasksInRepo)
 
 	s.T().Run("without name", func(t *testing.T) {
-		s.Error(service.CreateTask(context.Background(), task.CreateTaskRequest{
+		_, err := service.CreateTask(context.Background(), task.CreateTaskRequest{
 			Name: "",
-		}), "task name is required")
+		})
+		s.Error(err, "task name is required")
 	})
 	s.T().Run("success", func(t *testing.T) {
-		s.NoError(service.CreateTask(context.Background(), task.CreateTaskRequest{
+		_, err := service.CreateTask(context.Background(), task.CreateTaskRequest{
 			Name: "task 1",
-		}))
+		})
+		s.NoError(err)
 	})
 
 	tasksInRepo, err = repo.ListTasks(context.Background())
@@ -47,9 +50,10 @@ func (s *TaskServiceTaskSuite) TestCreateTask() {
 	s.Equal(domain.TaskStatusIncomplete, tasksInRepo[0].Status)
 
 	s.T().Run("another task", func(t *testing.T) {
-		s.NoError(service.CreateTask(context.Background(), task.CreateTaskRequest{
+		_, err := service.CreateTask(context.Background(), task.CreateTaskRequest{
 			Name: "task 2",
-		}))
+		})
+		s.NoError(err)
 	})
 
 	tasksInRepo, err = repo.ListTasks(context.Background())
@@ -62,9 +66,10 @@ func (s *TaskServiceTaskSuite) TestCreateTask() {
 	s.Equal(domain.TaskStatusIncomplete, tasksInRepo[1].Status)
 
 	s.T().Run("duplicated task name is allowed", func(t *testing.T) {
-		s.NoError(service.CreateTask(context.Background(), task.CreateTaskRequest{
+		_, err := service.CreateTask(context.Background(), task.CreateTaskRequest{
 			Name: "task 1",
-		}))
+		})
+		s.NoError(err)
 	})
 
 	tasksInRepo, err = repo.ListTasks(context.Background())
@@ -85,9 +90,10 @@ func (s *TaskServiceTaskSuite) TestListTasks() {
 
 	// 1.22 new feature: range a number like other language :D
 	for index := range 10 {
-		s.NoError(repo.CreateTask(context.Background(), domain.CreateTaskRequest{
+		_, err := repo.CreateTask(context.Background(), domain.CreateTaskRequest{
 			Name: fmt.Sprintf("task %d", index+1),
-		}))
+		})
+		s.NoError(err)
 	}
 
 	tasksInRepo, err := service.ListTasks(context.Background())
@@ -101,13 +107,37 @@ func (s *TaskServiceTaskSuite) TestListTasks() {
 	}
 }
 
+func (s *TaskServiceTaskSuite) TestFindTasks() {
+	repo := stub.NewInMemoryTaskRepository()
+	service := task.NewService(repo)
+
+	for _, name := range []string{"task 1", "task 2", "other"} {
+		_, err := service.CreateTask(context.Background(), task.CreateTaskRequest{Name: name})
+		s.NoError(err)
+	}
+
+	tasks, err := service.FindTasks(context.Background(), domain.TaskFilter{NameContains: "task"})
+	s.NoError(err)
+	s.Require().Len(tasks, 2)
+	s.Equal("task 1", tasks[0].Name)
+	s.Equal("task 2", tasks[1].Name)
+
+	// missing tasks are skipped.
+	tasks, err = service.GetTasks(context.Background(), []uint{3, 9, 1})
+	s.NoError(err)
+	s.Require().Len(tasks, 2)
+	s.Equal("other", tasks[0].Name)
+	s.Equal("task 1", tasks[1].Name)
+}
+
 func (s *TaskServiceTaskSuite) TestUpdateTask() {
 	repo := stub.NewInMemoryTaskRepository()
 	service := task.NewService(repo)
 
-	s.NoError(repo.CreateTask(context.Background(), domain.CreateTaskRequest{
+	_, err := repo.CreateTask(context.Background(), domain.CreateTaskRequest{
 		Name: "task 1",
-	}))
+	})
+	s.NoError(err)
 
 	tasksInRepo, err := repo.ListTasks(context.Background())
 	s.NoError(err)
@@ -174,9 +204,10 @@ func (s *TaskServiceTaskSuite) TestDeleteTask() {
 	service := task.NewService(repo)
 
 	for index := range 10 {
-		s.NoError(repo.CreateTask(context.Background(), domain.CreateTaskRequest{
+		_, err := repo.CreateTask(context.Background(), domain.CreateTaskRequest{
 			Name: fmt.Sprintf("task %d", index+1),
-		}))
+		})
+		s.NoError(err)
 	}
 
 	tasksInRepo, err := repo.ListTasks(context.Background())
@@ -200,6 +231,447 @@ func (s *TaskServiceTaskSuite) TestDeleteTask() {
 	s.Equal("task 10", tasksInRepo[6].Name)
 }
 
+func (s *TaskServiceTaskSuite) TestBatchTasks() {
+	repo := stub.NewInMemoryTaskRepository()
+	service := task.NewService(repo, task.WithMaxBatchSize(3))
+
+	_, err := repo.CreateTask(context.Background(), domain.CreateTaskRequest{
+		Name: "task 1",
+	})
+	s.NoError(err)
+
+	s.T().Run("batch size exceeded", func(t *testing.T) {
+		_, err := service.BatchTasks(context.Background(), task.BatchTasksRequest{
+			Atomic:     true,
+			Operations: make([]task.BatchOperation, 4),
+		})
+		s.ErrorIs(err, task.ErrBatchSizeExceeded)
+	})
+
+	s.T().Run("atomic - rolled back", func(t *testing.T) {
+		results, err := service.BatchTasks(context.Background(), task.BatchTasksRequest{
+			Atomic: true,
+			Operations: []task.BatchOperation{
+				{Type: domain.BatchOperationTypeCreate, Create: task.CreateTaskRequest{Name: "task 2"}},
+				{Type: domain.BatchOperationTypeDelete, ID: 99},
+			},
+		})
+		s.NoError(err)
+		s.Len(results, 2)
+		s.ErrorIs(results[0].Err, domain.ErrBatchRolledBack)
+		s.ErrorIs(results[1].Err, domain.ErrTaskNotFound)
+
+		tasksInRepo, err := repo.ListTasks(context.Background())
+		s.NoError(err)
+		s.Len(tasksInRepo, 1)
+	})
+
+	s.T().Run("atomic - invalid operation", func(t *testing.T) {
+		results, err := service.BatchTasks(context.Background(), task.BatchTasksRequest{
+			Atomic: true,
+			Operations: []task.BatchOperation{
+				{Type: domain.BatchOperationTypeCreate},
+				{Type: domain.BatchOperationTypeDelete, ID: 1},
+			},
+		})
+		s.NoError(err)
+		s.ErrorIs(results[0].Err, task.ErrTaskNameRequired)
+		s.ErrorIs(results[1].Err, domain.ErrBatchRolledBack)
+
+		tasksInRepo, err := repo.ListTasks(context.Background())
+		s.NoError(err)
+		s.Len(tasksInRepo, 1)
+	})
+
+	s.T().Run("atomic - success", func(t *testing.T) {
+		results, err := service.BatchTasks(context.Background(), task.BatchTasksRequest{
+			Atomic: true,
+			Operations: []task.BatchOperation{
+				{Type: domain.BatchOperationTypeCreate, Create: task.CreateTaskRequest{Name: "task 2"}},
+				{Type: domain.BatchOperationTypeUpdate, ID: 1, Update: task.UpdateTaskRequest{
+					Status: util.Pointer(domain.TaskStatusCompleted),
+				}},
+			},
+		})
+		s.NoError(err)
+		s.NoError(results[0].Err)
+		s.Equal(uint(2), results[0].Task.ID)
+		s.NoError(results[1].Err)
+		s.Equal(domain.TaskStatusCompleted, results[1].Task.Status)
+
+		tasksInRepo, err := repo.ListTasks(context.Background())
+		s.NoError(err)
+		s.Len(tasksInRepo, 2)
+		s.Equal(domain.TaskStatusCompleted, tasksInRepo[0].Status)
+		s.Equal("task 2", tasksInRepo[1].Name)
+	})
+
+	s.T().Run("best effort", func(t *testing.T) {
+		results, err := service.BatchTasks(context.Background(), task.BatchTasksRequest{
+			Operations: []task.BatchOperation{
+				{Type: domain.BatchOperationTypeDelete, ID: 1},
+				{Type: domain.BatchOperationTypeDelete, ID: 99},
+				{Type: domain.BatchOperationTypeUpdate, ID: 2, Update: task.UpdateTaskRequest{
+					Status: util.Pointer(domain.TaskStatus(99999)),
+				}},
+			},
+		})
+		s.NoError(err)
+		s.NoError(results[0].Err)
+		s.ErrorIs(results[1].Err, domain.ErrTaskNotFound)
+		s.ErrorIs(results[2].Err, task.ErrInvalidStatus)
+
+		tasksInRepo, err := repo.ListTasks(context.Background())
+		s.NoError(err)
+		s.Len(tasksInRepo, 1)
+		s.Equal("task 2", tasksInRepo[0].Name)
+	})
+}
+
+func (s *TaskServiceTaskSuite) TestImportTasks() {
+	repo := stub.NewInMemoryTaskRepository()
+	service := task.NewService(repo, task.WithMaxImportSize(3))
+	ctx := context.Background()
+
+	var events []domain.Event
+	subscription := service.EventBus().Subscribe("test", func(_ context.Context, envelope eventbus.Envelope) error {
+		events = append(events, envelope.Event)
+		return nil
+	})
+	defer subscription.Close()
+
+	_, err := repo.CreateTask(ctx, domain.CreateTaskRequest{Name: "task 1"})
+	s.Require().NoError(err)
+
+	s.T().Run("import size exceeded", func(t *testing.T) {
+		_, err := service.ImportTasks(ctx, task.ImportTasksRequest{Tasks: make([]domain.Task, 4)})
+		s.ErrorIs(err, task.ErrImportSizeExceeded)
+	})
+
+	s.T().Run("invalid", func(t *testing.T) {
+		results, err := service.ImportTasks(ctx, task.ImportTasksRequest{
+			Tasks: []domain.Task{
+				{ID: 5, Name: "task 5"},
+				{ID: 5, Name: "task 5 again"},
+				{Name: "task 6", Status: domain.TaskStatus(99)},
+			},
+			PreserveIDs: true,
+		})
+		s.NoError(err)
+		s.ErrorIs(results[0].Err, domain.ErrBatchRolledBack)
+		s.ErrorIs(results[1].Err, task.ErrDuplicateTaskID)
+		s.ErrorIs(results[2].Err, task.ErrInvalidStatus)
+	})
+
+	s.T().Run("conflict", func(t *testing.T) {
+		results, err := service.ImportTasks(ctx, task.ImportTasksRequest{
+			Tasks:       []domain.Task{{Name: "task 2"}, {ID: 1, Name: "task 1"}},
+			PreserveIDs: true,
+		})
+		s.NoError(err)
+		s.ErrorIs(results[0].Err, domain.ErrBatchRolledBack)
+		s.ErrorIs(results[1].Err, domain.ErrTaskConflict)
+
+		results, err = service.ImportTasks(ctx, task.ImportTasksRequest{
+			Tasks: []domain.Task{{Name: "task 2"}, {ID: 1, Name: "task 1"}},
+		})
+		s.NoError(err)
+		s.NoError(results[1].Err, "IDs are dropped unless preserved")
+		s.Equal(uint(3), results[1].Task.ID)
+	})
+
+	tasksInRepo, err := repo.ListTasks(ctx)
+	s.Require().NoError(err)
+	s.Require().Len(tasksInRepo, 3)
+	s.Require().Len(events, 2)
+	events = nil
+
+	s.T().Run("dry run", func(t *testing.T) {
+		results, err := service.ImportTasks(ctx, task.ImportTasksRequest{
+			Tasks:       []domain.Task{{ID: 1, Name: "task 1 - updated"}, {ID: 9, Name: "task 9"}},
+			PreserveIDs: true,
+			Conflict:    domain.ImportConflictOverwrite,
+			DryRun:      true,
+		})
+		s.NoError(err)
+		s.Equal(domain.ImportActionOverwritten, results[0].Action)
+		s.Equal("task 1", results[0].Before.Name)
+		s.Equal(domain.ImportActionCreated, results[1].Action)
+
+		t1, err := repo.GetTask(ctx, 1)
+		s.NoError(err)
+		s.Equal("task 1", t1.Name)
+		s.Empty(events)
+	})
+
+	s.T().Run("overwrite", func(t *testing.T) {
+		results, err := service.ImportTasks(ctx, task.ImportTasksRequest{
+			Tasks: []domain.Task{
+				{ID: 1, Name: "task 1 - updated", Status: domain.TaskStatusCompleted},
+				{Name: "task 10"},
+				{ID: 9, Name: "task 9"},
+			},
+			PreserveIDs: true,
+			Conflict:    domain.ImportConflictOverwrite,
+		})
+		s.NoError(err)
+		s.Equal(domain.ImportActionOverwritten, results[0].Action)
+		s.Equal(uint(10), results[1].Task.ID)
+		s.Equal(uint(9), results[2].Task.ID)
+
+		s.Require().Len(events, 4)
+		s.IsType(domain.TaskUpdated{}, events[0])
+		s.IsType(domain.TaskStatusChanged{}, events[1])
+		s.Equal(domain.TaskCreated{Task: results[1].Task}, events[2])
+
+		created, err := repo.CreateTask(ctx, domain.CreateTaskRequest{Name: "task 11"})
+		s.NoError(err)
+		s.Equal(uint(11), created.ID)
+	})
+
+	s.T().Run("skip", func(t *testing.T) {
+		results, err := service.ImportTasks(ctx, task.ImportTasksRequest{
+			Tasks:       []domain.Task{{ID: 1, Name: "task 1"}},
+			PreserveIDs: true,
+			Conflict:    domain.ImportConflictSkip,
+		})
+		s.NoError(err)
+		s.Equal(domain.ImportActionSkipped, results[0].Action)
+		s.Equal("task 1 - updated", results[0].Task.Name)
+	})
+
+	s.T().Run("export", func(t *testing.T) {
+		var ids []uint
+		s.NoError(service.ExportTasks(ctx, func(tasks []domain.Task) error {
+			s.LessOrEqual(len(tasks), task.DefaultMaxBatchSize)
+			for _, t := range tasks {
+				ids = append(ids, t.ID)
+			}
+			return nil
+		}))
+		s.ElementsMatch([]uint{1, 2, 3, 9, 10, 11}, ids)
+	})
+}
+
+func (s *TaskServiceTaskSuite) TestBulkTasks() {
+	repo := stub.NewInMemoryTaskRepository()
+	service := task.NewService(repo, task.WithMaxBatchSize(3))
+
+	for index := range 10 {
+		_, err := repo.CreateTask(context.Background(), domain.CreateTaskRequest{
+			Name: fmt.Sprintf("task %d", index+1),
+		})
+		s.NoError(err)
+	}
+
+	s.T().Run("empty filter", func(t *testing.T) {
+		_, err := service.BulkDeleteTasks(context.Background(), task.BulkDeleteTasksRequest{})
+		s.ErrorIs(err, task.ErrEmptyFilter)
+	})
+
+	s.T().Run("update - dry run", func(t *testing.T) {
+		result, err := service.BulkUpdateTasks(context.Background(), task.BulkUpdateTasksRequest{
+			Filter: domain.TaskFilter{NameContains: "task 1"},
+			Patch:  task.UpdateTaskRequest{Status: util.Pointer(domain.TaskStatusCompleted)},
+			DryRun: true,
+		})
+		s.NoError(err)
+		s.Equal([]uint{1, 10}, result.IDs)
+
+		tasksInRepo, err := repo.ListTasks(context.Background())
+		s.NoError(err)
+		for _, t := range tasksInRepo {
+			s.Equal(domain.TaskStatusIncomplete, t.Status)
+		}
+	})
+
+	s.T().Run("update", func(t *testing.T) {
+		result, err := service.BulkUpdateTasks(context.Background(), task.BulkUpdateTasksRequest{
+			Filter: domain.TaskFilter{IDs: []uint{2, 4, 6, 8, 99}},
+			Patch:  task.UpdateTaskRequest{Status: util.Pointer(domain.TaskStatusCompleted)},
+		})
+		s.NoError(err)
+		s.Equal([]uint{2, 4, 6, 8}, result.IDs)
+
+		tasksInRepo, err := repo.ListTasks(context.Background())
+		s.NoError(err)
+		for _, t := range tasksInRepo {
+			if t.ID%2 == 0 && t.ID < 10 {
+				s.Equal(domain.TaskStatusCompleted, t.Status)
+			} else {
+				s.Equal(domain.TaskStatusIncomplete, t.Status)
+			}
+		}
+	})
+
+	s.T().Run("delete", func(t *testing.T) {
+		result, err := service.BulkDeleteTasks(context.Background(), task.BulkDeleteTasksRequest{
+			Filter: domain.TaskFilter{Status: util.Pointer(domain.TaskStatusCompleted)},
+		})
+		s.NoError(err)
+		s.Equal([]uint{2, 4, 6, 8}, result.IDs)
+
+		tasksInRepo, err := repo.ListTasks(context.Background())
+		s.NoError(err)
+		s.Len(tasksInRepo, 6)
+	})
+}
+
+func (s *TaskServiceTaskSuite) TestEvents() {
+	repo := stub.NewInMemoryTaskRepository()
+	service := task.NewService(repo, task.WithEventReplaySize(3))
+	ctx := context.Background()
+
+	subscription := service.SubscribeEvents("")
+	defer subscription.Close()
+
+	var ids []string
+	receive := func() task.Event {
+		select {
+		case event := <-subscription.Events():
+			ids = append(ids, event.ID)
+			return event
+		default:
+			s.FailNow("no event published")
+			return task.Event{}
+		}
+	}
+
+	created, err := service.CreateTask(ctx, task.CreateTaskRequest{Name: "task 1"})
+	s.Require().NoError(err)
+	event := receive()
+	s.Equal(task.EventTypeCreated, event.Type)
+	s.Equal(created, event.Task)
+
+	name := "task 1 - updated"
+	s.Require().NoError(service.UpdateTask(ctx, created.ID, task.UpdateTaskRequest{Name: &name}))
+	event = receive()
+	s.Equal(task.EventTypeUpdated, event.Type)
+	s.Equal(name, event.Task.Name)
+
+	_, err = service.BatchTasks(ctx, task.BatchTasksRequest{
+		Atomic: true,
+		Operations: []task.BatchOperation{
+			{Type: domain.BatchOperationTypeCreate, Create: task.CreateTaskRequest{Name: "task 2"}},
+			{Type: domain.BatchOperationTypeCreate, Create: task.CreateTaskRequest{Name: "task 3"}},
+		},
+	})
+	s.Require().NoError(err)
+	s.Equal("task 2", receive().Task.Name)
+	s.Equal("task 3", receive().Task.Name)
+
+	_, err = service.BulkDeleteTasks(ctx, task.BulkDeleteTasksRequest{
+		Filter: domain.TaskFilter{NameContains: "task 2"},
+	})
+	s.Require().NoError(err)
+	event = receive()
+	s.Equal(task.EventTypeDeleted, event.Type)
+	s.Equal("task 2", event.Task.Name)
+
+	s.Require().NoError(service.DeleteTask(ctx, created.ID))
+	event = receive()
+	s.Equal(task.EventTypeDeleted, event.Type)
+	s.Equal(name, event.Task.Name)
+	s.Len(ids, 6)
+
+	s.Error(service.DeleteTask(ctx, created.ID))
+	s.Empty(subscription.Events())
+
+	s.T().Run("resume", func(t *testing.T) {
+		resumed := service.SubscribeEvents(ids[5])
+		defer resumed.Close()
+		s.False(resumed.Missed)
+		s.Empty(resumed.Replay)
+	})
+	s.T().Run("replay", func(t *testing.T) {
+		// the 3 latest of the 6 events are buffered.
+		resumed := service.SubscribeEvents(ids[2])
+		defer resumed.Close()
+		s.False(resumed.Missed)
+		s.Len(resumed.Replay, 3)
+		s.Equal(ids[3], resumed.Replay[0].ID)
+		s.Equal(ids[5], resumed.Replay[2].ID)
+	})
+	s.T().Run("missed", func(t *testing.T) {
+		resumed := service.SubscribeEvents(ids[0])
+		defer resumed.Close()
+		s.True(resumed.Missed)
+		s.Empty(resumed.Replay)
+	})
+	s.T().Run("unknown", func(t *testing.T) {
+		resumed := service.SubscribeEvents("unknown-1")
+		defer resumed.Close()
+		s.True(resumed.Missed)
+	})
+}
+
+func (s *TaskServiceTaskSuite) TestEventsSlowSubscriber() {
+	service := task.NewService(stub.NewInMemoryTaskRepository())
+
+	subscription := service.SubscribeEvents("")
+	defer subscription.Close()
+
+	for index := 0; index < 100; index++ {
+		_, err := service.CreateTask(context.Background(), task.CreateTaskRequest{Name: "task"})
+		s.Require().NoError(err)
+	}
+
+	// the lagging subscriber is disconnected instead of blocking writes.
+	var received int
+	for range subscription.Events() {
+		received++
+	}
+	s.Less(received, 100)
+}
+
+func (s *TaskServiceTaskSuite) TestDomainEvents() {
+	service := task.NewService(stub.NewInMemoryTaskRepository())
+	ctx := context.Background()
+
+	var events []domain.Event
+	subscription := service.EventBus().Subscribe("test", func(_ context.Context, envelope eventbus.Envelope) error {
+		events = append(events, envelope.Event)
+		return nil
+	})
+	defer subscription.Close()
+
+	created, err := service.CreateTask(ctx, task.CreateTaskRequest{Name: "task 1"})
+	s.Require().NoError(err)
+	s.Require().Len(events, 1)
+	s.Equal(domain.TaskCreated{Task: created}, events[0])
+
+	name := "task 1 - updated"
+	s.Require().NoError(service.UpdateTask(ctx, created.ID, task.UpdateTaskRequest{Name: &name}))
+	s.Require().Len(events, 2)
+	updated := events[1].(domain.TaskUpdated)
+	s.Equal("task 1", updated.Before.Name)
+	s.Equal(name, updated.After.Name)
+
+	status := domain.TaskStatusCompleted
+	s.Require().NoError(service.UpdateTask(ctx, created.ID, task.UpdateTaskRequest{Status: &status}))
+	s.Require().Len(events, 4)
+	s.IsType(domain.TaskUpdated{}, events[2])
+	s.Equal(domain.TaskStatusChanged{
+		Task: events[2].(domain.TaskUpdated).After,
+		From: domain.TaskStatusIncomplete,
+		To:   domain.TaskStatusCompleted,
+	}, events[3])
+
+	_, err = service.BatchTasks(ctx, task.BatchTasksRequest{
+		Operations: []task.BatchOperation{
+			{Type: domain.BatchOperationTypeUpdate, ID: created.ID, Update: task.UpdateTaskRequest{Name: &name}},
+		},
+	})
+	s.Require().NoError(err)
+	s.Require().Len(events, 5)
+	s.Equal(name, events[4].(domain.TaskUpdated).Before.Name)
+	s.Equal(domain.TaskStatusCompleted, events[4].(domain.TaskUpdated).Before.Status)
+
+	s.Require().NoError(service.DeleteTask(ctx, created.ID))
+	s.Require().Len(events, 6)
+	s.Equal(domain.TaskDeleted{Task: events[4].(domain.TaskUpdated).After}, events[5])
+}
+
 func TestTaskService(t *testing.T) {
 	suite.Run(t, new(TaskServiceTaskSuite))
 }
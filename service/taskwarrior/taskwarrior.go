@@ -0,0 +1,302 @@
+package taskwarrior
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/omegaatt36/gotasker/domain"
+	"github.com/omegaatt36/gotasker/format/taskwarrior"
+	"github.com/omegaatt36/gotasker/service/task"
+)
+
+// ErrDuplicateUUID is returned when an import contains a UUID more than
+// once.
+var ErrDuplicateUUID = errors.New("duplicate taskwarrior uuid")
+
+// Service represents a Taskwarrior service, which maps Taskwarrior tasks to
+// tasks by their UUIDs. Tasks never imported are exported with their default
+// UUIDs, see domain.DefaultTaskwarriorUUID.
+type Service struct {
+	tasks *task.Service
+	repo  domain.TaskwarriorRepository
+}
+
+// NewService creates a new Taskwarrior service.
+func NewService(tasks *task.Service, repo domain.TaskwarriorRepository) *Service {
+	return &Service{
+		tasks: tasks,
+		repo:  repo,
+	}
+}
+
+// toTaskwarriorTask maps the task to a Taskwarrior task, the mapped data is
+// nil for tasks never imported. Completed tasks are regarded as completed at
+// their last update.
+func toTaskwarriorTask(t *domain.Task, mapped *domain.TaskwarriorTask) taskwarrior.Task {
+	exported := taskwarrior.Task{
+		UUID:        domain.DefaultTaskwarriorUUID(t.ID),
+		Description: t.Name,
+		Status:      taskwarrior.StatusPending,
+		Entry:       t.CreatedAt,
+		Modified:    t.UpdatedAt,
+	}
+	if t.Status == domain.TaskStatusCompleted {
+		exported.Status = taskwarrior.StatusCompleted
+		exported.End = t.UpdatedAt
+	}
+
+	if mapped != nil {
+		exported.UUID = mapped.UUID
+		exported.Tags = mapped.Tags
+		exported.Attributes = mapped.Attributes
+		for _, annotation := range mapped.Annotations {
+			exported.Annotations = append(exported.Annotations, taskwarrior.Annotation(annotation))
+		}
+	}
+
+	return exported
+}
+
+// toImportedTask maps the Taskwarrior task to a task of the ID, which is zero
+// for new tasks. Pending, waiting and recurring tasks are incomplete.
+func toImportedTask(imported *taskwarrior.Task, id uint) domain.Task {
+	t := domain.Task{
+		ID:        id,
+		Name:      imported.Description,
+		Status:    domain.TaskStatusIncomplete,
+		CreatedAt: imported.Entry,
+		UpdatedAt: imported.Modified,
+	}
+	if imported.Status == taskwarrior.StatusCompleted {
+		t.Status = domain.TaskStatusCompleted
+	}
+	if t.UpdatedAt.IsZero() {
+		t.UpdatedAt = imported.End
+	}
+
+	return t
+}
+
+// toMapped keeps the data of the Taskwarrior task the task of the ID has no
+// fields for.
+func toMapped(uuid string, imported *taskwarrior.Task, id uint) domain.TaskwarriorTask {
+	mapped := domain.TaskwarriorTask{
+		UUID:       uuid,
+		TaskID:     id,
+		Tags:       imported.Tags,
+		Attributes: imported.Attributes,
+	}
+	for _, annotation := range imported.Annotations {
+		mapped.Annotations = append(mapped.Annotations, domain.TaskwarriorAnnotation(annotation))
+	}
+
+	return mapped
+}
+
+// ExportTasks walks through all tasks in chunks like task.Service.ExportTasks
+// and passes them as Taskwarrior tasks.
+func (s *Service) ExportTasks(ctx context.Context, fn func([]taskwarrior.Task) error) error {
+	mapped, err := s.repo.ListTaskwarriorTasks(ctx)
+	if err != nil {
+		return err
+	}
+
+	// a task imported by several UUIDs is exported by the first of them.
+	mappedByTask := make(map[uint]*domain.TaskwarriorTask, len(mapped))
+	for index := range mapped {
+		if _, ok := mappedByTask[mapped[index].TaskID]; !ok {
+			mappedByTask[mapped[index].TaskID] = &mapped[index]
+		}
+	}
+
+	return s.tasks.ExportTasks(ctx, func(tasks []domain.Task) error {
+		exported := make([]taskwarrior.Task, len(tasks))
+		for index := range tasks {
+			exported[index] = toTaskwarriorTask(&tasks[index], mappedByTask[tasks[index].ID])
+		}
+
+		return fn(exported)
+	})
+}
+
+// ImportTasksRequest defines the request for importing Taskwarrior tasks.
+type ImportTasksRequest struct {
+	Tasks []taskwarrior.Task
+	// DryRun only validates the tasks and reports what the import would do,
+	// created tasks have no IDs yet.
+	DryRun bool
+}
+
+// ImportResult defines the result of importing a single Taskwarrior task.
+type ImportResult struct {
+	UUID string
+	// Task is the imported task, or the deleted one. It's zero for deleted
+	// Taskwarrior tasks without tasks.
+	Task   domain.Task
+	Action domain.ImportAction
+	Err    error
+}
+
+// maxImportRetries is the number of times an import is retried when its
+// Taskwarrior tasks are changed by another import meanwhile.
+const maxImportRetries = 10
+
+// ImportTasks imports the tasks and returns the result of each task in
+// request order. Tasks of known UUIDs overwrite their tasks, the others are
+// created, and deleted ones delete their tasks. Like task.Service.ImportTasks
+// an invalid task fails the import and the other tasks are reported as rolled
+// back. The tasks, the deletions and the Taskwarrior tasks are written
+// atomically, and the import is retried if another import changes the
+// Taskwarrior tasks of its UUIDs meanwhile.
+func (s *Service) ImportTasks(ctx context.Context, req ImportTasksRequest) ([]ImportResult, error) {
+	for range maxImportRetries {
+		results, err := s.importTasks(ctx, req)
+		if errors.Is(err, domain.ErrTaskwarriorTasksChanged) {
+			continue
+		}
+
+		return results, err
+	}
+
+	return nil, domain.ErrTaskwarriorTasksChanged
+}
+
+func (s *Service) importTasks(ctx context.Context, req ImportTasksRequest) ([]ImportResult, error) {
+	uuids := make([]string, len(req.Tasks))
+	for index := range req.Tasks {
+		uuids[index] = strings.ToLower(req.Tasks[index].UUID)
+	}
+
+	mapped, err := s.repo.GetTaskwarriorTasks(ctx, uuids)
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[string]uint, len(mapped))
+	for _, m := range mapped {
+		known[m.UUID] = m.TaskID
+	}
+
+	ids := make(map[string]uint, len(uuids))
+	results := make([]ImportResult, len(req.Tasks))
+	seen := make(map[string]struct{}, len(uuids))
+	seenIDs := make(map[uint]struct{}, len(uuids))
+	var invalid bool
+	for index, uuid := range uuids {
+		results[index].UUID = uuid
+
+		id, ok := known[uuid]
+		if !ok {
+			id, _ = parseDefaultUUID(uuid)
+		}
+		ids[uuid] = id
+
+		if _, ok := seen[uuid]; ok {
+			results[index].Err = fmt.Errorf("%w: %s", ErrDuplicateUUID, uuid)
+			invalid = true
+			continue
+		}
+		seen[uuid] = struct{}{}
+
+		if _, ok := seenIDs[id]; ok && id > 0 {
+			results[index].Err = fmt.Errorf("%w: %d", task.ErrDuplicateTaskID, id)
+			invalid = true
+			continue
+		}
+		seenIDs[id] = struct{}{}
+	}
+
+	if invalid {
+		rollBackImport(results)
+		return results, nil
+	}
+
+	var (
+		imported         []domain.Task
+		deletions        []uint
+		taskwarriorTasks = domain.TaskwarriorImport{Mapped: known}
+	)
+	for index, uuid := range uuids {
+		id := ids[uuid]
+		if req.Tasks[index].Status == taskwarrior.StatusDeleted {
+			if id > 0 {
+				deletions = append(deletions, id)
+			}
+			taskwarriorTasks.Released = append(taskwarriorTasks.Released, uuid)
+			continue
+		}
+
+		imported = append(imported, toImportedTask(&req.Tasks[index], id))
+		taskwarriorTasks.Saved = append(taskwarriorTasks.Saved, toMapped(uuid, &req.Tasks[index], id))
+	}
+
+	importResults, err := s.tasks.ImportTasks(ctx, task.ImportTasksRequest{
+		Tasks:       imported,
+		PreserveIDs: true,
+		Conflict:    domain.ImportConflictOverwrite,
+		DryRun:      req.DryRun,
+		Deletions:   deletions,
+		Taskwarrior: &taskwarriorTasks,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// the results of the deletions follow the results of the other tasks.
+	deletionResults := importResults[len(imported):]
+	importResults = importResults[:len(imported)]
+
+	var failed bool
+	for index := range req.Tasks {
+		result := &results[index]
+
+		var imported domain.ImportResult
+		switch {
+		case req.Tasks[index].Status != taskwarrior.StatusDeleted:
+			imported, importResults = importResults[0], importResults[1:]
+		case ids[result.UUID] > 0:
+			imported, deletionResults = deletionResults[0], deletionResults[1:]
+		default:
+			imported = domain.ImportResult{Action: domain.ImportActionSkipped}
+		}
+
+		result.Task = imported.Task
+		result.Action = imported.Action
+		if result.Err = imported.Err; result.Err != nil {
+			failed = true
+		}
+	}
+
+	if failed {
+		rollBackImport(results)
+	}
+
+	return results, nil
+}
+
+// rollBackImport marks the tasks without errors as rolled back.
+func rollBackImport(results []ImportResult) {
+	for index := range results {
+		if results[index].Err == nil {
+			results[index].Err = domain.ErrBatchRolledBack
+		}
+	}
+}
+
+// parseDefaultUUID returns the task ID of a default UUID.
+func parseDefaultUUID(uuid string) (uint, bool) {
+	hex, ok := strings.CutPrefix(uuid, domain.TaskwarriorUUIDPrefix)
+	if !ok || len(hex) != 12 {
+		return 0, false
+	}
+
+	id, err := strconv.ParseUint(hex, 16, 0)
+	if err != nil || id == 0 {
+		return 0, false
+	}
+
+	return uint(id), true
+}
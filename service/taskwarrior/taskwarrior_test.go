@@ -0,0 +1,257 @@
+package taskwarrior_test
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/omegaatt36/gotasker/domain"
+	"github.com/omegaatt36/gotasker/domain/stub"
+	format "github.com/omegaatt36/gotasker/format/taskwarrior"
+	"github.com/omegaatt36/gotasker/persistance"
+	"github.com/omegaatt36/gotasker/persistance/database"
+	"github.com/omegaatt36/gotasker/service/task"
+	"github.com/omegaatt36/gotasker/service/taskwarrior"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type TaskwarriorServiceSuite struct {
+	suite.Suite
+
+	repo    *stub.InMemoryTaskRepository
+	service *taskwarrior.Service
+}
+
+func (s *TaskwarriorServiceSuite) SetupTest() {
+	s.repo = stub.NewInMemoryTaskRepository()
+	s.service = taskwarrior.NewService(task.NewService(s.repo), s.repo)
+}
+
+func (s *TaskwarriorServiceSuite) export() []format.Task {
+	var exported []format.Task
+	s.Require().NoError(s.service.ExportTasks(context.Background(), func(tasks []format.Task) error {
+		exported = append(exported, tasks...)
+		return nil
+	}))
+
+	return exported
+}
+
+func (s *TaskwarriorServiceSuite) TestExportDefaultUUIDs() {
+	ctx := context.Background()
+
+	t, err := s.repo.CreateTask(ctx, domain.CreateTaskRequest{Name: "task 1", Notes: "buy milk"})
+	s.Require().NoError(err)
+
+	exported := s.export()
+	s.Require().Len(exported, 1)
+	s.Equal(domain.DefaultTaskwarriorUUID(t.ID), exported[0].UUID)
+	s.Equal("676f7461-736b-8000-8000-000000000001", exported[0].UUID)
+	s.Equal(format.StatusPending, exported[0].Status)
+	s.True(exported[0].End.IsZero())
+
+	// re-importing the export updates the task.
+	exported[0].Status = format.StatusCompleted
+	exported[0].End = time.Now()
+	results, err := s.service.ImportTasks(ctx, taskwarrior.ImportTasksRequest{Tasks: exported})
+	s.Require().NoError(err)
+	s.Require().Len(results, 1)
+	s.Require().NoError(results[0].Err)
+	s.Equal(domain.ImportActionOverwritten, results[0].Action)
+	s.Equal(t.ID, results[0].Task.ID)
+
+	tasks, err := s.repo.ListTasks(ctx)
+	s.Require().NoError(err)
+	s.Require().Len(tasks, 1)
+	s.Equal(domain.TaskStatusCompleted, tasks[0].Status)
+	s.Equal("buy milk", tasks[0].Notes, "Taskwarrior tasks have no notes, overwriting keeps them")
+}
+
+func (s *TaskwarriorServiceSuite) TestImportTwice() {
+	ctx := context.Background()
+
+	entry := time.Date(2024, time.May, 1, 8, 30, 0, 0, time.UTC)
+	imported := []format.Task{
+		{
+			UUID:        "5D2A4A57-2E1A-4C1B-9C1F-0E1A2B3C4D5E",
+			Description: "task 1",
+			Status:      format.StatusPending,
+			Entry:       entry,
+			Tags:        []string{"home"},
+			Annotations: []format.Annotation{{Entry: entry, Description: "note"}},
+			Attributes:  map[string]json.RawMessage{"project": json.RawMessage(`"house"`)},
+		},
+		{
+			UUID:        "7b1c2d3e-4f50-4a6b-8c7d-9e0f1a2b3c4d",
+			Description: "task 2",
+			Status:      format.StatusWaiting,
+		},
+	}
+
+	results, err := s.service.ImportTasks(ctx, taskwarrior.ImportTasksRequest{Tasks: imported})
+	s.Require().NoError(err)
+	s.Require().Len(results, 2)
+	for _, result := range results {
+		s.Require().NoError(result.Err)
+		s.Equal(domain.ImportActionCreated, result.Action)
+	}
+	s.Equal("5d2a4a57-2e1a-4c1b-9c1f-0e1a2b3c4d5e", results[0].UUID)
+	s.Equal(entry, results[0].Task.CreatedAt)
+	s.Equal(domain.TaskStatusIncomplete, results[1].Task.Status)
+
+	imported[0].Description = "renamed"
+	results, err = s.service.ImportTasks(ctx, taskwarrior.ImportTasksRequest{Tasks: imported})
+	s.Require().NoError(err)
+	for _, result := range results {
+		s.Require().NoError(result.Err)
+		s.Equal(domain.ImportActionOverwritten, result.Action)
+	}
+
+	tasks, err := s.repo.ListTasks(ctx)
+	s.Require().NoError(err)
+	s.Require().Len(tasks, 2)
+	s.Equal("renamed", tasks[0].Name)
+
+	exported := s.export()
+	s.Require().Len(exported, 2)
+	for _, t := range exported {
+		if t.Description != "renamed" {
+			continue
+		}
+
+		s.Equal("5d2a4a57-2e1a-4c1b-9c1f-0e1a2b3c4d5e", t.UUID)
+		s.Equal([]string{"home"}, t.Tags)
+		s.Equal([]format.Annotation{{Entry: entry, Description: "note"}}, t.Annotations)
+		s.JSONEq(`"house"`, string(t.Attributes["project"]))
+	}
+}
+
+func (s *TaskwarriorServiceSuite) TestImportDeleted() {
+	ctx := context.Background()
+
+	imported := []format.Task{{
+		UUID:        "5d2a4a57-2e1a-4c1b-9c1f-0e1a2b3c4d5e",
+		Description: "task 1",
+		Status:      format.StatusPending,
+	}}
+	_, err := s.service.ImportTasks(ctx, taskwarrior.ImportTasksRequest{Tasks: imported})
+	s.Require().NoError(err)
+
+	imported[0].Status = format.StatusDeleted
+	imported = append(imported, format.Task{
+		UUID:        "7b1c2d3e-4f50-4a6b-8c7d-9e0f1a2b3c4d",
+		Description: "never imported",
+		Status:      format.StatusDeleted,
+	})
+
+	results, err := s.service.ImportTasks(ctx, taskwarrior.ImportTasksRequest{Tasks: imported, DryRun: true})
+	s.Require().NoError(err)
+	s.Equal(domain.ImportActionDeleted, results[0].Action)
+	s.Equal(domain.ImportActionSkipped, results[1].Action)
+
+	tasks, err := s.repo.ListTasks(ctx)
+	s.Require().NoError(err)
+	s.Len(tasks, 1)
+
+	results, err = s.service.ImportTasks(ctx, taskwarrior.ImportTasksRequest{Tasks: imported})
+	s.Require().NoError(err)
+	s.Equal(domain.ImportActionDeleted, results[0].Action)
+	s.Equal("task 1", results[0].Task.Name)
+	s.Equal(domain.ImportActionSkipped, results[1].Action)
+
+	tasks, err = s.repo.ListTasks(ctx)
+	s.Require().NoError(err)
+	s.Empty(tasks)
+
+	// the UUID is released, importing it again creates a task.
+	imported[0].Status = format.StatusPending
+	results, err = s.service.ImportTasks(ctx, taskwarrior.ImportTasksRequest{Tasks: imported[:1]})
+	s.Require().NoError(err)
+	s.Equal(domain.ImportActionCreated, results[0].Action)
+}
+
+func (s *TaskwarriorServiceSuite) TestImportInvalid() {
+	ctx := context.Background()
+
+	results, err := s.service.ImportTasks(ctx, taskwarrior.ImportTasksRequest{Tasks: []format.Task{
+		{UUID: "5d2a4a57-2e1a-4c1b-9c1f-0e1a2b3c4d5e", Description: "task 1", Status: format.StatusPending},
+		{UUID: "5D2A4A57-2E1A-4C1B-9C1F-0E1A2B3C4D5E", Description: "task 2", Status: format.StatusPending},
+	}})
+	s.Require().NoError(err)
+	s.ErrorIs(results[0].Err, domain.ErrBatchRolledBack)
+	s.ErrorIs(results[1].Err, taskwarrior.ErrDuplicateUUID)
+
+	results, err = s.service.ImportTasks(ctx, taskwarrior.ImportTasksRequest{Tasks: []format.Task{
+		{UUID: "5d2a4a57-2e1a-4c1b-9c1f-0e1a2b3c4d5e", Description: "task 1", Status: format.StatusPending},
+		{UUID: "7b1c2d3e-4f50-4a6b-8c7d-9e0f1a2b3c4d", Status: format.StatusPending},
+	}})
+	s.Require().NoError(err)
+	s.ErrorIs(results[0].Err, domain.ErrBatchRolledBack)
+	s.ErrorIs(results[1].Err, task.ErrTaskNameRequired)
+
+	tasks, err := s.repo.ListTasks(ctx)
+	s.Require().NoError(err)
+	s.Empty(tasks)
+	s.Empty(s.export())
+}
+
+func (s *TaskwarriorServiceSuite) TestConcurrentImports() {
+	miniredis := database.InitializeTestingRedis()
+	defer miniredis.Close()
+
+	database.Initialize(context.Background(), miniredis.Addr(), "")
+	ctx := context.Background()
+	repo := persistance.NewRedisRepo(database.Redis())
+	service := taskwarrior.NewService(task.NewService(repo), persistance.NewRedisTaskwarriorRepo(database.Redis()))
+
+	imported := []format.Task{{
+		UUID:        "5d2a4a57-2e1a-4c1b-9c1f-0e1a2b3c4d5e",
+		Description: "task 1",
+		Status:      format.StatusPending,
+	}}
+
+	// concurrent imports of a new UUID create a single task, the others
+	// overwrite it.
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			results, err := service.ImportTasks(ctx, taskwarrior.ImportTasksRequest{Tasks: imported})
+			s.NoError(err)
+			s.Len(results, 1)
+		}()
+	}
+	wg.Wait()
+
+	tasks, err := repo.ListTasks(ctx)
+	s.Require().NoError(err)
+	s.Require().Len(tasks, 1)
+
+	mapped, err := persistance.NewRedisTaskwarriorRepo(database.Redis()).ListTaskwarriorTasks(ctx)
+	s.Require().NoError(err)
+	s.Require().Len(mapped, 1)
+	s.Equal(tasks[0].ID, mapped[0].TaskID)
+
+	// the deletion and the release of the UUID are written together.
+	imported[0].Status = format.StatusDeleted
+	results, err := service.ImportTasks(ctx, taskwarrior.ImportTasksRequest{Tasks: imported})
+	s.Require().NoError(err)
+	s.Equal(domain.ImportActionDeleted, results[0].Action)
+
+	tasks, err = repo.ListTasks(ctx)
+	s.Require().NoError(err)
+	s.Empty(tasks)
+
+	mapped, err = persistance.NewRedisTaskwarriorRepo(database.Redis()).ListTaskwarriorTasks(ctx)
+	s.Require().NoError(err)
+	s.Empty(mapped)
+}
+
+func TestTaskwarriorServiceSuite(t *testing.T) {
+	suite.Run(t, new(TaskwarriorServiceSuite))
+}
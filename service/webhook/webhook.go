@@ -0,0 +1,457 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/omegaatt36/gotasker/domain"
+	"github.com/omegaatt36/gotasker/logging"
+	"github.com/omegaatt36/gotasker/service/delivery"
+	"github.com/omegaatt36/gotasker/service/eventbus"
+	"github.com/omegaatt36/gotasker/service/task"
+)
+
+var (
+	ErrInvalidURL   = errors.New("webhook url must be an absolute http or https url")
+	ErrInvalidEvent = errors.New("invalid webhook event")
+)
+
+// headers of deliveries.
+const (
+	// HeaderSignature is the HMAC-SHA256 of the body keyed by the webhook
+	// secret, see Sign.
+	HeaderSignature = "X-Gotasker-Signature"
+	HeaderEvent     = "X-Gotasker-Event"
+	HeaderDelivery  = "X-Gotasker-Delivery"
+)
+
+// default delivery settings.
+const (
+	DefaultMaxAttempts  = 8
+	DefaultBackoff      = 10 * time.Second
+	DefaultMaxBackoff   = time.Hour
+	DefaultPollInterval = time.Second
+	DefaultTimeout      = 10 * time.Second
+)
+
+// claimLimit is the number of deliveries attempted per poll.
+const claimLimit = 100
+
+// EventTypes are the task events webhooks subscribe to.
+var EventTypes = []task.EventType{task.EventTypeCreated, task.EventTypeUpdated, task.EventTypeDeleted}
+
+// Service represents a webhook service. It records a delivery for every
+// task event and subscribed webhook, and delivers them from a Redis queue
+// shared by all processes.
+type Service struct {
+	repo  domain.WebhookRepository
+	tasks *task.Service
+
+	client *http.Client
+	queue  delivery.Queue
+}
+
+// Option configures a webhook service.
+type Option func(*Service)
+
+// WithHTTPClient sets the client sending deliveries.
+func WithHTTPClient(client *http.Client) Option {
+	return func(s *Service) {
+		if client != nil {
+			s.client = client
+		}
+	}
+}
+
+// WithMaxAttempts sets the number of attempts before a delivery fails.
+func WithMaxAttempts(attempts int) Option {
+	return func(s *Service) {
+		s.queue.SetMaxAttempts(attempts)
+	}
+}
+
+// WithBackoff sets the backoff of retried deliveries, see delivery.Backoff.
+func WithBackoff(base, limit time.Duration) Option {
+	return func(s *Service) {
+		s.queue.Backoff = s.queue.Backoff.With(base, limit)
+	}
+}
+
+// WithPollInterval sets the interval of polling the delivery queue.
+func WithPollInterval(interval time.Duration) Option {
+	return func(s *Service) {
+		s.queue.SetPollInterval(interval)
+	}
+}
+
+// NewService creates a new webhook service delivering the events of tasks.
+func NewService(repo domain.WebhookRepository, tasks *task.Service, opts ...Option) *Service {
+	s := &Service{
+		repo:   repo,
+		tasks:  tasks,
+		client: &http.Client{Timeout: DefaultTimeout},
+		queue: delivery.Queue{
+			MaxAttempts:  DefaultMaxAttempts,
+			Backoff:      delivery.Backoff{Base: DefaultBackoff, Max: DefaultMaxBackoff},
+			PollInterval: DefaultPollInterval,
+		},
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Sign returns the signature of the body, receivers compare it with the
+// HeaderSignature header using hmac.Equal.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// CreateWebhookRequest defines the request for creating a webhook.
+type CreateWebhookRequest struct {
+	URL string
+	// Events are the subscribed event types, empty subscribes to all events.
+	Events []string
+	// Secret signs the deliveries, a random one is generated if empty.
+	Secret string
+}
+
+// CreateWebhook creates a new webhook.
+func (s *Service) CreateWebhook(ctx context.Context, req CreateWebhookRequest) (domain.Webhook, error) {
+	if err := validateURL(req.URL); err != nil {
+		return domain.Webhook{}, err
+	}
+
+	if err := validateEvents(req.Events); err != nil {
+		return domain.Webhook{}, err
+	}
+
+	if req.Secret == "" {
+		secret, err := generateSecret()
+		if err != nil {
+			return domain.Webhook{}, err
+		}
+		req.Secret = secret
+	}
+
+	return s.repo.CreateWebhook(ctx, domain.CreateWebhookRequest{
+		URL:    req.URL,
+		Events: req.Events,
+		Secret: req.Secret,
+	})
+}
+
+// ListWebhooks lists all webhooks.
+func (s *Service) ListWebhooks(ctx context.Context) ([]domain.Webhook, error) {
+	return s.repo.ListWebhooks(ctx)
+}
+
+// GetWebhook gets a webhook.
+func (s *Service) GetWebhook(ctx context.Context, id uint) (domain.Webhook, error) {
+	return s.repo.GetWebhook(ctx, id)
+}
+
+// UpdateWebhookRequest defines the request for updating a webhook.
+type UpdateWebhookRequest struct {
+	URL    *string
+	Events *[]string
+	Secret *string
+	// Active pauses the deliveries of the webhook if false.
+	Active *bool
+}
+
+// UpdateWebhook updates a webhook.
+func (s *Service) UpdateWebhook(ctx context.Context, id uint, req UpdateWebhookRequest) (domain.Webhook, error) {
+	if req.URL != nil {
+		if err := validateURL(*req.URL); err != nil {
+			return domain.Webhook{}, err
+		}
+	}
+
+	if req.Events != nil {
+		if err := validateEvents(*req.Events); err != nil {
+			return domain.Webhook{}, err
+		}
+	}
+
+	if req.Secret != nil && *req.Secret == "" {
+		secret, err := generateSecret()
+		if err != nil {
+			return domain.Webhook{}, err
+		}
+		req.Secret = &secret
+	}
+
+	return s.repo.UpdateWebhook(ctx, id, domain.UpdateWebhookRequest{
+		URL:    req.URL,
+		Events: req.Events,
+		Secret: req.Secret,
+		Active: req.Active,
+	})
+}
+
+// DeleteWebhook deletes a webhook and its delivery log.
+func (s *Service) DeleteWebhook(ctx context.Context, id uint) error {
+	return s.repo.DeleteWebhook(ctx, id)
+}
+
+// ListDeliveries lists the delivery log of a webhook, the latest first.
+func (s *Service) ListDeliveries(ctx context.Context, webhookID uint) ([]domain.Delivery, error) {
+	if _, err := s.repo.GetWebhook(ctx, webhookID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.ListDeliveries(ctx, webhookID)
+}
+
+// Redeliver queues a new delivery of the payload of a logged delivery.
+func (s *Service) Redeliver(ctx context.Context, webhookID, deliveryID uint) (domain.Delivery, error) {
+	if _, err := s.repo.GetWebhook(ctx, webhookID); err != nil {
+		return domain.Delivery{}, err
+	}
+
+	delivery, err := s.repo.GetDelivery(ctx, webhookID, deliveryID)
+	if err != nil {
+		return domain.Delivery{}, err
+	}
+
+	return s.repo.CreateDelivery(ctx, domain.Delivery{
+		WebhookID:     webhookID,
+		EventID:       delivery.EventID,
+		Event:         delivery.Event,
+		Payload:       delivery.Payload,
+		State:         domain.DeliveryStatePending,
+		NextAttemptAt: time.Now(),
+	})
+}
+
+// Start records the deliveries of task events published from now on and
+// delivers the due ones in the background. The returned channel is closed
+// once it has stopped after the context is canceled.
+func (s *Service) Start(ctx context.Context) <-chan struct{} {
+	subscription := s.tasks.EventBus().SubscribeAsync("webhooks", s.RecordEvent, eventbus.AsyncOptions{})
+
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+
+		s.queue.Poll(ctx, "webhook deliveries", s.DeliverDue)
+		subscription.Close()
+	}()
+
+	return stopped
+}
+
+// payload is the body of deliveries.
+type payload struct {
+	ID    string      `json:"id"`
+	Event string      `json:"event"`
+	Task  payloadTask `json:"task"`
+}
+
+type payloadTask struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// RecordEvent queues a delivery of the event to every active webhook
+// subscribed to it, it's the handler subscribed to the event bus.
+func (s *Service) RecordEvent(ctx context.Context, envelope eventbus.Envelope) error {
+	var (
+		eventType task.EventType
+		t         domain.Task
+	)
+	switch event := envelope.Event.(type) {
+	case domain.TaskCreated:
+		eventType, t = task.EventTypeCreated, event.Task
+	case domain.TaskUpdated:
+		eventType, t = task.EventTypeUpdated, event.After
+	case domain.TaskDeleted:
+		eventType, t = task.EventTypeDeleted, event.Task
+	default:
+		return nil
+	}
+
+	webhooks, err := s.repo.ListWebhooks(ctx)
+	if err != nil {
+		return err
+	}
+
+	bs, err := json.Marshal(payload{
+		ID:    envelope.ID,
+		Event: string(eventType),
+		Task: payloadTask{
+			ID:        t.ID,
+			Name:      t.Name,
+			Status:    t.Status.String(),
+			CreatedAt: t.CreatedAt,
+			UpdatedAt: t.UpdatedAt,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	var errs []error
+	for _, webhook := range webhooks {
+		if !webhook.Active || (len(webhook.Events) > 0 && !slices.Contains(webhook.Events, string(eventType))) {
+			continue
+		}
+
+		if _, err := s.repo.CreateDelivery(ctx, domain.Delivery{
+			WebhookID:     webhook.ID,
+			EventID:       envelope.ID,
+			Event:         string(eventType),
+			Payload:       bs,
+			State:         domain.DeliveryStatePending,
+			NextAttemptAt: time.Now(),
+		}); err != nil {
+			errs = append(errs, fmt.Errorf("webhook %d: %w", webhook.ID, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// DeliverDue attempts the deliveries which are due and returns the number of
+// attempts.
+func (s *Service) DeliverDue(ctx context.Context) (int, error) {
+	// a claimed delivery is due again if it's not saved within the lease,
+	// which outlasts the request.
+	lease := s.client.Timeout + time.Minute
+	deliveries, err := s.repo.ClaimDueDeliveries(ctx, time.Now(), lease, claimLimit)
+	if err != nil {
+		return 0, err
+	}
+
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.deliver(ctx, delivery); err != nil {
+				logging.ErrorfCtx(ctx, "deliver %d of webhook %d failed: %v", delivery.ID, delivery.WebhookID, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	return len(deliveries), nil
+}
+
+// deliver attempts the delivery and saves the result, deliveries to inactive
+// webhooks fail without being retried.
+func (s *Service) deliver(ctx context.Context, d domain.Delivery) error {
+	webhook, err := s.repo.GetWebhook(ctx, d.WebhookID)
+	if err != nil {
+		// the delivery log is deleted with the webhook.
+		if errors.Is(err, domain.ErrWebhookNotFound) {
+			return nil
+		}
+
+		return err
+	}
+
+	var attempt domain.DeliveryAttempt
+	if webhook.Active {
+		attempt = s.attempt(ctx, &webhook, &d)
+	} else {
+		attempt = domain.DeliveryAttempt{At: time.Now(), Error: "webhook is inactive"}
+	}
+	d.Attempts = append(d.Attempts, attempt)
+
+	result := delivery.Retry
+	switch {
+	case attempt.StatusCode >= 200 && attempt.StatusCode < 300:
+		result = delivery.Succeeded
+	case !webhook.Active:
+		result = delivery.Failed
+	}
+	d.State, d.NextAttemptAt = s.queue.Next(result, len(d.Attempts), time.Now())
+
+	return s.repo.SaveDelivery(ctx, d)
+}
+
+func (s *Service) attempt(ctx context.Context, webhook *domain.Webhook, delivery *domain.Delivery) domain.DeliveryAttempt {
+	attempt := domain.DeliveryAttempt{At: time.Now()}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "gotasker-webhook")
+	req.Header.Set(HeaderSignature, Sign(webhook.Secret, delivery.Payload))
+	req.Header.Set(HeaderEvent, delivery.Event)
+	req.Header.Set(HeaderDelivery, fmt.Sprintf("%d", delivery.ID))
+
+	resp, err := s.client.Do(req)
+	attempt.Duration = time.Since(attempt.At)
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	defer resp.Body.Close()
+
+	// drain the body so the connection is reused.
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	attempt.StatusCode = resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		attempt.Error = resp.Status
+	}
+
+	return attempt
+}
+
+func validateURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidURL
+	}
+
+	return nil
+}
+
+func validateEvents(events []string) error {
+	for _, event := range events {
+		if !slices.Contains(EventTypes, task.EventType(event)) {
+			return fmt.Errorf("%w: %s", ErrInvalidEvent, event)
+		}
+	}
+
+	return nil
+}
+
+func generateSecret() (string, error) {
+	bs := make([]byte, 32)
+	if _, err := rand.Read(bs); err != nil {
+		return "", fmt.Errorf("failed to generate secret: %w", err)
+	}
+
+	return hex.EncodeToString(bs), nil
+}
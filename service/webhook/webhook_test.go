@@ -0,0 +1,225 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/omegaatt36/gotasker/domain"
+	"github.com/omegaatt36/gotasker/domain/stub"
+	"github.com/omegaatt36/gotasker/logging"
+	"github.com/omegaatt36/gotasker/persistance"
+	"github.com/omegaatt36/gotasker/persistance/database"
+	"github.com/omegaatt36/gotasker/service/eventbus"
+	"github.com/omegaatt36/gotasker/service/task"
+	"github.com/omegaatt36/gotasker/service/webhook"
+	"github.com/omegaatt36/gotasker/util"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type WebhookServiceSuite struct {
+	suite.Suite
+}
+
+func (s *WebhookServiceSuite) SetupSuite() {
+	logging.Init(false, "error")
+}
+
+// receiver records the deliveries it receives and responds with the queued
+// status codes, 200 once they run out.
+type receiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []receivedRequest
+}
+
+type receivedRequest struct {
+	header http.Header
+	body   []byte
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.requests = append(r.requests, receivedRequest{header: req.Header, body: body})
+	status := http.StatusOK
+	if len(r.statuses) > 0 {
+		status, r.statuses = r.statuses[0], r.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func (r *receiver) received() []receivedRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]receivedRequest(nil), r.requests...)
+}
+
+func (s *WebhookServiceSuite) newService(opts ...webhook.Option) (*webhook.Service, *task.Service, func()) {
+	miniredis := database.InitializeTestingRedis()
+	database.Initialize(context.Background(), miniredis.Addr(), "")
+
+	tasks := task.NewService(stub.NewInMemoryTaskRepository())
+	service := webhook.NewService(persistance.NewRedisWebhookRepo(database.Redis()), tasks, opts...)
+
+	return service, tasks, miniredis.Close
+}
+
+func (s *WebhookServiceSuite) TestCreateWebhook() {
+	service, _, closeRedis := s.newService()
+	defer closeRedis()
+
+	ctx := context.Background()
+
+	_, err := service.CreateWebhook(ctx, webhook.CreateWebhookRequest{URL: "ftp://example.com"})
+	s.ErrorIs(err, webhook.ErrInvalidURL)
+
+	_, err = service.CreateWebhook(ctx, webhook.CreateWebhookRequest{URL: "http://example.com", Events: []string{"archived"}})
+	s.ErrorIs(err, webhook.ErrInvalidEvent)
+
+	created, err := service.CreateWebhook(ctx, webhook.CreateWebhookRequest{URL: "http://example.com"})
+	s.Require().NoError(err)
+	s.Len(created.Secret, 64)
+	s.True(created.Active)
+
+	updated, err := service.UpdateWebhook(ctx, created.ID, webhook.UpdateWebhookRequest{
+		Events: &[]string{"deleted"},
+		Active: util.Pointer(false),
+	})
+	s.Require().NoError(err)
+	s.Equal([]string{"deleted"}, updated.Events)
+	s.False(updated.Active)
+	s.Equal(created.Secret, updated.Secret)
+
+	s.Require().NoError(service.DeleteWebhook(ctx, created.ID))
+	_, err = service.GetWebhook(ctx, created.ID)
+	s.ErrorIs(err, domain.ErrWebhookNotFound)
+}
+
+func (s *WebhookServiceSuite) TestDeliver() {
+	service, tasks, closeRedis := s.newService(webhook.WithBackoff(time.Millisecond, 10*time.Millisecond))
+	defer closeRedis()
+
+	recv := &receiver{statuses: []int{http.StatusInternalServerError}}
+	server := httptest.NewServer(recv)
+	defer server.Close()
+
+	ctx := context.Background()
+	hook, err := service.CreateWebhook(ctx, webhook.CreateWebhookRequest{URL: server.URL, Secret: "secret"})
+	s.Require().NoError(err)
+	unsubscribed, err := service.CreateWebhook(ctx, webhook.CreateWebhookRequest{URL: server.URL, Events: []string{"deleted"}})
+	s.Require().NoError(err)
+
+	created, err := tasks.CreateTask(ctx, task.CreateTaskRequest{Name: "task 1"})
+	s.Require().NoError(err)
+	s.Require().NoError(service.RecordEvent(ctx, eventbus.Envelope{ID: "event-1", Event: domain.TaskCreated{Task: created}}))
+	s.Require().NoError(service.RecordEvent(ctx, eventbus.Envelope{
+		ID:    "event-2",
+		Event: domain.TaskStatusChanged{Task: created, To: domain.TaskStatusCompleted},
+	}))
+
+	// the first attempt fails with 500, the retry succeeds.
+	s.Eventually(func() bool {
+		_, err := service.DeliverDue(ctx)
+		s.Require().NoError(err)
+		return len(recv.received()) == 2
+	}, time.Second, 5*time.Millisecond)
+
+	requests := recv.received()
+	body := requests[1].body
+	s.Equal(webhook.Sign("secret", body), requests[1].header.Get(webhook.HeaderSignature))
+	s.Equal("created", requests[1].header.Get(webhook.HeaderEvent))
+	s.Equal(requests[0].body, body)
+
+	var payload map[string]any
+	s.Require().NoError(json.Unmarshal(body, &payload))
+	s.Equal("event-1", payload["id"])
+	s.Equal("created", payload["event"])
+	s.Equal(map[string]any{
+		"id":         float64(created.ID),
+		"name":       "task 1",
+		"status":     "incomplete",
+		"created_at": created.CreatedAt.Format(time.RFC3339Nano),
+		"updated_at": created.UpdatedAt.Format(time.RFC3339Nano),
+	}, payload["task"])
+
+	deliveries, err := service.ListDeliveries(ctx, hook.ID)
+	s.Require().NoError(err)
+	s.Require().Len(deliveries, 1)
+	s.Equal(domain.DeliveryStateSucceeded, deliveries[0].State)
+	s.Require().Len(deliveries[0].Attempts, 2)
+	s.Equal(http.StatusInternalServerError, deliveries[0].Attempts[0].StatusCode)
+	s.Equal(http.StatusOK, deliveries[0].Attempts[1].StatusCode)
+	first := deliveries[0]
+
+	deliveries, err = service.ListDeliveries(ctx, unsubscribed.ID)
+	s.Require().NoError(err)
+	s.Empty(deliveries)
+
+	s.T().Run("redeliver", func(t *testing.T) {
+		redelivery, err := service.Redeliver(ctx, hook.ID, first.ID)
+		s.Require().NoError(err)
+		s.Equal(domain.DeliveryStatePending, redelivery.State)
+
+		n, err := service.DeliverDue(ctx)
+		s.Require().NoError(err)
+		s.Equal(1, n)
+		s.Len(recv.received(), 3)
+		s.Equal(body, recv.received()[2].body)
+
+		deliveries, err := service.ListDeliveries(ctx, hook.ID)
+		s.Require().NoError(err)
+		s.Require().Len(deliveries, 2)
+		s.Equal(redelivery.ID, deliveries[0].ID)
+		s.Equal(domain.DeliveryStateSucceeded, deliveries[0].State)
+
+		_, err = service.Redeliver(ctx, unsubscribed.ID, redelivery.ID)
+		s.ErrorIs(err, domain.ErrDeliveryNotFound)
+	})
+}
+
+func (s *WebhookServiceSuite) TestDeliverFailed() {
+	service, tasks, closeRedis := s.newService(
+		webhook.WithBackoff(time.Millisecond, time.Millisecond),
+		webhook.WithMaxAttempts(2),
+		webhook.WithPollInterval(time.Millisecond),
+	)
+	defer closeRedis()
+
+	recv := &receiver{statuses: []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusOK}}
+	server := httptest.NewServer(recv)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	hook, err := service.CreateWebhook(ctx, webhook.CreateWebhookRequest{URL: server.URL})
+	s.Require().NoError(err)
+
+	stopped := service.Start(ctx)
+	_, err = tasks.CreateTask(ctx, task.CreateTaskRequest{Name: "task 1"})
+	s.Require().NoError(err)
+
+	s.Eventually(func() bool {
+		deliveries, err := service.ListDeliveries(ctx, hook.ID)
+		s.Require().NoError(err)
+		return len(deliveries) == 1 && deliveries[0].State == domain.DeliveryStateFailed
+	}, time.Second, 5*time.Millisecond)
+
+	cancel()
+	<-stopped
+
+	s.Len(recv.received(), 2)
+}
+
+func TestWebhookServiceSuite(t *testing.T) {
+	suite.Run(t, new(WebhookServiceSuite))
+}
@@ -0,0 +1,383 @@
+// Package tui is an interactive terminal UI of the tasks of a running server.
+package tui
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/omegaatt36/gotasker/domain"
+
+	"github.com/charmbracelet/bubbles/cursor"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// DefaultRefreshInterval is the default interval of reloading the tasks.
+const DefaultRefreshInterval = 2 * time.Second
+
+// TaskClient is the task API the UI talks to, see client.Client.
+type TaskClient interface {
+	ListTasks(ctx context.Context) ([]domain.Task, error)
+	CreateTask(ctx context.Context, req domain.CreateTaskRequest) (domain.Task, error)
+	UpdateTask(ctx context.Context, id uint, req domain.UpdateTaskRequest) error
+	DeleteTask(ctx context.Context, id uint) error
+}
+
+// mode is the state of the input.
+type mode int
+
+const (
+	modeList mode = iota
+	modeAdd
+	modeRename
+	modeFilter
+	modeConfirmDelete
+)
+
+// messages of the commands.
+type (
+	tasksMsg struct {
+		tasks []domain.Task
+		err   error
+	}
+	// writtenMsg reports a write, the tasks are reloaded after it.
+	writtenMsg struct {
+		status string
+		err    error
+	}
+	tickMsg struct{}
+)
+
+// Model is the bubbletea model of the task list.
+type Model struct {
+	ctx             context.Context
+	client          TaskClient
+	refreshInterval time.Duration
+
+	tasks  []domain.Task
+	loaded bool
+	// selected is the ID of the selected task, which is kept across reloads.
+	selected uint
+	filter   string
+
+	mode   mode
+	input  textinput.Model
+	status string
+	err    error
+
+	width  int
+	height int
+}
+
+// Option configures a model.
+type Option func(*Model)
+
+// WithRefreshInterval sets the interval of reloading the tasks from the
+// server, zero disables reloading.
+func WithRefreshInterval(interval time.Duration) Option {
+	return func(m *Model) {
+		if interval >= 0 {
+			m.refreshInterval = interval
+		}
+	}
+}
+
+// New creates a new model of the tasks of the client, ctx bounds the
+// requests to the server.
+func New(ctx context.Context, client TaskClient, opts ...Option) Model {
+	input := textinput.New()
+	input.Cursor.SetMode(cursor.CursorStatic)
+	input.CharLimit = 256
+
+	m := Model{
+		ctx:             ctx,
+		client:          client,
+		refreshInterval: DefaultRefreshInterval,
+		input:           input,
+	}
+
+	for _, opt := range opts {
+		opt(&m)
+	}
+
+	return m
+}
+
+// Init implements tea.Model.
+func (m Model) Init() tea.Cmd {
+	return tea.Batch(m.load(), m.tick())
+}
+
+// Update implements tea.Model.
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		return m, nil
+	case tasksMsg:
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+
+		m.tasks, m.loaded, m.err = msg.tasks, true, nil
+		m.keepSelection()
+		return m, nil
+	case writtenMsg:
+		m.status, m.err = msg.status, msg.err
+		return m, m.load()
+	case tickMsg:
+		// skip reloading while a prompt is open, the selection may change.
+		if m.mode == modeList {
+			return m, tea.Batch(m.load(), m.tick())
+		}
+
+		return m, m.tick()
+	case tea.KeyMsg:
+		if msg.Type == tea.KeyCtrlC {
+			return m, tea.Quit
+		}
+
+		if m.mode == modeList {
+			return m.updateList(msg)
+		}
+
+		return m.updatePrompt(msg)
+	}
+
+	return m, nil
+}
+
+func (m Model) updateList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.status = ""
+
+	switch msg.String() {
+	case "q":
+		return m, tea.Quit
+	case "up", "k":
+		m.move(-1)
+	case "down", "j":
+		m.move(1)
+	case "home", "g":
+		m.move(-len(m.tasks))
+	case "end", "G":
+		m.move(len(m.tasks))
+	case "r":
+		return m, m.load()
+	case " ", "x", "enter":
+		if t, ok := m.selectedTask(); ok {
+			return m, m.toggle(t)
+		}
+	case "a":
+		return m, m.prompt(modeAdd, "")
+	case "e":
+		if t, ok := m.selectedTask(); ok {
+			return m, m.prompt(modeRename, t.Name)
+		}
+	case "d", "delete":
+		if _, ok := m.selectedTask(); ok {
+			m.mode = modeConfirmDelete
+		}
+	case "/":
+		return m, m.prompt(modeFilter, m.filter)
+	case "esc":
+		m.filter = ""
+		m.keepSelection()
+	}
+
+	return m, nil
+}
+
+func (m Model) updatePrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.mode == modeConfirmDelete {
+		m.mode = modeList
+		if t, ok := m.selectedTask(); ok && (msg.String() == "y" || msg.String() == "Y") {
+			return m, m.delete(t)
+		}
+
+		return m, nil
+	}
+
+	switch msg.Type {
+	case tea.KeyEsc:
+		if m.mode == modeFilter {
+			m.filter = ""
+			m.keepSelection()
+		}
+		m.closePrompt()
+		return m, nil
+	case tea.KeyEnter:
+		value := strings.TrimSpace(m.input.Value())
+		mode := m.mode
+		m.closePrompt()
+
+		switch mode {
+		case modeAdd:
+			if value != "" {
+				return m, m.create(value)
+			}
+		case modeRename:
+			if t, ok := m.selectedTask(); ok && value != "" && value != t.Name {
+				return m, m.rename(t, value)
+			}
+		}
+
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+
+	// the list is filtered while typing.
+	if m.mode == modeFilter {
+		m.filter = m.input.Value()
+		m.keepSelection()
+	}
+
+	return m, cmd
+}
+
+func (m *Model) prompt(mode mode, value string) tea.Cmd {
+	m.mode = mode
+	m.input.SetValue(value)
+	m.input.CursorEnd()
+
+	switch mode {
+	case modeAdd:
+		m.input.Prompt = "add: "
+	case modeRename:
+		m.input.Prompt = "rename: "
+	case modeFilter:
+		m.input.Prompt = "/"
+	}
+
+	return m.input.Focus()
+}
+
+func (m *Model) closePrompt() {
+	m.mode = modeList
+	m.input.Blur()
+	m.input.Reset()
+}
+
+// visible returns the tasks matching the filter, case-insensitively.
+func (m *Model) visible() []domain.Task {
+	if m.filter == "" {
+		return m.tasks
+	}
+
+	filter := strings.ToLower(m.filter)
+	var tasks []domain.Task
+	for _, t := range m.tasks {
+		if strings.Contains(strings.ToLower(t.Name), filter) {
+			tasks = append(tasks, t)
+		}
+	}
+
+	return tasks
+}
+
+// cursor returns the index of the selected task in the visible tasks.
+func (m *Model) cursor() int {
+	return max(0, slices.IndexFunc(m.visible(), func(t domain.Task) bool {
+		return t.ID == m.selected
+	}))
+}
+
+func (m *Model) selectedTask() (domain.Task, bool) {
+	tasks := m.visible()
+	if len(tasks) == 0 {
+		return domain.Task{}, false
+	}
+
+	return tasks[m.cursor()], true
+}
+
+func (m *Model) move(delta int) {
+	tasks := m.visible()
+	if len(tasks) == 0 {
+		return
+	}
+
+	m.selected = tasks[min(max(m.cursor()+delta, 0), len(tasks)-1)].ID
+}
+
+// keepSelection selects the nearest visible task if the selected one is
+// gone.
+func (m *Model) keepSelection() {
+	tasks := m.visible()
+	if len(tasks) == 0 || slices.ContainsFunc(tasks, func(t domain.Task) bool { return t.ID == m.selected }) {
+		return
+	}
+
+	index, _ := slices.BinarySearchFunc(tasks, m.selected, func(t domain.Task, id uint) int {
+		return int(t.ID) - int(id)
+	})
+	m.selected = tasks[min(index, len(tasks)-1)].ID
+}
+
+func (m Model) load() tea.Cmd {
+	return func() tea.Msg {
+		tasks, err := m.client.ListTasks(m.ctx)
+		return tasksMsg{tasks: tasks, err: err}
+	}
+}
+
+func (m Model) tick() tea.Cmd {
+	if m.refreshInterval == 0 {
+		return nil
+	}
+
+	return tea.Tick(m.refreshInterval, func(time.Time) tea.Msg {
+		return tickMsg{}
+	})
+}
+
+func (m Model) create(name string) tea.Cmd {
+	return func() tea.Msg {
+		created, err := m.client.CreateTask(m.ctx, domain.CreateTaskRequest{Name: name})
+		if err != nil {
+			return writtenMsg{err: err}
+		}
+
+		return writtenMsg{status: fmt.Sprintf("added task %d", created.ID)}
+	}
+}
+
+func (m Model) toggle(t domain.Task) tea.Cmd {
+	status := domain.TaskStatusCompleted
+	if t.Status == domain.TaskStatusCompleted {
+		status = domain.TaskStatusIncomplete
+	}
+
+	return func() tea.Msg {
+		if err := m.client.UpdateTask(m.ctx, t.ID, domain.UpdateTaskRequest{Status: &status}); err != nil {
+			return writtenMsg{err: err}
+		}
+
+		return writtenMsg{status: fmt.Sprintf("task %d is %s", t.ID, status)}
+	}
+}
+
+func (m Model) rename(t domain.Task, name string) tea.Cmd {
+	return func() tea.Msg {
+		if err := m.client.UpdateTask(m.ctx, t.ID, domain.UpdateTaskRequest{Name: &name}); err != nil {
+			return writtenMsg{err: err}
+		}
+
+		return writtenMsg{status: fmt.Sprintf("renamed task %d", t.ID)}
+	}
+}
+
+func (m Model) delete(t domain.Task) tea.Cmd {
+	return func() tea.Msg {
+		if err := m.client.DeleteTask(m.ctx, t.ID); err != nil {
+			return writtenMsg{err: err}
+		}
+
+		return writtenMsg{status: fmt.Sprintf("deleted task %d", t.ID)}
+	}
+}
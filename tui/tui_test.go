@@ -0,0 +1,243 @@
+package tui_test
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/omegaatt36/gotasker/api"
+	"github.com/omegaatt36/gotasker/api/validation"
+	"github.com/omegaatt36/gotasker/client"
+	"github.com/omegaatt36/gotasker/domain"
+	"github.com/omegaatt36/gotasker/logging"
+	"github.com/omegaatt36/gotasker/persistance/database"
+	"github.com/omegaatt36/gotasker/tui"
+
+	"github.com/alicebob/miniredis/v2"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+)
+
+type TUISuite struct {
+	suite.Suite
+
+	miniredis *miniredis.Miniredis
+	server    *httptest.Server
+	client    *client.Client
+}
+
+func (s *TUISuite) SetupSuite() {
+	gin.SetMode(gin.TestMode)
+	logging.Init(false, "error")
+}
+
+func (s *TUISuite) SetupTest() {
+	s.miniredis = database.InitializeTestingRedis()
+	database.Initialize(context.Background(), s.miniredis.Addr(), "")
+
+	s.server = httptest.NewServer(api.NewServer(api.Config{ResponseValidation: validation.ResponseModeFail}).Handler())
+
+	c, err := client.New(s.server.URL)
+	s.Require().NoError(err)
+	s.client = c
+
+	for _, name := range []string{"buy milk", "walk the dog", "feed the cat"} {
+		_, err := s.client.CreateTask(context.Background(), domain.CreateTaskRequest{Name: name})
+		s.Require().NoError(err)
+	}
+}
+
+func (s *TUISuite) TearDownTest() {
+	s.server.Close()
+	s.miniredis.Close()
+}
+
+// start creates a model without reloading and runs its initial load.
+func (s *TUISuite) start() tea.Model {
+	m := tui.New(context.Background(), s.client, tui.WithRefreshInterval(0))
+	return run(m, m.Init())
+}
+
+// run runs the command and its follow-ups, as the program would.
+func run(m tea.Model, cmd tea.Cmd) tea.Model {
+	if cmd == nil {
+		return m
+	}
+
+	switch msg := cmd().(type) {
+	case tea.BatchMsg:
+		for _, cmd := range msg {
+			m = run(m, cmd)
+		}
+		return m
+	case tea.QuitMsg, nil:
+		return m
+	default:
+		m, cmd = m.Update(msg)
+		return run(m, cmd)
+	}
+}
+
+// press sends the keys, a key of several runes is typed.
+func press(m tea.Model, keys ...string) tea.Model {
+	for _, key := range keys {
+		var msg tea.KeyMsg
+		switch key {
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		case "esc":
+			msg = tea.KeyMsg{Type: tea.KeyEsc}
+		case "space":
+			msg = tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}}
+		case "ctrl+u":
+			msg = tea.KeyMsg{Type: tea.KeyCtrlU}
+		default:
+			msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+		}
+
+		var cmd tea.Cmd
+		m, cmd = m.Update(msg)
+		m = run(m, cmd)
+	}
+
+	return m
+}
+
+// selectedLine returns the line of the selected task.
+func selectedLine(m tea.Model) string {
+	for _, line := range strings.Split(m.View(), "\n") {
+		if strings.HasPrefix(line, "> ") {
+			return line
+		}
+	}
+
+	return ""
+}
+
+func (s *TUISuite) task(id uint) domain.Task {
+	t, err := s.client.GetTask(context.Background(), id)
+	s.Require().NoError(err)
+
+	return t
+}
+
+func (s *TUISuite) TestList() {
+	m := s.start()
+
+	view := m.View()
+	s.Contains(view, "3 tasks, 0 completed")
+	s.Contains(view, "[ ]    2  walk the dog")
+	s.Contains(selectedLine(m), "buy milk")
+
+	m = press(m, "j", "j", "j")
+	s.Contains(selectedLine(m), "feed the cat")
+	m = press(m, "k")
+	s.Contains(selectedLine(m), "walk the dog")
+	m = press(m, "g")
+	s.Contains(selectedLine(m), "buy milk")
+
+	// tasks changed by other clients show up on refresh.
+	_, err := s.client.CreateTask(context.Background(), domain.CreateTaskRequest{Name: "water plants"})
+	s.Require().NoError(err)
+	s.NotContains(m.View(), "water plants")
+	m = press(m, "r")
+	s.Contains(m.View(), "water plants")
+}
+
+func (s *TUISuite) TestEdit() {
+	m := s.start()
+
+	m = press(m, "space")
+	s.Equal(domain.TaskStatusCompleted, s.task(1).Status)
+	s.Contains(m.View(), "1 completed")
+	s.Contains(m.View(), "task 1 is completed")
+	m = press(m, "x")
+	s.Equal(domain.TaskStatusIncomplete, s.task(1).Status)
+
+	m = press(m, "a", "water plants", "enter")
+	s.Contains(m.View(), "added task 4")
+	s.Contains(m.View(), "water plants")
+
+	m = press(m, "a", "abandoned", "esc")
+	s.NotContains(m.View(), "abandoned")
+
+	m = press(m, "j", "e")
+	s.Contains(m.View(), "rename: walk the dog")
+	m = press(m, "ctrl+u", "walk the cat", "enter")
+	s.Equal("walk the cat", s.task(2).Name)
+	s.Contains(selectedLine(m), "walk the cat")
+
+	m = press(m, "d")
+	s.Contains(m.View(), `delete task 2 "walk the cat"? (y/N)`)
+	m = press(m, "n")
+	s.Contains(m.View(), "walk the cat")
+
+	m = press(m, "d", "y")
+	_, err := s.client.GetTask(context.Background(), 2)
+	s.ErrorIs(err, domain.ErrTaskNotFound)
+	s.Contains(m.View(), "deleted task 2")
+	s.Contains(selectedLine(m), "feed the cat", "the next task is selected")
+}
+
+func (s *TUISuite) TestFilter() {
+	m := s.start()
+
+	m = press(m, "/", "THE")
+	s.NotContains(m.View(), "buy milk")
+	s.Contains(m.View(), `filter: "THE"`)
+	s.Contains(selectedLine(m), "walk the dog")
+
+	m = press(m, " c")
+	s.NotContains(m.View(), "walk the dog")
+	s.Contains(selectedLine(m), "feed the cat")
+
+	// the filter is kept, and actions apply to the visible tasks.
+	m = press(m, "enter", "space")
+	s.Equal(domain.TaskStatusCompleted, s.task(3).Status)
+
+	m = press(m, "/", "ctrl+u", "nothing", "enter")
+	s.Contains(m.View(), "no matching tasks")
+	m = press(m, "space", "d", "e")
+	s.NotContains(m.View(), "rename:", "there is no task to edit")
+
+	m = press(m, "esc")
+	s.NotContains(m.View(), "filter:")
+	s.Contains(m.View(), "buy milk")
+}
+
+func (s *TUISuite) TestScroll() {
+	for index := range 20 {
+		_, err := s.client.CreateTask(context.Background(), domain.CreateTaskRequest{Name: fmt.Sprintf("task %d", index+4)})
+		s.Require().NoError(err)
+	}
+
+	m := s.start()
+	m, _ = m.Update(tea.WindowSizeMsg{Width: 80, Height: 10})
+
+	s.Len(strings.Split(m.View(), "\n"), 10)
+
+	m = press(m, "G")
+	s.Contains(selectedLine(m), "task 23")
+	s.NotContains(m.View(), "buy milk")
+	s.Len(strings.Split(m.View(), "\n"), 10)
+}
+
+func (s *TUISuite) TestError() {
+	c, err := client.New(s.server.URL, client.WithMaxRetries(0))
+	s.Require().NoError(err)
+
+	model := tui.New(context.Background(), c, tui.WithRefreshInterval(0))
+	m := run(model, model.Init())
+
+	s.server.Close()
+	m = press(m, "space")
+	s.Contains(m.View(), "error: ")
+	s.Contains(m.View(), "buy milk", "the last loaded tasks are kept")
+}
+
+func TestTUI(t *testing.T) {
+	suite.Run(t, new(TUISuite))
+}
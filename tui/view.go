@@ -0,0 +1,115 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/omegaatt36/gotasker/domain"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	titleStyle     = lipgloss.NewStyle().Bold(true)
+	selectedStyle  = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("12"))
+	completedStyle = lipgloss.NewStyle().Faint(true).Strikethrough(true)
+	mutedStyle     = lipgloss.NewStyle().Faint(true)
+	errorStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+)
+
+const helpText = "↑/↓ move · space toggle · a add · e rename · d delete · / filter · r refresh · q quit"
+
+// chromeLines is the number of lines around the task list.
+const chromeLines = 3
+
+// View implements tea.Model.
+func (m Model) View() string {
+	var b strings.Builder
+
+	b.WriteString(m.headerView())
+	b.WriteString("\n")
+	b.WriteString(m.listView())
+	b.WriteString("\n")
+	b.WriteString(m.footerView())
+	b.WriteString("\n")
+	b.WriteString(mutedStyle.Render(helpText))
+
+	return b.String()
+}
+
+func (m *Model) headerView() string {
+	completed := 0
+	for _, t := range m.tasks {
+		if t.Status == domain.TaskStatusCompleted {
+			completed++
+		}
+	}
+
+	header := titleStyle.Render("gotasker") + fmt.Sprintf("  %d tasks, %d completed", len(m.tasks), completed)
+	if m.filter != "" {
+		header += mutedStyle.Render(fmt.Sprintf("  filter: %q", m.filter))
+	}
+
+	return header
+}
+
+func (m *Model) listView() string {
+	if !m.loaded {
+		return mutedStyle.Render("loading...")
+	}
+
+	tasks := m.visible()
+	if len(tasks) == 0 {
+		if m.filter != "" {
+			return mutedStyle.Render("no matching tasks")
+		}
+
+		return mutedStyle.Render("no tasks, press a to add one")
+	}
+
+	// the list scrolls to keep the selected task in view.
+	cursor := m.cursor()
+	start, end := 0, len(tasks)
+	if rows := m.height - chromeLines; m.height > 0 && rows > 0 && len(tasks) > rows {
+		start = min(max(cursor-rows/2, 0), len(tasks)-rows)
+		end = start + rows
+	}
+
+	lines := make([]string, 0, end-start)
+	for index := start; index < end; index++ {
+		lines = append(lines, m.taskView(&tasks[index], index == cursor))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (m *Model) taskView(t *domain.Task, selected bool) string {
+	check, name := "[ ]", t.Name
+	if t.Status == domain.TaskStatusCompleted {
+		check, name = "[x]", completedStyle.Render(name)
+	}
+
+	line := fmt.Sprintf("%s %4d  %s", check, t.ID, name)
+	if selected {
+		return selectedStyle.Render("> ") + line
+	}
+
+	return "  " + line
+}
+
+func (m *Model) footerView() string {
+	switch m.mode {
+	case modeConfirmDelete:
+		if t, ok := m.selectedTask(); ok {
+			return fmt.Sprintf("delete task %d %q? (y/N)", t.ID, t.Name)
+		}
+	case modeAdd, modeRename, modeFilter:
+		return m.input.View()
+	}
+
+	if m.err != nil {
+		return errorStyle.Render("error: " + m.err.Error())
+	}
+
+	return mutedStyle.Render(m.status)
+}
@@ -1,6 +1,10 @@
 package util
 
-import "os"
+import (
+	"os"
+	"strconv"
+	"time"
+)
 
 func GetENV(key string, defaultValue string) string {
 	if value, ok := os.LookupEnv(key); ok {
@@ -9,3 +13,31 @@ func GetENV(key string, defaultValue string) string {
 
 	return defaultValue
 }
+
+func GetENVInt(key string, defaultValue int) int {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return defaultValue
+	}
+
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return defaultValue
+	}
+
+	return i
+}
+
+func GetENVDuration(key string, defaultValue time.Duration) time.Duration {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return defaultValue
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return defaultValue
+	}
+
+	return d
+}
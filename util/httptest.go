@@ -29,6 +29,7 @@ type HTTPTestRequest struct {
 // HTTPTestResponse defines the response for testing.
 type HTTPTestResponse struct {
 	StatusCode int
+	Header     http.Header
 	Body       []byte
 }
 
@@ -53,7 +54,7 @@ func HTTPTest(req HTTPTestRequest) (*HTTPTestResponse, error) {
 
 	for key, values := range req.Header {
 		for _, value := range values {
-			req.Header.Add(key, value)
+			httpReq.Header.Add(key, value)
 		}
 	}
 	httpReq.Header.Add("Content-Type", "application/json")
@@ -82,6 +83,7 @@ func HTTPTest(req HTTPTestRequest) (*HTTPTestResponse, error) {
 
 	return &HTTPTestResponse{
 		StatusCode: w.Code,
+		Header:     w.Header(),
 		Body:       w.Body.Bytes(),
 	}, nil
 }